@@ -0,0 +1,78 @@
+package evccdb
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// AuditSuffix is appended to the database path to name its audit log
+const AuditSuffix = ".evccdb-audit.jsonl"
+
+// AuditEntry records a write operation on a database
+type AuditEntry struct {
+	Time    time.Time `json:"time"`
+	Command string    `json:"command"`
+	// Database is the path of the written database as given on the command line
+	Database string `json:"database"`
+	// Args are the command line arguments the operation was started with
+	Args       []string       `json:"args"`
+	Rows       map[string]int `json:"rows,omitempty"`
+	DurationMS int64          `json:"duration_ms"`
+	User       string         `json:"user"`
+	Host       string         `json:"host"`
+	Error      string         `json:"error,omitempty"`
+}
+
+// AppendAudit appends an entry to the audit log of the database at path. The
+// log is a JSON lines file next to the database, so it survives rolled back
+// transactions and restores of the database file.
+func AppendAudit(path string, entry AuditEntry) error {
+	b, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(path+AuditSuffix, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log: %w", err)
+	}
+	_, err = f.Write(append(b, '\n'))
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+	return nil
+}
+
+// ReadAudit returns the audit log of the database at path, oldest entry first
+func ReadAudit(path string) ([]AuditEntry, error) {
+	f, err := os.Open(path + AuditSuffix)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	var entries []AuditEntry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var entry AuditEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, fmt.Errorf("failed to parse audit log line %d: %w", line, err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, scanner.Err()
+}
@@ -0,0 +1,47 @@
+package evccdb
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestAuditLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "evcc.db")
+
+	entries, err := ReadAudit(path)
+	if err != nil || len(entries) != 0 {
+		t.Fatalf("Expected empty audit log, got %v, %v", entries, err)
+	}
+
+	first := AuditEntry{
+		Time:     time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC),
+		Command:  "rename",
+		Database: "evcc.db",
+		Args:     []string{"rename", "--db", "evcc.db", "--vehicle", "e-Golf:ID.3"},
+		Rows:     map[string]int{"sessions": 12},
+		User:     "pi",
+		Host:     "evcc",
+	}
+	second := AuditEntry{Time: first.Time.Add(time.Hour), Command: "delete", Error: "failed"}
+
+	for _, e := range []AuditEntry{first, second} {
+		if err := AppendAudit(path, e); err != nil {
+			t.Fatalf("AppendAudit failed: %v", err)
+		}
+	}
+
+	entries, err = ReadAudit(path)
+	if err != nil {
+		t.Fatalf("ReadAudit failed: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("Expected 2 entries, got %d", len(entries))
+	}
+	if entries[0].Command != "rename" || entries[0].Database != "evcc.db" || entries[0].Rows["sessions"] != 12 || len(entries[0].Args) != 5 || !entries[0].Time.Equal(first.Time) {
+		t.Errorf("Unexpected first entry: %+v", entries[0])
+	}
+	if entries[1].Error != "failed" {
+		t.Errorf("Unexpected second entry: %+v", entries[1])
+	}
+}
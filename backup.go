@@ -0,0 +1,23 @@
+package evccdb
+
+import (
+	"context"
+	"fmt"
+)
+
+// Backup writes a consistent snapshot of the database to path using VACUUM INTO.
+// The target file must not exist. It fails with ErrInsufficientSpace before writing if the
+// filesystem of path cannot hold the snapshot.
+func (c *Client) Backup(ctx context.Context, path string) error {
+	size, err := c.UsedSize()
+	if err != nil {
+		return err
+	}
+	if err := CheckFreeSpace(path, size); err != nil {
+		return err
+	}
+	if _, err := c.db.ExecContext(ctx, "VACUUM INTO ?", path); err != nil {
+		return fmt.Errorf("failed to back up database to %s: %w", path, err)
+	}
+	return nil
+}
@@ -2,8 +2,12 @@ package evccdb
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestExportJSON(t *testing.T) {
@@ -32,12 +36,16 @@ func TestExportJSON(t *testing.T) {
 		t.Error("ExportedAt should not be empty")
 	}
 
-	configTables := []string{"settings", "configs", "caches"}
+	configTables := []string{"settings", "configs"}
 	for _, table := range configTables {
 		if _, exists := export.Tables[table]; !exists {
 			t.Errorf("Expected table %s in export", table)
 		}
 	}
+
+	if _, exists := export.Tables["caches"]; exists {
+		t.Error("Expected caches to be excluded by default")
+	}
 }
 
 func TestExportJSONMetrics(t *testing.T) {
@@ -71,7 +79,7 @@ func TestExportJSONAll(t *testing.T) {
 	defer cleanup()
 
 	var buf bytes.Buffer
-	opts := TransferOptions{Mode: TransferAll}
+	opts := TransferOptions{Mode: TransferAll, IncludeCaches: true}
 
 	err := client.ExportJSON(&buf, opts)
 	if err != nil {
@@ -248,3 +256,328 @@ func TestFormatValueForSQL(t *testing.T) {
 		})
 	}
 }
+
+func TestBackup(t *testing.T) {
+	client, cleanup := createTestDB(t)
+	defer cleanup()
+
+	path := filepath.Join(t.TempDir(), "snapshot.db")
+	ctx := context.Background()
+
+	if err := client.Backup(ctx, path); err != nil {
+		t.Fatalf("Backup failed: %v", err)
+	}
+
+	snapshot, err := Open(path)
+	if err != nil {
+		t.Fatalf("Failed to open backup: %v", err)
+	}
+	defer func() { _ = snapshot.Close() }()
+
+	expected, _ := client.GetRowCount("sessions")
+	count, err := snapshot.GetRowCount("sessions")
+	if err != nil {
+		t.Fatalf("Failed to count sessions in backup: %v", err)
+	}
+	if count != expected {
+		t.Errorf("Expected %d sessions in backup, got %d", expected, count)
+	}
+
+	// Existing files are never overwritten
+	if err := client.Backup(ctx, path); err == nil {
+		t.Error("Expected error when backup file already exists")
+	}
+}
+
+func TestExportImportUserVersion(t *testing.T) {
+	src, srcCleanup := createTestDB(t)
+	defer srcCleanup()
+
+	_, _ = src.db.Exec("PRAGMA user_version = 7")
+	_, _ = src.db.Exec("PRAGMA application_id = 4242")
+
+	var buf bytes.Buffer
+	opts := TransferOptions{Mode: TransferConfig}
+	if err := src.ExportJSON(&buf, opts); err != nil {
+		t.Fatalf("ExportJSON failed: %v", err)
+	}
+
+	var export ExportFormat
+	if err := json.Unmarshal(buf.Bytes(), &export); err != nil {
+		t.Fatalf("Failed to unmarshal exported JSON: %v", err)
+	}
+	if export.UserVersion != 7 || export.ApplicationID != 4242 {
+		t.Errorf("Expected user_version 7 and application_id 4242, got %d and %d", export.UserVersion, export.ApplicationID)
+	}
+
+	dst, dstCleanup := createTestDB(t)
+	defer dstCleanup()
+
+	if err := dst.ImportJSON(bytes.NewReader(buf.Bytes()), opts); err != nil {
+		t.Fatalf("ImportJSON failed: %v", err)
+	}
+
+	userVersion, _ := dst.UserVersion()
+	applicationID, _ := dst.ApplicationID()
+	if userVersion != 7 || applicationID != 4242 {
+		t.Errorf("Expected restored user_version 7 and application_id 4242, got %d and %d", userVersion, applicationID)
+	}
+}
+
+func TestExportMetadata(t *testing.T) {
+	src, srcCleanup := createTestDB(t)
+	defer srcCleanup()
+
+	var buf bytes.Buffer
+	if err := src.ExportJSON(&buf, TransferOptions{Mode: TransferConfig}); err != nil {
+		t.Fatalf("ExportJSON failed: %v", err)
+	}
+
+	var export ExportFormat
+	if err := json.Unmarshal(buf.Bytes(), &export); err != nil {
+		t.Fatalf("Failed to unmarshal exported JSON: %v", err)
+	}
+	if export.Metadata == nil {
+		t.Fatal("Expected export metadata")
+	}
+	if export.Metadata.EvccdbVersion == "" {
+		t.Error("Expected evccdb version in metadata")
+	}
+
+	dst, dstCleanup := createTestDB(t)
+	defer dstCleanup()
+
+	hash, _ := dst.schemaHash("settings")
+	if export.Metadata.SchemaHashes["settings"] != hash {
+		t.Errorf("Expected equal schema hash for identical tables, got %q and %q", export.Metadata.SchemaHashes["settings"], hash)
+	}
+
+	_, _ = dst.db.Exec("ALTER TABLE settings ADD COLUMN note TEXT")
+	if changed, _ := dst.schemaHash("settings"); changed == hash {
+		t.Error("Expected schema hash to change after ALTER TABLE")
+	}
+
+	if err := dst.ImportJSON(bytes.NewReader(buf.Bytes()), TransferOptions{Mode: TransferConfig}); err != nil {
+		t.Fatalf("ImportJSON failed: %v", err)
+	}
+}
+
+func TestImportSkipsUnknownTables(t *testing.T) {
+	client, cleanup := createTestDB(t)
+	defer cleanup()
+
+	if _, err := client.db.Exec("CREATE TABLE secrets (key TEXT PRIMARY KEY, value TEXT)"); err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+
+	export := `{"version": "1", "tables": {
+		"settings": [{"key": "imported", "value": "yes"}],
+		"secrets": [{"key": "token", "value": "overwritten"}]
+	}}`
+
+	if err := client.ImportJSON(strings.NewReader(export), TransferOptions{Mode: TransferAll}); err != nil {
+		t.Fatalf("ImportJSON failed: %v", err)
+	}
+	if count, _ := client.GetRowCount("secrets"); count != 0 {
+		t.Errorf("Expected unknown table to be skipped, got %d rows", count)
+	}
+	var value string
+	_ = client.db.QueryRow("SELECT value FROM settings WHERE key = 'imported'").Scan(&value)
+	if value != "yes" {
+		t.Errorf("Expected known table to be imported, got %q", value)
+	}
+
+	if err := client.ImportJSON(strings.NewReader(export), TransferOptions{Mode: TransferAll, AllowUnknownTables: true}); err != nil {
+		t.Fatalf("ImportJSON failed: %v", err)
+	}
+	if count, _ := client.GetRowCount("secrets"); count != 1 {
+		t.Errorf("Expected unknown table to be imported when allowed, got %d rows", count)
+	}
+}
+
+func TestImportSQLHooks(t *testing.T) {
+	client, cleanup := createTestDB(t)
+	defer cleanup()
+
+	export := `{"version": "1", "tables": {"settings": [{"key": "telemetry", "value": "true"}]}}`
+	opts := TransferOptions{
+		Mode:    TransferAll,
+		PreSQL:  "DELETE FROM settings WHERE key = 'stale'; INSERT INTO settings (key, value) VALUES ('stale', 'x')",
+		PostSQL: "UPDATE settings SET value = 'false' WHERE key = 'telemetry'",
+	}
+	if err := client.ImportJSON(strings.NewReader(export), opts); err != nil {
+		t.Fatalf("ImportJSON failed: %v", err)
+	}
+	var value string
+	_ = client.db.QueryRow("SELECT value FROM settings WHERE key = 'telemetry'").Scan(&value)
+	if value != "false" {
+		t.Errorf("Expected post SQL to clear telemetry, got %q", value)
+	}
+
+	// A failing hook rolls back the whole import
+	opts.PostSQL = "UPDATE missing SET value = 1"
+	export = `{"version": "1", "tables": {"settings": [{"key": "rolled_back", "value": "yes"}]}}`
+	if err := client.ImportJSON(strings.NewReader(export), opts); err == nil {
+		t.Fatal("Expected error from post SQL")
+	}
+	var count int
+	_ = client.db.QueryRow("SELECT COUNT(*) FROM settings WHERE key = 'rolled_back'").Scan(&count)
+	if count != 0 {
+		t.Errorf("Expected import to be rolled back, got %d rows", count)
+	}
+}
+
+func TestStreamTable(t *testing.T) {
+	client, cleanup := createTestDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	rows, errc := client.StreamTable(ctx, "sessions")
+
+	count := 0
+	for row := range rows {
+		if _, ok := row["loadpoint"]; !ok {
+			t.Errorf("Expected loadpoint column in streamed row, got %v", row)
+		}
+		count++
+	}
+	if err := <-errc; err != nil {
+		t.Fatalf("StreamTable failed: %v", err)
+	}
+
+	if count != 5 {
+		t.Errorf("Expected 5 streamed sessions, got %d", count)
+	}
+}
+
+func TestStreamTableCancel(t *testing.T) {
+	client, cleanup := createTestDB(t)
+	defer cleanup()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	rows, errc := client.StreamTable(ctx, "sessions")
+
+	<-rows
+	cancel()
+	for range rows {
+	}
+
+	if err := <-errc; err == nil {
+		t.Error("Expected error after cancellation")
+	}
+
+	_, errc = client.StreamTable(context.Background(), "bad table")
+	if err := <-errc; err == nil {
+		t.Error("Expected error for invalid table name")
+	}
+}
+
+func TestExportSQL(t *testing.T) {
+	src, srcCleanup := createTestDB(t)
+	defer srcCleanup()
+
+	var buf bytes.Buffer
+	if err := src.ExportSQL(&buf, TransferOptions{Mode: TransferAll}); err != nil {
+		t.Fatalf("ExportSQL failed: %v", err)
+	}
+
+	dump := buf.String()
+	if !strings.Contains(dump, `CREATE TABLE IF NOT EXISTS "sessions"`) {
+		t.Errorf("Expected CREATE TABLE for sessions in dump:\n%s", dump)
+	}
+
+	// The dump loads into an empty database
+	path := filepath.Join(t.TempDir(), "restored.db")
+	dst, err := Open(path)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer func() { _ = dst.Close() }()
+
+	if _, err := dst.db.Exec(dump); err != nil {
+		t.Fatalf("Failed to load dump: %v", err)
+	}
+
+	for _, table := range []string{"settings", "configs", "sessions"} {
+		expected, _ := src.GetRowCount(table)
+		count, err := dst.GetRowCount(table)
+		if err != nil {
+			t.Fatalf("Failed to count %s: %v", table, err)
+		}
+		if count != expected {
+			t.Errorf("Expected %d rows in %s, got %d", expected, table, count)
+		}
+	}
+
+	var created time.Time
+	if err := dst.db.QueryRow("SELECT created FROM sessions WHERE id = 1").Scan(&created); err != nil {
+		t.Fatalf("Failed to read restored session: %v", err)
+	}
+	if want := time.Date(2023, 4, 1, 10, 0, 0, 0, time.UTC); !created.Equal(want) {
+		t.Errorf("Expected created %v, got %v", want, created)
+	}
+}
+
+func TestExportSQLBlob(t *testing.T) {
+	src, srcCleanup := createTestDB(t)
+	defer srcCleanup()
+
+	if _, err := src.db.Exec("CREATE TABLE blobs (id INTEGER PRIMARY KEY, data BLOB); INSERT INTO blobs VALUES (1, X'00ff27')"); err != nil {
+		t.Fatal(err)
+	}
+	src.InvalidateSchema()
+
+	for dialect, expected := range map[SQLDialect][2]string{
+		DialectSQLite:   {`"data" BLOB`, `(1, X'00ff27')`},
+		DialectPostgres: {`"data" BYTEA`, `(1, '\x00ff27')`},
+		DialectDuckDB:   {`"data" BLOB`, `(1, '\x00\xff\x27')`},
+	} {
+		var buf bytes.Buffer
+		if err := src.ExportSQL(&buf, TransferOptions{Tables: []string{"blobs"}, SQLDialect: dialect}); err != nil {
+			t.Fatalf("ExportSQL failed: %v", err)
+		}
+		if dump := buf.String(); !strings.Contains(dump, expected[0]) || !strings.Contains(dump, expected[1]) {
+			t.Errorf("Expected %s and %s in dump:\n%s", expected[0], expected[1], dump)
+		}
+	}
+
+	// The sqlite dump restores the bytes
+	var buf bytes.Buffer
+	if err := src.ExportSQL(&buf, TransferOptions{Tables: []string{"blobs"}}); err != nil {
+		t.Fatalf("ExportSQL failed: %v", err)
+	}
+	dst, err := Open(filepath.Join(t.TempDir(), "restored.db"))
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer func() { _ = dst.Close() }()
+	if _, err := dst.db.Exec(buf.String()); err != nil {
+		t.Fatalf("Failed to load dump: %v", err)
+	}
+	var data []byte
+	if err := dst.db.QueryRow("SELECT data FROM blobs WHERE typeof(data) = 'blob'").Scan(&data); err != nil {
+		t.Fatalf("Failed to read restored blob: %v", err)
+	}
+	if !bytes.Equal(data, []byte{0x00, 0xff, 0x27}) {
+		t.Errorf("Expected restored bytes 00ff27, got %x", data)
+	}
+}
+
+func TestImportJSONContextCancelled(t *testing.T) {
+	client, cleanup := createTestDB(t)
+	defer cleanup()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	export := `{"version": "1", "tables": {"settings": [{"key": "imported", "value": "yes"}]}}`
+	if err := client.ImportJSONContext(ctx, strings.NewReader(export), TransferOptions{Mode: TransferAll}); err == nil {
+		t.Fatal("Expected cancelled import to fail")
+	}
+
+	var count int
+	_ = client.db.QueryRow("SELECT COUNT(*) FROM settings WHERE key = 'imported'").Scan(&count)
+	if count != 0 {
+		t.Errorf("Cancelled import wrote %d rows", count)
+	}
+}
@@ -0,0 +1,30 @@
+package evccdb
+
+import (
+	"context"
+	"fmt"
+)
+
+// cachesPrefixFilter matches cache keys starting with a prefix, an empty prefix matches all keys
+const cachesPrefixFilter = "substr(key, 1, length(?1)) = ?1"
+
+// CountCaches returns the number of cache entries whose key starts with prefix
+func (c *Client) CountCaches(ctx context.Context, prefix string) (int, error) {
+	var count int
+	err := c.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM caches WHERE "+cachesPrefixFilter, prefix).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count caches: %w", err)
+	}
+	return count, nil
+}
+
+// ClearCaches deletes the cache entries whose key starts with prefix, e.g. stale
+// vehicle API tokens, and returns the number of deleted entries
+func (c *Client) ClearCaches(ctx context.Context, prefix string) (int, error) {
+	res, err := c.db.ExecContext(ctx, "DELETE FROM caches WHERE "+cachesPrefixFilter, prefix)
+	if err != nil {
+		return 0, fmt.Errorf("failed to clear caches: %w", err)
+	}
+	count, err := res.RowsAffected()
+	return int(count), err
+}
@@ -0,0 +1,32 @@
+package evccdb
+
+import (
+	"context"
+	"testing"
+)
+
+func TestClearCaches(t *testing.T) {
+	client, cleanup := createTestDB(t)
+	defer cleanup()
+
+	_, err := client.db.Exec(`INSERT INTO caches (key, value) VALUES
+		('token.vw', 'a'), ('token.tesla', 'b'), ('tokenizer', 'c'), ('tariff%', 'd')`)
+	if err != nil {
+		t.Fatalf("Failed to insert caches: %v", err)
+	}
+
+	ctx := context.Background()
+	if count, err := client.CountCaches(ctx, "token."); err != nil || count != 2 {
+		t.Errorf("CountCaches = %d, %v, want 2", count, err)
+	}
+	if count, err := client.CountCaches(ctx, "tariff%"); err != nil || count != 1 {
+		t.Errorf("CountCaches with wildcard = %d, %v, want 1", count, err)
+	}
+
+	if count, err := client.ClearCaches(ctx, "token."); err != nil || count != 2 {
+		t.Errorf("ClearCaches = %d, %v, want 2", count, err)
+	}
+	if count, err := client.ClearCaches(ctx, ""); err != nil || count != 2 {
+		t.Errorf("ClearCaches all = %d, %v, want 2", count, err)
+	}
+}
@@ -0,0 +1,88 @@
+package evccdb
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/iseeberg79/evccdb/internal/sqlbuild"
+)
+
+// TableChecksums returns a content hash for every row of a table, keyed by
+// primary key. Rows of tables without primary key are keyed by their hash.
+func (c *Client) TableChecksums(ctx context.Context, table string) (map[string]string, error) {
+	cols, err := c.GetTableColumns(table)
+	if err != nil {
+		return nil, err
+	}
+	return rowChecksums(ctx, c.db, table, cols)
+}
+
+// rowChecksums hashes the given columns of every row of a table
+func rowChecksums(ctx context.Context, q querier, table string, cols []ColumnInfo) (map[string]string, error) {
+	query, err := sqlbuild.Select(table, columnNames(cols))
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := q.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query %s: %w", table, err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	sums := make(map[string]string)
+	for rows.Next() {
+		values := make([]any, len(cols))
+		scanPtrs := make([]any, len(cols))
+		for i := range cols {
+			scanPtrs[i] = &values[i]
+		}
+
+		if err := rows.Scan(scanPtrs...); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+
+		key, sum := rowKeyChecksum(cols, values)
+		sums[key] = sum
+	}
+
+	return sums, rows.Err()
+}
+
+// rowKeyChecksum returns the primary key and content hash of a row
+func rowKeyChecksum(cols []ColumnInfo, values []any) (string, string) {
+	h := sha256.New()
+	var key []string
+	for i, col := range cols {
+		v := checksumValue(values[i])
+		h.Write([]byte(v))
+		h.Write([]byte{0x1f})
+		if col.Primary {
+			key = append(key, v)
+		}
+	}
+
+	sum := hex.EncodeToString(h.Sum(nil))
+	if len(key) == 0 {
+		return sum, sum
+	}
+	return strings.Join(key, "\x1f"), sum
+}
+
+// checksumValue renders a driver value in a stable form for hashing
+func checksumValue(val any) string {
+	switch v := val.(type) {
+	case nil:
+		return "\x00"
+	case []byte:
+		return string(v)
+	case time.Time:
+		return v.UTC().Format(time.RFC3339Nano)
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,69 @@
+package evccdb
+
+import (
+	"context"
+	"testing"
+)
+
+func TestTableChecksums(t *testing.T) {
+	client, cleanup := createTestDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	before, err := client.TableChecksums(ctx, "settings")
+	if err != nil {
+		t.Fatalf("TableChecksums failed: %v", err)
+	}
+	if len(before) != 6 {
+		t.Fatalf("Expected 6 checksums, got %d", len(before))
+	}
+
+	_, _ = client.db.Exec("UPDATE settings SET value = 'now' WHERE key = 'lp1.mode'")
+
+	after, _ := client.TableChecksums(ctx, "settings")
+	if after["lp1.mode"] == before["lp1.mode"] {
+		t.Error("Expected checksum of changed row to differ")
+	}
+	if after["lp1.title"] != before["lp1.title"] {
+		t.Error("Expected checksum of unchanged row to match")
+	}
+}
+
+func TestTransferIncremental(t *testing.T) {
+	src, srcCleanup := createTestDB(t)
+	defer srcCleanup()
+
+	dst, dstCleanup := createTestDB(t)
+	defer dstCleanup()
+
+	_, _ = src.db.Exec("UPDATE settings SET value = 'now' WHERE key = 'lp1.mode'")
+	_, _ = dst.db.Exec("DELETE FROM settings WHERE key = 'lp2.title'")
+
+	copied := make(map[string]int)
+	ctx := context.Background()
+	opts := TransferOptions{
+		Tables:      []string{"settings", "meters"},
+		Incremental: true,
+		OnProgress: func(table string, count int) {
+			copied[table] = count
+		},
+	}
+
+	if err := Transfer(ctx, src, dst, opts); err != nil {
+		t.Fatalf("Transfer failed: %v", err)
+	}
+
+	// One changed and one missing row
+	if copied["settings"] != 2 {
+		t.Errorf("Expected 2 settings copied, got %d", copied["settings"])
+	}
+
+	srcSums, _ := src.TableChecksums(ctx, "settings")
+	dstSums, _ := dst.TableChecksums(ctx, "settings")
+	for key, sum := range srcSums {
+		if dstSums[key] != sum {
+			t.Errorf("Setting %s differs after incremental transfer", key)
+		}
+	}
+}
@@ -1,27 +1,46 @@
 package evccdb
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
-	"regexp"
+	"slices"
+	"strings"
+	"sync"
 
+	"github.com/iseeberg79/evccdb/internal/sqlbuild"
 	_ "github.com/mattn/go-sqlite3"
 )
 
-var validIdentifier = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
-
 // ValidateIdentifier checks if a string is safe to use as a SQL identifier
 func ValidateIdentifier(name string) error {
-	if !validIdentifier.MatchString(name) {
-		return fmt.Errorf("invalid identifier: %q", name)
-	}
-	return nil
+	return sqlbuild.ValidateIdentifier(name)
 }
 
 // Client represents a connection to an evcc SQLite database
 type Client struct {
-	db   *sql.DB
-	path string
+	db     *sql.DB
+	path   string
+	schema schemaCache
+	// keep holds a connection open that keeps an in-memory database alive
+	keep *sql.Conn
+}
+
+// schemaCache caches the tables and columns of the database until InvalidateSchema is called
+type schemaCache struct {
+	mu      sync.Mutex
+	tables  []string
+	loaded  bool
+	columns map[string][]ColumnInfo
+}
+
+// InvalidateSchema drops the cached tables and columns. The client invalidates the
+// cache after its own schema changes, callers changing the schema through other
+// connections must call it.
+func (c *Client) InvalidateSchema() {
+	c.schema.mu.Lock()
+	defer c.schema.mu.Unlock()
+	c.schema.tables, c.schema.loaded, c.schema.columns = nil, false, nil
 }
 
 // Open opens a connection to an evcc SQLite database
@@ -43,16 +62,39 @@ func Open(path string) (*Client, error) {
 	}, nil
 }
 
+// attachable reports whether the database is a plain file that can be attached to another connection
+func (c *Client) attachable() bool {
+	return c.path != "" && c.path != ":memory:" && !strings.HasPrefix(c.path, "file:") && !strings.Contains(c.path, "?")
+}
+
 // Close closes the database connection
 func (c *Client) Close() error {
 	if c.db == nil {
 		return nil
 	}
+	if c.keep != nil {
+		_ = c.keep.Close()
+	}
 	return c.db.Close()
 }
 
 // GetTables returns a list of all tables in the database
 func (c *Client) GetTables() ([]string, error) {
+	c.schema.mu.Lock()
+	defer c.schema.mu.Unlock()
+
+	if !c.schema.loaded {
+		tables, err := c.queryTables()
+		if err != nil {
+			return nil, err
+		}
+		c.schema.tables, c.schema.loaded = tables, true
+	}
+	return slices.Clone(c.schema.tables), nil
+}
+
+// queryTables reads the table names from sqlite_master
+func (c *Client) queryTables() ([]string, error) {
 	rows, err := c.db.Query(`
 		SELECT name FROM sqlite_master
 		WHERE type='table' AND name NOT LIKE 'sqlite_%'
@@ -77,29 +119,100 @@ func (c *Client) GetTables() ([]string, error) {
 
 // TableExists checks if a table exists in the database
 func (c *Client) TableExists(name string) (bool, error) {
-	var count int
-	err := c.db.QueryRow(`
-		SELECT COUNT(*) FROM sqlite_master
-		WHERE type='table' AND name = ?
-	`, name).Scan(&count)
+	tables, err := c.GetTables()
 	if err != nil {
 		return false, fmt.Errorf("failed to check table existence: %w", err)
 	}
+	return slices.Contains(tables, name), nil
+}
+
+// tableExists checks if a table exists, e.g. within a transaction
+func tableExists(ctx context.Context, q querier, name string) (bool, error) {
+	var count int
+	if err := q.QueryRowContext(ctx, "SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?", name).Scan(&count); err != nil {
+		return false, fmt.Errorf("failed to check table existence: %w", err)
+	}
 	return count > 0, nil
 }
 
 // ColumnInfo represents information about a column
 type ColumnInfo struct {
-	Name    string
-	Type    string
-	NotNull bool
-	Default *string
-	Primary bool
+	Name      string
+	Type      string
+	NotNull   bool
+	Default   *string
+	Primary   bool
+	Generated bool
+}
+
+// columnNames returns the names of the given columns
+func columnNames(cols []ColumnInfo) []string {
+	names := make([]string, len(cols))
+	for i, col := range cols {
+		names[i] = col.Name
+	}
+	return names
+}
+
+// columnKind is the portable value type of a SQLite column
+type columnKind int
+
+const (
+	kindString columnKind = iota
+	kindInt
+	kindReal
+	kindTimestamp
+	kindBytes
+)
+
+// columnKindOf maps a declared SQLite column type to a portable value type
+// following the SQLite type affinity rules
+func columnKindOf(colType string) columnKind {
+	t := strings.ToUpper(colType)
+	switch {
+	case strings.Contains(t, "DATE") || strings.Contains(t, "TIME"):
+		return kindTimestamp
+	case strings.Contains(t, "INT"):
+		return kindInt
+	case strings.Contains(t, "CHAR") || strings.Contains(t, "CLOB") || strings.Contains(t, "TEXT"):
+		return kindString
+	case strings.Contains(t, "BLOB"):
+		return kindBytes
+	case strings.Contains(t, "REAL") || strings.Contains(t, "FLOA") || strings.Contains(t, "DOUB"),
+		strings.Contains(t, "NUM") || strings.Contains(t, "DEC"):
+		return kindReal
+	default:
+		return kindString
+	}
 }
 
 // GetTableColumns returns the columns for a table
 func (c *Client) GetTableColumns(table string) ([]ColumnInfo, error) {
-	rows, err := c.db.Query(fmt.Sprintf("PRAGMA table_info(`%s`)", table))
+	c.schema.mu.Lock()
+	defer c.schema.mu.Unlock()
+
+	cols, ok := c.schema.columns[table]
+	if !ok {
+		var err error
+		if cols, err = tableColumns(context.Background(), c.db, table); err != nil {
+			return nil, err
+		}
+		if c.schema.columns == nil {
+			c.schema.columns = make(map[string][]ColumnInfo)
+		}
+		c.schema.columns[table] = cols
+	}
+	return slices.Clone(cols), nil
+}
+
+// tableColumns returns the columns for a table using the given connection or transaction
+func tableColumns(ctx context.Context, q querier, table string) ([]ColumnInfo, error) {
+	name, err := sqlbuild.QuoteIdent(table)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := q.QueryContext(ctx, fmt.Sprintf("PRAGMA table_xinfo(%s)", name))
 	if err != nil {
 		return nil, fmt.Errorf("failed to query columns for %s: %w", table, err)
 	}
@@ -112,28 +225,213 @@ func (c *Client) GetTableColumns(table string) ([]ColumnInfo, error) {
 		var notNull int
 		var dfltValue *string
 		var pk int
+		var hidden int
 
-		if err := rows.Scan(&cid, &name, &colType, &notNull, &dfltValue, &pk); err != nil {
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &dfltValue, &pk, &hidden); err != nil {
 			return nil, fmt.Errorf("failed to scan column info: %w", err)
 		}
 
+		// hidden is 2 or 3 for virtual and stored generated columns
 		columns = append(columns, ColumnInfo{
-			Name:    name,
-			Type:    colType,
-			NotNull: notNull != 0,
-			Default: dfltValue,
-			Primary: pk != 0,
+			Name:      name,
+			Type:      colType,
+			NotNull:   notNull != 0,
+			Default:   dfltValue,
+			Primary:   pk != 0,
+			Generated: hidden == 2 || hidden == 3,
 		})
 	}
 
 	return columns, rows.Err()
 }
 
+// IsStrictTable reports whether a table was created with the STRICT option
+func (c *Client) IsStrictTable(table string) (bool, error) {
+	return isStrictTable(context.Background(), c.db, table)
+}
+
+// isStrictTable reports whether a table is STRICT, e.g. within a transaction
+func isStrictTable(ctx context.Context, q querier, table string) (bool, error) {
+	var strict int
+	err := q.QueryRowContext(ctx, "SELECT strict FROM pragma_table_list WHERE schema = 'main' AND name = ?", table).Scan(&strict)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to check strict mode of %s: %w", table, err)
+	}
+	return strict != 0, nil
+}
+
+// GetForeignKeyTables returns the tables referenced by foreign keys of a table
+func (c *Client) GetForeignKeyTables(table string) ([]string, error) {
+	rows, err := c.db.Query("SELECT DISTINCT \"table\" FROM pragma_foreign_key_list(?)", table)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query foreign keys for %s: %w", table, err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var refs []string
+	for rows.Next() {
+		var ref string
+		if err := rows.Scan(&ref); err != nil {
+			return nil, fmt.Errorf("failed to scan foreign key: %w", err)
+		}
+		refs = append(refs, ref)
+	}
+
+	return refs, rows.Err()
+}
+
+// OrderTablesByDependencies sorts tables so that tables referenced by foreign
+// keys come before the tables referencing them. Independent tables keep their
+// relative order, cycles are broken by the given order.
+func (c *Client) OrderTablesByDependencies(tables []string) ([]string, error) {
+	inSet := make(map[string]bool)
+	for _, table := range tables {
+		inSet[table] = true
+	}
+
+	visited := make(map[string]bool)
+	var ordered []string
+
+	var visit func(table string) error
+	visit = func(table string) error {
+		if visited[table] {
+			return nil
+		}
+		visited[table] = true
+
+		refs, err := c.GetForeignKeyTables(table)
+		if err != nil {
+			return err
+		}
+		for _, ref := range refs {
+			if inSet[ref] {
+				if err := visit(ref); err != nil {
+					return err
+				}
+			}
+		}
+
+		ordered = append(ordered, table)
+		return nil
+	}
+
+	for _, table := range tables {
+		if err := visit(table); err != nil {
+			return nil, err
+		}
+	}
+
+	return ordered, nil
+}
+
+// UserVersion returns the schema version stored in PRAGMA user_version
+func (c *Client) UserVersion() (int, error) {
+	return c.pragmaInt("user_version")
+}
+
+// ApplicationID returns the application identifier stored in PRAGMA application_id
+func (c *Client) ApplicationID() (int, error) {
+	return c.pragmaInt("application_id")
+}
+
+// DB returns the database handle for custom queries. Prefer RunInTx for writes,
+// and call InvalidateSchema after changing tables through the handle.
+func (c *Client) DB() *sql.DB {
+	return c.db
+}
+
+// Path returns the path or URI the database was opened with
+func (c *Client) Path() string {
+	return c.path
+}
+
+// SQLiteVersion returns the version of the SQLite library
+func (c *Client) SQLiteVersion() (string, error) {
+	var version string
+	if err := c.db.QueryRow("SELECT sqlite_version()").Scan(&version); err != nil {
+		return "", fmt.Errorf("failed to read SQLite version: %w", err)
+	}
+	return version, nil
+}
+
+// JournalMode returns the journal mode stored in PRAGMA journal_mode, e.g. wal or delete
+func (c *Client) JournalMode() (string, error) {
+	var mode string
+	if err := c.db.QueryRow("PRAGMA journal_mode").Scan(&mode); err != nil {
+		return "", fmt.Errorf("failed to read journal_mode: %w", err)
+	}
+	return mode, nil
+}
+
+// PageSize returns the page size in bytes stored in PRAGMA page_size
+func (c *Client) PageSize() (int, error) {
+	return c.pragmaInt("page_size")
+}
+
+// ReadOnly reports whether the database was opened read-only, by URI parameter
+// mode=ro or immutable=1, or is set to PRAGMA query_only
+func (c *Client) ReadOnly() (bool, error) {
+	if strings.HasPrefix(c.path, "file:") && (strings.Contains(c.path, "mode=ro") || strings.Contains(c.path, "immutable=1")) {
+		return true, nil
+	}
+	queryOnly, err := c.pragmaInt("query_only")
+	return queryOnly == 1, err
+}
+
+// Environment describes the database file and the SQLite library of a client
+type Environment struct {
+	Path          string `json:"path"`
+	SQLiteVersion string `json:"sqlite_version"`
+	JournalMode   string `json:"journal_mode"`
+	PageSize      int    `json:"page_size"`
+	ReadOnly      bool   `json:"read_only"`
+	UserVersion   int    `json:"user_version"`
+}
+
+// Environment returns the path and the SQLite environment of the database
+func (c *Client) Environment() (*Environment, error) {
+	env := &Environment{Path: c.path}
+
+	var err error
+	if env.SQLiteVersion, err = c.SQLiteVersion(); err != nil {
+		return nil, err
+	}
+	if env.JournalMode, err = c.JournalMode(); err != nil {
+		return nil, err
+	}
+	if env.PageSize, err = c.PageSize(); err != nil {
+		return nil, err
+	}
+	if env.ReadOnly, err = c.ReadOnly(); err != nil {
+		return nil, err
+	}
+	if env.UserVersion, err = c.UserVersion(); err != nil {
+		return nil, err
+	}
+	return env, nil
+}
+
+// pragmaInt reads an integer pragma
+func (c *Client) pragmaInt(name string) (int, error) {
+	var value int
+	if err := c.db.QueryRow("PRAGMA " + name).Scan(&value); err != nil {
+		return 0, fmt.Errorf("failed to read %s: %w", name, err)
+	}
+	return value, nil
+}
+
 // GetRowCount returns the number of rows in a table
 func (c *Client) GetRowCount(table string) (int, error) {
-	var count int
-	err := c.db.QueryRow(fmt.Sprintf("SELECT COUNT(*) FROM `%s`", table)).Scan(&count)
+	query, err := sqlbuild.Count(table)
 	if err != nil {
+		return 0, err
+	}
+
+	var count int
+	if err := c.db.QueryRow(query).Scan(&count); err != nil {
 		return 0, fmt.Errorf("failed to count rows in %s: %w", table, err)
 	}
 	return count, nil
@@ -141,17 +439,22 @@ func (c *Client) GetRowCount(table string) (int, error) {
 
 // GetConfigTables returns the list of configuration tables
 func (c *Client) GetConfigTables() []string {
-	return []string{"settings", "configs", "caches"}
+	return TablesOf(CategoryConfig)
 }
 
 // GetMetricsTables returns the list of metrics tables
 func (c *Client) GetMetricsTables() []string {
-	return []string{"meters", "sessions", "grid_sessions"}
+	return TablesOf(CategoryMetrics)
+}
+
+// GetCacheTables returns the list of cache tables
+func (c *Client) GetCacheTables() []string {
+	return TablesOf(CategoryCache)
 }
 
 // GetAllTables returns all known tables
 func (c *Client) GetAllTables() []string {
-	return append(c.GetConfigTables(), c.GetMetricsTables()...)
+	return TablesOf(CategoryConfig, CategoryCache, CategoryMetrics)
 }
 
 // ResolveTables returns the list of tables based on the transfer mode
@@ -165,14 +468,27 @@ func (c *Client) ResolveTables(opts TransferOptions) ([]string, error) {
 		return opts.Tables, nil
 	}
 
+	// Modes redefined by the user list their tables explicitly
+	if tables, ok := transferModeOverride(opts.Mode); ok {
+		return c.ResolveTables(TransferOptions{Tables: tables})
+	}
+
+	var categories []TableCategory
 	switch opts.Mode {
 	case TransferConfig:
-		return c.GetConfigTables(), nil
+		categories = []TableCategory{CategoryConfig}
 	case TransferMetrics:
-		return c.GetMetricsTables(), nil
+		categories = []TableCategory{CategoryMetrics}
 	case TransferAll:
-		return c.GetAllTables(), nil
+		categories = []TableCategory{CategoryConfig, CategoryMetrics}
 	default:
 		return nil, fmt.Errorf("unknown transfer mode: %d", opts.Mode)
 	}
+
+	// Caches hold volatile tokens that should not normally be restored
+	if opts.IncludeCaches && opts.Mode != TransferMetrics {
+		categories = append(categories, CategoryCache)
+	}
+
+	return TablesOf(categories...), nil
 }
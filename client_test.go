@@ -1,6 +1,7 @@
 package evccdb
 
 import (
+	"strings"
 	"testing"
 )
 
@@ -106,19 +107,56 @@ func TestGetRowCount(t *testing.T) {
 	}
 }
 
+func TestSchemaCache(t *testing.T) {
+	client, cleanup := createTestDB(t)
+	defer cleanup()
+
+	if exists, _ := client.TableExists("extra"); exists {
+		t.Fatal("Table extra should not exist")
+	}
+	cols, _ := client.GetTableColumns("caches")
+
+	// Schema changes through the raw connection are not seen until the cache is invalidated
+	if _, err := client.db.Exec("CREATE TABLE extra (id INTEGER); ALTER TABLE caches ADD COLUMN ttl INTEGER"); err != nil {
+		t.Fatal(err)
+	}
+	if exists, _ := client.TableExists("extra"); exists {
+		t.Error("Expected cached table list")
+	}
+	if got, _ := client.GetTableColumns("caches"); len(got) != len(cols) {
+		t.Error("Expected cached columns")
+	}
+
+	client.InvalidateSchema()
+
+	if exists, _ := client.TableExists("extra"); !exists {
+		t.Error("Expected table extra after invalidation")
+	}
+	if got, _ := client.GetTableColumns("caches"); len(got) != len(cols)+1 {
+		t.Errorf("Expected %d columns after invalidation, got %d", len(cols)+1, len(got))
+	}
+}
+
 func TestResolveTables(t *testing.T) {
 	client, cleanup := createTestDB(t)
 	defer cleanup()
 
 	tests := []struct {
-		name     string
-		mode     TransferMode
-		expected []string
+		name          string
+		mode          TransferMode
+		includeCaches bool
+		expected      []string
 	}{
 		{
 			name:     "Config mode",
 			mode:     TransferConfig,
-			expected: []string{"settings", "configs", "caches"},
+			expected: []string{"settings", "configs"},
+		},
+		{
+			name:          "Config mode with caches",
+			mode:          TransferConfig,
+			includeCaches: true,
+			expected:      []string{"settings", "configs", "caches"},
 		},
 		{
 			name:     "Metrics mode",
@@ -129,7 +167,7 @@ func TestResolveTables(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			tables, err := client.ResolveTables(TransferOptions{Mode: tt.mode})
+			tables, err := client.ResolveTables(TransferOptions{Mode: tt.mode, IncludeCaches: tt.includeCaches})
 			if err != nil {
 				t.Fatalf("Failed to resolve tables: %v", err)
 			}
@@ -193,3 +231,39 @@ func TestCloseNilDatabase(t *testing.T) {
 		t.Fatalf("Close on nil database should not error: %v", err)
 	}
 }
+
+func TestImportRejectsInvalidTableName(t *testing.T) {
+	client, cleanup := createTestDB(t)
+	defer cleanup()
+
+	export := `{"version": "1", "tables": {"settings` + "`" + `; DROP TABLE sessions; --": [{"key": "a", "value": "b"}]}}`
+	if err := client.ImportJSON(strings.NewReader(export), TransferOptions{Mode: TransferAll, AllowUnknownTables: true}); err == nil {
+		t.Fatal("Expected import of invalid table name to fail")
+	}
+
+	if exists, _ := client.TableExists("sessions"); !exists {
+		t.Error("sessions table was dropped")
+	}
+}
+
+func TestEnvironment(t *testing.T) {
+	client, cleanup := createTestDB(t)
+	defer cleanup()
+
+	env, err := client.Environment()
+	if err != nil {
+		t.Fatalf("Environment failed: %v", err)
+	}
+	if env.Path != client.Path() || env.SQLiteVersion == "" || env.JournalMode != "delete" || env.PageSize == 0 || env.ReadOnly {
+		t.Errorf("Unexpected environment: %+v", env)
+	}
+
+	ro, err := Open("file:" + client.Path() + "?mode=ro")
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer func() { _ = ro.Close() }()
+	if readOnly, err := ro.ReadOnly(); err != nil || !readOnly {
+		t.Errorf("Expected read-only database, got %v, err %v", readOnly, err)
+	}
+}
@@ -0,0 +1,222 @@
+package evccdb
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/iseeberg79/evccdb/internal/sqlbuild"
+	sqlite3 "github.com/mattn/go-sqlite3"
+)
+
+// clonePages is the number of pages copied per backup step between cancellation checks
+const clonePages = 1024
+
+// CheckClone reports why the source cannot be cloned into the destination. Cloning
+// needs an empty destination that has no tables or exactly the tables of the source
+// with the same schema.
+func CheckClone(src, dst *Client, opts TransferOptions) error {
+	switch {
+	case opts.Incremental:
+		return fmt.Errorf("clone cannot be combined with incremental transfers")
+	case opts.TimeOffset != 0:
+		return fmt.Errorf("clone cannot shift timestamps")
+	case opts.SettingsMerge != MergeReplace || opts.OnSettingConflict != nil:
+		return fmt.Errorf("clone cannot merge settings")
+	case opts.guestOptions():
+		return fmt.Errorf("clone cannot filter guest sessions")
+	case len(opts.Classes) > 0:
+		return fmt.Errorf("clone cannot filter config classes")
+	case opts.MaxRowsPerTable > 0:
+		return fmt.Errorf("clone cannot limit the rows per table")
+	case strings.TrimSpace(opts.PreSQL) != "":
+		return fmt.Errorf("clone cannot run SQL before copying, use post SQL")
+	}
+
+	srcTables, err := src.GetTables()
+	if err != nil {
+		return err
+	}
+	dstTables, err := dst.GetTables()
+	if err != nil {
+		return err
+	}
+	if len(dstTables) == 0 {
+		return nil
+	}
+	if !slices.Equal(srcTables, dstTables) {
+		return fmt.Errorf("destination tables differ from the source")
+	}
+
+	for _, table := range dstTables {
+		srcHash, err := src.schemaHash(table)
+		if err != nil {
+			return err
+		}
+		dstHash, err := dst.schemaHash(table)
+		if err != nil {
+			return err
+		}
+		if srcHash != dstHash {
+			return fmt.Errorf("schema of table %s differs", table)
+		}
+
+		count, err := dst.GetRowCount(table)
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			return fmt.Errorf("destination table %s is not empty", table)
+		}
+	}
+	return nil
+}
+
+// cloneTransfer copies the whole source database page by page into the destination
+// with the SQLite backup API, which also adopts the page size of the source. Tables
+// that are not transferred are emptied afterwards, or dropped if the destination did
+// not have them.
+func cloneTransfer(ctx context.Context, src, dst *Client, tables []string, opts TransferOptions) error {
+	if err := CheckClone(src, dst, opts); err != nil {
+		return fmt.Errorf("cannot clone: %w", err)
+	}
+
+	srcTables, err := src.GetTables()
+	if err != nil {
+		return err
+	}
+	dstTables, err := dst.GetTables()
+	if err != nil {
+		return err
+	}
+
+	// The clone needs room for all pages of the source
+	size, err := src.FileSize()
+	if err != nil {
+		return err
+	}
+	file, err := dst.File()
+	if err != nil {
+		return err
+	}
+	if file != "" {
+		if err := CheckFreeSpace(file, size); err != nil {
+			return err
+		}
+	}
+
+	if err := backupInto(ctx, src, dst); err != nil {
+		return err
+	}
+	dst.InvalidateSchema()
+
+	tx, err := dst.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if slices.Contains(tables, "configs") && !opts.KeepTemplates {
+		if _, err := remapTemplates(ctx, tx.tx, opts.OnTemplateAlias); err != nil {
+			return err
+		}
+	}
+
+	for _, rename := range opts.LoadpointRenames {
+		if _, err := tx.RenameLoadpoint(ctx, rename.OldName, rename.NewName); err != nil {
+			return fmt.Errorf("failed to rename loadpoint %q to %q: %w", rename.OldName, rename.NewName, err)
+		}
+	}
+	for _, rename := range opts.VehicleRenames {
+		if _, err := tx.RenameVehicle(ctx, rename.OldName, rename.NewName); err != nil {
+			return fmt.Errorf("failed to rename vehicle %q to %q: %w", rename.OldName, rename.NewName, err)
+		}
+	}
+
+	// The destination was empty, so the identity settings are all copies of the source
+	if opts.StripInstanceIdentity && slices.Contains(tables, "settings") {
+		if _, err := stripIdentitySettings(ctx, tx.tx); err != nil {
+			return err
+		}
+	}
+
+	// Renames may touch tables that are removed, so they run first
+	for _, table := range srcTables {
+		if slices.Contains(tables, table) {
+			continue
+		}
+		name, err := sqlbuild.QuoteIdent(table)
+		if err != nil {
+			return err
+		}
+		stmt := "DROP TABLE " + name
+		if slices.Contains(dstTables, table) {
+			stmt = "DELETE FROM " + name
+		}
+		if _, err := tx.tx.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("failed to remove table %s: %w", table, err)
+		}
+	}
+
+	if err := runSQL(ctx, tx.tx, "post", opts.PostSQL); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	if opts.OnProgress != nil {
+		for _, table := range tables {
+			if count, err := dst.GetRowCount(table); err == nil {
+				opts.OnProgress(table, count)
+			}
+		}
+	}
+	return nil
+}
+
+// backupInto replaces the content of dst with src using the SQLite backup API
+func backupInto(ctx context.Context, src, dst *Client) error {
+	srcConn, err := src.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get connection: %w", err)
+	}
+	defer func() { _ = srcConn.Close() }()
+
+	dstConn, err := dst.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get connection: %w", err)
+	}
+	defer func() { _ = dstConn.Close() }()
+
+	return dstConn.Raw(func(d any) error {
+		return srcConn.Raw(func(s any) error {
+			dstRaw, ok := d.(*sqlite3.SQLiteConn)
+			srcRaw, ok2 := s.(*sqlite3.SQLiteConn)
+			if !ok || !ok2 {
+				return fmt.Errorf("clone needs sqlite3 connections")
+			}
+
+			b, err := dstRaw.Backup("main", srcRaw, "main")
+			if err != nil {
+				return fmt.Errorf("failed to start clone: %w", err)
+			}
+			for done := false; !done; {
+				if err := ctx.Err(); err != nil {
+					_ = b.Close()
+					return err
+				}
+				if done, err = b.Step(clonePages); err != nil {
+					_ = b.Close()
+					return fmt.Errorf("failed to clone database: %w", err)
+				}
+			}
+			if err := b.Finish(); err != nil {
+				return fmt.Errorf("failed to clone database: %w", err)
+			}
+			return nil
+		})
+	})
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/iseeberg79/evccdb"
+)
+
+// renameRequest is the body of the rename endpoints
+type renameRequest struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// deleteRequest is the body of the delete endpoint
+type deleteRequest struct {
+	Loadpoint string `json:"loadpoint"`
+	Vehicle   string `json:"vehicle"`
+}
+
+// adminRoutes registers the write endpoints, all accept dryRun=true to only count affected rows
+func (s *server) adminRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("/rename/loadpoint", s.writable(s.handleRename(s.client.RenameLoadpoint, s.client.RenameLoadpointDryRun)))
+	mux.HandleFunc("/rename/vehicle", s.writable(s.handleRename(s.client.RenameVehicle, s.client.RenameVehicleDryRun)))
+	mux.HandleFunc("/delete/sessions", s.writable(s.handleDeleteSessions))
+}
+
+// renameFunc renames a loadpoint or vehicle
+type renameFunc func(ctx context.Context, oldName, newName string) (evccdb.RenameResult, error)
+
+// handleRename returns a handler renaming from to to, or counting the affected rows on dry run
+func (s *server) handleRename(rename, dryRun renameFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req renameRequest
+		dry, ok := decodeAdminRequest(w, r, &req)
+		if !ok {
+			return
+		}
+		if req.From == "" || req.To == "" {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "from and to are required"})
+			return
+		}
+
+		fn := dryRun
+		if !dry {
+			unlock, ok := s.beginWrite(w, r)
+			if !ok {
+				return
+			}
+			defer unlock()
+			fn = rename
+		}
+
+		result, err := fn(r.Context(), req.From, req.To)
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, result)
+	}
+}
+
+// handleDeleteSessions deletes the sessions of a loadpoint or vehicle, or counts them on dry run
+func (s *server) handleDeleteSessions(w http.ResponseWriter, r *http.Request) {
+	var req deleteRequest
+	dry, ok := decodeAdminRequest(w, r, &req)
+	if !ok {
+		return
+	}
+	if (req.Loadpoint == "") == (req.Vehicle == "") {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "exactly one of loadpoint or vehicle is required"})
+		return
+	}
+
+	if !dry {
+		unlock, ok := s.beginWrite(w, r)
+		if !ok {
+			return
+		}
+		defer unlock()
+	}
+
+	var result evccdb.RenameResult
+	var err error
+	switch {
+	case req.Loadpoint != "" && dry:
+		result.Sessions, err = s.client.CountLoadpointSessions(r.Context(), req.Loadpoint)
+	case req.Loadpoint != "":
+		result.Sessions, err = s.client.DeleteLoadpointSessions(r.Context(), req.Loadpoint)
+	case dry:
+		result.Sessions, err = s.client.CountVehicleSessions(r.Context(), req.Vehicle)
+	default:
+		result.Sessions, err = s.client.DeleteVehicleSessions(r.Context(), req.Vehicle)
+	}
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+// decodeAdminRequest checks the method and content type, decodes the JSON body into v and
+// returns the dryRun parameter
+func decodeAdminRequest(w http.ResponseWriter, r *http.Request, v any) (bool, bool) {
+	if r.Method != http.MethodPost {
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+		return false, false
+	}
+	if !requireJSON(w, r) {
+		return false, false
+	}
+
+	dry := false
+	if param := r.URL.Query().Get("dryRun"); param != "" {
+		var err error
+		if dry, err = strconv.ParseBool(param); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("invalid dryRun %q", param)})
+			return false, false
+		}
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("invalid request body: %v", err)})
+		return false, false
+	}
+
+	return dry, true
+}
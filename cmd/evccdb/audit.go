@@ -0,0 +1,222 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/user"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/iseeberg79/evccdb"
+	"github.com/spf13/cobra"
+)
+
+// audit collects the databases written by the running command and the affected rows
+var audit struct {
+	mu   sync.Mutex
+	dbs  []string
+	rows map[string]int
+}
+
+// auditDatabase registers a database written by the running command
+func auditDatabase(path string) {
+	audit.mu.Lock()
+	defer audit.mu.Unlock()
+	audit.dbs = append(audit.dbs, path)
+}
+
+// auditRows adds affected rows of a table to the audit entry of the running command
+func auditRows(table string, count int) {
+	audit.mu.Lock()
+	defer audit.mu.Unlock()
+	if audit.rows == nil {
+		audit.rows = make(map[string]int)
+	}
+	audit.rows[table] += count
+}
+
+// auditRenameResult adds the rows changed by a rename
+func auditRenameResult(result evccdb.RenameResult) {
+	auditRows("sessions", result.Sessions)
+	auditRows("settings", result.Settings)
+	auditRows("configs", result.Configs)
+}
+
+// writeAudit appends the finished command to the audit log of every database it wrote
+func writeAudit(cmd *cobra.Command, start time.Time, err error) {
+	audit.mu.Lock()
+	defer audit.mu.Unlock()
+	if cmd == nil || len(audit.dbs) == 0 {
+		return
+	}
+
+	entry := newAuditEntry(strings.TrimPrefix(cmd.CommandPath(), cmd.Root().Name()+" "), os.Args[1:], start)
+	entry.Rows = audit.rows
+	if err != nil {
+		entry.Error = err.Error()
+	}
+
+	for _, db := range audit.dbs {
+		entry.Database = db
+		if err := evccdb.AppendAudit(db, entry); err != nil {
+			fmt.Fprintf(os.Stderr, evccdb.Translate("WARNING: %v\n"), err)
+		}
+	}
+}
+
+// newAuditEntry returns an audit entry of the current user and host
+func newAuditEntry(command string, args []string, start time.Time) evccdb.AuditEntry {
+	entry := evccdb.AuditEntry{
+		Time:       start.UTC(),
+		Command:    command,
+		Args:       args,
+		DurationMS: time.Since(start).Milliseconds(),
+	}
+	if u, err := user.Current(); err == nil {
+		entry.User = u.Username
+	}
+	entry.Host, _ = os.Hostname()
+	return entry
+}
+
+func runHistory(cmd *cobra.Command, args []string) error {
+	entries, err := evccdb.ReadAudit(historyDB)
+	if err != nil {
+		return err
+	}
+
+	result := &evccdb.QueryResult{Columns: []string{"time", "command", "rows", "duration", "user", "result"}}
+	for _, e := range entries {
+		tables := make([]string, 0, len(e.Rows))
+		for table := range e.Rows {
+			tables = append(tables, table)
+		}
+		sort.Strings(tables)
+
+		var rows []string
+		for _, table := range tables {
+			rows = append(rows, fmt.Sprintf("%s=%d", table, e.Rows[table]))
+		}
+
+		status := "ok"
+		if e.Error != "" {
+			status = "error: " + e.Error
+		}
+
+		result.Rows = append(result.Rows, []any{
+			e.Time.Local().Format("2006-01-02 15:04:05"),
+			shellJoin(e.Args),
+			strings.Join(rows, ", "),
+			(time.Duration(e.DurationMS) * time.Millisecond).String(),
+			e.User + "@" + e.Host,
+			status,
+		})
+	}
+
+	return writeQueryResult(os.Stdout, result, historyFormat)
+}
+
+// notReplayable are the commands whose audit entries a replay script skips, with the reason
+var notReplayable = map[string]string{
+	"serve":          "write request of serve",
+	"config orphans": "interactive",
+	"batch":          "targets are set by the config file",
+}
+
+// shellSafe matches arguments that need no quoting in a shell script
+var shellSafe = regexp.MustCompile(`^[A-Za-z0-9_@%+=:,./-]+$`)
+
+func runHistoryExport(cmd *cobra.Command, args []string) error {
+	entries, err := evccdb.ReadAudit(historyDB)
+	if err != nil {
+		return err
+	}
+
+	w := os.Stdout
+	if output != "" {
+		f, err := os.Create(output)
+		if err != nil {
+			return fmt.Errorf("failed to create output file: %w", err)
+		}
+		defer func() { _ = f.Close() }()
+		w = f
+	}
+
+	if !historyScript {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(entries)
+	}
+
+	writeReplayScript(w, cmd.Root(), historyDB, entries)
+
+	if output != "" {
+		return os.Chmod(output, 0o755)
+	}
+	return nil
+}
+
+// commentEscaper escapes line breaks, which would end a shell comment and run the rest of the line
+var commentEscaper = strings.NewReplacer("\r", `\r`, "\n", `\n`)
+
+// writeReplayScript writes a shell script repeating the entries against the database in $1
+func writeReplayScript(w io.Writer, root *cobra.Command, db string, entries []evccdb.AuditEntry) {
+	fmt.Fprintf(w, "#!/bin/sh\n# Replays the write operations recorded for %s\n", commentEscaper.Replace(db))
+	fmt.Fprintf(w, "set -e\nDB=\"${1:?usage: $0 <database>}\"\n")
+	for _, e := range entries {
+		fmt.Fprintf(w, "\n# %s\n", commentEscaper.Replace(fmt.Sprintf("%s %s@%s", e.Time.Local().Format("2006-01-02 15:04:05"), e.User, e.Host)))
+		switch reason, skip := notReplayable[e.Command]; {
+		case skip:
+			fmt.Fprintf(w, "# %s\n", commentEscaper.Replace(fmt.Sprintf("skipped (%s): %s", reason, shellJoin(e.Args))))
+		case e.Error != "":
+			fmt.Fprintf(w, "# %s\n", commentEscaper.Replace(fmt.Sprintf("skipped (failed: %s): %s", e.Error, shellJoin(e.Args))))
+		default:
+			fmt.Fprintln(w, replayCommand(root, e))
+		}
+	}
+}
+
+// replayCommand returns the shell command repeating the entry against the database
+// in $DB, confirmation prompts are answered by --yes
+func replayCommand(root *cobra.Command, e evccdb.AuditEntry) string {
+	words := []string{"evccdb"}
+	yes := false
+	for _, arg := range e.Args {
+		switch {
+		case arg == e.Database:
+			words = append(words, `"$DB"`)
+		case strings.HasPrefix(arg, "-") && strings.HasSuffix(arg, "="+e.Database):
+			words = append(words, strings.TrimSuffix(arg, e.Database)+`"$DB"`)
+		default:
+			words = append(words, shellQuote(arg))
+		}
+		yes = yes || arg == "-y" || arg == "--yes"
+	}
+
+	if c, _, err := root.Find(e.Args); err == nil && !yes && c.Flags().Lookup("yes") != nil {
+		words = append(words, "--yes")
+	}
+	return strings.Join(words, " ")
+}
+
+// shellJoin quotes and joins the arguments of a command line
+func shellJoin(args []string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		quoted[i] = shellQuote(arg)
+	}
+	return strings.Join(quoted, " ")
+}
+
+// shellQuote quotes s for a POSIX shell
+func shellQuote(s string) string {
+	if shellSafe.MatchString(s) {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/iseeberg79/evccdb"
+	"github.com/spf13/cobra"
+)
+
+func TestReplayScriptEscapesComments(t *testing.T) {
+	entries := []evccdb.AuditEntry{
+		{Time: time.Now(), Command: "serve", Database: "evcc.db", Args: []string{"serve", "--db", "evcc.db", "--note", "a\ntouch /tmp/pwned"}, User: "root", Host: "host"},
+		{Time: time.Now(), Command: "import", Database: "evcc.db", Args: []string{"import", "--target", "evcc.db"}, User: "root\nid", Host: "host", Error: "failed\ntouch /tmp/pwned"},
+	}
+
+	var buf bytes.Buffer
+	writeReplayScript(&buf, &cobra.Command{Use: "evccdb"}, "evcc.db", entries)
+
+	// Every line is a comment or a line written by the script itself, none runs a command of the entries
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line != "" && !strings.HasPrefix(line, "#") && line != "set -e" && !strings.HasPrefix(line, "DB=") {
+			t.Errorf("Unexpected command in replay script: %q\n%s", line, buf.String())
+		}
+	}
+	if !strings.Contains(buf.String(), `'a\ntouch /tmp/pwned'`) {
+		t.Errorf("Expected the escaped argument in the comment:\n%s", buf.String())
+	}
+}
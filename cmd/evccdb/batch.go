@@ -0,0 +1,348 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/iseeberg79/evccdb"
+	"github.com/spf13/cobra"
+)
+
+// batchStep is one operation of a batch, exactly one of the operations is set
+type batchStep struct {
+	Name     string         `yaml:"name"`
+	DryRun   bool           `yaml:"dry_run"`
+	Backup   *batchBackup   `yaml:"backup"`
+	Transfer *batchTransfer `yaml:"transfer"`
+	Rename   *batchSessions `yaml:"rename"`
+	Delete   *batchSessions `yaml:"delete"`
+}
+
+// batchBackup snapshots a database
+type batchBackup struct {
+	DB     string `yaml:"db"`
+	Output string `yaml:"output"`
+}
+
+// batchTransfer copies tables between databases
+type batchTransfer struct {
+	From            string   `yaml:"from"`
+	To              string   `yaml:"to"`
+	Mode            string   `yaml:"mode"`
+	Tables          []string `yaml:"tables"`
+	RenameLoadpoint string   `yaml:"rename_loadpoint"`
+	RenameVehicle   string   `yaml:"rename_vehicle"`
+	Incremental     bool     `yaml:"incremental"`
+}
+
+// batchSessions selects loadpoints and vehicles of a database. Rename
+// steps take "Old:New" pairs, delete steps take names.
+type batchSessions struct {
+	DB        string `yaml:"db"`
+	Loadpoint string `yaml:"loadpoint"`
+	Vehicle   string `yaml:"vehicle"`
+	Trash     bool   `yaml:"trash"`
+}
+
+func runBatch(cmd *cobra.Command, args []string) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	if len(cfg.Batch) == 0 {
+		return fmt.Errorf("config file defines no batch steps")
+	}
+
+	// Validate all steps before anything is written
+	for i, step := range cfg.Batch {
+		if _, err := step.describe(); err != nil {
+			return fmt.Errorf("step %d: %w", i+1, err)
+		}
+	}
+
+	if !dryRun && !assumeYes && !confirm() {
+		fmt.Println(evccdb.Translate("Operation cancelled"))
+		return nil
+	}
+
+	snapshotDir, err := os.MkdirTemp("", "evccdb-batch-")
+	if err != nil {
+		return fmt.Errorf("failed to create snapshot directory: %w", err)
+	}
+	defer func() { _ = os.RemoveAll(snapshotDir) }()
+
+	// snapshots maps each database written by the batch to its state before the batch
+	snapshots := make(map[string]string)
+	ctx := cmd.Context()
+
+	for i, step := range cfg.Batch {
+		desc, _ := step.describe()
+		dry := dryRun || step.DryRun
+		if dry {
+			desc += evccdb.Translate(" (dry run)")
+		}
+		fmt.Printf(evccdb.Translate("Step %d/%d: %s\n"), i+1, len(cfg.Batch), desc)
+
+		if target := step.target(); target != "" && !dry {
+			if _, ok := snapshots[target]; !ok {
+				unlock, err := lockDatabase(ctx, target)
+				if err != nil {
+					return rollbackBatch(snapshots, fmt.Errorf("step %d: %w", i+1, err))
+				}
+				defer unlock()
+
+				snapshot := filepath.Join(snapshotDir, fmt.Sprintf("%d.db", len(snapshots)))
+				if err := snapshotDB(ctx, target, snapshot); err != nil {
+					return rollbackBatch(snapshots, fmt.Errorf("step %d: %w", i+1, err))
+				}
+				snapshots[target] = snapshot
+			}
+		}
+
+		if err := step.run(ctx, dry); err != nil {
+			return rollbackBatch(snapshots, fmt.Errorf("step %d (%s) failed: %w", i+1, desc, err))
+		}
+	}
+
+	fmt.Println(evccdb.Translate("Batch completed successfully"))
+	return nil
+}
+
+// describe summarizes the step and checks that exactly one operation is set
+func (s batchStep) describe() (string, error) {
+	var desc []string
+	if b := s.Backup; b != nil {
+		desc = append(desc, fmt.Sprintf("backup %s to %s", b.DB, b.Output))
+	}
+	if t := s.Transfer; t != nil {
+		desc = append(desc, fmt.Sprintf("transfer %s -> %s", t.From, t.To))
+	}
+	if r := s.Rename; r != nil {
+		desc = append(desc, fmt.Sprintf("rename in %s", r.DB))
+	}
+	if d := s.Delete; d != nil {
+		desc = append(desc, fmt.Sprintf("delete sessions in %s", d.DB))
+	}
+
+	if len(desc) != 1 {
+		return "", fmt.Errorf("expected exactly one of backup, transfer, rename or delete")
+	}
+	if s.Name != "" {
+		return s.Name + ": " + desc[0], nil
+	}
+	return desc[0], nil
+}
+
+// target returns the database the step writes to
+func (s batchStep) target() string {
+	switch {
+	case s.Transfer != nil:
+		return s.Transfer.To
+	case s.Rename != nil:
+		return s.Rename.DB
+	case s.Delete != nil:
+		return s.Delete.DB
+	default:
+		return ""
+	}
+}
+
+// run executes the step, each step runs in its own transaction
+func (s batchStep) run(ctx context.Context, dry bool) error {
+	switch {
+	case s.Backup != nil:
+		if dry {
+			return nil
+		}
+		client, err := evccdb.Open(s.Backup.DB)
+		if err != nil {
+			return fmt.Errorf("failed to open database: %w", err)
+		}
+		defer func() { _ = client.Close() }()
+		return client.Backup(ctx, s.Backup.Output)
+
+	case s.Transfer != nil:
+		return s.Transfer.run(ctx, dry)
+
+	case s.Rename != nil:
+		return s.Rename.rename(ctx, dry)
+
+	default:
+		return s.Delete.delete(ctx, dry)
+	}
+}
+
+// run transfers the tables
+func (t *batchTransfer) run(ctx context.Context, dry bool) error {
+	src, err := evccdb.Open(t.From)
+	if err != nil {
+		return fmt.Errorf("failed to open source database: %w", err)
+	}
+	defer func() { _ = src.Close() }()
+
+	dst, err := evccdb.Open(t.To)
+	if err != nil {
+		return fmt.Errorf("failed to open destination database: %w", err)
+	}
+	defer func() { _ = dst.Close() }()
+
+	opts := evccdb.TransferOptions{
+		Mode:        parseMode(t.Mode),
+		Tables:      t.Tables,
+		DryRun:      dry,
+		Incremental: t.Incremental,
+		OnProgress: func(table string, count int) {
+			fmt.Printf(evccdb.Translate("  %s: %d rows\n"), table, count)
+		},
+	}
+	if opts.LoadpointRenames, err = parseRenames(t.RenameLoadpoint); err != nil {
+		return fmt.Errorf("invalid rename_loadpoint: %w", err)
+	}
+	if opts.VehicleRenames, err = parseRenames(t.RenameVehicle); err != nil {
+		return fmt.Errorf("invalid rename_vehicle: %w", err)
+	}
+
+	return evccdb.Transfer(ctx, src, dst, opts)
+}
+
+// rename renames the loadpoints and vehicles given as Old:New pairs
+func (r *batchSessions) rename(ctx context.Context, dry bool) error {
+	loadpoints, err := parseRenames(r.Loadpoint)
+	if err != nil {
+		return fmt.Errorf("invalid loadpoint: %w", err)
+	}
+	vehicles, err := parseRenames(r.Vehicle)
+	if err != nil {
+		return fmt.Errorf("invalid vehicle: %w", err)
+	}
+
+	return r.inTx(ctx, dry, func(client *evccdb.Client, tx *evccdb.Tx) error {
+		renames := []struct {
+			kind     string
+			mappings []evccdb.RenameMapping
+			rename   func(context.Context, string, string) (evccdb.RenameResult, error)
+		}{
+			{"loadpoint", loadpoints, client.RenameLoadpointDryRun},
+			{"vehicle", vehicles, client.RenameVehicleDryRun},
+		}
+		if tx != nil {
+			renames[0].rename, renames[1].rename = tx.RenameLoadpoint, tx.RenameVehicle
+		}
+
+		for _, r := range renames {
+			for _, m := range r.mappings {
+				result, err := r.rename(ctx, m.OldName, m.NewName)
+				if err != nil {
+					return fmt.Errorf("failed to rename %s %q: %w", r.kind, m.OldName, err)
+				}
+				fmt.Printf("  %s %q -> %q: %d sessions, %d settings, %d configs\n",
+					r.kind, m.OldName, m.NewName, result.Sessions, result.Settings, result.Configs)
+			}
+		}
+		return nil
+	})
+}
+
+// delete deletes or trashes the sessions of the given loadpoints and vehicles
+func (d *batchSessions) delete(ctx context.Context, dry bool) error {
+	return d.inTx(ctx, dry, func(client *evccdb.Client, tx *evccdb.Tx) error {
+		deletes := []struct {
+			kind   string
+			names  []string
+			delete func(context.Context, string) (int, error)
+		}{
+			{"loadpoint", parseNames(d.Loadpoint), client.CountLoadpointSessions},
+			{"vehicle", parseNames(d.Vehicle), client.CountVehicleSessions},
+		}
+		switch {
+		case tx != nil && d.Trash:
+			deletes[0].delete, deletes[1].delete = tx.TrashLoadpointSessions, tx.TrashVehicleSessions
+		case tx != nil:
+			deletes[0].delete, deletes[1].delete = tx.DeleteLoadpointSessions, tx.DeleteVehicleSessions
+		}
+
+		for _, del := range deletes {
+			for _, name := range del.names {
+				count, err := del.delete(ctx, name)
+				if err != nil {
+					return fmt.Errorf("failed to delete sessions for %s %q: %w", del.kind, name, err)
+				}
+				fmt.Printf("  %s %q: %d sessions\n", del.kind, name, count)
+			}
+		}
+		return nil
+	})
+}
+
+// inTx opens the database and runs fn in a transaction, tx is nil on dry run
+func (r *batchSessions) inTx(ctx context.Context, dry bool, fn func(*evccdb.Client, *evccdb.Tx) error) error {
+	client, err := evccdb.Open(r.DB)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer func() { _ = client.Close() }()
+
+	if dry {
+		return fn(client, nil)
+	}
+
+	tx, err := client.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if err := fn(client, tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// snapshotDB backs up a database before the batch first writes to it
+func snapshotDB(ctx context.Context, path, snapshot string) error {
+	if _, err := os.Stat(path); err != nil {
+		return fmt.Errorf("failed to snapshot %s: %w", path, err)
+	}
+
+	client, err := evccdb.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer func() { _ = client.Close() }()
+
+	return client.Backup(ctx, snapshot)
+}
+
+// rollbackBatch restores every database written by the batch to its state before the batch
+func rollbackBatch(snapshots map[string]string, cause error) error {
+	for path, snapshot := range snapshots {
+		if err := copyFile(snapshot, path); err != nil {
+			fmt.Printf(evccdb.Translate("WARNING: failed to roll back %s: %v\n"), path, err)
+			continue
+		}
+		fmt.Printf(evccdb.Translate("Rolled back %s\n"), path)
+	}
+	return cause
+}
+
+// copyFile replaces dst with the content of src
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = in.Close() }()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		_ = out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/iseeberg79/evccdb"
+	"github.com/spf13/cobra"
+)
+
+func runCacheClear(cmd *cobra.Command, args []string) error {
+	if !dryRun && !assumeYes && !confirm() {
+		fmt.Println(evccdb.Translate("Operation cancelled"))
+		return nil
+	}
+
+	client, err := evccdb.Open(cacheDB)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer func() { _ = client.Close() }()
+	ctx := cmd.Context()
+
+	if dryRun {
+		count, err := client.CountCaches(ctx, cachePrefix)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Would delete %d cache entries\n", count)
+		return nil
+	}
+
+	unlock, err := lockDatabase(ctx, cacheDB)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if err := backupBeforeWrite(ctx, client, cacheDB); err != nil {
+		return err
+	}
+
+	count, err := client.ClearCaches(ctx, cachePrefix)
+	if err != nil {
+		return err
+	}
+	auditRows("caches", count)
+	fmt.Printf("Deleted %d cache entries\n", count)
+	return nil
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"math"
+	"os"
+	"strings"
+
+	"github.com/iseeberg79/evccdb"
+	"github.com/spf13/cobra"
+)
+
+const (
+	barWidth   = 40
+	sparkRunes = "▁▂▃▄▅▆▇█"
+)
+
+func runChart(cmd *cobra.Command, args []string) error {
+	client, err := evccdb.Open(chartDB)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer func() { _ = client.Close() }()
+
+	points, err := client.Series(cmd.Context(), chartMetric, evccdb.SeriesFilter{
+		Vehicle:   chartVehicle,
+		Loadpoint: chartLoadpoint,
+	})
+	if err != nil {
+		return err
+	}
+	if len(points) == 0 {
+		fmt.Println("No data")
+		return nil
+	}
+
+	if output != "" {
+		f, err := os.Create(output)
+		if err != nil {
+			return fmt.Errorf("failed to create output file: %w", err)
+		}
+		defer func() { _ = f.Close() }()
+
+		if err := renderSVG(f, chartMetric, points); err != nil {
+			return fmt.Errorf("failed to write chart: %w", err)
+		}
+		fmt.Printf("Chart written to %s\n", output)
+		return nil
+	}
+
+	switch chartStyle {
+	case "bars":
+		renderBars(os.Stdout, points)
+	case "sparkline":
+		fmt.Printf("%s %s %s\n", points[0].Label, renderSparkline(points), points[len(points)-1].Label)
+	default:
+		return fmt.Errorf("unknown style %q, expected bars or sparkline", chartStyle)
+	}
+	return nil
+}
+
+// maxValue returns the largest value of a series, at least 1 to avoid division by zero
+func maxValue(points []evccdb.SeriesPoint) float64 {
+	max := 0.0
+	for _, p := range points {
+		max = math.Max(max, p.Value)
+	}
+	if max == 0 {
+		return 1
+	}
+	return max
+}
+
+// renderBars writes one horizontal bar per point
+func renderBars(w io.Writer, points []evccdb.SeriesPoint) {
+	max := maxValue(points)
+	for _, p := range points {
+		n := int(math.Round(math.Max(p.Value, 0) / max * barWidth))
+		_, _ = fmt.Fprintf(w, "%-10s %-*s %.1f\n", p.Label, barWidth, strings.Repeat("█", n), p.Value)
+	}
+}
+
+// renderSparkline returns the series as a single line of block characters
+func renderSparkline(points []evccdb.SeriesPoint) string {
+	runes := []rune(sparkRunes)
+	max := maxValue(points)
+
+	var b strings.Builder
+	for _, p := range points {
+		i := int(math.Round(math.Max(p.Value, 0) / max * float64(len(runes)-1)))
+		b.WriteRune(runes[i])
+	}
+	return b.String()
+}
+
+// renderSVG writes a vertical bar chart as SVG
+func renderSVG(w io.Writer, title string, points []evccdb.SeriesPoint) error {
+	const (
+		height = 300
+		top    = 30
+		bottom = 60
+		step   = 24
+	)
+
+	width := len(points)*step + 40
+	if width < 240 {
+		width = 240
+	}
+	plot := float64(height - top - bottom)
+	max := maxValue(points)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" font-family="sans-serif" font-size="10">`+"\n", width, height)
+	fmt.Fprintf(&b, `<text x="20" y="18" font-size="14">%s</text>`+"\n", html.EscapeString(title))
+	for i, p := range points {
+		h := math.Max(p.Value, 0) / max * plot
+		x := 20 + i*step
+		y := float64(height-bottom) - h
+		fmt.Fprintf(&b, `<rect x="%d" y="%.1f" width="%d" height="%.1f" fill="#4caf50"><title>%s: %.2f</title></rect>`+"\n",
+			x, y, step-4, h, html.EscapeString(p.Label), p.Value)
+		fmt.Fprintf(&b, `<text transform="translate(%d,%d) rotate(60)">%s</text>`+"\n", x+4, height-bottom+8, html.EscapeString(p.Label))
+	}
+	b.WriteString("</svg>\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
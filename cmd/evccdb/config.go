@@ -0,0 +1,90 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/iseeberg79/evccdb"
+	"gopkg.in/yaml.v3"
+)
+
+// config is the content of the evccdb config file
+type config struct {
+	Queries map[string]struct {
+		Description string `yaml:"description"`
+		SQL         string `yaml:"sql"`
+	} `yaml:"queries"`
+	Batch            []batchStep                   `yaml:"batch"`
+	TemplateAliases  map[string]string             `yaml:"template_aliases"`
+	TemplateDefaults map[string]map[string]any     `yaml:"template_defaults"`
+	Modes            map[string][]string           `yaml:"modes"`
+	ExchangeRates    map[string]map[string]float64 `yaml:"exchange_rates"`
+}
+
+// defaultConfigPath returns the config file location in the user config directory
+func defaultConfigPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "evccdb", "config.yaml")
+}
+
+// loadConfig reads the config file, a missing default config file is not an error
+func loadConfig() (*config, error) {
+	path := configPath
+	if path == "" {
+		path = defaultConfigPath()
+	}
+	if path == "" {
+		return &config{}, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) && configPath == "" {
+		return &config{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var cfg config
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	for name, q := range cfg.Queries {
+		if q.SQL == "" {
+			return nil, fmt.Errorf("named query %q in %s has no sql", name, path)
+		}
+		evccdb.RegisterNamedQuery(evccdb.NamedQuery{Name: name, Description: q.Description, SQL: q.SQL})
+	}
+
+	for from, to := range cfg.TemplateAliases {
+		evccdb.RegisterTemplateAlias(evccdb.TemplateAlias{From: from, To: to})
+	}
+
+	for template, defaults := range cfg.TemplateDefaults {
+		evccdb.RegisterTemplateDefaults(template, defaults)
+	}
+
+	for name, tables := range cfg.Modes {
+		if len(tables) == 0 {
+			return nil, fmt.Errorf("mode %q in %s has no tables", name, path)
+		}
+		evccdb.RegisterTransferMode(name, tables)
+	}
+
+	for from, rates := range cfg.ExchangeRates {
+		for to, rate := range rates {
+			if rate <= 0 {
+				return nil, fmt.Errorf("exchange rate %s to %s in %s must be positive", from, to, path)
+			}
+			evccdb.RegisterExchangeRate(evccdb.ExchangeRate{From: from, To: to, Rate: rate})
+		}
+	}
+
+	return &cfg, nil
+}
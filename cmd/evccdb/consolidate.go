@@ -0,0 +1,110 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/iseeberg79/evccdb"
+	"github.com/spf13/cobra"
+)
+
+func runConsolidate(cmd *cobra.Command, args []string) error {
+	if _, err := loadConfig(); err != nil {
+		return err
+	}
+	ctx := cmd.Context()
+
+	var sources []evccdb.ConsolidateSource
+	defer func() {
+		for _, src := range sources {
+			_ = src.Client.Close()
+		}
+	}()
+
+	for _, path := range consolidateFrom {
+		client, err := evccdb.Open(path)
+		if err != nil {
+			return fmt.Errorf("failed to open database %s: %w", path, err)
+		}
+		src := evccdb.ConsolidateSource{Client: client}
+		if prefixLoadpoints {
+			src.LoadpointPrefix = evccdb.LoadpointPrefix(path)
+		}
+		sources = append(sources, src)
+	}
+
+	dst, err := evccdb.Open(transferDst)
+	if err != nil {
+		return fmt.Errorf("failed to open target database: %w", err)
+	}
+	defer func() { _ = dst.Close() }()
+
+	for _, src := range sources {
+		if err := evccdb.CheckDistinct(src.Client, dst); err != nil {
+			return err
+		}
+	}
+
+	if !dryRun && !assumeYes && !confirm() {
+		fmt.Println(evccdb.Translate("Operation cancelled"))
+		return nil
+	}
+
+	if !dryRun {
+		unlock, err := lockDatabase(ctx, transferDst)
+		if err != nil {
+			return err
+		}
+		defer unlock()
+
+		if err := backupBeforeWrite(ctx, dst, transferDst); err != nil {
+			return err
+		}
+	}
+
+	mapping, err := evccdb.Consolidate(ctx, sources, dst, evccdb.ConsolidateOptions{
+		DryRun:          dryRun,
+		ConvertCurrency: convertCurrency,
+		OnProgress: func(source int, table string, count int) {
+			path := consolidateFrom[source]
+			if dryRun {
+				fmt.Printf("Would merge %d rows of %s from %s\n", count, table, path)
+				return
+			}
+			auditRows(table, count)
+			fmt.Printf("Merged %d rows of %s from %s\n", count, table, path)
+		},
+	})
+	if err != nil {
+		return err
+	}
+	if !dryRun {
+		if err := optimizeAfterWrite(ctx, dst); err != nil {
+			return err
+		}
+	}
+
+	if mappingFile == "" {
+		return nil
+	}
+	return writeIDMapping(mappingFile, mapping)
+}
+
+// writeIDMapping writes the old and new id of every merged row as CSV
+func writeIDMapping(path string, mapping []evccdb.IDMapping) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create mapping file: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	result := &evccdb.QueryResult{Columns: []string{"source", "table", "old_id", "new_id"}}
+	for _, m := range mapping {
+		result.Rows = append(result.Rows, []any{consolidateFrom[m.Source], m.Table, m.OldID, m.NewID})
+	}
+	if err := result.WriteCSV(f); err != nil {
+		return err
+	}
+	fmt.Printf("Wrote id mapping of %d rows to %s\n", len(mapping), path)
+	return nil
+}
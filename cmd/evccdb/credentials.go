@@ -0,0 +1,49 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/iseeberg79/evccdb"
+	"github.com/spf13/cobra"
+)
+
+func runCheckCredentials(cmd *cobra.Command, args []string) error {
+	client, err := evccdb.Open(credsDB)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer func() { _ = client.Close() }()
+
+	creds, err := client.CheckCredentials(cmd.Context(), time.Now(), time.Duration(credsDays)*24*time.Hour)
+	if err != nil {
+		return err
+	}
+
+	result := &evccdb.QueryResult{Columns: []string{"table", "key", "kind", "expiry", "status"}}
+	var expired, expiring int
+	for _, cred := range creds {
+		var expiry any
+		if !cred.Expiry.IsZero() {
+			expiry = cred.Expiry.Local().Format("2006-01-02 15:04:05")
+		}
+		result.Rows = append(result.Rows, []any{cred.Table, cred.Key, cred.Kind, expiry, string(cred.Status)})
+
+		switch cred.Status {
+		case evccdb.CredentialExpired:
+			expired++
+		case evccdb.CredentialExpiring:
+			expiring++
+		}
+	}
+
+	if err := writeQueryResult(os.Stdout, result, credsFormat); err != nil {
+		return err
+	}
+
+	if expired > 0 || expiring > 0 {
+		fmt.Fprintf(os.Stderr, evccdb.Translate("WARNING: %d tokens expired, %d expire within %d days, log in again in evcc\n"), expired, expiring, credsDays)
+	}
+	return nil
+}
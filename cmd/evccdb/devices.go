@@ -0,0 +1,234 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/iseeberg79/evccdb"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+func runConfigOrphans(cmd *cobra.Command, args []string) error {
+	client, err := evccdb.Open(configDB)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer func() { _ = client.Close() }()
+	ctx := cmd.Context()
+
+	g, err := client.DeviceGraph(ctx)
+	if err != nil {
+		return err
+	}
+
+	orphans := g.Orphans()
+	if len(orphans) == 0 {
+		fmt.Println(evccdb.Translate("No orphaned device references found"))
+		return nil
+	}
+
+	for _, o := range orphans {
+		fmt.Printf("%s: %s\n", o.Source(), o.Reason)
+	}
+	if !fixOrphans {
+		return nil
+	}
+
+	if !assumeYes && !confirm() {
+		fmt.Println(evccdb.Translate("Operation cancelled"))
+		return nil
+	}
+	unlock, err := lockDatabase(ctx, configDB)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if err := backupBeforeWrite(ctx, client, configDB); err != nil {
+		return err
+	}
+
+	for _, o := range orphans {
+		replacement, ok := promptReplacement(g, o)
+		if !ok {
+			continue
+		}
+		if err := client.FixOrphan(ctx, o, replacement); err != nil {
+			return err
+		}
+		writtenRows.Add(1)
+		if o.ConfigID == 0 {
+			auditRows("settings", 1)
+		} else {
+			auditRows("configs", 1)
+		}
+		if replacement == "" {
+			fmt.Printf(evccdb.Translate("Removed reference %s from %s\n"), o.Ref, o.Source())
+		} else {
+			fmt.Printf(evccdb.Translate("Replaced reference %s with %s in %s\n"), o.Ref, replacement, o.Source())
+		}
+	}
+	return nil
+}
+
+// promptReplacement asks for a device of the expected class replacing the orphaned
+// reference, an empty replacement removes it and false skips the reference
+func promptReplacement(g *evccdb.DeviceGraph, o evccdb.OrphanRef) (string, bool) {
+	candidates := g.DevicesOfClass(o.Class)
+
+	fmt.Printf(evccdb.Translate("\n%s references %s (%s)\n"), o.Source(), o.Ref, o.Reason)
+	for i, cfg := range candidates {
+		fmt.Printf("  %d) db:%d %s\n", i+1, cfg.ID, cfg.Title)
+	}
+	if len(candidates) > 0 {
+		fmt.Printf(evccdb.Translate("Replace with [1-%d], "), len(candidates))
+	}
+	fmt.Print(evccdb.Translate("'r' to remove, Enter to skip: "))
+
+	var answer string
+	_, _ = fmt.Scanln(&answer)
+	answer = strings.TrimSpace(answer)
+
+	if strings.EqualFold(answer, "r") {
+		return "", true
+	}
+	if i, err := strconv.Atoi(answer); err == nil && i >= 1 && i <= len(candidates) {
+		return fmt.Sprintf("db:%d", candidates[i-1].ID), true
+	}
+	return "", false
+}
+
+func runConfigCopy(cmd *cobra.Command, args []string) error {
+	class, err := evccdb.ParseClass(configClass)
+	if err != nil {
+		return fmt.Errorf("invalid --class: %w", err)
+	}
+
+	if !dryRun && !assumeYes && !confirm() {
+		fmt.Println(evccdb.Translate("Operation cancelled"))
+		return nil
+	}
+
+	src, err := evccdb.Open(transferSrc)
+	if err != nil {
+		return fmt.Errorf("failed to open source database: %w", err)
+	}
+	defer func() { _ = src.Close() }()
+
+	dst, err := evccdb.Open(transferDst)
+	if err != nil {
+		return fmt.Errorf("failed to open target database: %w", err)
+	}
+	defer func() { _ = dst.Close() }()
+
+	if err := evccdb.CheckDistinct(src, dst); err != nil {
+		return err
+	}
+
+	ctx := cmd.Context()
+	if !dryRun {
+		unlock, err := lockDatabase(ctx, transferDst)
+		if err != nil {
+			return err
+		}
+		defer unlock()
+
+		if err := backupBeforeWrite(ctx, dst, transferDst); err != nil {
+			return err
+		}
+	}
+
+	result, err := evccdb.CopyConfig(ctx, src, dst, class, configName, dryRun)
+	if err != nil {
+		return err
+	}
+
+	if dryRun {
+		fmt.Printf("Would copy %s %q (db:%d) with %d settings\n", class, configName, result.SourceID, result.Settings)
+	} else {
+		auditRows("configs", 1)
+		auditRows("settings", result.Settings)
+		fmt.Printf("Copied %s %q (db:%d) to db:%d with %d settings\n", class, configName, result.SourceID, result.TargetID, result.Settings)
+	}
+	for _, ref := range result.Refs {
+		fmt.Printf(evccdb.Translate("WARNING: %s references %s of the source database, run 'config orphans --fix' on the target\n"), ref.Key, ref.Ref)
+	}
+	return nil
+}
+
+// secretParams are substrings of config parameters masked by config diff
+var secretParams = []string{"password", "secret", "token", "pin", "key"}
+
+// maskSecret replaces the value of a secret parameter unless --show-secrets is set
+func maskSecret(key string, value any) any {
+	if showSecrets || value == nil {
+		return value
+	}
+	lower := strings.ToLower(key)
+	for _, s := range secretParams {
+		if strings.Contains(lower, s) {
+			return "***"
+		}
+	}
+	return value
+}
+
+func runConfigDiff(cmd *cobra.Command, args []string) error {
+	if _, err := loadConfig(); err != nil {
+		return err
+	}
+
+	class, err := evccdb.ParseClass(configClass)
+	if err != nil {
+		return fmt.Errorf("invalid --class: %w", err)
+	}
+
+	client, err := evccdb.Open(configDB)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer func() { _ = client.Close() }()
+
+	d, err := client.DiffConfig(cmd.Context(), class, configName)
+	if err != nil {
+		return err
+	}
+
+	for key, value := range d.Values {
+		d.Values[key] = maskSecret(key, value)
+	}
+	result := &evccdb.QueryResult{Columns: []string{"parameter", "value", "default", "status"}}
+	for i, f := range d.Fields {
+		d.Fields[i].Value = maskSecret(f.Key, f.Value)
+		result.Rows = append(result.Rows, []any{f.Key, d.Fields[i].Value, f.Default, string(f.Status)})
+	}
+
+	if configFormat == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(d)
+	}
+	if configFormat != "table" {
+		return writeQueryResult(os.Stdout, result, configFormat)
+	}
+
+	fmt.Printf("%s db:%d", class, d.ID)
+	if d.Template != "" {
+		fmt.Printf(" (template %s)", d.Template)
+	}
+	fmt.Println()
+	data, err := yaml.Marshal(d.Values)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("\n%s\n", data)
+
+	if !d.KnownTemplate {
+		fmt.Printf("No defaults known for template %q, all parameters are listed as custom\n\n", d.Template)
+	}
+	return writeQueryResult(os.Stdout, result, configFormat)
+}
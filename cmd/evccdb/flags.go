@@ -0,0 +1,110 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/iseeberg79/evccdb"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// flagAlias is another name of a flag, so the same concept has the same names on all commands
+type flagAlias struct {
+	alias string
+	name  string
+	// deprecated aliases are old names that warn when used
+	deprecated bool
+	// command limits the alias to a command path like "evccdb fleet stats", empty means all
+	// commands with the flag
+	command string
+}
+
+// flagAliases are applied to every command that has the flag but not the alias
+var flagAliases = []flagAlias{
+	{alias: "source", name: "from"},
+	{alias: "target", name: "to"},
+	{alias: "from", name: "source"},
+	{alias: "to", name: "target"},
+	{alias: "database", name: "db"},
+	{alias: "by", name: "group-by", deprecated: true, command: "evccdb fleet stats"},
+}
+
+var (
+	deprecatedFlagsMu     sync.Mutex
+	deprecatedFlagsWarned = map[string]bool{}
+)
+
+// applyFlagAliases lets the flags of cmd and its subcommands be set by their aliases
+func applyFlagAliases(cmd *cobra.Command) {
+	// Merges the persistent flags of the parents into Flags
+	_ = cmd.InheritedFlags()
+
+	flags := cmd.Flags()
+	aliases := make(map[string]flagAlias)
+	for _, a := range flagAliases {
+		if (a.command == "" || a.command == cmd.CommandPath()) && flags.Lookup(a.name) != nil && flags.Lookup(a.alias) == nil {
+			aliases[a.alias] = a
+		}
+	}
+	if len(aliases) > 0 {
+		flags.SetNormalizeFunc(func(f *pflag.FlagSet, name string) pflag.NormalizedName {
+			a, ok := aliases[name]
+			if !ok {
+				return pflag.NormalizedName(name)
+			}
+			if a.deprecated {
+				warnDeprecatedFlag(a)
+			}
+			return pflag.NormalizedName(a.name)
+		})
+	}
+
+	for _, sub := range cmd.Commands() {
+		applyFlagAliases(sub)
+	}
+}
+
+// warnDeprecatedFlag warns once per process about the use of a deprecated flag name
+func warnDeprecatedFlag(a flagAlias) {
+	deprecatedFlagsMu.Lock()
+	defer deprecatedFlagsMu.Unlock()
+
+	if !deprecatedFlagsWarned[a.alias] {
+		deprecatedFlagsWarned[a.alias] = true
+		fmt.Fprintf(os.Stderr, evccdb.Translate("WARNING: --%s is deprecated, use --%s\n"), a.alias, a.name)
+	}
+}
+
+// databaseEnv names the database file of commands that are given no database flag
+const databaseEnv = "EVCC_DATABASE"
+
+// dbFlagAnnotation names the database flag of a command without --db, which the global
+// --db sets, e.g. source of export
+const dbFlagAnnotation = "evccdb-db-flag"
+
+// applyGlobalDB sets the database flag of cmd from the global --db flag or EVCC_DATABASE
+// unless it is given. Commands with their own --db flag shadow the global flag, so for them
+// only the environment applies.
+func applyGlobalDB(cmd *cobra.Command) error {
+	global := cmd.Root().PersistentFlags().Lookup("db")
+	flags := cmd.Flags()
+
+	name := "db"
+	value := os.Getenv(databaseEnv)
+	if f := flags.Lookup("db"); f == global {
+		name = cmd.Annotations[dbFlagAnnotation]
+		if global.Changed {
+			if name == "" {
+				return fmt.Errorf("--db does not apply to %s", cmd.CommandPath())
+			}
+			value = globalDB
+		}
+	}
+
+	if f := flags.Lookup(name); name == "" || f == nil || f.Changed || value == "" {
+		return nil
+	}
+	return flags.Set(name, value)
+}
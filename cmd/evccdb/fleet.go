@@ -0,0 +1,49 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"os"
+
+	"github.com/iseeberg79/evccdb"
+	"github.com/spf13/cobra"
+)
+
+func runFleetStats(cmd *cobra.Command, args []string) error {
+	var clients []*evccdb.Client
+	defer func() {
+		for _, client := range clients {
+			_ = client.Close()
+		}
+	}()
+
+	for _, path := range fleetDBs {
+		client, err := evccdb.Open(path)
+		if err != nil {
+			return fmt.Errorf("failed to open database %s: %w", path, err)
+		}
+		clients = append(clients, client)
+	}
+
+	stats, err := evccdb.FleetStats(cmd.Context(), clients, fleetBy)
+	if err != nil {
+		return err
+	}
+
+	result := &evccdb.QueryResult{Columns: []string{fleetBy, "sites", "sessions", "charged_kwh", "solar_kwh", "price"}}
+	for _, s := range stats {
+		name := s.Vehicle
+		if name == "" {
+			name = "(unknown)"
+		}
+		result.Rows = append(result.Rows, []any{name, s.Sites, s.Sessions, round(s.ChargedKwh, 2), round(s.SolarKwh, 2), round(s.Price, 2)})
+	}
+
+	return writeQueryResult(os.Stdout, result, fleetFormat)
+}
+
+// round rounds v to the given number of decimals
+func round(v float64, decimals int) float64 {
+	p := math.Pow(10, float64(decimals))
+	return math.Round(v*p) / p
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+// grafanaQuery is the request body of the SimpleJSON /query endpoint
+type grafanaQuery struct {
+	Range struct {
+		From time.Time `json:"from"`
+		To   time.Time `json:"to"`
+	} `json:"range"`
+	Targets []struct {
+		Target string `json:"target"`
+	} `json:"targets"`
+}
+
+// grafanaSeries is a time series in the SimpleJSON response format
+type grafanaSeries struct {
+	Target     string       `json:"target"`
+	Datapoints [][2]float64 `json:"datapoints"`
+}
+
+// grafanaRoutes registers the Grafana SimpleJSON datasource endpoints below /grafana
+func (s *server) grafanaRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("/grafana/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/grafana/" {
+			http.NotFound(w, r)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+	})
+	mux.HandleFunc("/grafana/search", s.handleGrafanaSearch)
+	mux.HandleFunc("/grafana/query", s.handleGrafanaQuery)
+}
+
+// handleGrafanaSearch lists the available targets
+func (s *server) handleGrafanaSearch(w http.ResponseWriter, r *http.Request) {
+	targets, err := s.client.TimeSeriesTargets(r.Context())
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, targets)
+}
+
+// handleGrafanaQuery returns the requested targets as time series
+func (s *server) handleGrafanaQuery(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "POST required"})
+		return
+	}
+
+	var query grafanaQuery
+	if err := json.NewDecoder(r.Body).Decode(&query); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+
+	result := make([]grafanaSeries, 0, len(query.Targets))
+	for _, t := range query.Targets {
+		if t.Target == "" {
+			continue
+		}
+
+		points, err := s.client.TimeSeries(r.Context(), t.Target, query.Range.From, query.Range.To)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+
+		series := grafanaSeries{Target: t.Target, Datapoints: make([][2]float64, 0, len(points))}
+		for _, p := range points {
+			series.Datapoints = append(series.Datapoints, [2]float64{p.Value, float64(p.Time.UnixMilli())})
+		}
+		result = append(result, series)
+	}
+
+	writeJSON(w, http.StatusOK, result)
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/iseeberg79/evccdb"
+	"github.com/spf13/cobra"
+)
+
+func runGuests(cmd *cobra.Command, args []string) error {
+	client, err := evccdb.Open(guestsDB)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer func() { _ = client.Close() }()
+	ctx := cmd.Context()
+
+	if !dryRun && !assumeYes && !confirm() {
+		fmt.Println(evccdb.Translate("Operation cancelled"))
+		return nil
+	}
+
+	if !dryRun {
+		unlock, err := lockDatabase(ctx, guestsDB)
+		if err != nil {
+			return err
+		}
+		defer unlock()
+
+		if err := backupBeforeWrite(ctx, client, guestsDB); err != nil {
+			return err
+		}
+	}
+
+	groups, err := client.AssignGuestVehicles(ctx, guestName, dryRun)
+	if err != nil {
+		return err
+	}
+	if len(groups) == 0 {
+		fmt.Println("No sessions without vehicle but with identifier found")
+		return nil
+	}
+
+	verb := "Assigned"
+	if dryRun {
+		verb = "Would assign"
+	}
+	for _, g := range groups {
+		if !dryRun {
+			auditRows("sessions", g.Sessions)
+		}
+		fmt.Printf("%s %d sessions of identifier %s to vehicle %q\n", verb, g.Sessions, g.Identifier, g.Vehicle)
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/iseeberg79/evccdb"
+	"github.com/spf13/cobra"
+)
+
+func runInspect(cmd *cobra.Command, args []string) error {
+	path := inspectDB
+	if path == "" {
+		var err error
+		if path, err = discoverDatabase(); err != nil {
+			return err
+		}
+		if path == "" {
+			fmt.Fprintln(os.Stderr, evccdb.Translate("Operation cancelled"))
+			return nil
+		}
+	}
+
+	client, err := evccdb.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer func() { _ = client.Close() }()
+
+	env, err := client.Environment()
+	if err != nil {
+		return err
+	}
+	health, err := client.CheckHealth(cmd.Context(), evccdb.HealthOptions{})
+	if err != nil {
+		return err
+	}
+	info := &evccdb.QueryResult{Columns: []string{"key", "value"}, Rows: [][]any{
+		{"path", env.Path},
+		{"size", evccdb.FormatSize(health.Size)},
+		{"sqlite_version", env.SQLiteVersion},
+		{"journal_mode", env.JournalMode},
+		{"page_size", env.PageSize},
+		{"user_version", env.UserVersion},
+		{"read_only", env.ReadOnly},
+	}}
+	if health.LastSession != nil {
+		info.Rows = append(info.Rows, []any{"last_session", health.LastSession.Local().Format("2006-01-02 15:04:05")})
+	}
+	if health.LastMeter != nil {
+		info.Rows = append(info.Rows, []any{"last_meter", health.LastMeter.Local().Format("2006-01-02 15:04:05")})
+	}
+
+	tableNames, err := client.GetTables()
+	if err != nil {
+		return fmt.Errorf("failed to list tables: %w", err)
+	}
+	tables := &evccdb.QueryResult{Columns: []string{"table", "rows"}}
+	for _, table := range tableNames {
+		rows, err := client.GetRowCount(table)
+		if err != nil {
+			return fmt.Errorf("failed to count rows of %s: %w", table, err)
+		}
+		tables.Rows = append(tables.Rows, []any{table, rows})
+	}
+
+	return writeSections(os.Stdout, []evccdb.Section{
+		{Name: "database", Title: evccdb.Translate("Database:"), Result: info},
+		{Name: "tables", Title: evccdb.Translate("Tables:"), Result: tables},
+	}, inspectFormat)
+}
+
+// discoverDatabase looks for the evcc database and lets the user confirm or pick it, an
+// empty path means the user declined
+func discoverDatabase() (string, error) {
+	found := evccdb.DiscoverDatabases(evccdb.DiscoverOptions{})
+	switch {
+	case len(found) == 0:
+		return "", fmt.Errorf("no evcc database found, use --db")
+	case len(found) == 1:
+		db := found[0]
+		if assumeYes {
+			return db.Path, nil
+		}
+		fmt.Fprintf(os.Stderr, evccdb.Translate("Found %s (%s, %s). Inspect it? [y/N]: "), db.Path, db.Source, evccdb.FormatSize(db.Size))
+		var answer string
+		_, _ = fmt.Scanln(&answer)
+		if !strings.EqualFold(answer, "y") && !strings.EqualFold(answer, "yes") {
+			return "", nil
+		}
+		return db.Path, nil
+	case assumeYes:
+		return "", fmt.Errorf("found %d evcc databases, use --db to pick one", len(found))
+	}
+
+	fmt.Fprint(os.Stderr, evccdb.Translate("Found evcc databases:\n"))
+	for i, db := range found {
+		fmt.Fprintf(os.Stderr, "  %d) %s (%s, %s, %s)\n", i+1, db.Path, db.Source, evccdb.FormatSize(db.Size), db.Modified.Local().Format("2006-01-02 15:04:05"))
+	}
+	fmt.Fprintf(os.Stderr, evccdb.Translate("Database to inspect [1-%d]: "), len(found))
+	var answer string
+	_, _ = fmt.Scanln(&answer)
+	n, err := strconv.Atoi(answer)
+	if err != nil || n < 1 || n > len(found) {
+		return "", nil
+	}
+	return found[n-1].Path, nil
+}
@@ -2,15 +2,23 @@ package main
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/iseeberg79/evccdb"
 	"github.com/spf13/cobra"
 )
 
 var (
+	globalDB         string
 	source           string
 	target           string
 	output           string
@@ -22,44 +30,220 @@ var (
 	transferDst      string
 	renameLoadpoints string
 	renameVehicles   string
+	renameIndices    string
 	renameDB         string
 	deleteDB         string
 	deleteLoadpoints string
 	deleteVehicles   string
 	assumeYes        bool
+	confirmPlan      bool
+	autoBackup       string
+	useTrash         bool
+	trashDB          string
+	deferFKs         bool
+	allowUnknown     bool
+	signKey          string
+	verifyKey        string
+	fleetDBs         []string
+	fleetBy          string
+	fleetFormat      string
+	settingsMerge    string
+	useAttach        bool
+	incremental      bool
+	format           string
+	csvTable         string
+	columnMap        string
+	locale           string
+	csvProfile       string
+	timezone         string
+	checkSkew        bool
+	timeOffset       string
+	serveDB          string
+	listenAddr       string
+	healthInterval   time.Duration
+	maxSessionAge    time.Duration
+	maxMeterAge      time.Duration
+	maxSizeMB        int64
+	serveUI          bool
+	serveReadonly    bool
+	maxUploadSize    string
+	queryDB          string
+	queryFormat      string
+	queryNamed       string
+	listNamed        bool
+	configPath       string
+	chartDB          string
+	chartMetric      string
+	chartVehicle     string
+	chartLoadpoint   string
+	chartStyle       string
+	configDB         string
+	fixOrphans       bool
+	configClass      string
+	configName       string
+	keepTemplates    bool
+	settingsDB       string
+	settingsFormat   string
+	cacheDB          string
+	cachePrefix      string
+	includeCaches    bool
+	fastWrite        bool
+	cloneDB          bool
+	sampleRows       int
+	waitLock         bool
+	forceLock        bool
+	historyDB        string
+	historyFormat    string
+	historyScript    bool
+	policyDB         string
+	consolidateFrom  []string
+	prefixLoadpoints bool
+	mappingFile      string
+	profileDB        string
+	profileName      string
+	excludeGuests    bool
+	guestVehicle     string
+	guestsDB         string
+	guestName        string
+	convertCurrency  bool
+	lang             string
+	overwrite        bool
+	timestampOutput  bool
+	compression      string
+	compressLevel    int
+	splitSize        string
+	preSQLFile       string
+	postSQLFile      string
+	stripIdentity    bool
+	credsDB          string
+	credsFormat      string
+	credsDays        int
+	configClasses    string
+	configFormat     string
+	showSecrets      bool
+	checksumCache    bool
+	verifyFormat     string
+	verifyJobs       int
+	onError          string
+	limitRows        int
+	truncateTables   string
+	schemaObjects    bool
+	sqlDialect       string
+	reportDB         string
+	reportFormat     string
+	gapFactor        float64
+	gapMin           time.Duration
+	sessionDB        string
+	sessionID        int64
+	sessionFormat    string
+	statsDB          string
+	statsFormat      string
+	statsGroupBy     string
+	statsHolidays    []string
+	tariffDB         string
+	tariffCSV        string
+	tariffMeters     bool
+	tariffSolarPrice float64
+	tariffSessions   bool
+	tariffFormat     string
+	solarWindow      string
+	solarWorst       int
+	outlierFactor    float64
+	inspectDB        string
+	inspectFormat    string
+	optimizeDB       bool
 )
 
+// localeUsage describes the --locale flag of the commands writing CSV
+const optimizeUsage = "Afterwards run PRAGMA optimize and vacuum the database if writes left free pages, reporting the reclaimed space"
+
+const localeUsage = "Locale of CSV, markdown and HTML output, e.g. de-DE for decimal comma, local dates and German headers"
+
+// formatUsage describes the --format flag of the commands writing results
+var formatUsage = "Output format: " + strings.Join(evccdb.Renderers(), ", ")
+
 func main() {
 	rootCmd := &cobra.Command{
 		Use:   "evccdb",
 		Short: "Tool for evcc database backup and transfer",
 		Long:  "evccdb provides selective backup, restore, and transfer of evcc SQLite database data",
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			evccdb.SetLanguage(messageLanguage())
+			return applyGlobalDB(cmd)
+		},
 	}
+	rootCmd.PersistentFlags().StringVar(&globalDB, "db", "", "Database file of the command, e.g. the source of export and the target of import (default $"+databaseEnv+")")
+	rootCmd.PersistentFlags().StringVar(&autoBackup, "auto-backup", "", "Snapshot the target database into this directory before writing")
+	rootCmd.PersistentFlags().Lookup("auto-backup").NoOptDefVal = "."
+	rootCmd.PersistentFlags().StringVar(&configPath, "config", "", "Config file (default "+defaultConfigPath()+")")
+	rootCmd.PersistentFlags().BoolVar(&waitLock, "wait", false, "Wait for another evccdb process writing the database to finish")
+	rootCmd.PersistentFlags().BoolVar(&forceLock, "force", false, "Remove the lock file of another evccdb process, e.g. after a crash")
+	rootCmd.PersistentFlags().StringVar(&lang, "lang", "", "Language of messages: en, de (default: from LC_ALL, LC_MESSAGES or LANG)")
 
 	// Export command
 	exportCmd := &cobra.Command{
-		Use:   "export",
-		Short: "Export database tables to JSON",
-		RunE:  runExport,
+		Use:         "export",
+		Short:       "Export database tables to JSON, SQL or Parquet",
+		RunE:        runExport,
+		Annotations: map[string]string{dbFlagAnnotation: "source"},
 	}
 	exportCmd.Flags().StringVar(&source, "source", "", "Source database file (required)")
-	exportCmd.Flags().StringVar(&output, "output", "", "Output JSON file (required)")
-	exportCmd.Flags().StringVar(&modeStr, "mode", "config", "Transfer mode: config, metrics, all")
+	exportCmd.Flags().StringVar(&output, "output", "", "Output file, or directory for parquet, may contain {hostname}, {dbname}, {date}, {time} and {mode} (required)")
+	exportCmd.Flags().StringVar(&format, "format", "json", "Output format: json, sql, parquet")
+	exportCmd.Flags().StringVar(&modeStr, "mode", "config", "Transfer mode: config, metrics, all, or a mode of the config file")
 	exportCmd.Flags().StringVar(&tables, "tables", "", "Comma-separated table names (overrides mode)")
+	exportCmd.Flags().BoolVar(&includeCaches, "include-caches", false, "Include the caches table with its volatile API tokens")
+	exportCmd.Flags().StringVar(&configClasses, "class", "", "Only export configs of these classes and their settings, e.g. vehicle,charger")
+	exportCmd.Flags().BoolVar(&excludeGuests, "exclude-guest-sessions", false, "Skip sessions without vehicle, e.g. of guests charging")
+	exportCmd.Flags().StringVar(&guestVehicle, "guest-vehicle", "", "Assign sessions without vehicle to this placeholder vehicle")
+	exportCmd.Flags().BoolVar(&schemaObjects, "schema-objects", false, "Include the DDL of views and triggers in json and sql exports")
+	exportCmd.Flags().StringVar(&timezone, "tz", "", "Time zone of timestamps without offset in json exports, e.g. Europe/Berlin or Local (default: exported without offset)")
+	exportCmd.Flags().StringVar(&sqlDialect, "sql-dialect", "sqlite", "Database of sql exports for BLOB columns: sqlite, postgres, duckdb")
 	exportCmd.Flags().BoolVar(&verbose, "verbose", false, "Show progress")
+	exportCmd.Flags().StringVar(&signKey, "sign-key", "", "Sign the export with this ed25519 private key (PEM), writing <output>.sig")
+	exportCmd.Flags().BoolVar(&overwrite, "force", false, "Overwrite an existing output file")
+	exportCmd.Flags().StringVar(&compression, "compress", "", "Compression of json and sql exports: none, gzip, zstd (default: by extension .gz or .zst)")
+	exportCmd.Flags().IntVar(&compressLevel, "compress-level", 0, "Compression level, 1-9 for gzip, 1-22 for zstd (default: the default level)")
+	exportCmd.Flags().StringVar(&splitSize, "split-size", "", "Split json and sql exports into parts of this size, e.g. 100MB, with a manifest")
+	exportCmd.Flags().BoolVar(&timestampOutput, "timestamp", false, "Append the current time to the output name, e.g. evcc-20240131-183000.json")
 	_ = exportCmd.MarkFlagRequired("source")
 	_ = exportCmd.MarkFlagRequired("output")
+	exportCmd.MarkFlagsMutuallyExclusive("exclude-guest-sessions", "guest-vehicle")
 
 	// Import command
 	importCmd := &cobra.Command{
-		Use:   "import",
-		Short: "Import JSON data into database",
-		RunE:  runImport,
+		Use:         "import",
+		Short:       "Import JSON or CSV data into database",
+		RunE:        runImport,
+		Annotations: map[string]string{dbFlagAnnotation: "target"},
 	}
-	importCmd.Flags().StringVar(&source, "source", "", "Source JSON file (required)")
+	importCmd.Flags().StringVar(&source, "source", "", "Source JSON or CSV file (required)")
 	importCmd.Flags().StringVar(&target, "target", "", "Target database file (required)")
-	importCmd.Flags().StringVar(&modeStr, "mode", "config", "Transfer mode: config, metrics, all")
+	importCmd.Flags().StringVar(&modeStr, "mode", "config", "Transfer mode: config, metrics, all, or a mode of the config file")
 	importCmd.Flags().StringVar(&tables, "tables", "", "Comma-separated table names (overrides mode)")
+	importCmd.Flags().BoolVar(&includeCaches, "include-caches", false, "Include the caches table with its volatile API tokens")
+	importCmd.Flags().BoolVar(&fastWrite, "fast", false, "Speed up the import with pragmas that risk corruption on power loss")
+	importCmd.Flags().BoolVar(&deferFKs, "defer-foreign-keys", false, "Check foreign keys only at commit")
+	importCmd.Flags().BoolVar(&allowUnknown, "allow-unknown-tables", false, "Import tables of the export file that are not known evcc tables")
+	importCmd.Flags().StringVar(&settingsMerge, "settings-merge", "replace", "Settings in both databases: replace, prefer-newer, prefer-target, interactive")
+	importCmd.Flags().BoolVar(&convertCurrency, "convert-currency", false, "Convert session prices of a source using another currency by the exchange rates of the config file")
+	importCmd.Flags().BoolVar(&keepTemplates, "keep-templates", false, "Do not rename config templates renamed by evcc")
+	importCmd.Flags().StringVar(&verifyKey, "verify-key", "", "Require a valid <source>.sig signature by this ed25519 public key (PEM)")
+	importCmd.Flags().StringVar(&format, "format", "json", "Input format: json, csv")
+	importCmd.Flags().StringVar(&csvTable, "table", "sessions", "Target table for CSV import (only sessions is supported)")
+	importCmd.Flags().StringVar(&columnMap, "map", "", "CSV column mapping: \"Header=column,...\"")
+	importCmd.Flags().StringVar(&csvProfile, "profile", "evcc", "CSV profile: "+csvProfileNames())
+	importCmd.Flags().StringVar(&timezone, "tz", "", "Time zone of timestamps without offset, e.g. Europe/Berlin or Local (default: UTC, local time for CSV)")
+	importCmd.Flags().StringVar(&locale, "locale", "", "Number format of the CSV file, e.g. en or de (default: detect)")
+	importCmd.Flags().BoolVar(&stripIdentity, "strip-instance-identity", false, "Leave out settings identifying the source installation, e.g. sponsor token and telemetry")
+	importCmd.Flags().StringVar(&preSQLFile, "pre-sql-file", "", "Run the SQL statements of this file in the import transaction before writing")
+	importCmd.Flags().StringVar(&postSQLFile, "post-sql-file", "", "Run the SQL statements of this file in the import transaction after writing")
+	importCmd.Flags().BoolVar(&schemaObjects, "schema-objects", false, "Recreate the views and triggers of the export missing in the target")
+	importCmd.Flags().StringVar(&truncateTables, "truncate", "", "Delete the target rows of these tables before writing, all imported tables if given without value")
+	importCmd.Flags().Lookup("truncate").NoOptDefVal = "all"
+	importCmd.Flags().IntVar(&limitRows, "limit-rows", 0, "Import at most this many rows per table, e.g. for a trial restore into a scratch database")
+	importCmd.Flags().StringVar(&onError, "on-error", "fail", "Rows rejected by the database: fail, skip, collect (skip and list them in the failure report)")
+	importCmd.Flags().BoolVar(&optimizeDB, "optimize", false, optimizeUsage)
 	importCmd.Flags().BoolVar(&verbose, "verbose", false, "Show progress")
 	_ = importCmd.MarkFlagRequired("source")
 	_ = importCmd.MarkFlagRequired("target")
@@ -72,14 +256,39 @@ func main() {
 	}
 	transferCmd.Flags().StringVar(&transferSrc, "from", "", "Source database file (required)")
 	transferCmd.Flags().StringVar(&transferDst, "to", "", "Target database file (required)")
-	transferCmd.Flags().StringVar(&modeStr, "mode", "config", "Transfer mode: config, metrics, all")
+	transferCmd.Flags().StringVar(&modeStr, "mode", "config", "Transfer mode: config, metrics, all, or a mode of the config file")
 	transferCmd.Flags().StringVar(&tables, "tables", "", "Comma-separated table names (overrides mode)")
+	transferCmd.Flags().BoolVar(&includeCaches, "include-caches", false, "Include the caches table with its volatile API tokens")
+	transferCmd.Flags().StringVar(&configClasses, "class", "", "Only transfer configs of these classes and their settings, e.g. vehicle,charger")
+	transferCmd.Flags().BoolVar(&excludeGuests, "exclude-guest-sessions", false, "Skip sessions without vehicle, e.g. of guests charging")
+	transferCmd.Flags().StringVar(&guestVehicle, "guest-vehicle", "", "Assign sessions without vehicle to this placeholder vehicle")
 	transferCmd.Flags().BoolVar(&dryRun, "dry-run", false, "Show what would be transferred without doing it")
+	transferCmd.Flags().IntVar(&sampleRows, "sample", 0, "With --dry-run or --confirm, show the field changes of up to N replaced rows per table")
+	transferCmd.Flags().BoolVar(&deferFKs, "defer-foreign-keys", false, "Check foreign keys only at commit")
+	transferCmd.Flags().StringVar(&settingsMerge, "settings-merge", "replace", "Settings in both databases: replace, prefer-newer, prefer-target, interactive")
+	transferCmd.Flags().BoolVar(&convertCurrency, "convert-currency", false, "Convert session prices of a source using another currency by the exchange rates of the config file")
+	transferCmd.Flags().BoolVar(&keepTemplates, "keep-templates", false, "Do not rename config templates renamed by evcc")
+	transferCmd.Flags().BoolVar(&useAttach, "attach", false, "Copy tables with SQL directly between the attached database files")
+	transferCmd.Flags().BoolVar(&fastWrite, "fast", false, "Speed up the transfer with pragmas that risk corruption on power loss")
+	transferCmd.Flags().BoolVar(&cloneDB, "clone", false, "Copy the whole database into an empty destination with the same schema, then remove the tables not transferred")
+	transferCmd.Flags().BoolVar(&incremental, "incremental", false, "Only copy rows that are missing or changed in the destination")
+	transferCmd.Flags().BoolVar(&checkSkew, "check-skew", false, "Report clock differences between the databases' sessions")
+	transferCmd.Flags().StringVar(&timeOffset, "time-offset", "", "Shift source timestamps by a duration, e.g. -90s, or auto to use the detected skew")
+	transferCmd.Flags().StringVar(&truncateTables, "truncate", "", "Delete the destination rows of these tables before writing, all transferred tables if given without value")
+	transferCmd.Flags().Lookup("truncate").NoOptDefVal = "all"
+	transferCmd.Flags().IntVar(&limitRows, "limit-rows", 0, "Transfer at most this many rows per table, e.g. for a trial restore into a scratch database")
+	transferCmd.Flags().BoolVar(&stripIdentity, "strip-instance-identity", false, "Leave out settings identifying the source installation, e.g. sponsor token and telemetry")
+	transferCmd.Flags().StringVar(&preSQLFile, "pre-sql-file", "", "Run the SQL statements of this file in the transfer transaction before writing")
+	transferCmd.Flags().StringVar(&postSQLFile, "post-sql-file", "", "Run the SQL statements of this file in the transfer transaction after writing")
+	transferCmd.Flags().BoolVar(&optimizeDB, "optimize", false, optimizeUsage)
 	transferCmd.Flags().BoolVar(&verbose, "verbose", false, "Show progress")
 	transferCmd.Flags().StringVar(&renameLoadpoints, "rename-loadpoint", "", "Rename loadpoints: OldName:NewName,OldName2:NewName2")
 	transferCmd.Flags().StringVar(&renameVehicles, "rename-vehicle", "", "Rename vehicles: OldName:NewName,OldName2:NewName2")
+	transferCmd.Flags().BoolVar(&confirmPlan, "confirm", false, "Show the transfer plan and ask for confirmation before writing")
+	transferCmd.Flags().BoolVarP(&assumeYes, "yes", "y", false, "Skip confirmation prompt")
 	_ = transferCmd.MarkFlagRequired("from")
 	_ = transferCmd.MarkFlagRequired("to")
+	transferCmd.MarkFlagsMutuallyExclusive("exclude-guest-sessions", "guest-vehicle")
 
 	// Rename command
 	renameCmd := &cobra.Command{
@@ -90,6 +299,7 @@ func main() {
 	renameCmd.Flags().StringVar(&renameDB, "db", "", "Database file (required)")
 	renameCmd.Flags().StringVar(&renameLoadpoints, "loadpoint", "", "Rename loadpoints: OldName:NewName,OldName2:NewName2")
 	renameCmd.Flags().StringVar(&renameVehicles, "vehicle", "", "Rename vehicles: OldName:NewName,OldName2:NewName2")
+	renameCmd.Flags().StringVar(&renameIndices, "loadpoint-index", "", "Rename loadpoints by index: 1:NewName,2:NewName2")
 	renameCmd.Flags().BoolVar(&dryRun, "dry-run", false, "Show what would be renamed without doing it")
 	renameCmd.Flags().BoolVar(&verbose, "verbose", false, "Show detailed output")
 	_ = renameCmd.MarkFlagRequired("db")
@@ -100,7 +310,7 @@ func main() {
 		Short: "Delete session data for loadpoints or vehicles",
 		Long: `Delete session data for specific loadpoints or vehicles.
 
-WARNING: This operation is destructive and cannot be undone.
+WARNING: This operation is destructive and cannot be undone unless --trash is used.
 Make sure evcc is stopped and not accessing the database before running this command.`,
 		RunE: runDelete,
 	}
@@ -108,28 +318,547 @@ Make sure evcc is stopped and not accessing the database before running this com
 	deleteCmd.Flags().StringVar(&deleteLoadpoints, "loadpoint", "", "Delete sessions for loadpoints: Name1,Name2")
 	deleteCmd.Flags().StringVar(&deleteVehicles, "vehicle", "", "Delete sessions for vehicles: Name1,Name2")
 	deleteCmd.Flags().BoolVar(&dryRun, "dry-run", false, "Show what would be deleted without doing it")
+	deleteCmd.Flags().BoolVar(&useTrash, "trash", false, "Move sessions to the trash table instead of deleting them")
+	deleteCmd.Flags().BoolVar(&optimizeDB, "optimize", false, optimizeUsage)
 	deleteCmd.Flags().BoolVarP(&assumeYes, "yes", "y", false, "Skip confirmation prompt")
 	deleteCmd.Flags().BoolVar(&verbose, "verbose", false, "Show detailed output")
 	_ = deleteCmd.MarkFlagRequired("db")
 
-	rootCmd.AddCommand(exportCmd, importCmd, transferCmd, renameCmd, deleteCmd)
+	// Trash command
+	trashCmd := &cobra.Command{
+		Use:   "trash",
+		Short: "Restore or purge sessions deleted with --trash",
+	}
+	trashCmd.PersistentFlags().StringVar(&trashDB, "db", "", "Database file (required)")
+	_ = trashCmd.MarkPersistentFlagRequired("db")
+
+	trashRestoreCmd := &cobra.Command{
+		Use:   "restore",
+		Short: "Move all trashed sessions back into the sessions table",
+		RunE:  runTrashRestore,
+	}
+	trashPurgeCmd := &cobra.Command{
+		Use:   "purge",
+		Short: "Permanently delete all trashed sessions",
+		RunE:  runTrashPurge,
+	}
+	trashPurgeCmd.Flags().BoolVar(&dryRun, "dry-run", false, "Show what would be purged without doing it")
+	trashPurgeCmd.Flags().BoolVar(&optimizeDB, "optimize", false, optimizeUsage)
+	trashPurgeCmd.Flags().BoolVarP(&assumeYes, "yes", "y", false, "Skip confirmation prompt")
+	trashCmd.AddCommand(trashRestoreCmd, trashPurgeCmd)
+
+	// Query command
+	queryCmd := &cobra.Command{
+		Use:   "query [SELECT statement]",
+		Short: "Run a read-only SELECT query",
+		Long: `Run a read-only SELECT query against the database.
+
+Only SELECT statements reading the settings, meters, sessions and grid_sessions
+tables are allowed. Named queries from the built-in library or the config file
+are run with --named.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: runQuery,
+	}
+	queryCmd.Flags().StringVar(&queryDB, "db", "", "Database file (required)")
+	queryCmd.Flags().StringVar(&queryFormat, "format", "table", formatUsage)
+	queryCmd.Flags().StringVar(&locale, "locale", "", localeUsage)
+	queryCmd.Flags().StringVar(&queryNamed, "named", "", "Run a named query instead of a SELECT statement")
+	queryCmd.Flags().BoolVar(&listNamed, "list", false, "List the named queries")
+
+	// Chart command
+	chartCmd := &cobra.Command{
+		Use:   "chart",
+		Short: "Draw session metrics as terminal or SVG chart",
+		RunE:  runChart,
+	}
+	chartCmd.Flags().StringVar(&chartDB, "db", "", "Database file (required)")
+	chartCmd.Flags().StringVar(&chartMetric, "metric", "kwh-per-month", "Metric: "+strings.Join(evccdb.SeriesMetrics(), ", "))
+	chartCmd.Flags().StringVar(&chartVehicle, "vehicle", "", "Only include sessions of this vehicle")
+	chartCmd.Flags().StringVar(&chartLoadpoint, "loadpoint", "", "Only include sessions of this loadpoint")
+	chartCmd.Flags().StringVar(&chartStyle, "style", "bars", "Terminal style: bars, sparkline")
+	chartCmd.Flags().StringVar(&output, "output", "", "Write an SVG chart to this file")
+	_ = chartCmd.MarkFlagRequired("db")
+
+	// Serve command
+	serveCmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Serve database health and data over HTTP",
+		RunE:  runServe,
+	}
+	serveCmd.Flags().StringVar(&serveDB, "db", "", "Database file (required)")
+	serveCmd.Flags().StringVar(&listenAddr, "listen", "127.0.0.1:8080", "Address to listen on, e.g. :8080 for all interfaces")
+	serveCmd.Flags().DurationVar(&healthInterval, "interval", time.Minute, "Interval between health checks")
+	serveCmd.Flags().DurationVar(&maxMeterAge, "max-meter-age", 6*time.Hour, "Report degraded if the newest meter reading is older (0 disables)")
+	serveCmd.Flags().DurationVar(&maxSessionAge, "max-session-age", 0, "Report degraded if the newest session is older (0 disables)")
+	serveCmd.Flags().Int64Var(&maxSizeMB, "max-size-mb", 0, "Report degraded if the database is larger (0 disables)")
+	serveCmd.Flags().BoolVar(&serveUI, "ui", false, "Serve a web UI to browse tables, download exports and upload imports")
+	serveCmd.Flags().BoolVar(&serveReadonly, "serve-readonly", false, "Disable all write endpoints (rename, delete, import)")
+	serveCmd.Flags().StringVar(&maxUploadSize, "max-upload-size", "1GB", "Limit of the uploads open at the same time together and of an import body, e.g. 500MB")
+	_ = serveCmd.MarkFlagRequired("db")
+
+	// Batch command
+	batchCmd := &cobra.Command{
+		Use:   "batch",
+		Short: "Run the operations of the batch section of the config file",
+		RunE:  runBatch,
+	}
+	batchCmd.Flags().BoolVar(&dryRun, "dry-run", false, "Show what every step would do without doing it")
+	batchCmd.Flags().BoolVarP(&assumeYes, "yes", "y", false, "Skip confirmation prompt")
+
+	// Fleet command
+	fleetCmd := &cobra.Command{
+		Use:   "fleet",
+		Short: "Combine data of several evcc installations",
+	}
+	fleetStatsCmd := &cobra.Command{
+		Use:   "stats",
+		Short: "Show per-vehicle session totals across databases",
+		RunE:  runFleetStats,
+	}
+	fleetStatsCmd.Flags().StringArrayVar(&fleetDBs, "db", nil, "Database file, repeat for each installation (required)")
+	fleetStatsCmd.Flags().StringVar(&fleetBy, "group-by", "vehicle", "Merge sessions by: vehicle, identifier")
+	fleetStatsCmd.Flags().StringVar(&fleetFormat, "format", "table", formatUsage)
+	fleetStatsCmd.Flags().StringVar(&locale, "locale", "", localeUsage)
+	_ = fleetStatsCmd.MarkFlagRequired("db")
+	fleetCmd.AddCommand(fleetStatsCmd)
+
+	// Config command
+	configCmd := &cobra.Command{
+		Use:   "config",
+		Short: "Inspect and repair device configurations",
+	}
+	configOrphansCmd := &cobra.Command{
+		Use:   "orphans",
+		Short: "Find configs and settings referencing deleted devices",
+		RunE:  runConfigOrphans,
+	}
+	configOrphansCmd.Flags().StringVar(&configDB, "db", "", "Database file (required)")
+	configOrphansCmd.Flags().BoolVar(&fixOrphans, "fix", false, "Interactively replace or remove each orphaned reference")
+	configOrphansCmd.Flags().BoolVarP(&assumeYes, "yes", "y", false, "Skip confirmation prompt")
+	_ = configOrphansCmd.MarkFlagRequired("db")
+
+	configCopyCmd := &cobra.Command{
+		Use:   "copy",
+		Short: "Copy a single device configuration with its settings between databases",
+		RunE:  runConfigCopy,
+	}
+	configCopyCmd.Flags().StringVar(&transferSrc, "from", "", "Source database (required)")
+	configCopyCmd.Flags().StringVar(&transferDst, "to", "", "Target database (required)")
+	configCopyCmd.Flags().StringVar(&configClass, "class", "", "Device class: charger, meter, vehicle, circuit, loadpoint, tariff (required)")
+	configCopyCmd.Flags().StringVar(&configName, "name", "", "Device title, template or db:<id> (required)")
+	configCopyCmd.Flags().BoolVar(&dryRun, "dry-run", false, "Show what would be copied without doing it")
+	configCopyCmd.Flags().BoolVarP(&assumeYes, "yes", "y", false, "Skip confirmation prompt")
+	_ = configCopyCmd.MarkFlagRequired("from")
+	_ = configCopyCmd.MarkFlagRequired("to")
+	_ = configCopyCmd.MarkFlagRequired("class")
+	_ = configCopyCmd.MarkFlagRequired("name")
+	configDiffCmd := &cobra.Command{
+		Use:   "diff",
+		Short: "Show a device configuration and the parameters overriding its template defaults",
+		RunE:  runConfigDiff,
+	}
+	configDiffCmd.Flags().StringVar(&configDB, "db", "", "Database file (required)")
+	configDiffCmd.Flags().StringVar(&configClass, "class", "", "Device class: charger, meter, vehicle, circuit, loadpoint, tariff (required)")
+	configDiffCmd.Flags().StringVar(&configName, "name", "", "Device title, template or db:<id> (required)")
+	configDiffCmd.Flags().StringVar(&configFormat, "format", "table", formatUsage)
+	configDiffCmd.Flags().BoolVar(&showSecrets, "show-secrets", false, "Show passwords and tokens instead of masking them")
+	_ = configDiffCmd.MarkFlagRequired("db")
+	_ = configDiffCmd.MarkFlagRequired("class")
+	_ = configDiffCmd.MarkFlagRequired("name")
+	configCmd.AddCommand(configOrphansCmd, configCopyCmd, configDiffCmd)
+
+	// Settings command
+	settingsCmd := &cobra.Command{
+		Use:   "settings",
+		Short: "Inspect the settings table",
+	}
+	settingsDocCmd := &cobra.Command{
+		Use:   "doc",
+		Short: "Group settings keys by namespace and flag unknown keys",
+		RunE:  runSettingsDoc,
+	}
+	settingsDocCmd.Flags().StringVar(&settingsDB, "db", "", "Database file (required)")
+	settingsDocCmd.Flags().StringVar(&settingsFormat, "format", "table", formatUsage)
+	settingsDocCmd.Flags().StringVar(&locale, "locale", "", localeUsage)
+	settingsDocCmd.Flags().BoolVar(&verbose, "verbose", false, "List the keys of every namespace")
+	_ = settingsDocCmd.MarkFlagRequired("db")
+	settingsCmd.AddCommand(settingsDocCmd)
+
+	// Cache command
+	cacheCmd := &cobra.Command{
+		Use:   "cache",
+		Short: "Manage the caches table",
+	}
+	cacheClearCmd := &cobra.Command{
+		Use:   "clear",
+		Short: "Delete cache entries, e.g. stale vehicle API tokens",
+		RunE:  runCacheClear,
+	}
+	cacheClearCmd.Flags().StringVar(&cacheDB, "db", "", "Database file (required)")
+	cacheClearCmd.Flags().StringVar(&cachePrefix, "prefix", "", "Only delete entries whose key starts with this prefix")
+	cacheClearCmd.Flags().BoolVar(&dryRun, "dry-run", false, "Show how many entries would be deleted without doing it")
+	cacheClearCmd.Flags().BoolVarP(&assumeYes, "yes", "y", false, "Skip confirmation prompt")
+	_ = cacheClearCmd.MarkFlagRequired("db")
+	cacheCmd.AddCommand(cacheClearCmd)
+
+	// History command
+	historyCmd := &cobra.Command{
+		Use:   "history",
+		Short: "Show the audit log of write operations on a database",
+		RunE:  runHistory,
+	}
+	historyCmd.Flags().StringVar(&historyDB, "db", "", "Database file (required)")
+	historyCmd.Flags().StringVar(&historyFormat, "format", "table", formatUsage)
+	historyCmd.Flags().StringVar(&locale, "locale", "", localeUsage)
+	_ = historyCmd.MarkFlagRequired("db")
+	historyExportCmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export the audit log as JSON or as a script replaying it against another database",
+		RunE:  runHistoryExport,
+	}
+	historyExportCmd.Flags().StringVar(&historyDB, "db", "", "Database file (required)")
+	historyExportCmd.Flags().BoolVar(&historyScript, "as-script", false, "Write a shell script replaying the operations against the database given as its argument")
+	historyExportCmd.Flags().StringVarP(&output, "output", "o", "", "Output file (default stdout)")
+	_ = historyExportCmd.MarkFlagRequired("db")
+	historyCmd.AddCommand(historyExportCmd)
+
+	// Policy command
+	policyCmd := &cobra.Command{
+		Use:   "policy",
+		Short: "Manage the retention policy stored in the database",
+	}
+	policySetCmd := &cobra.Command{
+		Use:   "set",
+		Short: "Store how long sessions, meters and grid sessions are kept",
+		RunE:  runPolicySet,
+	}
+	policySetCmd.Flags().StringVar(&policyDB, "db", "", "Database file (required)")
+	policySetCmd.Flags().String("sessions", "", "Keep sessions for this period, e.g. 90d, 8w, 36m, 2y, 0 keeps them forever")
+	policySetCmd.Flags().String("meters", "", "Keep meter readings for this period")
+	policySetCmd.Flags().String("grid-sessions", "", "Keep grid sessions for this period")
+	_ = policySetCmd.MarkFlagRequired("db")
+	policyShowCmd := &cobra.Command{
+		Use:   "show",
+		Short: "Show the stored retention policy",
+		RunE:  runPolicyShow,
+	}
+	policyShowCmd.Flags().StringVar(&policyDB, "db", "", "Database file (required)")
+	_ = policyShowCmd.MarkFlagRequired("db")
+	policyApplyCmd := &cobra.Command{
+		Use:   "apply",
+		Short: "Delete the rows older than the stored retention policy allows",
+		RunE:  runPolicyApply,
+	}
+	policyApplyCmd.Flags().StringVar(&policyDB, "db", "", "Database file (required)")
+	policyApplyCmd.Flags().BoolVar(&dryRun, "dry-run", false, "Show how many rows would be deleted without doing it")
+	policyApplyCmd.Flags().BoolVar(&optimizeDB, "optimize", false, optimizeUsage)
+	policyApplyCmd.Flags().BoolVarP(&assumeYes, "yes", "y", false, "Skip confirmation prompt")
+	_ = policyApplyCmd.MarkFlagRequired("db")
+	policyCmd.AddCommand(policySetCmd, policyShowCmd, policyApplyCmd)
+
+	// Consolidate command
+	consolidateCmd := &cobra.Command{
+		Use:   "consolidate",
+		Short: "Merge the sessions of several databases into one",
+		RunE:  runConsolidate,
+	}
+	consolidateCmd.Flags().StringArrayVar(&consolidateFrom, "from", nil, "Source database file, repeat for each installation (required)")
+	consolidateCmd.Flags().StringVar(&transferDst, "to", "", "Target database file (required)")
+	consolidateCmd.Flags().BoolVar(&prefixLoadpoints, "prefix-loadpoints", false, "Prefix loadpoint names with the source file name, e.g. cabin/Garage")
+	consolidateCmd.Flags().BoolVar(&convertCurrency, "convert-currency", false, "Convert session prices of a source using another currency by the exchange rates of the config file")
+	consolidateCmd.Flags().StringVar(&mappingFile, "mapping", "", "Write the old and new id of every merged row to this CSV file")
+	consolidateCmd.Flags().BoolVar(&dryRun, "dry-run", false, "Show how many rows would be merged without doing it")
+	consolidateCmd.Flags().BoolVar(&optimizeDB, "optimize", false, optimizeUsage)
+	consolidateCmd.Flags().BoolVarP(&assumeYes, "yes", "y", false, "Skip confirmation prompt")
+	_ = consolidateCmd.MarkFlagRequired("from")
+	_ = consolidateCmd.MarkFlagRequired("to")
+
+	// Vehicle command
+	vehicleCmd := &cobra.Command{
+		Use:   "vehicle",
+		Short: "Manage vehicle profiles",
+	}
+	vehicleExportProfileCmd := &cobra.Command{
+		Use:   "export-profile",
+		Short: "Export the settings and config of a vehicle as a YAML profile",
+		RunE:  runVehicleExportProfile,
+	}
+	vehicleExportProfileCmd.Flags().StringVar(&profileDB, "db", "", "Database file (required)")
+	vehicleExportProfileCmd.Flags().StringVar(&profileName, "vehicle", "", "Vehicle name (required)")
+	vehicleExportProfileCmd.Flags().StringVar(&output, "output", "", "Profile file (default: stdout)")
+	_ = vehicleExportProfileCmd.MarkFlagRequired("db")
+	_ = vehicleExportProfileCmd.MarkFlagRequired("vehicle")
+	vehicleImportProfileCmd := &cobra.Command{
+		Use:   "import-profile",
+		Short: "Apply a vehicle profile to a database",
+		RunE:  runImportProfile,
+	}
+	vehicleImportProfileCmd.Flags().StringVar(&profileDB, "db", "", "Database file (required)")
+	vehicleImportProfileCmd.Flags().StringVar(&source, "source", "", "Profile file (required)")
+	vehicleImportProfileCmd.Flags().StringVar(&profileName, "vehicle", "", "Apply the profile to this vehicle (default: the profile's vehicle)")
+	vehicleImportProfileCmd.Flags().BoolVar(&dryRun, "dry-run", false, "Show what would be applied without doing it")
+	vehicleImportProfileCmd.Flags().BoolVarP(&assumeYes, "yes", "y", false, "Skip confirmation prompt")
+	_ = vehicleImportProfileCmd.MarkFlagRequired("db")
+	_ = vehicleImportProfileCmd.MarkFlagRequired("source")
+	vehicleCmd.AddCommand(vehicleExportProfileCmd, vehicleImportProfileCmd)
 
-	if err := rootCmd.Execute(); err != nil {
+	// Loadpoint command
+	loadpointCmd := &cobra.Command{
+		Use:   "loadpoint",
+		Short: "Manage loadpoint profiles",
+	}
+	loadpointExportProfileCmd := &cobra.Command{
+		Use:   "export-profile",
+		Short: "Export the settings and config of a loadpoint without its devices as a YAML profile",
+		RunE:  runLoadpointExportProfile,
+	}
+	loadpointExportProfileCmd.Flags().StringVar(&profileDB, "db", "", "Database file (required)")
+	loadpointExportProfileCmd.Flags().StringVar(&profileName, "loadpoint", "", "Loadpoint name (required)")
+	loadpointExportProfileCmd.Flags().StringVar(&output, "output", "", "Profile file (default: stdout)")
+	_ = loadpointExportProfileCmd.MarkFlagRequired("db")
+	_ = loadpointExportProfileCmd.MarkFlagRequired("loadpoint")
+	loadpointImportProfileCmd := &cobra.Command{
+		Use:   "import-profile",
+		Short: "Apply a loadpoint profile to a database",
+		RunE:  runImportProfile,
+	}
+	loadpointImportProfileCmd.Flags().StringVar(&profileDB, "db", "", "Database file (required)")
+	loadpointImportProfileCmd.Flags().StringVar(&source, "source", "", "Profile file (required)")
+	loadpointImportProfileCmd.Flags().StringVar(&profileName, "loadpoint", "", "Apply the profile to this loadpoint, created if missing (default: the profile's loadpoint)")
+	loadpointImportProfileCmd.Flags().BoolVar(&dryRun, "dry-run", false, "Show what would be applied without doing it")
+	loadpointImportProfileCmd.Flags().BoolVarP(&assumeYes, "yes", "y", false, "Skip confirmation prompt")
+	_ = loadpointImportProfileCmd.MarkFlagRequired("db")
+	_ = loadpointImportProfileCmd.MarkFlagRequired("source")
+	loadpointCmd.AddCommand(loadpointExportProfileCmd, loadpointImportProfileCmd)
+
+	// Guests command
+	guestsCmd := &cobra.Command{
+		Use:   "guests",
+		Short: "Assign sessions without vehicle to a guest vehicle per identifier",
+		RunE:  runGuests,
+	}
+	guestsCmd.Flags().StringVar(&guestsDB, "db", "", "Database file (required)")
+	guestsCmd.Flags().StringVar(&guestName, "name", evccdb.DefaultGuestName, "Vehicle name, {identifier} is replaced by the session identifier")
+	guestsCmd.Flags().BoolVar(&dryRun, "dry-run", false, "Show which sessions would be assigned without doing it")
+	guestsCmd.Flags().BoolVarP(&assumeYes, "yes", "y", false, "Skip confirmation prompt")
+	_ = guestsCmd.MarkFlagRequired("db")
+
+	// Check credentials command
+	checkCredentialsCmd := &cobra.Command{
+		Use:   "check-credentials",
+		Short: "Report stored tokens that are expired or about to expire, e.g. after restoring an old backup",
+		RunE:  runCheckCredentials,
+	}
+	checkCredentialsCmd.Flags().StringVar(&credsDB, "db", "", "Database file (required)")
+	checkCredentialsCmd.Flags().IntVar(&credsDays, "days", 30, "Report tokens expiring within this many days")
+	checkCredentialsCmd.Flags().StringVar(&credsFormat, "format", "table", formatUsage)
+	checkCredentialsCmd.Flags().StringVar(&locale, "locale", "", localeUsage)
+	_ = checkCredentialsCmd.MarkFlagRequired("db")
+
+	// Verify command
+	verifyCmd := &cobra.Command{
+		Use:   "verify",
+		Short: "Compare the row counts and checksums of the tables of two databases, e.g. after a transfer",
+		RunE:  runVerify,
+	}
+	verifyCmd.Flags().StringVar(&transferSrc, "from", "", "Source database file (required)")
+	verifyCmd.Flags().StringVar(&transferDst, "to", "", "Target database file (required)")
+	verifyCmd.Flags().StringVar(&modeStr, "mode", "all", "Transfer mode: config, metrics, all, or a mode of the config file")
+	verifyCmd.Flags().StringVar(&tables, "tables", "", "Comma-separated table names (overrides mode)")
+	verifyCmd.Flags().BoolVar(&includeCaches, "include-caches", false, "Include the caches table")
+	verifyCmd.Flags().BoolVar(&checksumCache, "cache", false, "Keep the checksums of older rows in both databases to speed up repeated runs")
+	verifyCmd.Flags().IntVar(&verifyJobs, "jobs", 0, "Number of tables hashed concurrently (default: number of CPUs)")
+	verifyCmd.Flags().StringVar(&verifyFormat, "format", "table", formatUsage)
+	verifyCmd.Flags().StringVar(&locale, "locale", "", localeUsage)
+	_ = verifyCmd.MarkFlagRequired("from")
+	_ = verifyCmd.MarkFlagRequired("to")
+
+	// Report command
+	reportCmd := &cobra.Command{
+		Use:   "report",
+		Short: "Analyze the recorded history",
+	}
+	reportGapsCmd := &cobra.Command{
+		Use:   "gaps",
+		Short: "Find periods without sessions or meter readings, e.g. lost by a crash of evcc",
+		RunE:  runReportGaps,
+	}
+	reportGapsCmd.Flags().StringVar(&reportDB, "db", "", "Database file (required)")
+	reportGapsCmd.Flags().Float64Var(&gapFactor, "factor", evccdb.DefaultGapFactor, "Report gaps longer than this multiple of the normal interval of a loadpoint or meter")
+	reportGapsCmd.Flags().DurationVar(&gapMin, "min-gap", evccdb.DefaultMinGap, "Shortest reported gap")
+	reportGapsCmd.Flags().StringVar(&reportFormat, "format", "table", formatUsage)
+	reportGapsCmd.Flags().StringVar(&locale, "locale", "", localeUsage)
+	_ = reportGapsCmd.MarkFlagRequired("db")
+	reportGridCmd := &cobra.Command{
+		Use:   "grid",
+		Short: "List the periods in which the grid power was limited, e.g. dimming by the grid operator",
+		RunE:  runReportGrid,
+	}
+	reportGridCmd.Flags().StringVar(&reportDB, "db", "", "Database file (required)")
+	reportGridCmd.Flags().StringVar(&reportFormat, "format", "table", formatUsage)
+	reportGridCmd.Flags().StringVar(&locale, "locale", "", localeUsage)
+	_ = reportGridCmd.MarkFlagRequired("db")
+	reportSolarCmd := &cobra.Command{
+		Use:   "solar",
+		Short: "Show the solar share per month and the sessions that could have charged more solar energy",
+		RunE:  runReportSolar,
+	}
+	reportSolarCmd.Flags().StringVar(&reportDB, "db", "", "Database file (required)")
+	reportSolarCmd.Flags().StringVar(&solarWindow, "window", "10:00-16:00", "Time of day in which PV typically covers charging")
+	reportSolarCmd.Flags().IntVar(&solarWorst, "worst", evccdb.DefaultWorstSessions, "Number of ranked sessions")
+	reportSolarCmd.Flags().StringVar(&reportFormat, "format", "table", formatUsage)
+	reportSolarCmd.Flags().StringVar(&locale, "locale", "", localeUsage)
+	_ = reportSolarCmd.MarkFlagRequired("db")
+	reportEfficiencyCmd := &cobra.Command{
+		Use:   "efficiency",
+		Short: "Show the consumption of vehicles per month from the odometer of their sessions",
+		RunE:  runReportEfficiency,
+	}
+	reportEfficiencyCmd.Flags().StringVar(&reportDB, "db", "", "Database file (required)")
+	reportEfficiencyCmd.Flags().Float64Var(&outlierFactor, "outlier-factor", evccdb.DefaultOutlierFactor, "Leave out consumption values this many interquartile ranges off, negative keeps all")
+	reportEfficiencyCmd.Flags().StringVar(&reportFormat, "format", "table", formatUsage)
+	reportEfficiencyCmd.Flags().StringVar(&locale, "locale", "", localeUsage)
+	_ = reportEfficiencyCmd.MarkFlagRequired("db")
+	reportCmd.AddCommand(reportGapsCmd, reportGridCmd, reportSolarCmd, reportEfficiencyCmd)
+
+	// Stats command
+	statsCmd := &cobra.Command{
+		Use:   "stats",
+		Short: "Summarize the charging sessions and grid sessions of a database",
+		RunE:  runStats,
+	}
+	statsCmd.Flags().StringVar(&statsDB, "db", "", "Database file (required)")
+	statsCmd.Flags().StringVar(&statsGroupBy, "group-by", "loadpoint", "Group sessions by: "+strings.Join(evccdb.SessionGroupings(), ", "))
+	statsCmd.Flags().StringSliceVar(&statsHolidays, "holidays", nil, "Dates the daytype grouping counts as holiday, e.g. 2024-12-25,2024-12-26")
+	statsCmd.Flags().StringVar(&statsFormat, "format", "table", formatUsage)
+	statsCmd.Flags().StringVar(&locale, "locale", "", localeUsage)
+	_ = statsCmd.MarkFlagRequired("db")
+
+	// Simulate tariff command
+	simulateTariffCmd := &cobra.Command{
+		Use:   "simulate-tariff",
+		Short: "Compute what the charging sessions would have cost under another tariff",
+		RunE:  runSimulateTariff,
+	}
+	simulateTariffCmd.Flags().StringVar(&tariffDB, "db", "", "Database file (required)")
+	simulateTariffCmd.Flags().StringVar(&tariffCSV, "price-csv", "", "CSV file of start times and prices per kWh (required)")
+	simulateTariffCmd.Flags().BoolVar(&tariffMeters, "meters", false, "Spread the energy of sessions by the meter readings of their loadpoint")
+	simulateTariffCmd.Flags().Float64Var(&tariffSolarPrice, "solar-price", 0, "Price per kWh of solar energy, e.g. the feed-in tariff")
+	simulateTariffCmd.Flags().BoolVar(&tariffSessions, "sessions", false, "List every session instead of the monthly totals")
+	simulateTariffCmd.Flags().StringVar(&tariffFormat, "format", "table", formatUsage)
+	simulateTariffCmd.Flags().StringVar(&locale, "locale", "", localeUsage)
+	_ = simulateTariffCmd.MarkFlagRequired("db")
+	_ = simulateTariffCmd.MarkFlagRequired("price-csv")
+
+	// Inspect command
+	inspectCmd := &cobra.Command{
+		Use:   "inspect",
+		Short: "Show the SQLite environment and the tables of a database, found automatically without --db",
+		RunE:  runInspect,
+	}
+	inspectCmd.Flags().StringVar(&inspectDB, "db", "", "Database file, looked for in the locations of evcc installations if not given")
+	inspectCmd.Flags().BoolVarP(&assumeYes, "yes", "y", false, "Inspect a single database found without asking")
+	inspectCmd.Flags().StringVar(&inspectFormat, "format", "table", formatUsage)
+	inspectCmd.Flags().StringVar(&locale, "locale", "", localeUsage)
+
+	// Session command
+	sessionCmd := &cobra.Command{
+		Use:   "session",
+		Short: "Analyze a single charging session",
+	}
+	sessionCurveCmd := &cobra.Command{
+		Use:   "curve",
+		Short: "Show the charging curve of a session from the meter readings of its loadpoint",
+		RunE:  runSessionCurve,
+	}
+	sessionCurveCmd.Flags().StringVar(&sessionDB, "db", "", "Database file (required)")
+	sessionCurveCmd.Flags().Int64Var(&sessionID, "id", 0, "Session id (required)")
+	sessionCurveCmd.Flags().StringVar(&sessionFormat, "format", "table", formatUsage)
+	sessionCurveCmd.Flags().StringVar(&locale, "locale", "", localeUsage)
+	_ = sessionCurveCmd.MarkFlagRequired("db")
+	_ = sessionCurveCmd.MarkFlagRequired("id")
+	sessionCmd.AddCommand(sessionCurveCmd)
+
+	rootCmd.AddCommand(exportCmd, importCmd, transferCmd, renameCmd, deleteCmd, trashCmd, queryCmd, chartCmd, serveCmd, batchCmd, fleetCmd, configCmd, settingsCmd, cacheCmd, historyCmd, policyCmd, consolidateCmd, vehicleCmd, loadpointCmd, guestsCmd, checkCredentialsCmd, verifyCmd, reportCmd, sessionCmd, statsCmd, simulateTariffCmd, inspectCmd)
+
+	applyFlagAliases(rootCmd)
+	silenceOnCancel(rootCmd)
+	ctx, stop := interruptContext()
+	defer stop()
+
+	start := time.Now()
+	executed, err := rootCmd.ExecuteContextC(ctx)
+	writeAudit(executed, start, err)
+	if err != nil {
+		if ctx.Err() != nil {
+			printCancelled()
+			os.Exit(130)
+		}
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
 }
 
 func runExport(cmd *cobra.Command, args []string) error {
+	if _, err := loadConfig(); err != nil {
+		return err
+	}
+
 	client, err := evccdb.Open(source)
 	if err != nil {
 		return fmt.Errorf("failed to open database: %w", err)
 	}
 	defer func() { _ = client.Close() }()
 
+	if signKey != "" && format == "parquet" {
+		return fmt.Errorf("--sign-key supports json and sql exports only")
+	}
+	if (compression != "" || compressLevel != 0) && format == "parquet" {
+		return fmt.Errorf("--compress supports json and sql exports only")
+	}
+	if splitSize != "" && format == "parquet" {
+		return fmt.Errorf("--split-size supports json and sql exports only")
+	}
+
+	output = expandOutputName(output, source, modeStr, time.Now())
+	if timestampOutput {
+		output = timestampedPath(output, time.Now())
+	}
+	file, err := client.File()
+	if err != nil {
+		return err
+	}
+	same, err := evccdb.SameFile(output, file)
+	if err != nil {
+		return err
+	}
+	if same {
+		return fmt.Errorf("%w: --output %s is the exported database", evccdb.ErrSameDatabase, output)
+	}
+	// The data in the database is a lower bound of the size of a JSON or SQL export
+	estimate, err := client.UsedSize()
+	if err != nil {
+		return err
+	}
+
 	mode := parseMode(modeStr)
 	opts := evccdb.TransferOptions{
-		Mode: mode,
+		Mode:                 mode,
+		IncludeCaches:        includeCaches,
+		ExcludeGuestSessions: excludeGuests,
+		GuestVehicle:         guestVehicle,
+		SchemaObjects:        schemaObjects,
+	}
+	if opts.SQLDialect, err = evccdb.ParseSQLDialect(sqlDialect); err != nil {
+		return err
+	}
+	if timezone != "" {
+		if opts.Location, err = time.LoadLocation(timezone); err != nil {
+			return fmt.Errorf("invalid time zone: %w", err)
+		}
+	}
+	if err := applyClasses(&opts); err != nil {
+		return err
 	}
 
 	if tables != "" {
@@ -141,28 +870,326 @@ func runExport(cmd *cobra.Command, args []string) error {
 
 	if verbose {
 		opts.OnProgress = func(table string, count int) {
-			fmt.Printf("Exported %s: %d rows\n", table, count)
+			fmt.Printf(evccdb.Translate("Exported %s: %d rows\n"), table, count)
+		}
+	}
+
+	switch format {
+	case "json":
+		if err := exportFile(cmd.Context(), client.ExportJSON, opts, estimate); err != nil {
+			return err
+		}
+	case "sql":
+		if err := exportFile(cmd.Context(), client.ExportSQL, opts, estimate); err != nil {
+			return err
 		}
+	case "parquet":
+		if err := exportParquet(cmd.Context(), client, opts); err != nil {
+			return fmt.Errorf("export failed: %w", err)
+		}
+	default:
+		return fmt.Errorf("unknown format %q, expected json, sql or parquet", format)
+	}
+
+	if signKey != "" {
+		if err := signFile(output, signKey); err != nil {
+			return err
+		}
+	}
+
+	fmt.Printf(evccdb.Translate("Successfully exported to %s\n"), output)
+	return nil
+}
+
+// signFile writes the detached signature of a file next to it
+func signFile(path, keyPath string) error {
+	key, err := evccdb.LoadSigningKey(keyPath)
+	if err != nil {
+		return err
+	}
+
+	f, err := evccdb.OpenExport(path)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+
+	sig, err := evccdb.SignExport(f, key)
+	if err != nil {
+		return err
+	}
+
+	sigPath := path + evccdb.SignatureSuffix
+	if err := os.WriteFile(sigPath, []byte(sig+"\n"), 0o644); err != nil {
+		return fmt.Errorf("failed to write signature: %w", err)
+	}
+	fmt.Printf(evccdb.Translate("Signature written to %s\n"), sigPath)
+	return nil
+}
+
+// verifyFile checks the detached signature of a file
+func verifyFile(path, keyPath string) error {
+	key, err := evccdb.LoadVerifyKey(keyPath)
+	if err != nil {
+		return err
 	}
 
-	outputFile, err := os.Create(output)
+	sig, err := os.ReadFile(path + evccdb.SignatureSuffix)
 	if err != nil {
-		return fmt.Errorf("failed to create output file: %w", err)
+		return fmt.Errorf("failed to read signature: %w", err)
 	}
-	defer func() { _ = outputFile.Close() }()
 
-	if err := client.ExportJSON(outputFile, opts); err != nil {
+	f, err := evccdb.OpenExport(path)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+
+	if err := evccdb.VerifyExport(f, string(sig), key); err != nil {
+		return err
+	}
+	fmt.Printf(evccdb.Translate("Signature of %s verified\n"), path)
+	return nil
+}
+
+// exportFile writes an export to the output file, keeping a previous file on failure or cancellation.
+// Uncompressed exports fail early if the filesystem has less free space than estimate.
+func exportFile(ctx context.Context, export func(io.Writer, evccdb.TransferOptions) error, opts evccdb.TransferOptions, estimate int64) error {
+	c := evccdb.CompressionOf(output)
+	if compression != "" {
+		var err error
+		if c, err = evccdb.ParseCompression(compression); err != nil {
+			return err
+		}
+	}
+	if c == evccdb.CompressNone {
+		if err := evccdb.CheckFreeSpace(output, estimate); err != nil {
+			return err
+		}
+	}
+
+	var size int64
+	if splitSize != "" {
+		var err error
+		if size, err = parseSize(splitSize); err != nil {
+			return err
+		}
+	}
+
+	// All files are committed together after the export succeeded
+	var files []*outputFile
+	abort := func() {
+		for _, f := range files {
+			f.Abort()
+		}
+	}
+	create := func(name string) (io.Writer, error) {
+		f, err := createOutput(name)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, f)
+		return f, nil
+	}
+
+	var w io.Writer
+	var split *evccdb.SplitWriter
+	if size > 0 {
+		split = evccdb.NewSplitWriter(output, size, create)
+		w = split
+	} else {
+		var err error
+		if w, err = create(output); err != nil {
+			return err
+		}
+	}
+
+	zw, err := evccdb.NewCompressWriter(w, c, compressLevel)
+	if err != nil {
+		abort()
+		return err
+	}
+
+	err = export(cancelWriter{ctx: ctx, w: zw}, opts)
+	if cerr := zw.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil && split != nil {
+		err = writeManifest(split.Manifest(), create)
+	}
+	if err != nil {
+		abort()
 		return fmt.Errorf("export failed: %w", err)
 	}
 
-	fmt.Printf("Successfully exported to %s\n", output)
+	for i, f := range files {
+		if err := f.Commit(); err != nil {
+			files = files[i+1:]
+			abort()
+			return err
+		}
+	}
+	return nil
+}
+
+// writeManifest writes the manifest of a split export
+func writeManifest(manifest evccdb.SplitManifest, create func(name string) (io.Writer, error)) error {
+	w, err := create(output + evccdb.SplitManifestSuffix)
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(manifest)
+}
+
+// parseSize parses a size like 100MB or 4GB, KB, MB and GB being powers of 1000 and KiB, MiB and GiB powers of 1024
+func parseSize(s string) (int64, error) {
+	units := []struct {
+		suffix string
+		factor int64
+	}{
+		{"KiB", 1 << 10}, {"MiB", 1 << 20}, {"GiB", 1 << 30},
+		{"KB", 1e3}, {"MB", 1e6}, {"GB", 1e9}, {"B", 1},
+	}
+
+	factor := int64(1)
+	number := strings.TrimSpace(s)
+	for _, u := range units {
+		if strings.HasSuffix(strings.ToUpper(number), strings.ToUpper(u.suffix)) {
+			number, factor = strings.TrimSpace(number[:len(number)-len(u.suffix)]), u.factor
+			break
+		}
+	}
+
+	n, err := strconv.ParseInt(number, 10, 64)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid size %q, expected e.g. 100MB", s)
+	}
+	return n * factor, nil
+}
+
+// outputFile is written to a temporary file next to its path and replaces the path only on
+// Commit, so a failed export never destroys the previous good one
+type outputFile struct {
+	*os.File
+	path string
+}
+
+// createOutput creates the output file at path, failing if it exists unless --force is given
+func createOutput(path string) (*outputFile, error) {
+	if !overwrite {
+		if _, err := os.Stat(path); err == nil {
+			return nil, fmt.Errorf("output file %s already exists, use --force to overwrite it or --timestamp", path)
+		}
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create output file: %w", err)
+	}
+	if err := f.Chmod(0o644); err != nil {
+		_ = f.Close()
+		_ = os.Remove(f.Name())
+		return nil, fmt.Errorf("failed to create output file: %w", err)
+	}
+	return &outputFile{File: f, path: path}, nil
+}
+
+// Commit closes the temporary file and renames it to the output path
+func (f *outputFile) Commit() error {
+	if err := f.Close(); err != nil {
+		_ = os.Remove(f.Name())
+		return fmt.Errorf("failed to write output file: %w", err)
+	}
+	if err := os.Rename(f.Name(), f.path); err != nil {
+		_ = os.Remove(f.Name())
+		return fmt.Errorf("failed to write output file: %w", err)
+	}
+	return nil
+}
+
+// Abort closes and removes the temporary file
+func (f *outputFile) Abort() {
+	_ = f.Close()
+	_ = os.Remove(f.Name())
+}
+
+// expandOutputName replaces the placeholders {hostname}, {dbname}, {date}, {time} and {mode} of an output name
+func expandOutputName(name, db, mode string, t time.Time) string {
+	hostname, _ := os.Hostname()
+	dbname := filepath.Base(db)
+	return strings.NewReplacer(
+		"{hostname}", hostname,
+		"{dbname}", strings.TrimSuffix(dbname, filepath.Ext(dbname)),
+		"{date}", t.Format("2006-01-02"),
+		"{time}", t.Format("150405"),
+		"{mode}", mode,
+	).Replace(name)
+}
+
+// timestampedPath appends the time to the name of path before its extension
+func timestampedPath(path string, t time.Time) string {
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + "-" + t.Format("20060102-150405") + ext
+}
+
+// exportParquet writes one Parquet file per table into the output directory
+func exportParquet(ctx context.Context, client *evccdb.Client, opts evccdb.TransferOptions) error {
+	tables, err := client.ResolveTables(opts)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(output, 0o755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	for _, table := range tables {
+		exists, err := client.TableExists(table)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			continue
+		}
+
+		f, err := createOutput(filepath.Join(output, table+".parquet"))
+		if err != nil {
+			return err
+		}
+
+		count, err := client.ExportParquet(ctx, f, table, opts)
+		if err != nil {
+			f.Abort()
+			return fmt.Errorf("failed to export table %s: %w", table, err)
+		}
+		if err := f.Commit(); err != nil {
+			return err
+		}
+
+		if opts.OnProgress != nil {
+			opts.OnProgress(table, count)
+		}
+	}
+
 	return nil
 }
 
 func runImport(cmd *cobra.Command, args []string) error {
-	sourceFile, err := os.Open(source)
+	if _, err := loadConfig(); err != nil {
+		return err
+	}
+
+	if verifyKey != "" {
+		if err := verifyFile(source, verifyKey); err != nil {
+			return err
+		}
+	}
+
+	sourceFile, err := evccdb.OpenExport(source)
 	if err != nil {
-		return fmt.Errorf("failed to open source file: %w", err)
+		return err
 	}
 	defer func() { _ = sourceFile.Close() }()
 
@@ -174,7 +1201,30 @@ func runImport(cmd *cobra.Command, args []string) error {
 
 	mode := parseMode(modeStr)
 	opts := evccdb.TransferOptions{
-		Mode: mode,
+		Mode:                  mode,
+		DeferForeignKeys:      deferFKs,
+		AllowUnknownTables:    allowUnknown,
+		IncludeCaches:         includeCaches,
+		Fast:                  fastWrite,
+		ConvertCurrency:       convertCurrency,
+		StripInstanceIdentity: stripIdentity,
+		MaxRowsPerTable:       limitRows,
+		Truncate:              truncateOption(),
+		SchemaObjects:         schemaObjects,
+	}
+
+	if err := applySettingsMerge(&opts); err != nil {
+		return err
+	}
+	if err := applyTemplateAliases(&opts); err != nil {
+		return err
+	}
+	if err := applySQLHooks(&opts); err != nil {
+		return err
+	}
+	report := evccdb.NewFailureReport("import", source, target, nil)
+	if err := applyErrorPolicy(&opts, report); err != nil {
+		return err
 	}
 
 	if tables != "" {
@@ -184,21 +1234,143 @@ func runImport(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	opts.OnProgress = func(table string, count int) {
+		auditRows(table, count)
+		if verbose {
+			fmt.Printf(evccdb.Translate("Imported %s: %d rows\n"), table, count)
+		}
+	}
 	if verbose {
-		opts.OnProgress = func(table string, count int) {
-			fmt.Printf("Imported %s: %d rows\n", table, count)
+		opts.OnRowProgress = printRowProgress
+	}
+
+	if timezone != "" {
+		loc, err := time.LoadLocation(timezone)
+		if err != nil {
+			return fmt.Errorf("invalid time zone: %w", err)
 		}
+		opts.Location = loc
+	}
+
+	// The imported rows take about as much space in the database as in the file
+	size, err := evccdb.ExportSize(source)
+	if err != nil {
+		return err
+	}
+	if err := evccdb.CheckFreeSpace(target, size); err != nil {
+		return err
 	}
 
-	if err := client.ImportJSON(sourceFile, opts); err != nil {
-		return fmt.Errorf("import failed: %w", err)
+	unlock, err := lockDatabase(cmd.Context(), target)
+	if err != nil {
+		return err
 	}
+	defer unlock()
 
-	fmt.Printf("Successfully imported from %s\n", source)
-	return nil
+	if err := backupBeforeWrite(cmd.Context(), client, target); err != nil {
+		return err
+	}
+
+	switch format {
+	case "json":
+		if err := client.ImportJSONContext(cmd.Context(), sourceFile, opts); err != nil {
+			return writeFailureReport(report, fmt.Errorf("import failed: %w", err))
+		}
+		if err := reportSkippedRows(report); err != nil {
+			return err
+		}
+	case "csv":
+		if csvTable != "sessions" {
+			return fmt.Errorf("CSV import supports only the sessions table, got %q", csvTable)
+		}
+		if opts.PreSQL != "" || opts.PostSQL != "" {
+			return fmt.Errorf("--pre-sql-file and --post-sql-file are not supported for CSV import")
+		}
+		if opts.SkipRowErrors {
+			return fmt.Errorf("--on-error %s is not supported for CSV import", onError)
+		}
+
+		columns, err := parseColumnMap(columnMap)
+		if err != nil {
+			return err
+		}
+
+		count, err := client.ImportSessionsCSV(cmd.Context(), sourceFile, evccdb.CSVOptions{
+			Columns:  columns,
+			Locale:   locale,
+			Profile:  csvProfile,
+			Location: opts.Location,
+		})
+		if err != nil {
+			return writeFailureReport(report, fmt.Errorf("import failed: %w", err))
+		}
+		opts.OnProgress(csvTable, count)
+	default:
+		return fmt.Errorf("unknown format %q, expected json or csv", format)
+	}
+
+	fmt.Printf(evccdb.Translate("Successfully imported from %s\n"), source)
+	return optimizeAfterWrite(cmd.Context(), client)
+}
+
+func runQuery(cmd *cobra.Command, args []string) error {
+	if _, err := loadConfig(); err != nil {
+		return err
+	}
+
+	if listNamed {
+		for _, q := range evccdb.NamedQueries() {
+			fmt.Printf("%-16s %s\n", q.Name, q.Description)
+		}
+		return nil
+	}
+
+	if queryDB == "" {
+		return fmt.Errorf("required flag \"db\" not set")
+	}
+	if (len(args) == 0) == (queryNamed == "") {
+		return fmt.Errorf("either a SELECT statement or --named is required")
+	}
+
+	client, err := evccdb.Open(queryDB)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer func() { _ = client.Close() }()
+
+	ctx := cmd.Context()
+	var result *evccdb.QueryResult
+	if queryNamed != "" {
+		result, err = client.QueryNamed(ctx, queryNamed)
+	} else {
+		result, err = client.Query(ctx, args[0], nil)
+	}
+	if err != nil {
+		return err
+	}
+
+	return writeQueryResult(os.Stdout, result, queryFormat)
+}
+
+// writeQueryResult writes a query result in the given output format
+func writeQueryResult(w io.Writer, result *evccdb.QueryResult, format string) error {
+	return writeSections(w, []evccdb.Section{{Result: result}}, format)
+}
+
+// writeSections writes the results of a command in the given output format
+func writeSections(w io.Writer, sections []evccdb.Section, format string) error {
+	renderer, err := evccdb.NewRenderer(format, evccdb.ParseLocale(locale))
+	if err != nil {
+		return err
+	}
+	return renderer.Render(w, sections...)
 }
 
 func runTransfer(cmd *cobra.Command, args []string) error {
+	if _, err := loadConfig(); err != nil {
+		return err
+	}
+
 	src, err := evccdb.Open(transferSrc)
 	if err != nil {
 		return fmt.Errorf("failed to open source database: %w", err)
@@ -211,10 +1383,41 @@ func runTransfer(cmd *cobra.Command, args []string) error {
 	}
 	defer func() { _ = dst.Close() }()
 
+	if err := evccdb.CheckDistinct(src, dst); err != nil {
+		return err
+	}
+
 	mode := parseMode(modeStr)
 	opts := evccdb.TransferOptions{
-		Mode:   mode,
-		DryRun: dryRun,
+		Mode:             mode,
+		DryRun:           dryRun,
+		DeferForeignKeys: deferFKs,
+		Attach:           useAttach,
+		Incremental:      incremental,
+		IncludeCaches:    includeCaches,
+		SampleRows:       sampleRows,
+		Fast:             fastWrite,
+		Clone:            cloneDB,
+		// Guest sessions are filtered row by row, --attach falls back to copying rows and --clone fails
+		ExcludeGuestSessions:  excludeGuests,
+		GuestVehicle:          guestVehicle,
+		ConvertCurrency:       convertCurrency,
+		StripInstanceIdentity: stripIdentity,
+		MaxRowsPerTable:       limitRows,
+		Truncate:              truncateOption(),
+	}
+
+	if err := applySettingsMerge(&opts); err != nil {
+		return err
+	}
+	if err := applyTemplateAliases(&opts); err != nil {
+		return err
+	}
+	if err := applySQLHooks(&opts); err != nil {
+		return err
+	}
+	if err := applyClasses(&opts); err != nil {
+		return err
 	}
 
 	if tables != "" {
@@ -242,23 +1445,78 @@ func runTransfer(cmd *cobra.Command, args []string) error {
 		opts.VehicleRenames = renames
 	}
 
+	opts.OnProgress = func(table string, count int) {
+		if !dryRun {
+			auditRows(table, count)
+		}
+		if verbose {
+			fmt.Printf(evccdb.Translate("Transferred %s: %d rows\n"), table, count)
+		}
+	}
 	if verbose {
-		opts.OnProgress = func(table string, count int) {
-			fmt.Printf("Transferred %s: %d rows\n", table, count)
+		opts.OnRowProgress = printRowProgress
+	}
+
+	ctx := cmd.Context()
+
+	if checkSkew || timeOffset == "auto" {
+		report, err := evccdb.DetectClockSkew(ctx, src, dst)
+		if err != nil {
+			return fmt.Errorf("failed to detect clock skew: %w", err)
+		}
+		report.Print(os.Stdout)
+		if timeOffset == "auto" && report.Skewed() {
+			opts.TimeOffset = report.Offset
+			fmt.Printf("Shifting source timestamps by %s\n", opts.TimeOffset)
+		}
+	}
+	if timeOffset != "" && timeOffset != "auto" {
+		offset, err := time.ParseDuration(timeOffset)
+		if err != nil {
+			return fmt.Errorf("invalid --time-offset: %w", err)
+		}
+		opts.TimeOffset = offset
+	}
+
+	if confirmPlan && !dryRun {
+		plan, err := evccdb.PlanTransfer(ctx, src, dst, opts)
+		if err != nil {
+			return fmt.Errorf("failed to plan transfer: %w", err)
+		}
+		fmt.Printf(evccdb.Translate("Transfer plan %s -> %s:\n"), transferSrc, transferDst)
+		plan.Print(os.Stdout)
+		if !assumeYes && !confirm() {
+			fmt.Println(evccdb.Translate("Operation cancelled"))
+			return nil
+		}
+	}
+
+	if !dryRun {
+		unlock, err := lockDatabase(ctx, transferDst)
+		if err != nil {
+			return err
+		}
+		defer unlock()
+
+		if err := backupBeforeWrite(ctx, dst, transferDst); err != nil {
+			return err
 		}
 	}
 
-	ctx := context.Background()
 	if err := evccdb.Transfer(ctx, src, dst, opts); err != nil {
-		return fmt.Errorf("transfer failed: %w", err)
+		err = fmt.Errorf("transfer failed: %w", err)
+		if dryRun {
+			return err
+		}
+		return writeFailureReport(evccdb.NewFailureReport("transfer", transferSrc, transferDst, nil), err)
 	}
 
 	if dryRun {
-		fmt.Println("Dry run completed (no changes made)")
-	} else {
-		fmt.Println("Transfer completed successfully")
+		fmt.Println(evccdb.Translate("Dry run completed (no changes made)"))
+		return nil
 	}
-	return nil
+	fmt.Println(evccdb.Translate("Transfer completed successfully"))
+	return optimizeAfterWrite(ctx, dst)
 }
 
 func runRename(cmd *cobra.Command, args []string) error {
@@ -268,7 +1526,27 @@ func runRename(cmd *cobra.Command, args []string) error {
 	}
 	defer func() { _ = client.Close() }()
 
-	ctx := context.Background()
+	ctx := cmd.Context()
+
+	// All renames share one transaction, so a failing rename reverts the whole invocation
+	var tx *evccdb.Tx
+	if !dryRun {
+		unlock, err := lockDatabase(ctx, renameDB)
+		if err != nil {
+			return err
+		}
+		defer unlock()
+
+		if err := backupBeforeWrite(ctx, client, renameDB); err != nil {
+			return err
+		}
+
+		tx, err = client.Begin(ctx)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = tx.Rollback() }()
+	}
 
 	// Parse and apply loadpoint renames
 	if renameLoadpoints != "" {
@@ -277,27 +1555,70 @@ func runRename(cmd *cobra.Command, args []string) error {
 			return fmt.Errorf("invalid --loadpoint: %w", err)
 		}
 
-		for _, rename := range renames {
+		for i, rename := range renames {
 			if dryRun {
 				result, err := client.RenameLoadpointDryRun(ctx, rename.OldName, rename.NewName)
 				if err != nil {
 					return fmt.Errorf("dry run failed for loadpoint %q: %w", rename.OldName, err)
 				}
-				fmt.Printf("Would rename loadpoint %q -> %q: sessions=%d, settings=%d, configs=%d\n",
+				fmt.Printf(evccdb.Translate("Would rename loadpoint %q -> %q: sessions=%d, settings=%d, configs=%d\n"),
 					rename.OldName, rename.NewName, result.Sessions, result.Settings, result.Configs)
 			} else {
-				result, err := client.RenameLoadpoint(ctx, rename.OldName, rename.NewName)
+				var result evccdb.RenameResult
+				err := tx.Step(ctx, fmt.Sprintf("rename_loadpoint_%d", i+1), func() (err error) {
+					result, err = tx.RenameLoadpoint(ctx, rename.OldName, rename.NewName)
+					return err
+				})
 				if err != nil {
 					return fmt.Errorf("failed to rename loadpoint %q: %w", rename.OldName, err)
 				}
+				auditRenameResult(result)
 				if verbose {
-					fmt.Printf("Renamed loadpoint %q -> %q: sessions=%d, settings=%d, configs=%d\n",
+					fmt.Printf(evccdb.Translate("Renamed loadpoint %q -> %q: sessions=%d, settings=%d, configs=%d\n"),
 						rename.OldName, rename.NewName, result.Sessions, result.Settings, result.Configs)
 				}
 			}
 		}
 	}
 
+	// Parse and apply loadpoint renames by index
+	if renameIndices != "" {
+		renames, err := parseRenames(renameIndices)
+		if err != nil {
+			return fmt.Errorf("invalid --loadpoint-index: %w", err)
+		}
+
+		for i, rename := range renames {
+			index, err := strconv.Atoi(rename.OldName)
+			if err != nil {
+				return fmt.Errorf("invalid --loadpoint-index: %q is not a loadpoint index", rename.OldName)
+			}
+
+			if dryRun {
+				result, err := client.RenameLoadpointIndexDryRun(ctx, index, rename.NewName)
+				if err != nil {
+					return fmt.Errorf("dry run failed for loadpoint lp%d: %w", index, err)
+				}
+				fmt.Printf(evccdb.Translate("Would rename loadpoint lp%d -> %q: sessions=%d, settings=%d, configs=%d\n"),
+					index, rename.NewName, result.Sessions, result.Settings, result.Configs)
+			} else {
+				var result evccdb.RenameResult
+				err := tx.Step(ctx, fmt.Sprintf("rename_loadpoint_index_%d", i+1), func() (err error) {
+					result, err = tx.RenameLoadpointIndex(ctx, index, rename.NewName)
+					return err
+				})
+				if err != nil {
+					return fmt.Errorf("failed to rename loadpoint lp%d: %w", index, err)
+				}
+				auditRenameResult(result)
+				if verbose {
+					fmt.Printf(evccdb.Translate("Renamed loadpoint lp%d -> %q: sessions=%d, settings=%d, configs=%d\n"),
+						index, rename.NewName, result.Sessions, result.Settings, result.Configs)
+				}
+			}
+		}
+	}
+
 	// Parse and apply vehicle renames
 	if renameVehicles != "" {
 		renames, err := parseRenames(renameVehicles)
@@ -305,21 +1626,26 @@ func runRename(cmd *cobra.Command, args []string) error {
 			return fmt.Errorf("invalid --vehicle: %w", err)
 		}
 
-		for _, rename := range renames {
+		for i, rename := range renames {
 			if dryRun {
 				result, err := client.RenameVehicleDryRun(ctx, rename.OldName, rename.NewName)
 				if err != nil {
 					return fmt.Errorf("dry run failed for vehicle %q: %w", rename.OldName, err)
 				}
-				fmt.Printf("Would rename vehicle %q -> %q: sessions=%d, settings=%d, configs=%d\n",
+				fmt.Printf(evccdb.Translate("Would rename vehicle %q -> %q: sessions=%d, settings=%d, configs=%d\n"),
 					rename.OldName, rename.NewName, result.Sessions, result.Settings, result.Configs)
 			} else {
-				result, err := client.RenameVehicle(ctx, rename.OldName, rename.NewName)
+				var result evccdb.RenameResult
+				err := tx.Step(ctx, fmt.Sprintf("rename_vehicle_%d", i+1), func() (err error) {
+					result, err = tx.RenameVehicle(ctx, rename.OldName, rename.NewName)
+					return err
+				})
 				if err != nil {
 					return fmt.Errorf("failed to rename vehicle %q: %w", rename.OldName, err)
 				}
+				auditRenameResult(result)
 				if verbose {
-					fmt.Printf("Renamed vehicle %q -> %q: sessions=%d, settings=%d, configs=%d\n",
+					fmt.Printf(evccdb.Translate("Renamed vehicle %q -> %q: sessions=%d, settings=%d, configs=%d\n"),
 						rename.OldName, rename.NewName, result.Sessions, result.Settings, result.Configs)
 				}
 			}
@@ -327,10 +1653,14 @@ func runRename(cmd *cobra.Command, args []string) error {
 	}
 
 	if dryRun {
-		fmt.Println("Dry run completed (no changes made)")
-	} else {
-		fmt.Println("Rename completed successfully")
+		fmt.Println(evccdb.Translate("Dry run completed (no changes made)"))
+		return nil
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
 	}
+	fmt.Println(evccdb.Translate("Rename completed successfully"))
 	return nil
 }
 
@@ -373,15 +1703,9 @@ func runDelete(cmd *cobra.Command, args []string) error {
 	}
 
 	// Confirm that evcc is stopped
-	if !dryRun && !assumeYes {
-		fmt.Print("WARNING: Make sure evcc is stopped and not accessing the database.\n")
-		fmt.Print("Type 'yes' to confirm and proceed: ")
-		var confirm string
-		_, _ = fmt.Scanln(&confirm)
-		if confirm != "yes" {
-			fmt.Println("Operation cancelled")
-			return nil
-		}
+	if !dryRun && !assumeYes && !confirm() {
+		fmt.Println(evccdb.Translate("Operation cancelled"))
+		return nil
 	}
 
 	client, err := evccdb.Open(deleteDB)
@@ -389,24 +1713,57 @@ func runDelete(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to open database: %w", err)
 	}
 	defer func() { _ = client.Close() }()
-	ctx := context.Background()
+	ctx := cmd.Context()
+
+	// All deletes share one transaction, so a failing delete reverts the whole invocation
+	var tx *evccdb.Tx
+	if !dryRun {
+		unlock, err := lockDatabase(ctx, deleteDB)
+		if err != nil {
+			return err
+		}
+		defer unlock()
+
+		if err := backupBeforeWrite(ctx, client, deleteDB); err != nil {
+			return err
+		}
+
+		tx, err = client.Begin(ctx)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = tx.Rollback() }()
+	}
 
 	// Parse and delete loadpoint sessions
 	if deleteLoadpoints != "" {
 		names := parseNames(deleteLoadpoints)
-		for _, name := range names {
+		for i, name := range names {
 			if dryRun {
 				count, err := client.CountLoadpointSessions(ctx, name)
 				if err != nil {
 					return fmt.Errorf("failed to count sessions for loadpoint %q: %w", name, err)
 				}
-				fmt.Printf("Would delete %d sessions for loadpoint %q\n", count, name)
+				fmt.Printf(evccdb.Translate("Would delete %d sessions for loadpoint %q\n"), count, name)
 			} else {
-				count, err := client.DeleteLoadpointSessions(ctx, name)
+				var count int
+				err := tx.Step(ctx, fmt.Sprintf("delete_loadpoint_%d", i+1), func() (err error) {
+					if useTrash {
+						count, err = tx.TrashLoadpointSessions(ctx, name)
+					} else {
+						count, err = tx.DeleteLoadpointSessions(ctx, name)
+					}
+					return err
+				})
 				if err != nil {
 					return fmt.Errorf("failed to delete sessions for loadpoint %q: %w", name, err)
 				}
-				fmt.Printf("Deleted %d sessions for loadpoint %q\n", count, name)
+				auditRows("sessions", count)
+				if useTrash {
+					fmt.Printf(evccdb.Translate("Moved %d sessions for loadpoint %q to trash\n"), count, name)
+				} else {
+					fmt.Printf(evccdb.Translate("Deleted %d sessions for loadpoint %q\n"), count, name)
+				}
 			}
 		}
 	}
@@ -414,32 +1771,357 @@ func runDelete(cmd *cobra.Command, args []string) error {
 	// Parse and delete vehicle sessions
 	if deleteVehicles != "" {
 		names := parseNames(deleteVehicles)
-		for _, name := range names {
+		for i, name := range names {
 			if dryRun {
 				count, err := client.CountVehicleSessions(ctx, name)
 				if err != nil {
 					return fmt.Errorf("failed to count sessions for vehicle %q: %w", name, err)
 				}
-				fmt.Printf("Would delete %d sessions for vehicle %q\n", count, name)
+				fmt.Printf(evccdb.Translate("Would delete %d sessions for vehicle %q\n"), count, name)
 			} else {
-				count, err := client.DeleteVehicleSessions(ctx, name)
+				var count int
+				err := tx.Step(ctx, fmt.Sprintf("delete_vehicle_%d", i+1), func() (err error) {
+					if useTrash {
+						count, err = tx.TrashVehicleSessions(ctx, name)
+					} else {
+						count, err = tx.DeleteVehicleSessions(ctx, name)
+					}
+					return err
+				})
 				if err != nil {
 					return fmt.Errorf("failed to delete sessions for vehicle %q: %w", name, err)
 				}
-				fmt.Printf("Deleted %d sessions for vehicle %q\n", count, name)
+				auditRows("sessions", count)
+				if useTrash {
+					fmt.Printf(evccdb.Translate("Moved %d sessions for vehicle %q to trash\n"), count, name)
+				} else {
+					fmt.Printf(evccdb.Translate("Deleted %d sessions for vehicle %q\n"), count, name)
+				}
 			}
 		}
 	}
 
 	if dryRun {
-		fmt.Println("Dry run completed (no changes made)")
-	} else {
-		fmt.Println("Delete completed successfully")
+		fmt.Println(evccdb.Translate("Dry run completed (no changes made)"))
+		return nil
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	fmt.Println(evccdb.Translate("Delete completed successfully"))
+	return optimizeAfterWrite(ctx, client)
+}
+
+func runTrashRestore(cmd *cobra.Command, args []string) error {
+	client, err := evccdb.Open(trashDB)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer func() { _ = client.Close() }()
+	ctx := cmd.Context()
+
+	unlock, err := lockDatabase(ctx, trashDB)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if err := backupBeforeWrite(ctx, client, trashDB); err != nil {
+		return err
+	}
+
+	count, mappings, err := client.RestoreTrashIDs(ctx)
+	if err != nil {
+		return fmt.Errorf("restore failed: %w", err)
+	}
+
+	auditRows("sessions", count)
+	fmt.Printf(evccdb.Translate("Restored %d sessions from trash\n"), count)
+	for _, m := range mappings {
+		fmt.Printf(evccdb.Translate("  Session %d restored as %d, its id is used by a newer session\n"), m.OldID, m.NewID)
+	}
+	return nil
+}
+
+func runTrashPurge(cmd *cobra.Command, args []string) error {
+	if !dryRun && !assumeYes && !confirm() {
+		fmt.Println(evccdb.Translate("Operation cancelled"))
+		return nil
+	}
+
+	client, err := evccdb.Open(trashDB)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer func() { _ = client.Close() }()
+	ctx := cmd.Context()
+
+	if dryRun {
+		count, err := client.CountTrash(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to count trashed sessions: %w", err)
+		}
+		fmt.Printf(evccdb.Translate("Would purge %d sessions from trash\n"), count)
+		return nil
+	}
+
+	unlock, err := lockDatabase(ctx, trashDB)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if err := backupBeforeWrite(ctx, client, trashDB); err != nil {
+		return err
+	}
+
+	count, err := client.PurgeTrash(ctx)
+	if err != nil {
+		return fmt.Errorf("purge failed: %w", err)
+	}
+
+	auditRows(evccdb.TrashTable, count)
+	fmt.Printf(evccdb.Translate("Purged %d sessions from trash\n"), count)
+	return optimizeAfterWrite(ctx, client)
+}
+
+// lockDatabase takes the lock file of a database the command writes to
+func lockDatabase(ctx context.Context, path string) (func(), error) {
+	lock, err := evccdb.LockDatabase(ctx, path, evccdb.LockOptions{Wait: waitLock, Force: forceLock})
+	if errors.Is(err, evccdb.ErrLocked) {
+		return nil, fmt.Errorf("%w, use --wait to wait for it or --force to remove a stale lock", err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	auditDatabase(path)
+	return func() { _ = lock.Release() }, nil
+}
+
+// printRowProgress prints the rows processed so far with the estimated remaining time
+func printRowProgress(p evccdb.Progress) {
+	fmt.Fprintf(os.Stderr, "%s: %d/%d rows, %.0f rows/s, %s remaining\n",
+		p.Table, p.Rows, p.Total, p.Rate(), p.Remaining().Round(time.Second))
+}
+
+// backupBeforeWrite snapshots the database into the --auto-backup directory if requested
+func backupBeforeWrite(ctx context.Context, client *evccdb.Client, path string) error {
+	if autoBackup == "" {
+		return nil
+	}
+
+	name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	backupPath := filepath.Join(autoBackup, fmt.Sprintf("%s-%s.db", name, time.Now().Format("20060102-150405")))
+	if err := client.Backup(ctx, backupPath); err != nil {
+		return fmt.Errorf("auto-backup failed: %w", err)
+	}
+
+	fmt.Printf(evccdb.Translate("Backup written to %s\n"), backupPath)
+	return nil
+}
+
+// optimizeAfterWrite optimizes the database written by a command if --optimize is given
+func optimizeAfterWrite(ctx context.Context, client *evccdb.Client) error {
+	if !optimizeDB {
+		return nil
+	}
+
+	result, err := client.Optimize(ctx, evccdb.OptimizeOptions{})
+	if err != nil {
+		return err
+	}
+	fmt.Printf(evccdb.Translate("Optimized database with %s: %s -> %s, %s reclaimed\n"),
+		result.Method, evccdb.FormatSize(result.SizeBefore), evccdb.FormatSize(result.SizeAfter), evccdb.FormatSize(max(result.Reclaimed(), 0)))
+	return nil
+}
+
+// writeFailureReport writes the report of a failed import or transfer next to the target
+// database and references it in the returned error
+func writeFailureReport(report *evccdb.FailureReport, err error) error {
+	report.SetError(err)
+	path := report.Target + evccdb.FailureReportSuffix
+	if werr := report.WriteFile(path); werr != nil {
+		fmt.Printf(evccdb.Translate("WARNING: %v\n"), werr)
+		return err
+	}
+	return fmt.Errorf("%w (details in %s)", err, path)
+}
+
+// applyErrorPolicy configures whether an import skips the rows rejected by the database,
+// which are recorded in report
+func applyErrorPolicy(opts *evccdb.TransferOptions, report *evccdb.FailureReport) error {
+	switch onError {
+	case "fail":
+		return nil
+	case "skip", "collect":
+		opts.SkipRowErrors = true
+		opts.OnRowError = report.AddSkipped
+		return nil
+	default:
+		return fmt.Errorf("invalid --on-error %q, expected fail, skip or collect", onError)
+	}
+}
+
+// reportSkippedRows warns about the rows skipped by an import and, for --on-error collect,
+// writes them to the failure report next to the target database
+func reportSkippedRows(report *evccdb.FailureReport) error {
+	if len(report.Skipped) == 0 {
+		return nil
+	}
+
+	tables := make([]string, 0, len(report.Skipped))
+	for table := range report.Skipped {
+		tables = append(tables, table)
+	}
+	sort.Strings(tables)
+	for _, table := range tables {
+		fmt.Printf(evccdb.Translate("WARNING: Skipped %d rows of %s rejected by the database\n"), report.Skipped[table], table)
+	}
+
+	if onError == "collect" {
+		path := report.Target + evccdb.FailureReportSuffix
+		if err := report.WriteFile(path); err != nil {
+			return err
+		}
+		fmt.Printf(evccdb.Translate("Skipped rows written to %s\n"), path)
 	}
 	return nil
 }
 
+// applySettingsMerge configures how conflicting settings are merged and reports every conflict.
+// Plain replace keeps the fast copy paths and reports nothing.
+func applySettingsMerge(opts *evccdb.TransferOptions) error {
+	if settingsMerge == "replace" {
+		return nil
+	}
+
+	interactive := settingsMerge == "interactive"
+	mode := settingsMerge
+	if interactive {
+		mode = "prefer-target"
+	}
+
+	var err error
+	if opts.SettingsMerge, err = evccdb.ParseSettingsMergeMode(mode); err != nil {
+		return fmt.Errorf("invalid --settings-merge: %w", err)
+	}
+
+	opts.OnSettingConflict = func(c *evccdb.SettingConflict) {
+		if interactive {
+			fmt.Printf(evccdb.Translate("Setting %s: source %q, target %q. Use source value? [y/N]: "), c.Key, c.SourceValue, c.TargetValue)
+			var answer string
+			_, _ = fmt.Scanln(&answer)
+			c.KeepSource = strings.EqualFold(answer, "y") || strings.EqualFold(answer, "yes")
+			return
+		}
+
+		if c.KeepSource {
+			fmt.Printf(evccdb.Translate("Setting conflict %s: source %q, target %q, keeping source value\n"), c.Key, c.SourceValue, c.TargetValue)
+		} else {
+			fmt.Printf(evccdb.Translate("Setting conflict %s: source %q, target %q, keeping target value\n"), c.Key, c.SourceValue, c.TargetValue)
+		}
+	}
+	return nil
+}
+
+// applyTemplateAliases reports every template renamed by the aliases of the config file
+func applyTemplateAliases(opts *evccdb.TransferOptions) error {
+	opts.KeepTemplates = keepTemplates
+	if keepTemplates {
+		return nil
+	}
+
+	opts.OnTemplateAlias = func(id int, from, to string) {
+		fmt.Printf(evccdb.Translate("Renamed template %q to %q in config db:%d\n"), from, to, id)
+	}
+	return nil
+}
+
+// applyClasses parses --class into the config classes to export or transfer
+func applyClasses(opts *evccdb.TransferOptions) error {
+	classes, err := evccdb.ParseClasses(configClasses)
+	if err != nil {
+		return fmt.Errorf("invalid --class: %w", err)
+	}
+	opts.Classes = classes
+	return nil
+}
+
+// applySQLHooks reads the SQL statements of --pre-sql-file and --post-sql-file
+func applySQLHooks(opts *evccdb.TransferOptions) error {
+	for _, hook := range []struct {
+		file string
+		sql  *string
+	}{{preSQLFile, &opts.PreSQL}, {postSQLFile, &opts.PostSQL}} {
+		if hook.file == "" {
+			continue
+		}
+		data, err := os.ReadFile(hook.file)
+		if err != nil {
+			return fmt.Errorf("failed to read SQL file: %w", err)
+		}
+		*hook.sql = string(data)
+	}
+	return nil
+}
+
+// confirm asks the user to confirm a write operation
+func confirm() bool {
+	fmt.Print(evccdb.Translate("WARNING: Make sure evcc is stopped and not accessing the database.\n"))
+	fmt.Print(evccdb.Translate("Type 'yes' to confirm and proceed: "))
+	var answer string
+	_, _ = fmt.Scanln(&answer)
+	return answer == "yes"
+}
+
+// messageLanguage returns the language of messages from --lang or the environment
+func messageLanguage() string {
+	if lang != "" {
+		return lang
+	}
+	for _, env := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		if v := os.Getenv(env); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// csvProfileNames lists the registered CSV import profiles
+func csvProfileNames() string {
+	var names []string
+	for _, p := range evccdb.CSVProfiles() {
+		names = append(names, p.Name)
+	}
+	return strings.Join(names, ", ")
+}
+
+// parseColumnMap parses a comma-separated list of Header=column pairs
+func parseColumnMap(s string) (map[string]string, error) {
+	columns := make(map[string]string)
+	for _, pair := range parseNames(s) {
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid column mapping %q, expected Header=column", pair)
+		}
+		columns[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+	}
+	return columns, nil
+}
+
 // parseNames parses comma-separated names
+// truncateOption returns the tables of --truncate, all written tables for "all"
+func truncateOption() []string {
+	names := parseNames(truncateTables)
+	for i, name := range names {
+		if name == "all" {
+			names[i] = evccdb.TruncateAll
+		}
+	}
+	return names
+}
+
 func parseNames(s string) []string {
 	var names []string
 	for _, name := range strings.Split(s, ",") {
@@ -452,14 +2134,9 @@ func parseNames(s string) []string {
 }
 
 func parseMode(modeStr string) evccdb.TransferMode {
-	switch modeStr {
-	case "config":
-		return evccdb.TransferConfig
-	case "metrics":
-		return evccdb.TransferMetrics
-	case "all":
-		return evccdb.TransferAll
-	default:
+	mode, err := evccdb.ParseTransferMode(modeStr)
+	if err != nil {
 		return evccdb.TransferConfig
 	}
+	return mode
 }
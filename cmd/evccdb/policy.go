@@ -0,0 +1,140 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/iseeberg79/evccdb"
+	"github.com/spf13/cobra"
+)
+
+func runPolicySet(cmd *cobra.Command, args []string) error {
+	client, err := evccdb.Open(policyDB)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer func() { _ = client.Close() }()
+	ctx := cmd.Context()
+
+	p, err := client.RetentionPolicy(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Only the given flags change the stored policy
+	for flag, r := range map[string]*evccdb.Retention{
+		"sessions":      &p.Sessions,
+		"meters":        &p.Meters,
+		"grid-sessions": &p.GridSessions,
+	} {
+		if !cmd.Flags().Changed(flag) {
+			continue
+		}
+		value, _ := cmd.Flags().GetString(flag)
+		if *r, err = evccdb.ParseRetention(value); err != nil {
+			return fmt.Errorf("invalid --%s: %w", flag, err)
+		}
+	}
+
+	unlock, err := lockDatabase(ctx, policyDB)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if err := client.SetRetentionPolicy(ctx, p); err != nil {
+		return err
+	}
+	printPolicy(p)
+	return nil
+}
+
+func runPolicyShow(cmd *cobra.Command, args []string) error {
+	client, err := evccdb.Open(policyDB)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer func() { _ = client.Close() }()
+
+	p, err := client.RetentionPolicy(cmd.Context())
+	if err != nil {
+		return err
+	}
+	printPolicy(p)
+	return nil
+}
+
+func runPolicyApply(cmd *cobra.Command, args []string) error {
+	client, err := evccdb.Open(policyDB)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer func() { _ = client.Close() }()
+	ctx := cmd.Context()
+
+	p, err := client.RetentionPolicy(ctx)
+	if err != nil {
+		return err
+	}
+	if p == (evccdb.RetentionPolicy{}) {
+		fmt.Println("No retention policy set, nothing to do")
+		return nil
+	}
+
+	if !dryRun && !assumeYes && !confirm() {
+		fmt.Println(evccdb.Translate("Operation cancelled"))
+		return nil
+	}
+
+	if !dryRun {
+		unlock, err := lockDatabase(ctx, policyDB)
+		if err != nil {
+			return err
+		}
+		defer unlock()
+
+		if err := backupBeforeWrite(ctx, client, policyDB); err != nil {
+			return err
+		}
+	}
+
+	deleted, err := client.ApplyRetention(ctx, p, time.Now(), dryRun)
+	if err != nil {
+		return err
+	}
+
+	for _, table := range []string{"sessions", "meters", "grid_sessions"} {
+		n, ok := deleted[table]
+		switch {
+		case !ok:
+			continue
+		case dryRun:
+			fmt.Printf("Would delete %d expired rows of %s\n", n, table)
+		default:
+			auditRows(table, n)
+			fmt.Printf("Deleted %d expired rows of %s\n", n, table)
+		}
+	}
+	if dryRun {
+		return nil
+	}
+	return optimizeAfterWrite(ctx, client)
+}
+
+// printPolicy prints the retention of each table
+func printPolicy(p evccdb.RetentionPolicy) {
+	for _, r := range []struct {
+		table string
+		r     evccdb.Retention
+	}{
+		{"sessions", p.Sessions},
+		{"meters", p.Meters},
+		{"grid_sessions", p.GridSessions},
+	} {
+		keep := "forever"
+		if r.r != (evccdb.Retention{}) {
+			keep = r.r.String()
+		}
+		fmt.Printf("%-14s keep %s\n", r.table, keep)
+	}
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/iseeberg79/evccdb"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+func runVehicleExportProfile(cmd *cobra.Command, args []string) error {
+	return exportProfile(cmd, (*evccdb.Client).VehicleProfile)
+}
+
+func runLoadpointExportProfile(cmd *cobra.Command, args []string) error {
+	return exportProfile(cmd, (*evccdb.Client).LoadpointProfile)
+}
+
+// exportProfile writes the profile of the named device
+func exportProfile(cmd *cobra.Command, profile func(*evccdb.Client, context.Context, string) (*evccdb.Profile, error)) error {
+	client, err := evccdb.Open(profileDB)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer func() { _ = client.Close() }()
+
+	p, err := profile(client, cmd.Context(), profileName)
+	if err != nil {
+		return err
+	}
+	return writeProfile(p)
+}
+
+// writeProfile writes the profile as YAML to the output file or stdout
+func writeProfile(p *evccdb.Profile) error {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(p); err != nil {
+		return fmt.Errorf("failed to encode profile: %w", err)
+	}
+	data := buf.Bytes()
+
+	if output == "" {
+		_, err := os.Stdout.Write(data)
+		return err
+	}
+	if err := os.WriteFile(output, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write profile: %w", err)
+	}
+	fmt.Printf("Exported %s %q with %d settings to %s\n", p.Class, p.Name, len(p.Settings), output)
+	return nil
+}
+
+// runImportProfile applies a profile file to the database, to another device if its name is given
+func runImportProfile(cmd *cobra.Command, args []string) error {
+	data, err := os.ReadFile(source)
+	if err != nil {
+		return fmt.Errorf("failed to read profile: %w", err)
+	}
+	var p evccdb.Profile
+	if err := yaml.Unmarshal(data, &p); err != nil {
+		return fmt.Errorf("failed to parse profile %s: %w", source, err)
+	}
+	if class := cmd.Parent().Name(); p.Class != class {
+		return fmt.Errorf("%s is a %s profile, not a %s profile", source, p.Class, class)
+	}
+
+	name := profileName
+	if name == "" {
+		name = p.Name
+	}
+
+	if dryRun {
+		fmt.Printf("Would apply %d settings to %s %q\n", len(p.Settings), p.Class, name)
+		if p.Config != nil {
+			fmt.Printf("Would write the %s config of type %s\n", p.Class, p.Config.Type)
+		}
+		return nil
+	}
+
+	client, err := evccdb.Open(profileDB)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer func() { _ = client.Close() }()
+	ctx := cmd.Context()
+
+	if !assumeYes && !confirm() {
+		fmt.Println(evccdb.Translate("Operation cancelled"))
+		return nil
+	}
+
+	unlock, err := lockDatabase(ctx, profileDB)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if err := backupBeforeWrite(ctx, client, profileDB); err != nil {
+		return err
+	}
+
+	result, err := client.ApplyProfile(ctx, &p, name)
+	if err != nil {
+		return err
+	}
+	auditRows("settings", result.Settings)
+
+	fmt.Printf("Applied %d settings to %s %q\n", result.Settings, p.Class, name)
+	switch {
+	case result.Created:
+		auditRows("configs", 1)
+		fmt.Printf("Created config db:%d\n", result.ConfigID)
+	case result.ConfigID != 0:
+		auditRows("configs", 1)
+		fmt.Printf("Updated config db:%d\n", result.ConfigID)
+	}
+	return nil
+}
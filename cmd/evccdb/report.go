@@ -0,0 +1,171 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/iseeberg79/evccdb"
+	"github.com/spf13/cobra"
+)
+
+func runReportGaps(cmd *cobra.Command, args []string) error {
+	client, err := evccdb.Open(reportDB)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer func() { _ = client.Close() }()
+
+	gaps, err := client.FindGaps(cmd.Context(), evccdb.GapOptions{Factor: gapFactor, MinGap: gapMin})
+	if err != nil {
+		return err
+	}
+
+	result := &evccdb.QueryResult{Columns: []string{"table", "series", "from", "to", "duration", "cadence"}}
+	for _, gap := range gaps {
+		result.Rows = append(result.Rows, []any{
+			gap.Table,
+			gap.Series,
+			gap.From.Local().Format("2006-01-02 15:04:05"),
+			gap.To.Local().Format("2006-01-02 15:04:05"),
+			formatDuration(gap.Duration),
+			formatDuration(gap.Cadence),
+		})
+	}
+	return writeQueryResult(os.Stdout, result, reportFormat)
+}
+
+// formatDuration formats a duration in days, hours and minutes, e.g. 3d4h or 15m
+func formatDuration(d time.Duration) string {
+	d = d.Round(time.Minute)
+	days := d / (24 * time.Hour)
+	d -= days * 24 * time.Hour
+	s := ""
+	if days > 0 {
+		s = fmt.Sprintf("%dd", days)
+	}
+	if h := d / time.Hour; h > 0 {
+		s += fmt.Sprintf("%dh", h)
+	}
+	if m := (d % time.Hour) / time.Minute; m > 0 || s == "" {
+		s += fmt.Sprintf("%dm", m)
+	}
+	return s
+}
+
+func runReportGrid(cmd *cobra.Command, args []string) error {
+	client, err := evccdb.Open(reportDB)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer func() { _ = client.Close() }()
+
+	sessions, err := client.GridSessions(cmd.Context())
+	if err != nil {
+		return err
+	}
+
+	result := &evccdb.QueryResult{Columns: []string{"id", "type", "created", "finished", "duration", "grid_power", "limit_power", "shifted_kwh"}}
+	for _, s := range sessions {
+		created, finished, _ := s.Times()
+		var end, duration any
+		if !finished.IsZero() {
+			end = finished.Local().Format("2006-01-02 15:04:05")
+			duration = formatDuration(s.Duration())
+		}
+		result.Rows = append(result.Rows, []any{
+			s.ID,
+			s.Type,
+			created.Local().Format("2006-01-02 15:04:05"),
+			end,
+			duration,
+			floatOrNil(s.GridPower),
+			floatOrNil(s.LimitPower),
+			round(s.ShiftedEnergy(), 2),
+		})
+	}
+	return writeQueryResult(os.Stdout, result, reportFormat)
+}
+
+// floatOrNil returns the value of f, nil for NULL
+func floatOrNil(f *float64) any {
+	if f == nil {
+		return nil
+	}
+	return *f
+}
+
+func runReportSolar(cmd *cobra.Command, args []string) error {
+	start, end, err := parseTimeWindow(solarWindow)
+	if err != nil {
+		return err
+	}
+
+	client, err := evccdb.Open(reportDB)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer func() { _ = client.Close() }()
+
+	report, err := client.SolarReport(cmd.Context(), evccdb.SolarOptions{WindowStart: start, WindowEnd: end, Worst: solarWorst})
+	if err != nil {
+		return err
+	}
+
+	months := &evccdb.QueryResult{Columns: []string{"month", "sessions", "charged_kwh", "solar_kwh", "solar_share", "shiftable_kwh"}}
+	for _, m := range report.Months {
+		months.Rows = append(months.Rows, []any{m.Month, m.Sessions, round(m.ChargedKwh, 2), round(m.SolarKwh, 2), round(m.SolarShare, 1), round(m.ShiftableKwh, 2)})
+	}
+	worst := &evccdb.QueryResult{Columns: []string{"id", "created", "loadpoint", "vehicle", "charged_kwh", "solar_kwh", "shiftable_kwh"}}
+	for _, s := range report.Worst {
+		worst.Rows = append(worst.Rows, []any{s.ID, s.Created.Local().Format("2006-01-02 15:04:05"), s.Loadpoint, s.Vehicle, round(s.ChargedKwh, 2), round(s.SolarKwh, 2), round(s.ShiftableKwh, 2)})
+	}
+
+	return writeSections(os.Stdout, []evccdb.Section{
+		{Name: "months", Title: evccdb.Translate("Solar share by month:"), Result: months},
+		{Name: "worst_sessions", Title: evccdb.Translate("Sessions with the most grid energy outside the PV window:"), Result: worst},
+	}, reportFormat)
+}
+
+// parseTimeWindow parses a time of day range like 10:00-16:00
+func parseTimeWindow(s string) (start, end time.Duration, err error) {
+	from, to, ok := strings.Cut(s, "-")
+	if !ok {
+		return 0, 0, fmt.Errorf("invalid time window %q, expected e.g. 10:00-16:00", s)
+	}
+	if start, err = parseTimeOfDay(from); err == nil {
+		end, err = parseTimeOfDay(to)
+	}
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid time window %q: %w", s, err)
+	}
+	return start, end, nil
+}
+
+// parseTimeOfDay parses 15:04 into the duration since midnight, 24:00 is the end of the day
+func parseTimeOfDay(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	if s == "24:00" {
+		return 24 * time.Hour, nil
+	}
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q", s)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+func runReportEfficiency(cmd *cobra.Command, args []string) error {
+	client, err := evccdb.Open(reportDB)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer func() { _ = client.Close() }()
+
+	efficiency, err := client.VehicleEfficiency(cmd.Context(), evccdb.EfficiencyOptions{OutlierFactor: outlierFactor})
+	if err != nil {
+		return err
+	}
+	return writeQueryResult(os.Stdout, efficiencyResult(efficiency), reportFormat)
+}
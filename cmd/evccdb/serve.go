@@ -0,0 +1,282 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/iseeberg79/evccdb"
+	"github.com/spf13/cobra"
+)
+
+// server serves the HTTP endpoints of evccdb serve
+type server struct {
+	client     *evccdb.Client
+	healthOpts evccdb.HealthOptions
+	ui         bool
+	readonly   bool
+	uploadDir  string
+	// maxUploadSize limits the bytes of all open uploads together and of a single import body
+	maxUploadSize int64
+
+	uploadsMu sync.Mutex
+	// uploads maps the id of an open upload to the time of its last chunk
+	uploads map[string]time.Time
+
+	mu        sync.RWMutex
+	health    *evccdb.HealthStatus
+	healthErr error
+
+	// progress is the row progress of the running import, nil if none is running
+	progress atomic.Pointer[evccdb.Progress]
+}
+
+func runServe(cmd *cobra.Command, args []string) error {
+	if _, err := loadConfig(); err != nil {
+		return err
+	}
+
+	client, err := evccdb.Open(serveDB)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer func() { _ = client.Close() }()
+
+	s := &server{
+		client: client,
+		healthOpts: evccdb.HealthOptions{
+			MaxSessionAge: maxSessionAge,
+			MaxMeterAge:   maxMeterAge,
+			MaxSize:       maxSizeMB * 1024 * 1024,
+		},
+		ui:       serveUI,
+		readonly: serveReadonly,
+	}
+
+	ctx := cmd.Context()
+	if s.ui {
+		if s.maxUploadSize, err = parseSize(maxUploadSize); err != nil {
+			return fmt.Errorf("invalid --max-upload-size: %w", err)
+		}
+		s.uploadDir, err = os.MkdirTemp("", "evccdb-uploads-")
+		if err != nil {
+			return fmt.Errorf("failed to create upload directory: %w", err)
+		}
+		defer func() { _ = os.RemoveAll(s.uploadDir) }()
+		s.uploads = make(map[string]time.Time)
+		go s.expireUploads(ctx)
+	}
+
+	s.checkHealth(ctx)
+	go s.monitor(ctx, healthInterval)
+
+	mux, err := s.routes()
+	if err != nil {
+		return err
+	}
+
+	// Slow clients may not hold connections open, exports and uploads get no read or write timeout
+	srv := &http.Server{
+		Addr:              listenAddr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+		IdleTimeout:       2 * time.Minute,
+	}
+
+	// Ctrl-C stops the server after the running requests finished
+	go func() {
+		<-ctx.Done()
+		_ = srv.Shutdown(context.Background())
+	}()
+
+	fmt.Printf("Serving %s on %s\n", serveDB, listenAddr)
+	if err := srv.ListenAndServe(); err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
+
+// routes registers the HTTP handlers
+func (s *server) routes() (*http.ServeMux, error) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/health", s.handleHealth)
+	mux.HandleFunc("/query", s.handleQuery)
+	mux.HandleFunc("/info", s.handleInfo)
+	s.grafanaRoutes(mux)
+	s.adminRoutes(mux)
+	if s.ui {
+		if err := s.uiRoutes(mux); err != nil {
+			return nil, fmt.Errorf("failed to load UI: %w", err)
+		}
+	}
+	return mux, nil
+}
+
+// monitor refreshes the health status periodically
+func (s *server) monitor(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.checkHealth(ctx)
+		}
+	}
+}
+
+// checkHealth runs a health check and stores the result
+func (s *server) checkHealth(ctx context.Context) {
+	health, err := s.client.CheckHealth(ctx, s.healthOpts)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.health, s.healthErr = health, err
+}
+
+// handleHealth returns the last health status, 503 if degraded
+func (s *server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	s.mu.RLock()
+	health, err := s.health, s.healthErr
+	s.mu.RUnlock()
+
+	if err != nil {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]string{"status": "error", "error": err.Error()})
+		return
+	}
+
+	status := http.StatusOK
+	if health.Status != evccdb.HealthOK {
+		status = http.StatusServiceUnavailable
+	}
+	writeJSON(w, status, health)
+}
+
+// handleInfo returns the database path and the SQLite environment
+func (s *server) handleInfo(w http.ResponseWriter, r *http.Request) {
+	env, err := s.client.Environment()
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, env)
+}
+
+// handleQuery runs the read-only query given in parameter q or the named query in parameter named
+func (s *server) handleQuery(w http.ResponseWriter, r *http.Request) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "json"
+	}
+	if format != "json" && format != "csv" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "format must be json or csv"})
+		return
+	}
+
+	var result *evccdb.QueryResult
+	var err error
+	if named := r.URL.Query().Get("named"); named != "" {
+		result, err = s.client.QueryNamed(r.Context(), named)
+	} else {
+		result, err = s.client.Query(r.Context(), r.URL.Query().Get("q"), nil)
+	}
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+
+	if format == "csv" {
+		w.Header().Set("Content-Type", "text/csv")
+	} else {
+		w.Header().Set("Content-Type", "application/json")
+	}
+	_ = writeQueryResult(w, result, format)
+}
+
+// writable rejects requests to write endpoints when the server is read-only, and requests
+// a browser sends on behalf of a page of another origin
+func (s *server) writable(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.readonly {
+			writeJSON(w, http.StatusForbidden, map[string]string{"error": "server is read-only"})
+			return
+		}
+		if !sameOrigin(r) {
+			writeJSON(w, http.StatusForbidden, map[string]string{"error": "cross-origin request refused"})
+			return
+		}
+		h(w, r)
+	}
+}
+
+// sameOrigin reports whether a request comes from a page served by this server. Browsers
+// send Origin with every cross-origin write, clients like curl send none.
+func sameOrigin(r *http.Request) bool {
+	if r.Header.Get("Sec-Fetch-Site") == "cross-site" {
+		return false
+	}
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	return err == nil && u.Host == r.Host
+}
+
+// requireJSON answers 415 unless the request body is declared as JSON, which a cross-site
+// form or simple request cannot do
+func requireJSON(w http.ResponseWriter, r *http.Request) bool {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || mediaType != "application/json" {
+		writeJSON(w, http.StatusUnsupportedMediaType, map[string]string{"error": "Content-Type must be application/json"})
+		return false
+	}
+	return true
+}
+
+// beginWrite takes the database lock and writes the auto-backup before a write request,
+// answering 423 while another evccdb process writes the database. The returned func
+// releases the lock and records the request in the audit log.
+func (s *server) beginWrite(w http.ResponseWriter, r *http.Request) (func(), bool) {
+	start := time.Now()
+	lock, err := evccdb.LockDatabase(r.Context(), serveDB, evccdb.LockOptions{})
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, evccdb.ErrLocked) {
+			status = http.StatusLocked
+		}
+		writeJSON(w, status, map[string]string{"error": err.Error()})
+		return nil, false
+	}
+
+	if err := backupBeforeWrite(r.Context(), s.client, serveDB); err != nil {
+		_ = lock.Release()
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return nil, false
+	}
+	return func() {
+		entry := newAuditEntry("serve", []string{r.Method, r.URL.RequestURI()}, start)
+		entry.Database = serveDB
+		if err := evccdb.AppendAudit(serveDB, entry); err != nil {
+			fmt.Fprintf(os.Stderr, evccdb.Translate("WARNING: %v\n"), err)
+		}
+		_ = lock.Release()
+	}, true
+}
+
+// writeJSON writes v as JSON response
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
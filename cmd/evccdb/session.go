@@ -0,0 +1,28 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/iseeberg79/evccdb"
+	"github.com/spf13/cobra"
+)
+
+func runSessionCurve(cmd *cobra.Command, args []string) error {
+	client, err := evccdb.Open(sessionDB)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer func() { _ = client.Close() }()
+
+	curve, err := client.SessionPowerCurve(cmd.Context(), sessionID)
+	if err != nil {
+		return err
+	}
+
+	result := &evccdb.QueryResult{Columns: []string{"time", "power_kw", "energy_kwh"}}
+	for _, p := range curve {
+		result.Rows = append(result.Rows, []any{p.Time.Local().Format("2006-01-02 15:04:05"), round(p.Power, 3), round(p.Energy, 3)})
+	}
+	return writeQueryResult(os.Stdout, result, sessionFormat)
+}
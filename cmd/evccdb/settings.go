@@ -0,0 +1,44 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/iseeberg79/evccdb"
+	"github.com/spf13/cobra"
+)
+
+func runSettingsDoc(cmd *cobra.Command, args []string) error {
+	client, err := evccdb.Open(settingsDB)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer func() { _ = client.Close() }()
+
+	doc, err := client.SettingsDoc(cmd.Context())
+	if err != nil {
+		return err
+	}
+
+	result := &evccdb.QueryResult{Columns: []string{"namespace", "keys", "description"}}
+	if verbose {
+		result.Columns = append(result.Columns, "names")
+	}
+	for _, g := range doc.Groups {
+		row := []any{g.Namespace, len(g.Keys), g.Description}
+		if verbose {
+			row = append(row, strings.Join(g.Keys, ", "))
+		}
+		result.Rows = append(result.Rows, row)
+	}
+
+	if err := writeQueryResult(os.Stdout, result, settingsFormat); err != nil {
+		return err
+	}
+
+	for _, key := range doc.Unknown {
+		fmt.Fprintf(os.Stderr, evccdb.Translate("WARNING: Unknown settings key %q\n"), key)
+	}
+	return nil
+}
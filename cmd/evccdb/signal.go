@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sync/atomic"
+	"syscall"
+
+	"github.com/iseeberg79/evccdb"
+	"github.com/spf13/cobra"
+)
+
+// writtenRows counts rows committed by commands writing in several transactions,
+// all other commands roll back completely when cancelled
+var writtenRows atomic.Int64
+
+// interruptContext returns a context cancelled by Ctrl-C or SIGTERM. A second
+// signal terminates the process, e.g. while waiting at a confirmation prompt.
+func interruptContext() (context.Context, context.CancelFunc) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+	return ctx, stop
+}
+
+// printCancelled reports what a cancelled operation left behind
+func printCancelled() {
+	if n := writtenRows.Load(); n > 0 {
+		fmt.Fprintf(os.Stderr, evccdb.Translate("Operation cancelled, %d rows written\n"), n)
+		return
+	}
+	fmt.Fprintln(os.Stderr, evccdb.Translate("Operation cancelled, no changes made"))
+}
+
+// cancelWriter fails writes once the context is cancelled
+type cancelWriter struct {
+	ctx context.Context
+	w   io.Writer
+}
+
+func (cw cancelWriter) Write(p []byte) (int, error) {
+	if err := cw.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return cw.w.Write(p)
+}
+
+// silenceOnCancel keeps cobra from printing the error and usage of interrupted
+// commands, main prints the cancellation summary instead
+func silenceOnCancel(cmd *cobra.Command) {
+	if run := cmd.RunE; run != nil {
+		cmd.RunE = func(c *cobra.Command, args []string) error {
+			err := run(c, args)
+			if err != nil && c.Context().Err() != nil {
+				c.SilenceErrors, c.SilenceUsage = true, true
+			}
+			return err
+		}
+	}
+	for _, sub := range cmd.Commands() {
+		silenceOnCancel(sub)
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/iseeberg79/evccdb"
+	"github.com/spf13/cobra"
+)
+
+func runStats(cmd *cobra.Command, args []string) error {
+	client, err := evccdb.Open(statsDB)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer func() { _ = client.Close() }()
+
+	ctx := cmd.Context()
+	groups, err := client.SessionStatsBy(ctx, statsGroupBy, evccdb.AggregateOptions{Holidays: statsHolidays})
+	if err != nil {
+		return err
+	}
+	sessions := &evccdb.QueryResult{Columns: []string{statsGroupBy, "sessions", "charged_kwh", "solar_kwh", "price"}}
+	for _, s := range groups {
+		name := s.Group
+		if name == "" {
+			name = "(unknown)"
+		}
+		sessions.Rows = append(sessions.Rows, []any{name, s.Sessions, round(s.ChargedKwh, 2), round(s.SolarKwh, 2), round(s.Price, 2)})
+	}
+	sections := []evccdb.Section{{Name: "sessions", Title: fmt.Sprintf(evccdb.Translate("Sessions by %s:"), statsGroupBy), Result: sessions}}
+
+	grid, err := client.GridStats(ctx)
+	if err != nil {
+		return err
+	}
+	if len(grid) > 0 {
+		result := &evccdb.QueryResult{Columns: []string{"type", "events", "duration", "longest", "shifted_kwh"}}
+		for _, s := range grid {
+			result.Rows = append(result.Rows, []any{s.Type, s.Events, formatDuration(s.Duration), formatDuration(s.Longest), round(s.ShiftedKwh, 2)})
+		}
+		sections = append(sections, evccdb.Section{Name: "grid_sessions", Title: evccdb.Translate("Grid sessions by type:"), Result: result})
+	}
+
+	efficiency, err := client.VehicleEfficiency(ctx, evccdb.EfficiencyOptions{})
+	if err != nil {
+		return err
+	}
+	if len(efficiency) > 0 {
+		sections = append(sections, evccdb.Section{Name: "efficiency", Title: evccdb.Translate("Consumption by vehicle and month:"), Result: efficiencyResult(efficiency)})
+	}
+
+	return writeSections(os.Stdout, sections, statsFormat)
+}
+
+// efficiencyResult lists the consumption of vehicles per month
+func efficiencyResult(efficiency []evccdb.Efficiency) *evccdb.QueryResult {
+	result := &evccdb.QueryResult{Columns: []string{"vehicle", "month", "distance_km", "charged_kwh", "kwh_per_100km", "outliers"}}
+	for _, e := range efficiency {
+		result.Rows = append(result.Rows, []any{e.Vehicle, e.Month, round(e.DistanceKm, 1), round(e.ChargedKwh, 2), round(e.KwhPer100km, 1), e.Outliers})
+	}
+	return result
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/iseeberg79/evccdb"
+	"github.com/spf13/cobra"
+)
+
+func runSimulateTariff(cmd *cobra.Command, args []string) error {
+	f, err := os.Open(tariffCSV)
+	if err != nil {
+		return fmt.Errorf("failed to open tariff: %w", err)
+	}
+	tariff, err := evccdb.ReadTariffCSV(f, nil)
+	_ = f.Close()
+	if err != nil {
+		return fmt.Errorf("failed to read tariff %s: %w", tariffCSV, err)
+	}
+
+	client, err := evccdb.Open(tariffDB)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer func() { _ = client.Close() }()
+
+	sessions, err := client.SimulateTariff(cmd.Context(), tariff, evccdb.TariffOptions{
+		Meters:     tariffMeters,
+		SolarPrice: tariffSolarPrice,
+	})
+	if err != nil {
+		return err
+	}
+
+	if tariffSessions {
+		result := &evccdb.QueryResult{Columns: []string{"id", "created", "loadpoint", "vehicle", "charged_kwh", "grid_kwh", "price", "simulated", "difference", "uncovered_kwh"}}
+		for _, s := range sessions {
+			result.Rows = append(result.Rows, []any{
+				s.ID,
+				s.Created.Local().Format("2006-01-02 15:04:05"),
+				s.Loadpoint,
+				s.Vehicle,
+				round(s.ChargedKwh, 2),
+				round(s.ChargedKwh-s.SolarKwh, 2),
+				round(s.Price, 2),
+				round(s.Simulated, 2),
+				round(s.Simulated-s.Price, 2),
+				round(s.UncoveredKwh, 2),
+			})
+		}
+		return writeQueryResult(os.Stdout, result, tariffFormat)
+	}
+
+	type month struct {
+		name                                       string
+		sessions                                   int
+		charged, grid, price, simulated, uncovered float64
+	}
+	var months []*month
+	total := &month{name: "total"}
+	for _, s := range sessions {
+		name := s.Created.Local().Format("2006-01")
+		if len(months) == 0 || months[len(months)-1].name != name {
+			months = append(months, &month{name: name})
+		}
+		for _, m := range []*month{months[len(months)-1], total} {
+			m.sessions++
+			m.charged += s.ChargedKwh
+			m.grid += s.ChargedKwh - s.SolarKwh
+			m.price += s.Price
+			m.simulated += s.Simulated
+			m.uncovered += s.UncoveredKwh
+		}
+	}
+
+	result := &evccdb.QueryResult{Columns: []string{"month", "sessions", "charged_kwh", "grid_kwh", "price", "simulated", "difference", "uncovered_kwh"}}
+	for _, m := range append(months, total) {
+		result.Rows = append(result.Rows, []any{m.name, m.sessions, round(m.charged, 2), round(m.grid, 2), round(m.price, 2), round(m.simulated, 2), round(m.simulated-m.price, 2), round(m.uncovered, 2)})
+	}
+	return writeQueryResult(os.Stdout, result, tariffFormat)
+}
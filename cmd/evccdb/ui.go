@@ -0,0 +1,154 @@
+package main
+
+import (
+	"embed"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"time"
+
+	"github.com/iseeberg79/evccdb"
+)
+
+//go:embed ui
+var uiAssets embed.FS
+
+// tableInfo describes a table in the UI table list
+type tableInfo struct {
+	Name string `json:"name"`
+	Rows int    `json:"rows"`
+}
+
+// uiRoutes registers the embedded web UI and the API endpoints it uses
+func (s *server) uiRoutes(mux *http.ServeMux) error {
+	assets, err := fs.Sub(uiAssets, "ui")
+	if err != nil {
+		return err
+	}
+
+	mux.Handle("/", http.FileServer(http.FS(assets)))
+	mux.HandleFunc("/api/tables", s.handleTables)
+	mux.HandleFunc("/api/export", s.handleExport)
+	mux.HandleFunc("/api/import", s.writable(s.handleImport))
+	mux.HandleFunc("/api/uploads", s.writable(s.handleUploads))
+	mux.HandleFunc("/api/uploads/", s.writable(s.handleUpload))
+	mux.HandleFunc("/api/progress", s.handleProgress)
+	return nil
+}
+
+// handleTables lists the tables with their row counts
+func (s *server) handleTables(w http.ResponseWriter, r *http.Request) {
+	tables, err := s.client.GetTables()
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+
+	infos := make([]tableInfo, 0, len(tables))
+	for _, table := range tables {
+		rows, err := s.client.GetRowCount(table)
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		infos = append(infos, tableInfo{Name: table, Rows: rows})
+	}
+	writeJSON(w, http.StatusOK, infos)
+}
+
+// handleExport downloads a JSON export of the tables selected by parameter mode
+func (s *server) handleExport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "GET required"})
+		return
+	}
+
+	name := r.URL.Query().Get("mode")
+	if name == "" {
+		name = "all"
+	}
+	mode, err := evccdb.ParseTransferMode(name)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"evcc-%s-%s.json\"", mode, time.Now().Format("20060102-150405")))
+	if r.Method == http.MethodHead {
+		return
+	}
+	if err := s.client.ExportJSON(w, evccdb.TransferOptions{Mode: mode}); err != nil {
+		fmt.Printf(evccdb.Translate("WARNING: export failed: %v\n"), err)
+	}
+}
+
+// handleImport imports the uploaded JSON export into the tables selected by parameter mode
+func (s *server) handleImport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "POST required"})
+		return
+	}
+	if !requireJSON(w, r) {
+		return
+	}
+
+	unlock, ok := s.beginWrite(w, r)
+	if !ok {
+		return
+	}
+	defer unlock()
+
+	imported, err := s.importJSON(http.MaxBytesReader(w, r.Body, s.maxUploadSize), r.URL.Query().Get("mode"))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, imported)
+}
+
+// importJSON imports a JSON export into the tables selected by mode, all if empty,
+// and returns the imported row counts per table
+func (s *server) importJSON(r io.Reader, mode string) (map[string]int, error) {
+	if mode == "" {
+		mode = "all"
+	}
+
+	imported := make(map[string]int)
+	opts := evccdb.TransferOptions{
+		Mode: parseMode(mode),
+		OnProgress: func(table string, count int) {
+			imported[table] = count
+		},
+		OnRowProgress: func(p evccdb.Progress) {
+			s.progress.Store(&p)
+		},
+	}
+	defer s.progress.Store(nil)
+	return imported, s.client.ImportJSON(r, opts)
+}
+
+// progressInfo is the progress of the running import with its estimated remaining time
+type progressInfo struct {
+	Running          bool   `json:"running"`
+	Table            string `json:"table,omitempty"`
+	Rows             int    `json:"rows,omitempty"`
+	Total            int    `json:"total,omitempty"`
+	RemainingSeconds int    `json:"remaining_seconds,omitempty"`
+}
+
+// handleProgress reports the row progress of the running import
+func (s *server) handleProgress(w http.ResponseWriter, r *http.Request) {
+	info := progressInfo{}
+	if p := s.progress.Load(); p != nil {
+		info = progressInfo{
+			Running:          true,
+			Table:            p.Table,
+			Rows:             p.Rows,
+			Total:            p.Total,
+			RemainingSeconds: int(p.Remaining().Seconds()),
+		}
+	}
+	writeJSON(w, http.StatusOK, info)
+}
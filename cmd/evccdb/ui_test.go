@@ -0,0 +1,46 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/iseeberg79/evccdb"
+)
+
+func TestHandleExport(t *testing.T) {
+	client, err := evccdb.OpenMemory(0)
+	if err != nil {
+		t.Fatalf("OpenMemory failed: %v", err)
+	}
+	defer func() { _ = client.Close() }()
+	s := &server{client: client}
+
+	for _, tt := range []struct {
+		method, target string
+		status         int
+		filename       string
+	}{
+		{http.MethodGet, "/api/export", http.StatusOK, "evcc-all-"},
+		{http.MethodGet, "/api/export?mode=metrics", http.StatusOK, "evcc-metrics-"},
+		{http.MethodHead, "/api/export?mode=config", http.StatusOK, "evcc-config-"},
+		{http.MethodPost, "/api/export", http.StatusMethodNotAllowed, ""},
+		{http.MethodGet, "/api/export?mode=bogus", http.StatusBadRequest, ""},
+		{http.MethodGet, "/api/export?mode=%22%0d%0aX-Injected:%201", http.StatusBadRequest, ""},
+	} {
+		rec := httptest.NewRecorder()
+		s.handleExport(rec, httptest.NewRequest(tt.method, tt.target, nil))
+
+		if rec.Code != tt.status {
+			t.Errorf("%s %s: expected status %d, got %d", tt.method, tt.target, tt.status, rec.Code)
+		}
+		disposition := rec.Header().Get("Content-Disposition")
+		if tt.filename != "" && !strings.Contains(disposition, `filename="`+tt.filename) {
+			t.Errorf("%s %s: expected filename %s..., got %q", tt.method, tt.target, tt.filename, disposition)
+		}
+		if tt.filename == "" && disposition != "" {
+			t.Errorf("%s %s: expected no download, got %q", tt.method, tt.target, disposition)
+		}
+	}
+}
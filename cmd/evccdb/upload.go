@@ -0,0 +1,244 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// maxUploads is the number of uploads that may be open at the same time
+	maxUploads = 4
+	// maxChunkSize limits the body of a single PUT, the web UI sends 8 MiB chunks
+	maxChunkSize = 64 << 20
+	// uploadIdleTimeout is the time after which an upload without new chunks is removed
+	uploadIdleTimeout = 30 * time.Minute
+)
+
+// handleUploads starts a resumable upload and returns its id
+func (s *server) handleUploads(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "POST required"})
+		return
+	}
+
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	id := hex.EncodeToString(buf)
+
+	s.uploadsMu.Lock()
+	defer s.uploadsMu.Unlock()
+
+	s.expireUploadsLocked(time.Now())
+	if len(s.uploads) >= maxUploads {
+		writeJSON(w, http.StatusTooManyRequests, map[string]string{"error": fmt.Sprintf("%d uploads are already open", len(s.uploads))})
+		return
+	}
+
+	file, err := os.Create(s.uploadPath(id))
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": fmt.Sprintf("failed to create upload: %v", err)})
+		return
+	}
+	_ = file.Close()
+	s.uploads[id] = time.Now()
+
+	writeJSON(w, http.StatusCreated, map[string]any{"id": id, "offset": 0})
+}
+
+// handleUpload serves /api/uploads/<id>: HEAD returns the received offset,
+// PUT appends a chunk and POST <id>/import imports the assembled file
+func (s *server) handleUpload(w http.ResponseWriter, r *http.Request) {
+	id, action, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/api/uploads/"), "/")
+	if _, err := hex.DecodeString(id); err != nil || id == "" {
+		http.NotFound(w, r)
+		return
+	}
+
+	if !s.touchUpload(id) {
+		http.NotFound(w, r)
+		return
+	}
+
+	path := s.uploadPath(id)
+	info, err := os.Stat(path)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	switch {
+	case action == "" && (r.Method == http.MethodHead || r.Method == http.MethodGet):
+		w.Header().Set("Upload-Offset", strconv.FormatInt(info.Size(), 10))
+		writeJSON(w, http.StatusOK, map[string]int64{"offset": info.Size()})
+	case action == "" && r.Method == http.MethodPut:
+		s.appendChunk(w, r, path, info.Size())
+	case action == "" && r.Method == http.MethodDelete:
+		s.removeUpload(id)
+		w.WriteHeader(http.StatusNoContent)
+	case action == "import" && r.Method == http.MethodPost:
+		s.importUpload(w, r, id)
+	default:
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+	}
+}
+
+// appendChunk appends the request body to the upload if its Content-Range starts at the received offset
+func (s *server) appendChunk(w http.ResponseWriter, r *http.Request, path string, offset int64) {
+	start, err := parseContentRangeStart(r.Header.Get("Content-Range"))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	if start != offset {
+		w.Header().Set("Upload-Offset", strconv.FormatInt(offset, 10))
+		writeJSON(w, http.StatusConflict, map[string]any{"error": fmt.Sprintf("chunk starts at %d, expected %d", start, offset), "offset": offset})
+		return
+	}
+
+	// The chunk may fill the space left of --max-upload-size by all open uploads
+	limit := min(int64(maxChunkSize), s.maxUploadSize-s.uploadsSize())
+	if r.ContentLength > limit {
+		writeJSON(w, http.StatusRequestEntityTooLarge, map[string]string{"error": fmt.Sprintf("chunk exceeds the %d bytes left for uploads", max(limit, 0))})
+		return
+	}
+
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	defer func() { _ = file.Close() }()
+
+	// Bytes received before a dropped connection are kept, the client resumes from the new offset
+	written, err := io.Copy(file, http.MaxBytesReader(w, r.Body, max(limit, 0)))
+	offset += written
+	w.Header().Set("Upload-Offset", strconv.FormatInt(offset, 10))
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		writeJSON(w, http.StatusRequestEntityTooLarge, map[string]any{"error": fmt.Sprintf("chunk exceeds the %d bytes left for uploads", tooLarge.Limit), "offset": offset})
+		return
+	}
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": fmt.Sprintf("failed to receive chunk: %v", err), "offset": offset})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]int64{"offset": offset})
+}
+
+// importUpload imports an assembled upload into the tables selected by parameter mode and removes it
+func (s *server) importUpload(w http.ResponseWriter, r *http.Request, id string) {
+	file, err := os.Open(s.uploadPath(id))
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	defer func() { _ = file.Close() }()
+
+	unlock, ok := s.beginWrite(w, r)
+	if !ok {
+		return
+	}
+	defer unlock()
+
+	imported, err := s.importJSON(file, r.URL.Query().Get("mode"))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+
+	s.removeUpload(id)
+	writeJSON(w, http.StatusOK, imported)
+}
+
+// touchUpload records activity of an open upload, false if there is no such upload
+func (s *server) touchUpload(id string) bool {
+	s.uploadsMu.Lock()
+	defer s.uploadsMu.Unlock()
+
+	if _, ok := s.uploads[id]; !ok {
+		return false
+	}
+	s.uploads[id] = time.Now()
+	return true
+}
+
+// removeUpload deletes an upload and its file
+func (s *server) removeUpload(id string) {
+	s.uploadsMu.Lock()
+	defer s.uploadsMu.Unlock()
+
+	delete(s.uploads, id)
+	_ = os.Remove(s.uploadPath(id))
+}
+
+// uploadsSize returns the bytes received by all open uploads
+func (s *server) uploadsSize() int64 {
+	s.uploadsMu.Lock()
+	defer s.uploadsMu.Unlock()
+
+	var size int64
+	for id := range s.uploads {
+		if info, err := os.Stat(s.uploadPath(id)); err == nil {
+			size += info.Size()
+		}
+	}
+	return size
+}
+
+// expireUploads removes the uploads idle for uploadIdleTimeout until ctx is done
+func (s *server) expireUploads(ctx context.Context) {
+	ticker := time.NewTicker(uploadIdleTimeout / 10)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			s.uploadsMu.Lock()
+			s.expireUploadsLocked(now)
+			s.uploadsMu.Unlock()
+		}
+	}
+}
+
+// expireUploadsLocked removes the uploads idle for uploadIdleTimeout, s.uploadsMu must be held
+func (s *server) expireUploadsLocked(now time.Time) {
+	for id, active := range s.uploads {
+		if now.Sub(active) > uploadIdleTimeout {
+			delete(s.uploads, id)
+			_ = os.Remove(s.uploadPath(id))
+		}
+	}
+}
+
+// uploadPath returns the file an upload is assembled in
+func (s *server) uploadPath(id string) string {
+	return filepath.Join(s.uploadDir, id+".part")
+}
+
+// parseContentRangeStart returns the first byte position of a "bytes start-end/total" header
+func parseContentRangeStart(header string) (int64, error) {
+	spec, ok := strings.CutPrefix(header, "bytes ")
+	if !ok {
+		return 0, fmt.Errorf("invalid Content-Range %q", header)
+	}
+	start, _, ok := strings.Cut(spec, "-")
+	if !ok {
+		return 0, fmt.Errorf("invalid Content-Range %q", header)
+	}
+	return strconv.ParseInt(start, 10, 64)
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/iseeberg79/evccdb"
+	"github.com/spf13/cobra"
+)
+
+func runVerify(cmd *cobra.Command, args []string) error {
+	if _, err := loadConfig(); err != nil {
+		return err
+	}
+
+	src, err := evccdb.Open(transferSrc)
+	if err != nil {
+		return fmt.Errorf("failed to open source database: %w", err)
+	}
+	defer func() { _ = src.Close() }()
+
+	dst, err := evccdb.Open(transferDst)
+	if err != nil {
+		return fmt.Errorf("failed to open target database: %w", err)
+	}
+	defer func() { _ = dst.Close() }()
+
+	opts := evccdb.TransferOptions{Mode: parseMode(modeStr), IncludeCaches: includeCaches}
+	if tables != "" {
+		opts.Tables = parseNames(tables)
+	}
+	verifyTables, err := src.ResolveTables(opts)
+	if err != nil {
+		return err
+	}
+
+	// The checksum cache is written into both databases
+	ctx := cmd.Context()
+	if checksumCache {
+		for _, path := range []string{transferSrc, transferDst} {
+			unlock, err := lockDatabase(ctx, path)
+			if err != nil {
+				return err
+			}
+			defer unlock()
+		}
+	}
+
+	verifications, err := evccdb.Verify(ctx, src, dst, verifyTables, evccdb.VerifyOptions{Cache: checksumCache, Workers: verifyJobs})
+	if err != nil {
+		return err
+	}
+
+	result := &evccdb.QueryResult{Columns: []string{"table", "source rows", "target rows", "status"}}
+	if checksumCache {
+		result.Columns = []string{"table", "source rows", "target rows", "cached rows", "status"}
+	}
+	var differ []string
+	for _, v := range verifications {
+		row := []any{v.Table, v.Source.Rows, v.Target.Rows}
+		if checksumCache {
+			row = append(row, min(v.Source.Cached, v.Target.Cached))
+		}
+		result.Rows = append(result.Rows, append(row, string(v.Status)))
+		if v.Status != evccdb.VerifyOK {
+			differ = append(differ, v.Table)
+		}
+	}
+
+	if err := writeQueryResult(os.Stdout, result, verifyFormat); err != nil {
+		return err
+	}
+	if len(differ) > 0 {
+		return fmt.Errorf("tables differ: %s", strings.Join(differ, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package evccdb
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Compression is the compression of an export file
+type Compression string
+
+const (
+	CompressNone Compression = ""
+	CompressGzip Compression = "gzip"
+	CompressZstd Compression = "zstd"
+)
+
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// ParseCompression parses a compression name: none, gzip or zstd
+func ParseCompression(s string) (Compression, error) {
+	switch strings.ToLower(s) {
+	case "", "none":
+		return CompressNone, nil
+	case "gzip", "gz":
+		return CompressGzip, nil
+	case "zstd", "zst":
+		return CompressZstd, nil
+	default:
+		return CompressNone, fmt.Errorf("unknown compression %q, expected none, gzip or zstd", s)
+	}
+}
+
+// CompressionOf returns the compression of a file by its extension .gz or .zst
+func CompressionOf(path string) Compression {
+	switch {
+	case strings.HasSuffix(path, ".gz"):
+		return CompressGzip
+	case strings.HasSuffix(path, ".zst"):
+		return CompressZstd
+	default:
+		return CompressNone
+	}
+}
+
+// nopWriteCloser keeps uncompressed output open on Close
+type nopWriteCloser struct{ io.Writer }
+
+func (nopWriteCloser) Close() error { return nil }
+
+// NewCompressWriter compresses everything written to w, level 0 uses the default level.
+// Close flushes the compressed data but does not close w.
+func NewCompressWriter(w io.Writer, c Compression, level int) (io.WriteCloser, error) {
+	switch c {
+	case CompressNone:
+		return nopWriteCloser{w}, nil
+	case CompressGzip:
+		if level == 0 {
+			level = gzip.DefaultCompression
+		} else if level < gzip.BestSpeed || level > gzip.BestCompression {
+			return nil, fmt.Errorf("invalid gzip level %d, expected 1 to 9", level)
+		}
+		return gzip.NewWriterLevel(w, level)
+	case CompressZstd:
+		opts := []zstd.EOption{zstd.WithEncoderConcurrency(1)}
+		if level != 0 {
+			if level < 1 || level > 22 {
+				return nil, fmt.Errorf("invalid zstd level %d, expected 1 to 22", level)
+			}
+			opts = append(opts, zstd.WithEncoderLevel(zstd.EncoderLevelFromZstd(level)))
+		}
+		zw, err := zstd.NewWriter(w, opts...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create zstd writer: %w", err)
+		}
+		return zw, nil
+	default:
+		return nil, fmt.Errorf("unknown compression %q", c)
+	}
+}
+
+// NewDecompressReader returns a reader of the uncompressed data of r, detecting
+// gzip and zstd compression by their magic bytes
+func NewDecompressReader(r io.Reader) (io.ReadCloser, error) {
+	br := bufio.NewReader(r)
+	magic, _ := br.Peek(len(zstdMagic))
+
+	switch {
+	case bytes.HasPrefix(magic, gzipMagic):
+		zr, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read gzip data: %w", err)
+		}
+		return zr, nil
+	case bytes.HasPrefix(magic, zstdMagic):
+		zr, err := zstd.NewReader(br, zstd.WithDecoderConcurrency(1))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read zstd data: %w", err)
+		}
+		return zr.IOReadCloser(), nil
+	default:
+		return io.NopCloser(br), nil
+	}
+}
@@ -0,0 +1,68 @@
+package evccdb
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+)
+
+func TestCompressRoundTrip(t *testing.T) {
+	data := bytes.Repeat([]byte(`{"meter":1,"ts":"2024-01-01T00:00:00Z","val":1.5}`), 100)
+
+	for _, c := range []Compression{CompressNone, CompressGzip, CompressZstd} {
+		var buf bytes.Buffer
+		zw, err := NewCompressWriter(&buf, c, 0)
+		if err != nil {
+			t.Fatalf("NewCompressWriter(%q) failed: %v", c, err)
+		}
+		if _, err := zw.Write(data); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+		if err := zw.Close(); err != nil {
+			t.Fatalf("Close failed: %v", err)
+		}
+		if c != CompressNone && buf.Len() >= len(data) {
+			t.Errorf("%s output is not compressed: %d bytes", c, buf.Len())
+		}
+
+		zr, err := NewDecompressReader(&buf)
+		if err != nil {
+			t.Fatalf("NewDecompressReader(%q) failed: %v", c, err)
+		}
+		got, err := io.ReadAll(zr)
+		if err != nil {
+			t.Fatalf("ReadAll failed: %v", err)
+		}
+		if !bytes.Equal(got, data) {
+			t.Errorf("%s round trip changed the data", c)
+		}
+	}
+
+	if _, err := NewCompressWriter(io.Discard, CompressZstd, 23); err == nil {
+		t.Error("Expected error for invalid zstd level")
+	}
+}
+
+func TestImportJSONCompressed(t *testing.T) {
+	src, cleanup := createTestDB(t)
+	defer cleanup()
+
+	var buf bytes.Buffer
+	zw, err := NewCompressWriter(&buf, CompressZstd, 3)
+	if err != nil {
+		t.Fatalf("NewCompressWriter failed: %v", err)
+	}
+	if err := src.ExportJSON(zw, TransferOptions{Mode: TransferConfig}); err != nil {
+		t.Fatalf("ExportJSON failed: %v", err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	dst, cleanup2 := createTestDB(t)
+	defer cleanup2()
+	if err := dst.ImportJSONContext(context.Background(), &buf, TransferOptions{Mode: TransferConfig}); err != nil {
+		t.Fatalf("ImportJSONContext failed: %v", err)
+	}
+}
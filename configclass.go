@@ -0,0 +1,134 @@
+package evccdb
+
+import (
+	"fmt"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ConfigClass is the device class of a configs row, numbered as in evcc
+type ConfigClass int
+
+const (
+	ClassCharger ConfigClass = iota + 1
+	ClassMeter
+	ClassVehicle
+	ClassCircuit
+	ClassLoadpoint
+	ClassTariff
+)
+
+// configClassNames are the evcc names of the config classes
+var configClassNames = map[ConfigClass]string{
+	ClassCharger:   "charger",
+	ClassMeter:     "meter",
+	ClassVehicle:   "vehicle",
+	ClassCircuit:   "circuit",
+	ClassLoadpoint: "loadpoint",
+	ClassTariff:    "tariff",
+}
+
+// ConfigClasses returns all known config classes in evcc order
+func ConfigClasses() []ConfigClass {
+	classes := make([]ConfigClass, 0, len(configClassNames))
+	for class := ClassCharger; class <= ClassTariff; class++ {
+		classes = append(classes, class)
+	}
+	return classes
+}
+
+// ClassName returns the evcc name of the class, "class N" for unknown classes
+func (c ConfigClass) ClassName() string {
+	if name, ok := configClassNames[c]; ok {
+		return name
+	}
+	return fmt.Sprintf("class %d", int(c))
+}
+
+// String returns the class name
+func (c ConfigClass) String() string {
+	return c.ClassName()
+}
+
+// ParseClass parses a class name like "charger" or its number
+func ParseClass(s string) (ConfigClass, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	for class, name := range configClassNames {
+		if name == s {
+			return class, nil
+		}
+	}
+	if n, err := strconv.Atoi(s); err == nil && n > 0 {
+		return ConfigClass(n), nil
+	}
+
+	var names []string
+	for _, class := range ConfigClasses() {
+		names = append(names, class.ClassName())
+	}
+	return 0, fmt.Errorf("unknown config class %q, expected one of %s", s, strings.Join(names, ", "))
+}
+
+// classSettings are the settings key patterns belonging to a config class, in path.Match syntax.
+// Chargers keep their settings in the config value.
+var classSettings = map[ConfigClass][]string{
+	ClassMeter:     {"gridMeter", "pvMeters", "batteryMeters", "auxMeters", "extMeters"},
+	ClassVehicle:   {"vehicle.*"},
+	ClassCircuit:   {"circuits"},
+	ClassLoadpoint: {"lp[0-9]*.*"},
+	ClassTariff:    {"tariffs", "tariff.*"},
+}
+
+// ParseClasses parses a comma-separated list of class names
+func ParseClasses(s string) ([]ConfigClass, error) {
+	var classes []ConfigClass
+	for _, name := range strings.Split(s, ",") {
+		if strings.TrimSpace(name) == "" {
+			continue
+		}
+		class, err := ParseClass(name)
+		if err != nil {
+			return nil, err
+		}
+		classes = append(classes, class)
+	}
+	return classes, nil
+}
+
+// classConfig reports whether a config of the given class is written with the class options
+func (opts TransferOptions) classConfig(class any) bool {
+	if len(opts.Classes) == 0 {
+		return true
+	}
+	n, err := strconv.Atoi(settingString(class))
+	return err == nil && slices.Contains(opts.Classes, ConfigClass(n))
+}
+
+// classSetting reports whether a setting is written with the class options
+func (opts TransferOptions) classSetting(key string) bool {
+	if len(opts.Classes) == 0 {
+		return true
+	}
+	for _, class := range opts.Classes {
+		for _, pattern := range classSettings[class] {
+			if ok, _ := path.Match(pattern, key); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// classRow applies the class options to a row of table, false if it is excluded
+func (opts TransferOptions) classRow(table string, row map[string]any) bool {
+	switch table {
+	case "configs":
+		return opts.classConfig(row["class"])
+	case "settings":
+		return opts.classSetting(settingString(row["key"]))
+	default:
+		return true
+	}
+}
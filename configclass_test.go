@@ -0,0 +1,72 @@
+package evccdb
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+func TestParseClass(t *testing.T) {
+	for _, class := range ConfigClasses() {
+		parsed, err := ParseClass(class.ClassName())
+		if err != nil {
+			t.Fatalf("ParseClass(%q) failed: %v", class.ClassName(), err)
+		}
+		if parsed != class {
+			t.Errorf("ParseClass(%q) = %d, want %d", class.ClassName(), parsed, class)
+		}
+	}
+
+	if class, err := ParseClass("5"); err != nil || class != ClassLoadpoint {
+		t.Errorf("ParseClass(\"5\") = %v, %v", class, err)
+	}
+	if _, err := ParseClass("inverter"); err == nil {
+		t.Error("ParseClass accepted unknown class")
+	}
+	if name := ConfigClass(42).ClassName(); name != "class 42" {
+		t.Errorf("Unexpected name for unknown class: %s", name)
+	}
+}
+
+func TestTransferClasses(t *testing.T) {
+	src, srcCleanup := createTestDB(t)
+	defer srcCleanup()
+	_, _ = src.db.Exec("INSERT INTO configs (id, class, type, value) VALUES (3, 1, 'template', '{\"template\":\"go-e\"}')")
+	_, _ = src.db.Exec("INSERT INTO settings (key, value) VALUES ('sponsorToken', 'secret')")
+
+	classes, err := ParseClasses("vehicle, charger")
+	if err != nil {
+		t.Fatalf("ParseClasses failed: %v", err)
+	}
+	opts := TransferOptions{Mode: TransferConfig, Classes: classes}
+
+	var buf bytes.Buffer
+	if err := src.ExportJSON(&buf, opts); err != nil {
+		t.Fatalf("ExportJSON failed: %v", err)
+	}
+	var export ExportFormat
+	if err := json.Unmarshal(buf.Bytes(), &export); err != nil {
+		t.Fatalf("Failed to decode export: %v", err)
+	}
+	if configs, _ := export.Tables["configs"].([]any); len(configs) != 2 {
+		t.Errorf("Expected the vehicle and charger configs, got %v", configs)
+	}
+	if settings, _ := export.Tables["settings"].([]any); len(settings) != 3 {
+		t.Errorf("Expected the vehicle settings only, got %v", settings)
+	}
+
+	dst, dstCleanup := createTestDB(t)
+	defer dstCleanup()
+	_, _ = dst.db.Exec("DELETE FROM configs")
+	_, _ = dst.db.Exec("DELETE FROM settings")
+	if err := Transfer(context.Background(), src, dst, opts); err != nil {
+		t.Fatalf("Transfer failed: %v", err)
+	}
+	if count, _ := dst.GetRowCount("configs"); count != 2 {
+		t.Errorf("Expected 2 configs, got %d", count)
+	}
+	if count, _ := dst.GetRowCount("settings"); count != 3 {
+		t.Errorf("Expected 3 settings, got %d", count)
+	}
+}
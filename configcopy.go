@@ -0,0 +1,182 @@
+package evccdb
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/iseeberg79/evccdb/internal/sqlbuild"
+)
+
+// ConfigCopyResult reports a config copied between databases
+type ConfigCopyResult struct {
+	SourceID int
+	// TargetID is the id of the copied config, 0 for a dry run
+	TargetID int
+	Title    string
+	Settings int
+	// Refs are the device references of the copied config, they keep the ids of the source
+	Refs []DeviceRef
+}
+
+// FindConfig returns the config of a class matching name by db:<id>, title or template
+func (c *Client) FindConfig(ctx context.Context, class ConfigClass, name string) (Config, error) {
+	g, err := c.DeviceGraph(ctx)
+	if err != nil {
+		return Config{}, err
+	}
+
+	var found []Config
+	for _, cfg := range g.DevicesOfClass(class) {
+		if configMatches(cfg, name) {
+			found = append(found, cfg)
+		}
+	}
+
+	switch len(found) {
+	case 0:
+		return Config{}, fmt.Errorf("%s %q not found", class, name)
+	case 1:
+		return found[0], nil
+	default:
+		return Config{}, fmt.Errorf("%s %q is ambiguous, use %s<id>", class, name, dbRefPrefix)
+	}
+}
+
+// configMatches reports whether name is the reference, title or template of the config
+func configMatches(cfg Config, name string) bool {
+	if name == fmt.Sprintf("%s%d", dbRefPrefix, cfg.ID) || name == cfg.Title {
+		return true
+	}
+
+	var data map[string]any
+	if json.Unmarshal([]byte(cfg.Value), &data) != nil {
+		return false
+	}
+	template, _ := data["template"].(string)
+	return template != "" && template == name
+}
+
+// CopyConfig copies one config and its dependent settings from src to dst, e.g. to
+// reuse the API tokens of a charger for a second evcc instance
+func CopyConfig(ctx context.Context, src, dst *Client, class ConfigClass, name string, dryRun bool) (ConfigCopyResult, error) {
+	if err := CheckDistinct(src, dst); err != nil {
+		return ConfigCopyResult{}, err
+	}
+
+	cfg, err := src.FindConfig(ctx, class, name)
+	if err != nil {
+		return ConfigCopyResult{}, err
+	}
+
+	result := ConfigCopyResult{SourceID: cfg.ID, Title: cfg.Title}
+
+	g, err := src.DeviceGraph(ctx)
+	if err != nil {
+		return result, err
+	}
+	for _, ref := range g.Refs {
+		if ref.ConfigID == cfg.ID && strings.HasPrefix(ref.Ref, dbRefPrefix) {
+			result.Refs = append(result.Refs, ref)
+		}
+	}
+
+	if !strings.HasPrefix(name, dbRefPrefix) {
+		if existing, err := dst.FindConfig(ctx, class, name); err == nil {
+			return result, fmt.Errorf("%s %q already exists in target as %s%d", class, name, dbRefPrefix, existing.ID)
+		}
+	}
+
+	// Vehicle settings are keyed by title, other classes keep their settings in the config value
+	var keys []SettingsKey
+	if class == ClassVehicle && cfg.Title != "" {
+		if keys, err = vehicleSettingsKeys(ctx, src.db, cfg.Title); err != nil {
+			return result, fmt.Errorf("failed to query settings: %w", err)
+		}
+	}
+	result.Settings = len(keys)
+
+	if dryRun {
+		return result, nil
+	}
+
+	tx, err := dst.db.BeginTx(ctx, nil)
+	if err != nil {
+		return result, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if result.TargetID, err = copyConfigRow(ctx, src, tx, cfg.ID); err != nil {
+		return result, err
+	}
+
+	for _, k := range keys {
+		var value sql.NullString
+		if err := src.db.QueryRowContext(ctx, "SELECT value FROM settings WHERE key = ?", k.String()).Scan(&value); err != nil {
+			return result, fmt.Errorf("failed to read setting %s: %w", k, err)
+		}
+		if _, err := tx.ExecContext(ctx, "INSERT OR REPLACE INTO settings (key, value) VALUES (?, ?)", k.String(), value); err != nil {
+			return result, fmt.Errorf("failed to write setting %s: %w", k, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return result, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return result, nil
+}
+
+// copyConfigRow inserts the config row with the given id of src into the target
+// configs table with a new id, copying the columns both tables have in common
+func copyConfigRow(ctx context.Context, src *Client, tx *sql.Tx, id int) (int, error) {
+	srcCols, err := tableColumns(ctx, src.db, "configs")
+	if err != nil {
+		return 0, err
+	}
+	dstCols, err := tableColumns(ctx, tx, "configs")
+	if err != nil {
+		return 0, err
+	}
+
+	known := make(map[string]bool)
+	for _, col := range dstCols {
+		known[col.Name] = !col.Generated
+	}
+
+	var names []string
+	for _, col := range srcCols {
+		if col.Name != "id" && known[col.Name] {
+			names = append(names, col.Name)
+		}
+	}
+
+	query, err := sqlbuild.Select("configs", names)
+	if err != nil {
+		return 0, err
+	}
+	values := make([]any, len(names))
+	ptrs := make([]any, len(names))
+	for i := range values {
+		ptrs[i] = &values[i]
+	}
+	if err := src.db.QueryRowContext(ctx, query+" WHERE id = ?", id).Scan(ptrs...); err != nil {
+		return 0, fmt.Errorf("failed to read config %d: %w", id, err)
+	}
+
+	insert, err := sqlbuild.Insert("configs", names, false)
+	if err != nil {
+		return 0, err
+	}
+	res, err := tx.ExecContext(ctx, insert, values...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to insert config: %w", err)
+	}
+
+	newID, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	return int(newID), nil
+}
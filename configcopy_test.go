@@ -0,0 +1,56 @@
+package evccdb
+
+import (
+	"context"
+	"testing"
+)
+
+func TestCopyConfig(t *testing.T) {
+	src, cleanup := createTestDB(t)
+	defer cleanup()
+
+	dst, cleanupDst := createEmptyDB(t, `
+		CREATE TABLE settings (key TEXT PRIMARY KEY, value TEXT);
+		CREATE TABLE configs (id INTEGER PRIMARY KEY, class INTEGER, type TEXT, value TEXT, title TEXT, icon TEXT, product TEXT);
+		INSERT INTO configs (id, class, type, value) VALUES (2, 1, 'template', '{"template":"go-e"}');
+	`)
+	defer cleanupDst()
+
+	ctx := context.Background()
+
+	result, err := CopyConfig(ctx, src, dst, ClassVehicle, "e-Golf", true)
+	if err != nil {
+		t.Fatalf("CopyConfig dry run failed: %v", err)
+	}
+	if result.SourceID != 2 || result.TargetID != 0 || result.Settings != 3 {
+		t.Errorf("Unexpected dry run result: %+v", result)
+	}
+
+	if result, err = CopyConfig(ctx, src, dst, ClassVehicle, "e-Golf", false); err != nil {
+		t.Fatalf("CopyConfig failed: %v", err)
+	}
+	if result.TargetID != 3 || result.Settings != 3 {
+		t.Errorf("Unexpected result: %+v", result)
+	}
+
+	var class int
+	var value string
+	if err := dst.db.QueryRow("SELECT class, value FROM configs WHERE id = ?", result.TargetID).Scan(&class, &value); err != nil {
+		t.Fatalf("Failed to read copied config: %v", err)
+	}
+	if class != int(ClassVehicle) || value != `{"title":"e-Golf","type":"vw"}` {
+		t.Errorf("Unexpected copied config: class=%d value=%s", class, value)
+	}
+
+	var minSoc string
+	if err := dst.db.QueryRow("SELECT value FROM settings WHERE key = 'vehicle.e-Golf.minSoc'").Scan(&minSoc); err != nil || minSoc != "25" {
+		t.Errorf("Expected minSoc 25, got %q (%v)", minSoc, err)
+	}
+
+	if _, err := CopyConfig(ctx, src, dst, ClassVehicle, "e-Golf", false); err == nil {
+		t.Error("Expected error copying an existing config")
+	}
+	if _, err := CopyConfig(ctx, src, dst, ClassCharger, "e-Golf", false); err == nil {
+		t.Error("Expected error copying a config of the wrong class")
+	}
+}
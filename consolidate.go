@@ -0,0 +1,261 @@
+package evccdb
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/iseeberg79/evccdb/internal/sqlbuild"
+)
+
+// consolidateTables are the history tables merged by Consolidate. Configs and
+// settings stay those of the destination, meter ids are local to each site.
+var consolidateTables = []string{"sessions", "grid_sessions"}
+
+// ConsolidateSource is a database merged by Consolidate
+type ConsolidateSource struct {
+	Client *Client
+	// LoadpointPrefix is prepended to the loadpoint names of the source's sessions, empty keeps them
+	LoadpointPrefix string
+}
+
+// ConsolidateOptions configures Consolidate
+type ConsolidateOptions struct {
+	DryRun bool
+	// OnProgress is called for every table of every source with the merged rows
+	OnProgress func(source int, table string, count int)
+	// ConvertCurrency converts the session prices of sources using another currency by the registered exchange rate
+	ConvertCurrency bool
+}
+
+// LoadpointPrefix returns the prefix derived from the file name of a database, e.g. "cabin/" for cabin.db
+func LoadpointPrefix(path string) string {
+	return strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)) + "/"
+}
+
+// IDMapping records the new id of a row merged by Consolidate
+type IDMapping struct {
+	// Source is the index of the source database
+	Source int    `json:"source"`
+	Table  string `json:"table"`
+	OldID  int64  `json:"old_id"`
+	NewID  int64  `json:"new_id"`
+}
+
+// consolidateRow is a source row waiting to be merged
+type consolidateRow struct {
+	source  int
+	created time.Time
+	values  []any
+}
+
+// Consolidate appends the sessions and grid sessions of several databases to dst
+// in one transaction, e.g. when decommissioning one of several sites. The merged
+// rows get new ids following those of the destination, in the order they were
+// created across all sources. The returned mapping lists the old and new id of
+// every merged row, also for a dry run.
+func Consolidate(ctx context.Context, sources []ConsolidateSource, dst *Client, opts ConsolidateOptions) ([]IDMapping, error) {
+	for _, src := range sources {
+		if err := CheckDistinct(src.Client, dst); err != nil {
+			return nil, err
+		}
+	}
+
+	tx, err := dst.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var mapping []IDMapping
+	for _, table := range consolidateTables {
+		exists, err := tableExists(ctx, tx.tx, table)
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			fmt.Printf(Translate("WARNING: Table %s does not exist in destination, skipping\n"), table)
+			continue
+		}
+
+		merged, err := mergeTable(ctx, tx, sources, dst, table, opts.ConvertCurrency)
+		if err != nil {
+			return nil, fmt.Errorf("failed to merge %s: %w", table, err)
+		}
+		mapping = append(mapping, merged...)
+
+		if opts.OnProgress != nil {
+			counts := make([]int, len(sources))
+			for _, m := range merged {
+				counts[m.Source]++
+			}
+			for i, count := range counts {
+				opts.OnProgress(i, table, count)
+			}
+		}
+	}
+
+	// A dry run merges into the transaction to report the mapping, but discards it
+	if opts.DryRun {
+		return mapping, nil
+	}
+	return mapping, tx.Commit()
+}
+
+// mergeTable inserts the rows of all sources ordered by creation with new ids, prefixing loadpoint names.
+// Sources may have different columns, e.g. of an older evcc version, the columns a source lacks are NULL.
+func mergeTable(ctx context.Context, tx *Tx, sources []ConsolidateSource, dst *Client, table string, convert bool) ([]IDMapping, error) {
+	dstCols, err := tableColumns(ctx, tx.tx, table)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []consolidateRow
+	sourceNames := make(map[int][]string)
+	for i, src := range sources {
+		rate := 1.0
+		if table == "sessions" {
+			var err error
+			if rate, err = mergePriceRate(ctx, src.Client, tx.tx, convert); err != nil {
+				return nil, fmt.Errorf("source %d: %w", i+1, err)
+			}
+		}
+
+		srcRows, srcNames, err := readConsolidateRows(ctx, i, src, tx.tx, table, rate)
+		if err != nil {
+			return nil, fmt.Errorf("source %d: %w", i+1, err)
+		}
+		if srcRows == nil {
+			continue
+		}
+		sourceNames[i] = srcNames
+		rows = append(rows, srcRows...)
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+
+	// Insert the destination columns any source has, by name
+	var names []string
+	for _, col := range dstCols {
+		for _, srcNames := range sourceNames {
+			if slices.Contains(srcNames, col.Name) {
+				names = append(names, col.Name)
+				break
+			}
+		}
+	}
+	for i, row := range rows {
+		srcNames := sourceNames[row.source]
+		values := make([]any, len(names))
+		for j, name := range names {
+			if k := slices.Index(srcNames, name); k >= 0 {
+				values[j] = row.values[k]
+			}
+		}
+		rows[i].values = values
+	}
+
+	// Sources are read ordered by creation, the stable sort keeps their order for equal timestamps
+	slices.SortStableFunc(rows, func(a, b consolidateRow) int {
+		return a.created.Compare(b.created)
+	})
+
+	idIdx := slices.Index(names, "id")
+	quoted, err := sqlbuild.QuoteIdent(table)
+	if err != nil {
+		return nil, err
+	}
+	var nextID int64
+	if err := tx.tx.QueryRowContext(ctx, "SELECT COALESCE(MAX(id), 0) FROM "+quoted).Scan(&nextID); err != nil {
+		return nil, fmt.Errorf("failed to query max id: %w", err)
+	}
+
+	insertQuery, err := sqlbuild.Insert(table, names, false)
+	if err != nil {
+		return nil, err
+	}
+	insert, err := tx.tx.PrepareContext(ctx, insertQuery)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare insert: %w", err)
+	}
+	defer func() { _ = insert.Close() }()
+
+	mapping := make([]IDMapping, 0, len(rows))
+	for _, row := range rows {
+		nextID++
+		oldID, _ := row.values[idIdx].(int64)
+		row.values[idIdx] = nextID
+		if _, err := insert.ExecContext(ctx, row.values...); err != nil {
+			return nil, fmt.Errorf("failed to insert row: %w", err)
+		}
+		mapping = append(mapping, IDMapping{Source: row.source, Table: table, OldID: oldID, NewID: nextID})
+	}
+	return mapping, nil
+}
+
+// readConsolidateRows reads the rows of a source table ordered by creation with prices
+// multiplied by rate, nil if the table does not exist
+func readConsolidateRows(ctx context.Context, source int, src ConsolidateSource, dst querier, table string, rate float64) ([]consolidateRow, []string, error) {
+	exists, err := src.Client.TableExists(table)
+	if err != nil || !exists {
+		return nil, nil, err
+	}
+
+	cols, _, err := commonColumns(ctx, src.Client, dst, table)
+	if err != nil {
+		return nil, nil, err
+	}
+	names := columnNames(cols)
+	idIdx := slices.Index(names, "id")
+	createdIdx := slices.Index(names, "created")
+	if idIdx < 0 || createdIdx < 0 {
+		return nil, nil, fmt.Errorf("table %s needs id and created columns", table)
+	}
+	loadpointIdx := -1
+	if table == "sessions" && src.LoadpointPrefix != "" {
+		loadpointIdx = slices.Index(names, "loadpoint")
+	}
+
+	query, err := sqlbuild.Select(table, names)
+	if err != nil {
+		return nil, nil, err
+	}
+	rows, err := src.Client.db.QueryContext(ctx, query+" ORDER BY created, id")
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to query source data: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	result := []consolidateRow{}
+	for rows.Next() {
+		values := make([]any, len(names))
+		ptrs := make([]any, len(names))
+		for i := range values {
+			ptrs[i] = &values[i]
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			return nil, nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+
+		if loadpointIdx >= 0 {
+			if name := settingString(values[loadpointIdx]); name != "" {
+				values[loadpointIdx] = src.LoadpointPrefix + name
+			}
+		}
+		if rate != 1 {
+			for i, name := range names {
+				if slices.Contains(priceColumns, name) {
+					values[i] = scalePrice(values[i], rate)
+				}
+			}
+		}
+
+		created, _ := timestampOf(values[createdIdx])
+		result = append(result, consolidateRow{source: source, created: created, values: values})
+	}
+	return result, names, rows.Err()
+}
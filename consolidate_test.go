@@ -0,0 +1,114 @@
+package evccdb
+
+import (
+	"context"
+	"testing"
+)
+
+func TestConsolidate(t *testing.T) {
+	house, houseCleanup := createTestDB(t)
+	defer houseCleanup()
+	cabin, cabinCleanup := createTestDB(t)
+	defer cabinCleanup()
+	dst, dstCleanup := createTestDB(t)
+	defer dstCleanup()
+
+	ctx := context.Background()
+
+	// Cabin sessions start half a day after the house sessions of the same day
+	if _, err := cabin.db.Exec("UPDATE sessions SET created = datetime(created, '+12 hours')"); err != nil {
+		t.Fatal(err)
+	}
+	sources := []ConsolidateSource{
+		{Client: house},
+		{Client: cabin, LoadpointPrefix: LoadpointPrefix("/data/cabin.db")},
+	}
+
+	if _, err := Consolidate(ctx, sources, dst, ConsolidateOptions{DryRun: true}); err != nil {
+		t.Fatalf("Consolidate dry run failed: %v", err)
+	}
+	if count, _ := dst.GetRowCount("sessions"); count != 5 {
+		t.Fatalf("Expected dry run to keep 5 sessions, got %d", count)
+	}
+
+	merged := map[int]int{}
+	opts := ConsolidateOptions{OnProgress: func(source int, table string, count int) {
+		if table == "sessions" {
+			merged[source] = count
+		}
+	}}
+	mapping, err := Consolidate(ctx, sources, dst, opts)
+	if err != nil {
+		t.Fatalf("Consolidate failed: %v", err)
+	}
+	if merged[0] != 5 || merged[1] != 5 {
+		t.Errorf("Expected 5 sessions per source, got %v", merged)
+	}
+
+	if count, _ := dst.GetRowCount("sessions"); count != 15 {
+		t.Errorf("Expected 15 sessions, got %d", count)
+	}
+
+	var prefixed int
+	if err := dst.db.QueryRow("SELECT COUNT(*) FROM sessions WHERE loadpoint = 'cabin/Garage'").Scan(&prefixed); err != nil {
+		t.Fatal(err)
+	}
+	if prefixed != 3 {
+		t.Errorf("Expected 3 sessions at cabin/Garage, got %d", prefixed)
+	}
+
+	// New ids follow the destination's and interleave the sources by creation
+	want := []IDMapping{
+		{Source: 0, Table: "sessions", OldID: 1, NewID: 6},
+		{Source: 1, Table: "sessions", OldID: 1, NewID: 7},
+		{Source: 0, Table: "sessions", OldID: 2, NewID: 8},
+	}
+	if len(mapping) != 10 {
+		t.Fatalf("Expected 10 mapped sessions, got %d", len(mapping))
+	}
+	for i, m := range want {
+		if mapping[i] != m {
+			t.Errorf("Expected mapping %v, got %v", m, mapping[i])
+		}
+	}
+
+	var loadpoint string
+	if err := dst.db.QueryRow("SELECT loadpoint FROM sessions WHERE id = 7").Scan(&loadpoint); err != nil {
+		t.Fatal(err)
+	}
+	if loadpoint != "cabin/Garage" {
+		t.Errorf("Expected session 7 at cabin/Garage, got %s", loadpoint)
+	}
+}
+
+func TestConsolidateDifferentColumns(t *testing.T) {
+	house, houseCleanup := createTestDB(t)
+	defer houseCleanup()
+	old, oldCleanup := createTestDB(t)
+	defer oldCleanup()
+	dst, dstCleanup := createTestDB(t)
+	defer dstCleanup()
+
+	// An older evcc version without odometer readings
+	if _, err := old.db.Exec("ALTER TABLE sessions DROP COLUMN odometer"); err != nil {
+		t.Fatal(err)
+	}
+	old.InvalidateSchema()
+
+	sources := []ConsolidateSource{{Client: house}, {Client: old}}
+	if _, err := Consolidate(context.Background(), sources, dst, ConsolidateOptions{}); err != nil {
+		t.Fatalf("Consolidate failed: %v", err)
+	}
+
+	var total, withOdometer int
+	if err := dst.db.QueryRow("SELECT COUNT(*), COUNT(odometer) FROM sessions").Scan(&total, &withOdometer); err != nil {
+		t.Fatal(err)
+	}
+	var houseOdometer int
+	if err := house.db.QueryRow("SELECT COUNT(odometer) FROM sessions").Scan(&houseOdometer); err != nil {
+		t.Fatal(err)
+	}
+	if total != 15 || withOdometer != 2*houseOdometer {
+		t.Errorf("Expected 15 sessions, %d with odometer, got %d and %d", 2*houseOdometer, total, withOdometer)
+	}
+}
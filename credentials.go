@@ -0,0 +1,163 @@
+package evccdb
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/iseeberg79/evccdb/internal/sqlbuild"
+)
+
+// CredentialStatus is the expiry state of a stored credential
+type CredentialStatus string
+
+const (
+	CredentialValid    CredentialStatus = "valid"
+	CredentialExpiring CredentialStatus = "expiring"
+	CredentialExpired  CredentialStatus = "expired"
+	// CredentialUnknown is a credential without readable expiry, e.g. an opaque refresh token
+	CredentialUnknown CredentialStatus = "unknown"
+)
+
+// Credential is a token stored in the settings or caches table
+type Credential struct {
+	Table  string           `json:"table"`
+	Key    string           `json:"key"`
+	Kind   string           `json:"kind"`
+	Expiry time.Time        `json:"expiry"`
+	Status CredentialStatus `json:"status"`
+}
+
+// credentialTables are the tables evcc stores tokens in
+var credentialTables = []string{"settings", "caches"}
+
+// CheckCredentials finds the tokens stored in the settings and caches tables, the sponsor
+// token and OAuth tokens of vehicle APIs, and reports whether they expired before now or
+// expire within the given duration
+func (c *Client) CheckCredentials(ctx context.Context, now time.Time, within time.Duration) ([]Credential, error) {
+	var result []Credential
+	for _, table := range credentialTables {
+		exists, err := c.TableExists(table)
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			continue
+		}
+
+		query, err := sqlbuild.Select(table, []string{"key", "value"})
+		if err != nil {
+			return nil, err
+		}
+		rows, err := c.db.QueryContext(ctx, query)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query %s: %w", table, err)
+		}
+		for rows.Next() {
+			var key string
+			var value any
+			if err := rows.Scan(&key, &value); err != nil {
+				_ = rows.Close()
+				return nil, fmt.Errorf("failed to scan %s: %w", table, err)
+			}
+			if cred, ok := parseCredential(key, settingString(value)); ok {
+				cred.Table = table
+				cred.Status = credentialStatus(cred.Expiry, now, within)
+				result = append(result, cred)
+			}
+		}
+		_ = rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Table != result[j].Table {
+			return result[i].Table < result[j].Table
+		}
+		return result[i].Key < result[j].Key
+	})
+	return result, nil
+}
+
+// credentialStatus classifies an expiry relative to now, the zero time is unknown
+func credentialStatus(expiry, now time.Time, within time.Duration) CredentialStatus {
+	switch {
+	case expiry.IsZero():
+		return CredentialUnknown
+	case !expiry.After(now):
+		return CredentialExpired
+	case expiry.Before(now.Add(within)):
+		return CredentialExpiring
+	default:
+		return CredentialValid
+	}
+}
+
+// parseCredential recognizes a JWT or an OAuth token, also stored as JSON string
+func parseCredential(key, value string) (Credential, bool) {
+	value = strings.TrimSpace(value)
+	var s string
+	if json.Unmarshal([]byte(value), &s) == nil {
+		value = s
+	}
+
+	if expiry, ok := jwtExpiry(value); ok {
+		kind := "jwt"
+		if key == "sponsorToken" {
+			kind = "sponsor token"
+		}
+		return Credential{Key: key, Kind: kind, Expiry: expiry}, true
+	}
+
+	var token struct {
+		AccessToken  string `json:"access_token"`
+		RefreshToken string `json:"refresh_token"`
+		Expiry       string `json:"expiry"`
+	}
+	if json.Unmarshal([]byte(value), &token) != nil {
+		return Credential{}, false
+	}
+
+	// Access tokens are renewed by the refresh token, which decides whether evcc can log in
+	if token.RefreshToken != "" {
+		expiry, _ := jwtExpiry(token.RefreshToken)
+		return Credential{Key: key, Kind: "refresh token", Expiry: expiry}, true
+	}
+	if token.AccessToken != "" {
+		expiry, ok := jwtExpiry(token.AccessToken)
+		if !ok || expiry.IsZero() {
+			expiry, _ = time.Parse(time.RFC3339Nano, token.Expiry)
+		}
+		return Credential{Key: key, Kind: "access token", Expiry: expiry}, true
+	}
+	return Credential{}, false
+}
+
+// jwtExpiry decodes the exp claim of a JWT without verifying it, ok is false for other
+// strings and the expiry is zero for tokens without exp claim
+func jwtExpiry(token string) (time.Time, bool) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return time.Time{}, false
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return time.Time{}, false
+	}
+	var claims struct {
+		Exp *float64 `json:"exp"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return time.Time{}, false
+	}
+	if claims.Exp == nil {
+		return time.Time{}, true
+	}
+	return time.Unix(int64(*claims.Exp), 0).UTC(), true
+}
@@ -0,0 +1,50 @@
+package evccdb
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// testJWT returns an unsigned JWT expiring at exp
+func testJWT(exp time.Time) string {
+	payload := base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf(`{"sub":"evcc","exp":%d}`, exp.Unix())))
+	return "eyJhbGciOiJIUzI1NiJ9." + payload + ".c2ln"
+}
+
+func TestCheckCredentials(t *testing.T) {
+	client, cleanup := createTestDB(t)
+	defer cleanup()
+
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	_, _ = client.db.Exec("INSERT INTO settings (key, value) VALUES ('sponsorToken', ?)", testJWT(now.AddDate(0, 0, -1)))
+	_, _ = client.db.Exec("INSERT INTO caches (key, value) VALUES (?, ?), (?, ?), (?, ?)",
+		"vw.token", fmt.Sprintf(`{"access_token":"abc","refresh_token":%q,"expiry":"2024-05-01T00:00:00Z"}`, testJWT(now.AddDate(0, 0, 10))),
+		"tesla.token", `{"access_token":"abc","token_type":"Bearer","expiry":"2024-09-01T00:00:00Z"}`,
+		"bmw.token", `{"access_token":"abc","refresh_token":"opaque"}`)
+
+	creds, err := client.CheckCredentials(context.Background(), now, 30*24*time.Hour)
+	if err != nil {
+		t.Fatalf("CheckCredentials failed: %v", err)
+	}
+
+	expected := map[string]CredentialStatus{
+		"sponsorToken": CredentialExpired,
+		"vw.token":     CredentialExpiring,
+		"tesla.token":  CredentialValid,
+		"bmw.token":    CredentialUnknown,
+	}
+	if len(creds) != len(expected) {
+		t.Fatalf("Expected %d credentials, got %+v", len(expected), creds)
+	}
+	for _, cred := range creds {
+		if cred.Status != expected[cred.Key] {
+			t.Errorf("Expected %s to be %s, got %s", cred.Key, expected[cred.Key], cred.Status)
+		}
+	}
+	if creds[len(creds)-1].Kind != "sponsor token" {
+		t.Errorf("Expected sponsor token last, got %+v", creds[len(creds)-1])
+	}
+}
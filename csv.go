@@ -0,0 +1,486 @@
+package evccdb
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/iseeberg79/evccdb/internal/sqlbuild"
+)
+
+// CSVOptions configures a CSV session import
+type CSVOptions struct {
+	// Columns maps CSV headers to sessions columns and overrides the built-in mapping
+	Columns map[string]string
+	// Locale selects the number format, e.g. "en" or "de". Empty detects it from the delimiter.
+	Locale string
+	// Profile selects the column mapping of the tool that wrote the file, default "evcc"
+	Profile string
+	// Location interprets timestamps of the file, nil means the local time zone
+	Location *time.Location
+}
+
+// CSVProfile maps the columns of a charging tool's CSV export to sessions columns
+type CSVProfile struct {
+	Name        string
+	Description string
+	// Columns maps CSV headers to sessions columns, headers are compared normalized
+	Columns map[string]string
+	// TimeLayouts are tried before the default timestamp formats
+	TimeLayouts []string
+}
+
+var (
+	csvProfilesMu sync.RWMutex
+	csvProfiles   = map[string]CSVProfile{}
+)
+
+func init() {
+	for _, p := range []CSVProfile{evccProfile, goeProfile, openWBProfile, wallboxProfile} {
+		RegisterCSVProfile(p)
+	}
+}
+
+// RegisterCSVProfile adds or replaces a CSV import profile
+func RegisterCSVProfile(p CSVProfile) {
+	csvProfilesMu.Lock()
+	defer csvProfilesMu.Unlock()
+	csvProfiles[p.Name] = p
+}
+
+// CSVProfiles returns the registered CSV import profiles sorted by name
+func CSVProfiles() []CSVProfile {
+	csvProfilesMu.RLock()
+	defer csvProfilesMu.RUnlock()
+
+	profiles := make([]CSVProfile, 0, len(csvProfiles))
+	for _, p := range csvProfiles {
+		profiles = append(profiles, p)
+	}
+	sort.Slice(profiles, func(i, j int) bool { return profiles[i].Name < profiles[j].Name })
+	return profiles
+}
+
+// csvProfile looks up a registered profile
+func csvProfile(name string) (CSVProfile, error) {
+	if name == "" {
+		name = evccProfile.Name
+	}
+
+	csvProfilesMu.RLock()
+	defer csvProfilesMu.RUnlock()
+
+	p, ok := csvProfiles[name]
+	if !ok {
+		names := make([]string, 0, len(csvProfiles))
+		for n := range csvProfiles {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		return CSVProfile{}, fmt.Errorf("unknown CSV profile %q, available: %s", name, strings.Join(names, ", "))
+	}
+	return p, nil
+}
+
+// evccProfile reads the sessions CSV export of the evcc web UI
+var evccProfile = CSVProfile{
+	Name:        "evcc",
+	Description: "evcc web UI sessions export",
+	Columns:     evccHeaderAliases,
+}
+
+// goeProfile reads the charging report of the go-e Charger app and portal
+var goeProfile = CSVProfile{
+	Name:        "go-e",
+	Description: "go-e Charger app/portal charging report",
+	Columns: map[string]string{
+		"Start":                 "created",
+		"End":                   "finished",
+		"Ende":                  "finished",
+		"Duration":              "charge_duration",
+		"Dauer":                 "charge_duration",
+		"Energy [kWh]":          "charged_kwh",
+		"Energie [kWh]":         "charged_kwh",
+		"ID Chip UID":           "identifier",
+		"ID Chip Name":          "vehicle",
+		"Meter Reading Start":   "meter_start_kwh",
+		"Zählerstand Start":     "meter_start_kwh",
+		"Meter Reading End":     "meter_end_kwh",
+		"Zählerstand Ende":      "meter_end_kwh",
+		"Session Number":        "",
+		"Sitzungsnummer":        "",
+		"Session Identifier":    "",
+		"Sitzungsidentifikator": "",
+		"ID Chip":               "",
+		"Max. Power [kW]":       "",
+		"Max. Leistung [kW]":    "",
+		"Max. Current [A]":      "",
+		"Max. Strom [A]":        "",
+		"Charger Serial":        "",
+		"Seriennummer":          "",
+	},
+	TimeLayouts: []string{"02.01.2006 15:04:05"},
+}
+
+// openWBProfile reads the charge log export of openWB 2
+var openWBProfile = CSVProfile{
+	Name:        "openwb",
+	Description: "openWB 2 charge log export",
+	Columns: map[string]string{
+		"Beginn":            "created",
+		"Start":             "created",
+		"Ende":              "finished",
+		"End":               "finished",
+		"Dauer":             "charge_duration",
+		"Duration":          "charge_duration",
+		"Ladepunkt":         "loadpoint",
+		"Chargepoint":       "loadpoint",
+		"Fahrzeug":          "vehicle",
+		"Vehicle":           "vehicle",
+		"Geladene Energie":  "charged_kwh",
+		"Charged Energy":    "charged_kwh",
+		"Kilometerstand":    "odometer",
+		"Kosten":            "price",
+		"Costs":             "price",
+		"Zählerstand Start": "meter_start_kwh",
+		"Zählerstand Ende":  "meter_end_kwh",
+		"Lademodus":         "",
+		"Charge Mode":       "",
+		"ID-Tag":            "identifier",
+	},
+	TimeLayouts: []string{"02.01.2006 15:04:05", "02.01.2006 15:04", "02.01.06 15:04"},
+}
+
+// wallboxProfile reads the sessions export of the myWallbox portal
+var wallboxProfile = CSVProfile{
+	Name:        "wallbox",
+	Description: "myWallbox portal sessions export",
+	Columns: map[string]string{
+		"Start":                  "created",
+		"End":                    "finished",
+		"Charger name":           "loadpoint",
+		"Charger":                "loadpoint",
+		"User":                   "identifier",
+		"Charging time (h:m:s)":  "charge_duration",
+		"Energy (kWh)":           "charged_kwh",
+		"Cost":                   "price",
+		"Energy price (per kWh)": "price_per_kwh",
+		"Green energy (%)":       "solar_percentage",
+		"Charger ID":             "",
+		"Charging speed":         "",
+		"Range":                  "",
+		"Currency":               "",
+	},
+	TimeLayouts: []string{"2006-01-02 15:04:05", "01/02/2006 15:04"},
+}
+
+// evccHeaderAliases maps normalized CSV headers of the evcc web UI export to sessions columns
+var evccHeaderAliases = map[string]string{
+	"created":          "created",
+	"erstellt":         "created",
+	"start":            "created",
+	"finished":         "finished",
+	"beendet":          "finished",
+	"ende":             "finished",
+	"chargingpoint":    "loadpoint",
+	"ladepunkt":        "loadpoint",
+	"identifier":       "identifier",
+	"identifikator":    "identifier",
+	"vehicle":          "vehicle",
+	"fahrzeug":         "vehicle",
+	"odometer":         "odometer",
+	"kilometerstand":   "odometer",
+	"meterstart":       "meter_start_kwh",
+	"zählerstandstart": "meter_start_kwh",
+	"meterstop":        "meter_end_kwh",
+	"meterend":         "meter_end_kwh",
+	"zählerstandende":  "meter_end_kwh",
+	"energy":           "charged_kwh",
+	"chargedenergy":    "charged_kwh",
+	"energie":          "charged_kwh",
+	"geladeneenergie":  "charged_kwh",
+	"solar":            "solar_percentage",
+	"sonne":            "solar_percentage",
+	"solaranteil":      "solar_percentage",
+	"price":            "price",
+	"preis":            "price",
+	"pricekwh":         "price_per_kwh",
+	"avgprice":         "price_per_kwh",
+	"preiskwh":         "price_per_kwh",
+	"øpreiskwh":        "price_per_kwh",
+	"co₂kwh":           "co2_per_kwh",
+	"co2kwh":           "co2_per_kwh",
+	"chargeduration":   "charge_duration",
+	"duration":         "charge_duration",
+	"ladedauer":        "charge_duration",
+	"dauer":            "charge_duration",
+}
+
+// csvTimeLayouts are the timestamp formats accepted in CSV files
+var csvTimeLayouts = []string{
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"02.01.2006 15:04:05",
+	"02.01.2006 15:04",
+}
+
+// ImportSessionsCSV imports sessions from a CSV export of evcc or another
+// charging tool and returns the number of imported sessions
+func (c *Client) ImportSessionsCSV(ctx context.Context, r io.Reader, opts CSVOptions) (int, error) {
+	br := bufio.NewReader(r)
+	header, err := br.ReadString('\n')
+	if err != nil && err != io.EOF {
+		return 0, fmt.Errorf("failed to read CSV header: %w", err)
+	}
+
+	delimiter := ','
+	if strings.Count(header, ";") > strings.Count(header, ",") {
+		delimiter = ';'
+	}
+
+	reader := csv.NewReader(io.MultiReader(strings.NewReader(header), br))
+	reader.Comma = delimiter
+	reader.FieldsPerRecord = -1
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse CSV: %w", err)
+	}
+	if len(records) == 0 {
+		return 0, fmt.Errorf("CSV file is empty")
+	}
+
+	cols, err := tableColumns(ctx, c.db, "sessions")
+	if err != nil {
+		return 0, err
+	}
+	if len(cols) == 0 {
+		return 0, fmt.Errorf("target database has no sessions table")
+	}
+
+	profile, err := csvProfile(opts.Profile)
+	if err != nil {
+		return 0, err
+	}
+
+	mapping, err := mapCSVHeader(records[0], cols, profile, opts.Columns)
+	if err != nil {
+		return 0, err
+	}
+
+	decimalComma := delimiter == ';'
+	if opts.Locale != "" {
+		decimalComma = usesDecimalComma(opts.Locale)
+	}
+
+	layouts := append(append([]string{}, profile.TimeLayouts...), csvTimeLayouts...)
+
+	loc := opts.Location
+	if loc == nil {
+		loc = time.Local
+	}
+
+	count := 0
+	err = c.inTx(ctx, func(tx *Tx) error {
+		for line, record := range records[1:] {
+			var names []string
+			var values []any
+
+			for i, col := range mapping {
+				if col == nil || i >= len(record) {
+					continue
+				}
+
+				val, err := parseCSVValue(strings.TrimSpace(record[i]), *col, decimalComma, layouts, loc)
+				if err != nil {
+					return fmt.Errorf("line %d, column %s: %w", line+2, col.Name, err)
+				}
+
+				names = append(names, col.Name)
+				values = append(values, val)
+			}
+
+			if len(names) == 0 {
+				continue
+			}
+
+			query, err := sqlbuild.Insert("sessions", names, false)
+			if err != nil {
+				return err
+			}
+			if _, err := tx.tx.ExecContext(ctx, query, values...); err != nil {
+				return fmt.Errorf("failed to insert session from line %d: %w", line+2, err)
+			}
+			count++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// mapCSVHeader resolves every CSV header to a sessions column, nil for unmapped headers
+func mapCSVHeader(header []string, cols []ColumnInfo, profile CSVProfile, overrides map[string]string) ([]*ColumnInfo, error) {
+	byName := make(map[string]*ColumnInfo)
+	aliases := make(map[string]string)
+	for i := range cols {
+		if cols[i].Generated {
+			continue
+		}
+		byName[cols[i].Name] = &cols[i]
+		aliases[normalizeHeader(cols[i].Name)] = cols[i].Name
+	}
+	for alias, name := range profile.Columns {
+		aliases[normalizeHeader(alias)] = name
+	}
+
+	mapping := make([]*ColumnInfo, len(header))
+	used := make(map[string]bool)
+	for i, h := range header {
+		h = strings.TrimSpace(strings.TrimPrefix(h, "\ufeff"))
+
+		name, ok := overrides[h]
+		if !ok {
+			name, ok = aliases[normalizeHeader(h)]
+		}
+		if !ok {
+			fmt.Printf(Translate("WARNING: Ignoring unknown CSV column %q\n"), h)
+			continue
+		}
+		if name == "" {
+			continue
+		}
+
+		col, ok := byName[name]
+		if !ok {
+			return nil, fmt.Errorf("CSV column %q maps to unknown sessions column %q", h, name)
+		}
+		if used[name] {
+			return nil, fmt.Errorf("sessions column %q is mapped more than once", name)
+		}
+		used[name] = true
+		mapping[i] = col
+	}
+
+	if !used["created"] {
+		return nil, fmt.Errorf("CSV has no column for the session start (created)")
+	}
+
+	return mapping, nil
+}
+
+// normalizeHeader lowercases a header and drops units in brackets and punctuation
+func normalizeHeader(h string) string {
+	if i := strings.IndexAny(h, "(["); i >= 0 {
+		h = h[:i]
+	}
+
+	var b strings.Builder
+	for _, r := range strings.ToLower(h) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '₂' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// usesDecimalComma reports whether numbers in the given locale use a decimal comma
+func usesDecimalComma(locale string) bool {
+	lang := strings.ToLower(strings.SplitN(strings.ReplaceAll(locale, "_", "-"), "-", 2)[0])
+	switch lang {
+	case "de", "fr", "nl", "it", "es", "pt", "da", "sv", "nb", "no", "fi", "pl", "cs", "sk", "hu", "ru", "tr":
+		return true
+	default:
+		return false
+	}
+}
+
+// parseCSVValue converts a CSV cell into a value for the given column, empty cells become NULL
+func parseCSVValue(s string, col ColumnInfo, decimalComma bool, layouts []string, loc *time.Location) (any, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	if col.Name == "charge_duration" {
+		return parseCSVDuration(s, decimalComma)
+	}
+
+	switch columnKindOf(col.Type) {
+	case kindInt:
+		f, err := parseLocaleNumber(s, decimalComma)
+		if err != nil {
+			return nil, err
+		}
+		return int64(f), nil
+	case kindReal:
+		return parseLocaleNumber(s, decimalComma)
+	case kindTimestamp:
+		for _, layout := range layouts {
+			if t, err := time.ParseInLocation(layout, s, loc); err == nil {
+				return t, nil
+			}
+		}
+		return nil, fmt.Errorf("invalid timestamp %q", s)
+	default:
+		return s, nil
+	}
+}
+
+// parseLocaleNumber parses a number with locale specific decimal and thousands separators
+func parseLocaleNumber(s string, decimalComma bool) (float64, error) {
+	s = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(s), "%"))
+	s = strings.NewReplacer(" ", "", "\u00a0", "", "\u202f", "", "'", "").Replace(s)
+
+	if decimalComma {
+		s = strings.ReplaceAll(s, ".", "")
+		s = strings.ReplaceAll(s, ",", ".")
+	} else {
+		s = strings.ReplaceAll(s, ",", "")
+	}
+
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid number %q", s)
+	}
+	return f, nil
+}
+
+// parseCSVDuration parses a charge duration given as Go duration, h:mm[:ss] or seconds
+// and returns it in nanoseconds as stored by evcc
+func parseCSVDuration(s string, decimalComma bool) (int64, error) {
+	if d, err := time.ParseDuration(s); err == nil {
+		return int64(d), nil
+	}
+
+	if parts := strings.Split(s, ":"); len(parts) == 2 || len(parts) == 3 {
+		var d time.Duration
+		units := []time.Duration{time.Hour, time.Minute, time.Second}
+		for i, part := range parts {
+			n, err := strconv.Atoi(part)
+			if err != nil {
+				return 0, fmt.Errorf("invalid duration %q", s)
+			}
+			d += time.Duration(n) * units[i]
+		}
+		return int64(d), nil
+	}
+
+	seconds, err := parseLocaleNumber(s, decimalComma)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+	return int64(seconds * float64(time.Second)), nil
+}
@@ -0,0 +1,168 @@
+package evccdb
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+const sessionsSchema = `CREATE TABLE sessions (
+	id INTEGER PRIMARY KEY,
+	created DATETIME,
+	finished DATETIME,
+	loadpoint TEXT,
+	identifier TEXT,
+	vehicle TEXT,
+	odometer REAL,
+	meter_start_kwh REAL,
+	meter_end_kwh REAL,
+	charged_kwh REAL,
+	solar_percentage REAL,
+	price REAL,
+	price_per_kwh REAL,
+	co2_per_kwh REAL,
+	charge_duration INTEGER
+)`
+
+func TestImportSessionsCSV(t *testing.T) {
+	client, cleanup := createEmptyDB(t, sessionsSchema)
+	defer cleanup()
+
+	data := "Created,Finished,Charging point,Identifier,Vehicle,Odometer (km),Meter start (kWh),Meter stop (kWh),Energy (kWh),Solar (%),Price,Price/kWh,CO₂/kWh (gCO₂eq),Duration\n" +
+		"2024-03-01 08:00:00,2024-03-01 10:30:00,Garage,,e-Golf,\"12,345\",100.5,112.5,12.0,75,3.6,0.3,120,2h30m0s\n" +
+		"2024-03-02 08:00:00,,Carport,,,,,,,,,,,\n"
+
+	count, err := client.ImportSessionsCSV(context.Background(), strings.NewReader(data), CSVOptions{})
+	if err != nil {
+		t.Fatalf("ImportSessionsCSV failed: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("Expected 2 imported sessions, got %d", count)
+	}
+
+	var loadpoint string
+	var odometer, charged float64
+	var duration int64
+	err = client.db.QueryRow("SELECT loadpoint, odometer, charged_kwh, charge_duration FROM sessions WHERE vehicle = 'e-Golf'").
+		Scan(&loadpoint, &odometer, &charged, &duration)
+	if err != nil {
+		t.Fatalf("Failed to read imported session: %v", err)
+	}
+	if loadpoint != "Garage" || odometer != 12345 || charged != 12 {
+		t.Errorf("Unexpected session values: %s %v %v", loadpoint, odometer, charged)
+	}
+	if duration != int64(150*60*1e9) {
+		t.Errorf("Expected duration of 2h30m in nanoseconds, got %d", duration)
+	}
+}
+
+func TestImportSessionsCSVGerman(t *testing.T) {
+	client, cleanup := createEmptyDB(t, sessionsSchema)
+	defer cleanup()
+
+	data := "Erstellt;Ladepunkt;Fahrzeug;Energie (kWh);Notiz\n" +
+		"01.03.2024 08:00;Garage;e-Golf;1.012,5;egal\n"
+
+	count, err := client.ImportSessionsCSV(context.Background(), strings.NewReader(data), CSVOptions{})
+	if err != nil {
+		t.Fatalf("ImportSessionsCSV failed: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("Expected 1 imported session, got %d", count)
+	}
+
+	var charged float64
+	if err := client.db.QueryRow("SELECT charged_kwh FROM sessions").Scan(&charged); err != nil {
+		t.Fatalf("Failed to read imported session: %v", err)
+	}
+	if charged != 1012.5 {
+		t.Errorf("Expected 1012.5 kWh, got %v", charged)
+	}
+}
+
+func TestImportSessionsCSVColumnMapping(t *testing.T) {
+	client, cleanup := createEmptyDB(t, sessionsSchema)
+	defer cleanup()
+
+	data := "Begin,Wallbox,kWh\n2024-03-01 08:00:00,Garage,7.5\n"
+
+	if _, err := client.ImportSessionsCSV(context.Background(), strings.NewReader(data), CSVOptions{}); err == nil {
+		t.Error("Expected error without a created column")
+	}
+
+	opts := CSVOptions{Columns: map[string]string{"Begin": "created", "Wallbox": "loadpoint", "kWh": "charged_kwh"}}
+	if _, err := client.ImportSessionsCSV(context.Background(), strings.NewReader(data), opts); err != nil {
+		t.Fatalf("ImportSessionsCSV failed: %v", err)
+	}
+
+	count, _ := client.CountLoadpointSessions(context.Background(), "Garage")
+	if count != 1 {
+		t.Errorf("Expected 1 Garage session, got %d", count)
+	}
+}
+
+func TestParseLocaleNumber(t *testing.T) {
+	tests := []struct {
+		input        string
+		decimalComma bool
+		expected     float64
+	}{
+		{"12.5", false, 12.5},
+		{"1,234.5", false, 1234.5},
+		{"12,5", true, 12.5},
+		{"1.234,5", true, 1234.5},
+		{"75 %", false, 75},
+	}
+
+	for _, tt := range tests {
+		result, err := parseLocaleNumber(tt.input, tt.decimalComma)
+		if err != nil {
+			t.Errorf("parseLocaleNumber(%q) failed: %v", tt.input, err)
+			continue
+		}
+		if result != tt.expected {
+			t.Errorf("parseLocaleNumber(%q) = %v, want %v", tt.input, result, tt.expected)
+		}
+	}
+}
+
+func TestImportSessionsCSVProfile(t *testing.T) {
+	client, cleanup := createEmptyDB(t, sessionsSchema)
+	defer cleanup()
+
+	data := "Session Number;Start;End;Duration;Energy [kWh];ID Chip Name\n" +
+		"1;01.03.2024 08:00:00;01.03.2024 09:30:00;01:30:00;10,5;Model 3\n"
+
+	opts := CSVOptions{Profile: "go-e"}
+	if _, err := client.ImportSessionsCSV(context.Background(), strings.NewReader(data), opts); err != nil {
+		t.Fatalf("ImportSessionsCSV failed: %v", err)
+	}
+
+	var vehicle string
+	var charged float64
+	var duration int64
+	if err := client.db.QueryRow("SELECT vehicle, charged_kwh, charge_duration FROM sessions").Scan(&vehicle, &charged, &duration); err != nil {
+		t.Fatalf("Failed to read imported session: %v", err)
+	}
+	if vehicle != "Model 3" || charged != 10.5 || duration != int64(90*60*1e9) {
+		t.Errorf("Unexpected session values: %s %v %d", vehicle, charged, duration)
+	}
+
+	if _, err := client.ImportSessionsCSV(context.Background(), strings.NewReader(data), CSVOptions{Profile: "unknown"}); err == nil {
+		t.Error("Expected error for unknown profile")
+	}
+}
+
+func TestRegisterCSVProfile(t *testing.T) {
+	RegisterCSVProfile(CSVProfile{Name: "test-profile", Columns: map[string]string{"Begin": "created"}})
+
+	found := false
+	for _, p := range CSVProfiles() {
+		if p.Name == "test-profile" {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("Expected registered profile in CSVProfiles")
+	}
+}
@@ -0,0 +1,142 @@
+package evccdb
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// priceColumns are the session columns holding amounts in the currency of the installation
+var priceColumns = []string{"price", "price_per_kwh"}
+
+// tariffCurrency matches the currency of the tariffs setting evcc stores as YAML
+var tariffCurrency = regexp.MustCompile(`(?m)^\s*currency:\s*["']?([A-Za-z]{3})\b`)
+
+// ExchangeRate converts amounts of one currency into another
+type ExchangeRate struct {
+	From string
+	To   string
+	// Rate is the amount of To per unit of From
+	Rate float64
+}
+
+// exchangeRates holds the static exchange rates added by the config file
+var (
+	exchangeRatesMu sync.RWMutex
+	exchangeRates   = map[[2]string]float64{}
+)
+
+// RegisterExchangeRate adds or replaces the rate between two currencies
+func RegisterExchangeRate(r ExchangeRate) {
+	exchangeRatesMu.Lock()
+	defer exchangeRatesMu.Unlock()
+	exchangeRates[[2]string{strings.ToUpper(r.From), strings.ToUpper(r.To)}] = r.Rate
+}
+
+// LookupExchangeRate returns the rate converting from into to, using the inverse rate if
+// only that is registered
+func LookupExchangeRate(from, to string) (float64, bool) {
+	exchangeRatesMu.RLock()
+	defer exchangeRatesMu.RUnlock()
+
+	from, to = strings.ToUpper(from), strings.ToUpper(to)
+	if rate, ok := exchangeRates[[2]string{from, to}]; ok {
+		return rate, true
+	}
+	if rate, ok := exchangeRates[[2]string{to, from}]; ok && rate != 0 {
+		return 1 / rate, true
+	}
+	return 0, false
+}
+
+// Currency returns the currency of the tariffs configured in the database, empty if unknown
+func (c *Client) Currency(ctx context.Context) (string, error) {
+	return readCurrency(ctx, c.db)
+}
+
+// readCurrency returns the tariff currency, e.g. within a transaction
+func readCurrency(ctx context.Context, q querier) (string, error) {
+	if exists, err := tableExists(ctx, q, "settings"); err != nil || !exists {
+		return "", err
+	}
+
+	var tariffs string
+	err := q.QueryRowContext(ctx, "SELECT value FROM settings WHERE key = 'tariffs'").Scan(&tariffs)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read tariffs: %w", err)
+	}
+
+	if m := tariffCurrency.FindStringSubmatch(tariffs); m != nil {
+		return strings.ToUpper(m[1]), nil
+	}
+	return "", nil
+}
+
+// priceRate returns the factor for prices of currency from merged into a database using to,
+// 1 if either currency is unknown or both are the same. Without convert, differing
+// currencies are only reported.
+func priceRate(from, to string, convert bool) (float64, error) {
+	if from == "" || to == "" || strings.EqualFold(from, to) {
+		return 1, nil
+	}
+	if !convert {
+		fmt.Printf(Translate("WARNING: Prices in %s are merged into a database using %s, use --convert-currency to convert them\n"), from, to)
+		return 1, nil
+	}
+
+	rate, ok := LookupExchangeRate(from, to)
+	if !ok {
+		return 0, fmt.Errorf("no exchange rate from %s to %s, add it to exchange_rates of the config file", from, to)
+	}
+	fmt.Printf(Translate("Converting prices from %s to %s at %g\n"), from, to, rate)
+	return rate, nil
+}
+
+// mergePriceRate returns the factor for the prices of src merged into dst
+func mergePriceRate(ctx context.Context, src *Client, dst querier, convert bool) (float64, error) {
+	from, err := src.Currency(ctx)
+	if err != nil {
+		return 0, err
+	}
+	to, err := readCurrency(ctx, dst)
+	if err != nil {
+		return 0, err
+	}
+	return priceRate(from, to, convert)
+}
+
+// convertsPrices reports whether the session prices of the source are scaled
+func (opts TransferOptions) convertsPrices() bool {
+	return opts.priceRate != 0 && opts.priceRate != 1
+}
+
+// scaleRowPrices multiplies the prices of exported session rows by rate
+func scaleRowPrices(rows []any, rate float64) {
+	for _, row := range rows {
+		if m, ok := row.(map[string]any); ok {
+			for _, col := range priceColumns {
+				if val, ok := m[col]; ok {
+					m[col] = scalePrice(val, rate)
+				}
+			}
+		}
+	}
+}
+
+// scalePrice multiplies a numeric price by rate, other values are returned unchanged
+func scalePrice(val any, rate float64) any {
+	switch v := val.(type) {
+	case float64:
+		return v * rate
+	case int64:
+		return float64(v) * rate
+	default:
+		return val
+	}
+}
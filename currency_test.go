@@ -0,0 +1,68 @@
+package evccdb
+
+import (
+	"context"
+	"math"
+	"testing"
+)
+
+func TestCurrency(t *testing.T) {
+	client, cleanup := createTestDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	if currency, err := client.Currency(ctx); err != nil || currency != "" {
+		t.Fatalf("Expected unknown currency, got %q, %v", currency, err)
+	}
+
+	if _, err := client.db.Exec("INSERT INTO settings (key, value) VALUES ('tariffs', ?)", "currency: chf\ngrid:\n  type: fixed\n"); err != nil {
+		t.Fatal(err)
+	}
+	if currency, err := client.Currency(ctx); err != nil || currency != "CHF" {
+		t.Errorf("Expected CHF, got %q, %v", currency, err)
+	}
+
+	RegisterExchangeRate(ExchangeRate{From: "GBP", To: "EUR", Rate: 1.25})
+	if rate, ok := LookupExchangeRate("eur", "gbp"); !ok || rate != 0.8 {
+		t.Errorf("Expected inverse rate 0.8, got %v, %v", rate, ok)
+	}
+}
+
+func TestTransferConvertCurrency(t *testing.T) {
+	src, srcCleanup := createTestDB(t)
+	defer srcCleanup()
+	dst, dstCleanup := createTestDB(t)
+	defer dstCleanup()
+
+	ctx := context.Background()
+	if _, err := src.db.Exec("INSERT INTO settings (key, value) VALUES ('tariffs', 'currency: CHF'); UPDATE sessions SET price = 10, price_per_kwh = 0.4"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := dst.db.Exec("INSERT INTO settings (key, value) VALUES ('tariffs', 'currency: SEK')"); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		exchangeRatesMu.Lock()
+		defer exchangeRatesMu.Unlock()
+		delete(exchangeRates, [2]string{"CHF", "SEK"})
+	})
+
+	opts := TransferOptions{Tables: []string{"sessions"}, ConvertCurrency: true}
+	if err := Transfer(ctx, src, dst, opts); err == nil {
+		t.Fatal("Expected error without exchange rate")
+	}
+
+	RegisterExchangeRate(ExchangeRate{From: "CHF", To: "SEK", Rate: 12})
+	if err := Transfer(ctx, src, dst, opts); err != nil {
+		t.Fatalf("Transfer failed: %v", err)
+	}
+
+	var price, perKwh float64
+	if err := dst.db.QueryRow("SELECT price, price_per_kwh FROM sessions WHERE id = 1").Scan(&price, &perKwh); err != nil {
+		t.Fatal(err)
+	}
+	if price != 120 || math.Abs(perKwh-4.8) > 1e-9 {
+		t.Errorf("Expected converted prices 120 and 4.8, got %v and %v", price, perKwh)
+	}
+}
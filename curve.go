@@ -0,0 +1,82 @@
+package evccdb
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// PowerPoint is the average power of the meter reading interval ending at Time
+type PowerPoint struct {
+	Time time.Time
+	// Power is the average power of the interval in kW
+	Power float64
+	// Energy is the energy since the start of the curve in kWh
+	Energy float64
+}
+
+// SessionPowerCurve returns the charging curve of a session from the readings of the meter
+// of its loadpoint, whose id is the loadpoint index. A session without finish time is
+// still charging, its curve ends now.
+func (c *Client) SessionPowerCurve(ctx context.Context, sessionID int64) ([]PowerPoint, error) {
+	var loadpoint sql.NullString
+	var created, finished any
+	err := c.db.QueryRowContext(ctx, "SELECT loadpoint, created, finished FROM sessions WHERE id = ?", sessionID).
+		Scan(&loadpoint, &created, &finished)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("session %d not found", sessionID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query session %d: %w", sessionID, err)
+	}
+
+	start, ok := timestampOf(created)
+	if !ok {
+		return nil, fmt.Errorf("session %d has no start time", sessionID)
+	}
+	end, ok := timestampOf(finished)
+	if !ok {
+		end = time.Now().UTC()
+	}
+
+	indices, err := loadpointIndices(ctx, c.db, loadpoint.String)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve loadpoint indices: %w", err)
+	}
+	if len(indices) != 1 {
+		return nil, fmt.Errorf("cannot find the meter of loadpoint %q", loadpoint.String)
+	}
+
+	return c.MeterPowerCurve(ctx, int64(indices[0]), start, end)
+}
+
+// MeterPowerCurve returns the average power of the reading intervals of a meter that overlap
+// from and to. Each reading is the energy in kWh recorded since the previous reading.
+func (c *Client) MeterPowerCurve(ctx context.Context, meter int64, from, to time.Time) ([]PowerPoint, error) {
+	// The readings around the range give the length of the first and the end of the last interval
+	points, err := c.TimeSeries(ctx, fmt.Sprintf("meter.%d", meter), from.Add(-24*time.Hour), to.Add(24*time.Hour))
+	if err != nil {
+		return nil, err
+	}
+
+	var curve []PowerPoint
+	var energy float64
+	for i := 1; i < len(points); i++ {
+		prev, p := points[i-1], points[i]
+		if !p.Time.After(from) {
+			continue
+		}
+		if !prev.Time.Before(to) {
+			break
+		}
+		energy += p.Value
+		curve = append(curve, PowerPoint{
+			Time:   p.Time,
+			Power:  p.Value / p.Time.Sub(prev.Time).Hours(),
+			Energy: energy,
+		})
+	}
+	return curve, nil
+}
@@ -0,0 +1,55 @@
+package evccdb
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSessionPowerCurve(t *testing.T) {
+	client, cleanup := createTestDB(t)
+	defer cleanup()
+
+	// Garage is loadpoint 1, charging 11 kW from 10:00 to 11:00 in 15 minute readings
+	_, _ = client.db.Exec("UPDATE sessions SET finished = '2023-04-01 11:00:00' WHERE id = 1")
+	start := time.Date(2023, 4, 1, 9, 30, 0, 0, time.UTC)
+	var values []string
+	for i := 0; i <= 8; i++ {
+		ts := start.Add(time.Duration(i) * 15 * time.Minute)
+		val := 0.0
+		if ts.After(start.Add(30*time.Minute)) && !ts.After(start.Add(90*time.Minute)) {
+			val = 2.75
+		}
+		values = append(values, fmt.Sprintf("(1, '%s', %g)", ts.Format("2006-01-02 15:04:05"), val))
+	}
+	if _, err := client.db.Exec("INSERT INTO meters (meter, ts, val) VALUES " + strings.Join(values, ",")); err != nil {
+		t.Fatal(err)
+	}
+
+	curve, err := client.SessionPowerCurve(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("SessionPowerCurve failed: %v", err)
+	}
+	if len(curve) != 4 {
+		t.Fatalf("Expected 4 intervals, got %+v", curve)
+	}
+	for _, p := range curve {
+		if math.Abs(p.Power-11) > 1e-9 {
+			t.Errorf("Expected 11 kW at %s, got %v", p.Time, p.Power)
+		}
+	}
+	if last := curve[3]; !last.Time.Equal(start.Add(90*time.Minute)) || math.Abs(last.Energy-11) > 1e-9 {
+		t.Errorf("Expected 11 kWh at 11:00, got %+v", last)
+	}
+
+	// eBikes has no meter readings, unknown sessions fail
+	if curve, err := client.SessionPowerCurve(context.Background(), 4); err != nil || len(curve) != 0 {
+		t.Errorf("Expected an empty curve, got %+v: %v", curve, err)
+	}
+	if _, err := client.SessionPowerCurve(context.Background(), 99); err == nil {
+		t.Error("Expected error for unknown session")
+	}
+}
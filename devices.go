@@ -0,0 +1,220 @@
+package evccdb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// dbRefPrefix prefixes references to devices stored in the configs table
+const dbRefPrefix = "db:"
+
+// deviceRefKeys maps config value keys referencing other devices to the referenced class
+var deviceRefKeys = map[string]ConfigClass{
+	"charger": ClassCharger,
+	"meter":   ClassMeter,
+	"vehicle": ClassVehicle,
+	"circuit": ClassCircuit,
+	"parent":  ClassCircuit,
+}
+
+// siteMeterSettings are the settings keys holding comma-separated meter references
+var siteMeterSettings = []string{"gridMeter", "pvMeters", "batteryMeters", "auxMeters", "extMeters"}
+
+// DeviceRef is a reference of a config or setting to a device
+type DeviceRef struct {
+	// ConfigID is the referencing config, 0 for references stored in settings
+	ConfigID int
+	// Key is the config value key or settings key holding the reference
+	Key   string
+	Ref   string
+	Class ConfigClass
+}
+
+// Source describes where the reference is stored
+func (r DeviceRef) Source() string {
+	if r.ConfigID == 0 {
+		return "setting " + r.Key
+	}
+	return fmt.Sprintf("config %s%d %s", dbRefPrefix, r.ConfigID, r.Key)
+}
+
+// OrphanRef is a reference to a device that does not exist
+type OrphanRef struct {
+	DeviceRef
+	Reason string
+}
+
+// DeviceGraph holds the device configs and the references between them
+type DeviceGraph struct {
+	Devices map[int]Config
+	Refs    []DeviceRef
+}
+
+// DeviceGraph loads the configs and the device references of configs and site settings
+func (c *Client) DeviceGraph(ctx context.Context) (*DeviceGraph, error) {
+	g := &DeviceGraph{Devices: make(map[int]Config)}
+
+	rows, err := c.db.QueryContext(ctx, "SELECT id, class, type, value FROM configs ORDER BY id")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query configs: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	for rows.Next() {
+		var cfg Config
+		if err := rows.Scan(&cfg.ID, &cfg.Class, &cfg.Type, &cfg.Value); err != nil {
+			return nil, fmt.Errorf("failed to scan config: %w", err)
+		}
+
+		// Values that are no JSON objects cannot reference devices
+		var data map[string]any
+		if json.Unmarshal([]byte(cfg.Value), &data) == nil {
+			cfg.Title, _ = data["title"].(string)
+			for key, class := range deviceRefKeys {
+				if ref, ok := data[key].(string); ok && ref != "" {
+					g.Refs = append(g.Refs, DeviceRef{ConfigID: cfg.ID, Key: key, Ref: ref, Class: class})
+				}
+			}
+		}
+		g.Devices[cfg.ID] = cfg
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, key := range siteMeterSettings {
+		var value string
+		err := c.db.QueryRowContext(ctx, "SELECT value FROM settings WHERE key = ?", key).Scan(&value)
+		if err != nil {
+			continue
+		}
+		for _, ref := range splitRefs(value) {
+			g.Refs = append(g.Refs, DeviceRef{Key: key, Ref: ref, Class: ClassMeter})
+		}
+	}
+
+	sort.SliceStable(g.Refs, func(i, j int) bool {
+		if g.Refs[i].ConfigID != g.Refs[j].ConfigID {
+			return g.Refs[i].ConfigID < g.Refs[j].ConfigID
+		}
+		return g.Refs[i].Key < g.Refs[j].Key
+	})
+
+	return g, nil
+}
+
+// DevicesOfClass returns the devices of a class ordered by id
+func (g *DeviceGraph) DevicesOfClass(class ConfigClass) []Config {
+	var devices []Config
+	for _, cfg := range g.Devices {
+		if cfg.Class == class {
+			devices = append(devices, cfg)
+		}
+	}
+	sort.Slice(devices, func(i, j int) bool { return devices[i].ID < devices[j].ID })
+	return devices
+}
+
+// Orphans returns the references to deleted devices or devices of the wrong class.
+// References by name point to devices of evcc.yaml and are not checked.
+func (g *DeviceGraph) Orphans() []OrphanRef {
+	var orphans []OrphanRef
+	for _, ref := range g.Refs {
+		if !strings.HasPrefix(ref.Ref, dbRefPrefix) {
+			continue
+		}
+
+		id, err := strconv.Atoi(strings.TrimPrefix(ref.Ref, dbRefPrefix))
+		if err != nil {
+			orphans = append(orphans, OrphanRef{DeviceRef: ref, Reason: "invalid device reference"})
+			continue
+		}
+
+		cfg, ok := g.Devices[id]
+		switch {
+		case !ok:
+			orphans = append(orphans, OrphanRef{DeviceRef: ref, Reason: fmt.Sprintf("%s %s does not exist", ref.Class, ref.Ref)})
+		case cfg.Class != ref.Class:
+			orphans = append(orphans, OrphanRef{DeviceRef: ref, Reason: fmt.Sprintf("%s is a %s, not a %s", ref.Ref, cfg.Class, ref.Class)})
+		}
+	}
+	return orphans
+}
+
+// FixOrphan replaces the orphaned reference with another device reference,
+// an empty replacement removes the reference
+func (c *Client) FixOrphan(ctx context.Context, orphan OrphanRef, replacement string) error {
+	if orphan.ConfigID == 0 {
+		return c.fixSettingRef(ctx, orphan.DeviceRef, replacement)
+	}
+
+	var value string
+	if err := c.db.QueryRowContext(ctx, "SELECT value FROM configs WHERE id = ?", orphan.ConfigID).Scan(&value); err != nil {
+		return fmt.Errorf("failed to read config %d: %w", orphan.ConfigID, err)
+	}
+
+	var data map[string]any
+	if err := json.Unmarshal([]byte(value), &data); err != nil {
+		return fmt.Errorf("failed to parse config %d: %w", orphan.ConfigID, err)
+	}
+
+	if replacement == "" {
+		delete(data, orphan.Key)
+	} else {
+		data[orphan.Key] = replacement
+	}
+
+	b, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	if _, err := c.db.ExecContext(ctx, "UPDATE configs SET value = ? WHERE id = ?", string(b), orphan.ConfigID); err != nil {
+		return fmt.Errorf("failed to update config %d: %w", orphan.ConfigID, err)
+	}
+	return nil
+}
+
+// fixSettingRef replaces or removes a meter reference in a site settings list
+func (c *Client) fixSettingRef(ctx context.Context, ref DeviceRef, replacement string) error {
+	var value string
+	if err := c.db.QueryRowContext(ctx, "SELECT value FROM settings WHERE key = ?", ref.Key).Scan(&value); err != nil {
+		return fmt.Errorf("failed to read setting %s: %w", ref.Key, err)
+	}
+
+	var refs []string
+	for _, r := range splitRefs(value) {
+		switch {
+		case r != ref.Ref:
+			refs = append(refs, r)
+		case replacement != "":
+			refs = append(refs, replacement)
+		}
+	}
+
+	var err error
+	if len(refs) == 0 {
+		_, err = c.db.ExecContext(ctx, "DELETE FROM settings WHERE key = ?", ref.Key)
+	} else {
+		_, err = c.db.ExecContext(ctx, "UPDATE settings SET value = ? WHERE key = ?", strings.Join(refs, ","), ref.Key)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update setting %s: %w", ref.Key, err)
+	}
+	return nil
+}
+
+// splitRefs splits a comma-separated list of device references
+func splitRefs(s string) []string {
+	var refs []string
+	for _, ref := range strings.Split(s, ",") {
+		if ref = strings.TrimSpace(ref); ref != "" {
+			refs = append(refs, ref)
+		}
+	}
+	return refs
+}
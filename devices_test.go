@@ -0,0 +1,77 @@
+package evccdb
+
+import (
+	"context"
+	"testing"
+)
+
+func TestDeviceGraphOrphans(t *testing.T) {
+	client, cleanup := createTestDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	_, err := client.db.Exec(`
+		INSERT INTO configs (id, class, type, value) VALUES
+			(3, 1, 'template', '{"template":"go-e"}'),
+			(4, 2, 'template', '{"template":"shelly"}'),
+			(5, 5, 'template', '{"title":"Carport","charger":"db:3","meter":"db:7","vehicle":"e-Golf"}');
+		INSERT INTO settings (key, value) VALUES ('gridMeter', 'db:4'), ('pvMeters', 'db:4,db:8,pv');
+	`)
+	if err != nil {
+		t.Fatalf("Failed to insert configs: %v", err)
+	}
+
+	g, err := client.DeviceGraph(ctx)
+	if err != nil {
+		t.Fatalf("DeviceGraph failed: %v", err)
+	}
+
+	orphans := g.Orphans()
+	got := make(map[string]string)
+	for _, o := range orphans {
+		got[o.Source()] = o.Reason
+	}
+
+	want := map[string]string{
+		"config db:1 charger": "db:1 is a loadpoint, not a charger",
+		"config db:5 meter":   "meter db:7 does not exist",
+		"setting pvMeters":    "meter db:8 does not exist",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("Expected %d orphans, got %v", len(want), got)
+	}
+	for source, reason := range want {
+		if got[source] != reason {
+			t.Errorf("Orphan %s: expected %q, got %q", source, reason, got[source])
+		}
+	}
+
+	if chargers := g.DevicesOfClass(ClassCharger); len(chargers) != 1 || chargers[0].ID != 3 {
+		t.Errorf("Unexpected chargers: %v", chargers)
+	}
+
+	for _, o := range orphans {
+		replacement := ""
+		if o.Key == "charger" {
+			replacement = "db:3"
+		}
+		if err := client.FixOrphan(ctx, o, replacement); err != nil {
+			t.Fatalf("FixOrphan %s failed: %v", o.Source(), err)
+		}
+	}
+
+	if g, err = client.DeviceGraph(ctx); err != nil {
+		t.Fatalf("DeviceGraph failed: %v", err)
+	}
+	if orphans := g.Orphans(); len(orphans) != 0 {
+		t.Errorf("Expected no orphans after fix, got %v", orphans)
+	}
+
+	var pvMeters string
+	if err := client.db.QueryRow("SELECT value FROM settings WHERE key = 'pvMeters'").Scan(&pvMeters); err != nil {
+		t.Fatalf("Failed to read pvMeters: %v", err)
+	}
+	if pvMeters != "db:4,pv" {
+		t.Errorf("Expected pvMeters db:4,pv, got %q", pvMeters)
+	}
+}
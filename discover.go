@@ -0,0 +1,178 @@
+package evccdb
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DiscoveredDatabase is an evcc database file found by DiscoverDatabases
+type DiscoveredDatabase struct {
+	Path string `json:"path"`
+	// Source tells where the path was found, e.g. a systemd unit or a standard location
+	Source   string    `json:"source"`
+	Size     int64     `json:"size"`
+	Modified time.Time `json:"modified"`
+}
+
+// DiscoverOptions configures DiscoverDatabases
+type DiscoverOptions struct {
+	// Root is the directory the searched paths are relative to, empty means /
+	Root string
+	// Home is the home directory of the user, empty means the home of the current user
+	Home string
+}
+
+// systemdUnitDirs are the directories of systemd unit files in order of precedence
+var systemdUnitDirs = []string{"/etc/systemd/system", "/run/systemd/system", "/lib/systemd/system", "/usr/lib/systemd/system"}
+
+// databaseLocations are the paths evcc installations use by default, with the kind of
+// installation they belong to
+var databaseLocations = []struct{ pattern, source string }{
+	{"/var/lib/evcc/evcc.db", "standard location"},
+	{"~/.evcc/evcc.db", "home directory"},
+	{"/var/lib/docker/volumes/*/_data/evcc.db", "docker volume"},
+	{"/var/lib/docker/volumes/*/_data/.evcc/evcc.db", "docker volume"},
+	{"/mnt/data/supervisor/addons/data/*evcc*/evcc.db", "Home Assistant add-on"},
+	{"/usr/share/hassio/addons/data/*evcc*/evcc.db", "Home Assistant add-on"},
+	{"/data/evcc.db", "Home Assistant add-on"},
+}
+
+// DiscoverDatabases looks for evcc databases in the paths of evcc systemd units and in the
+// default locations of packages, docker volumes and the Home Assistant add-on. Each file is
+// listed once, in the order of these sources.
+func DiscoverDatabases(opts DiscoverOptions) []DiscoveredDatabase {
+	root := opts.Root
+	if root == "" {
+		root = "/"
+	}
+	home := opts.Home
+	if home == "" {
+		home, _ = os.UserHomeDir()
+	}
+
+	var found []DiscoveredDatabase
+	seen := make(map[string]bool)
+	add := func(path, source string) {
+		info, err := os.Stat(path)
+		if err != nil || !info.Mode().IsRegular() {
+			return
+		}
+		key := path
+		if real, err := filepath.EvalSymlinks(path); err == nil {
+			key = real
+		}
+		if seen[key] {
+			return
+		}
+		seen[key] = true
+		found = append(found, DiscoveredDatabase{Path: path, Source: source, Size: info.Size(), Modified: info.ModTime()})
+	}
+
+	for _, unit := range systemdUnits(root) {
+		for _, path := range unitDatabases(root, unit) {
+			add(filepath.Join(root, path), "systemd unit "+filepath.Base(unit))
+		}
+	}
+
+	for _, loc := range databaseLocations {
+		pattern := loc.pattern
+		if strings.HasPrefix(pattern, "~/") {
+			if home == "" {
+				continue
+			}
+			pattern = filepath.Join(home, pattern[2:])
+		}
+		matches, _ := filepath.Glob(filepath.Join(root, pattern))
+		sort.Strings(matches)
+		for _, path := range matches {
+			add(path, loc.source)
+		}
+	}
+	return found
+}
+
+// systemdUnits returns the evcc service units and their drop-ins below root
+func systemdUnits(root string) []string {
+	var units []string
+	for _, dir := range systemdUnitDirs {
+		for _, pattern := range []string{"evcc*.service", "evcc*.service.d/*.conf"} {
+			matches, _ := filepath.Glob(filepath.Join(root, dir, pattern))
+			sort.Strings(matches)
+			units = append(units, matches...)
+		}
+	}
+	return units
+}
+
+// unitDatabases returns the database paths a systemd unit passes to evcc: the --database
+// flag, the EVCC_DATABASE_DSN variable, or the database of the config file given by --config
+func unitDatabases(root, unit string) []string {
+	f, err := os.Open(unit)
+	if err != nil {
+		return nil
+	}
+	defer func() { _ = f.Close() }()
+
+	var paths, configs []string
+	var workDir string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(strings.TrimSpace(scanner.Text()), "=")
+		if !ok {
+			continue
+		}
+		switch strings.TrimSpace(key) {
+		case "WorkingDirectory":
+			workDir = strings.TrimPrefix(strings.TrimSpace(value), "-")
+		case "Environment":
+			for _, field := range strings.Fields(value) {
+				if dsn, ok := strings.CutPrefix(strings.Trim(field, `"'`), "EVCC_DATABASE_DSN="); ok {
+					paths = append(paths, dsn)
+				}
+			}
+		case "ExecStart":
+			args := strings.Fields(value)
+			for i, arg := range args {
+				name, val, hasVal := strings.Cut(arg, "=")
+				if !hasVal && i+1 < len(args) {
+					val = args[i+1]
+				}
+				switch name {
+				case "--database":
+					paths = append(paths, strings.Trim(val, `"'`))
+				case "-c", "--config":
+					configs = append(configs, strings.Trim(val, `"'`))
+				}
+			}
+		}
+	}
+
+	for _, config := range configs {
+		if !filepath.IsAbs(config) && workDir != "" {
+			config = filepath.Join(workDir, config)
+		}
+		data, err := os.ReadFile(filepath.Join(root, config))
+		if err != nil {
+			continue
+		}
+		var cfg struct {
+			Database string `yaml:"database"`
+		}
+		if yaml.Unmarshal(data, &cfg) == nil && cfg.Database != "" {
+			paths = append(paths, cfg.Database)
+		}
+	}
+
+	for i, path := range paths {
+		if !filepath.IsAbs(path) && workDir != "" {
+			paths[i] = filepath.Join(workDir, path)
+		}
+	}
+	return paths
+}
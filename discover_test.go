@@ -0,0 +1,68 @@
+package evccdb
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDiscoverDatabases(t *testing.T) {
+	root := t.TempDir()
+	write := func(path, content string) {
+		t.Helper()
+		path = filepath.Join(root, path)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write("/etc/systemd/system/evcc.service", `[Service]
+WorkingDirectory=/opt/evcc
+ExecStart=/usr/bin/evcc --config evcc.yaml
+`)
+	write("/opt/evcc/evcc.yaml", "network:\n  port: 7070\ndatabase: /srv/evcc/evcc.db\n")
+	write("/srv/evcc/evcc.db", "db")
+	write("/etc/systemd/system/evcc-test.service.d/override.conf", `[Service]
+Environment="EVCC_DATABASE_DSN=/srv/test.db"
+`)
+	write("/srv/test.db", "db")
+	write("/var/lib/evcc/evcc.db", "db")
+	write("/home/user/.evcc/evcc.db", "db")
+	write("/var/lib/docker/volumes/evcc/_data/evcc.db", "db")
+	write("/mnt/data/supervisor/addons/data/49686a9f_evcc/evcc.db", "db")
+	// The unit points to a standard location, which must be listed only once
+	write("/lib/systemd/system/evcc.service", "[Service]\nExecStart=/usr/bin/evcc --database=/var/lib/evcc/evcc.db\n")
+	// Directories are no databases
+	if err := os.MkdirAll(filepath.Join(root, "/data/evcc.db"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	found := DiscoverDatabases(DiscoverOptions{Root: root, Home: "/home/user"})
+
+	expected := []struct{ path, source string }{
+		{"/srv/evcc/evcc.db", "systemd unit evcc.service"},
+		{"/srv/test.db", "systemd unit override.conf"},
+		{"/var/lib/evcc/evcc.db", "systemd unit evcc.service"},
+		{"/home/user/.evcc/evcc.db", "home directory"},
+		{"/var/lib/docker/volumes/evcc/_data/evcc.db", "docker volume"},
+		{"/mnt/data/supervisor/addons/data/49686a9f_evcc/evcc.db", "Home Assistant add-on"},
+	}
+	if len(found) != len(expected) {
+		t.Fatalf("Expected %d databases, got %+v", len(expected), found)
+	}
+	for i, e := range expected {
+		if found[i].Path != filepath.Join(root, e.path) || found[i].Source != e.source {
+			t.Errorf("Expected %s from %s at %d, got %+v", e.path, e.source, i, found[i])
+		}
+		if found[i].Size != 2 {
+			t.Errorf("Expected size 2 of %s, got %d", e.path, found[i].Size)
+		}
+	}
+
+	if found := DiscoverDatabases(DiscoverOptions{Root: t.TempDir(), Home: "/home/user"}); len(found) != 0 {
+		t.Errorf("Expected no databases, got %+v", found)
+	}
+}
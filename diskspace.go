@@ -0,0 +1,94 @@
+package evccdb
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// ErrInsufficientSpace is returned when the filesystem of a file to write has too little free space
+var ErrInsufficientSpace = errors.New("not enough free disk space")
+
+// errFreeSpaceUnsupported is returned by freeSpace on platforms without statfs
+var errFreeSpaceUnsupported = errors.New("free disk space not supported on this platform")
+
+// FreeSpace returns the bytes available to the user on the filesystem that holds path. The
+// path does not need to exist, its nearest existing parent directory is used.
+func FreeSpace(path string) (int64, error) {
+	dir, err := filepath.Abs(path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to resolve %s: %w", path, err)
+	}
+	for {
+		if _, err := os.Stat(dir); err == nil {
+			break
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+	return freeSpace(dir)
+}
+
+// CheckFreeSpace returns ErrInsufficientSpace with the required and available sizes if the
+// filesystem of path has less than required bytes free. It passes on platforms that cannot
+// report the free space.
+func CheckFreeSpace(path string, required int64) error {
+	available, err := FreeSpace(path)
+	if errors.Is(err, errFreeSpaceUnsupported) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if available < required {
+		return fmt.Errorf("%w for %s: %s required, %s available", ErrInsufficientSpace, path, FormatSize(required), FormatSize(available))
+	}
+	return nil
+}
+
+// FileSize returns the size of the database file in bytes, free pages included
+func (c *Client) FileSize() (int64, error) {
+	pages, err := c.pragmaInt("page_count")
+	if err != nil {
+		return 0, err
+	}
+	pageSize, err := c.PageSize()
+	if err != nil {
+		return 0, err
+	}
+	return int64(pages) * int64(pageSize), nil
+}
+
+// UsedSize returns the bytes of the database file in use, which a VACUUM INTO copy needs
+func (c *Client) UsedSize() (int64, error) {
+	pages, err := c.pragmaInt("page_count")
+	if err != nil {
+		return 0, err
+	}
+	free, err := c.pragmaInt("freelist_count")
+	if err != nil {
+		return 0, err
+	}
+	pageSize, err := c.PageSize()
+	if err != nil {
+		return 0, err
+	}
+	return int64(pages-free) * int64(pageSize), nil
+}
+
+// FormatSize formats a size in bytes with the decimal units of KB, MB and GB
+func FormatSize(n int64) string {
+	for _, u := range []struct {
+		suffix string
+		size   float64
+	}{{"GB", 1e9}, {"MB", 1e6}, {"KB", 1e3}} {
+		if float64(n) >= u.size {
+			return fmt.Sprintf("%.1f%s", float64(n)/u.size, u.suffix)
+		}
+	}
+	return fmt.Sprintf("%dB", n)
+}
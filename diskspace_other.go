@@ -0,0 +1,8 @@
+//go:build !linux && !darwin && !freebsd
+
+package evccdb
+
+// freeSpace is not supported without statfs, CheckFreeSpace then skips the check
+func freeSpace(dir string) (int64, error) {
+	return 0, errFreeSpaceUnsupported
+}
@@ -0,0 +1,17 @@
+//go:build linux || darwin || freebsd
+
+package evccdb
+
+import (
+	"fmt"
+	"syscall"
+)
+
+// freeSpace returns the bytes available to unprivileged users on the filesystem of dir
+func freeSpace(dir string) (int64, error) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(dir, &st); err != nil {
+		return 0, fmt.Errorf("failed to read free space of %s: %w", dir, err)
+	}
+	return int64(st.Bavail) * int64(st.Bsize), nil
+}
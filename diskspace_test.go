@@ -0,0 +1,61 @@
+package evccdb
+
+import (
+	"context"
+	"errors"
+	"math"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestCheckFreeSpace(t *testing.T) {
+	if runtime.GOOS != "linux" && runtime.GOOS != "darwin" && runtime.GOOS != "freebsd" {
+		t.Skip("Free disk space not supported")
+	}
+
+	path := filepath.Join(t.TempDir(), "missing", "export.json")
+	free, err := FreeSpace(path)
+	if err != nil || free <= 0 {
+		t.Fatalf("Expected free space of a missing file's parent, got %d, %v", free, err)
+	}
+	if err := CheckFreeSpace(path, 1); err != nil {
+		t.Errorf("Expected 1 byte to fit, got %v", err)
+	}
+	if err := CheckFreeSpace(path, math.MaxInt64); !errors.Is(err, ErrInsufficientSpace) {
+		t.Errorf("Expected ErrInsufficientSpace, got %v", err)
+	}
+
+	client, cleanup := createTestDB(t)
+	defer cleanup()
+	err = client.Backup(context.Background(), filepath.Join(t.TempDir(), "backup.db"))
+	if err != nil {
+		t.Errorf("Backup failed: %v", err)
+	}
+}
+
+func TestDatabaseSize(t *testing.T) {
+	client, cleanup := createTestDB(t)
+	defer cleanup()
+
+	if _, err := client.db.Exec("DELETE FROM sessions"); err != nil {
+		t.Fatal(err)
+	}
+	fileSize, err := client.FileSize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	usedSize, err := client.UsedSize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if usedSize <= 0 || usedSize > fileSize {
+		t.Errorf("Expected used size within the file size %d, got %d", fileSize, usedSize)
+	}
+
+	for n, expected := range map[int64]string{512: "512B", 45100: "45.1KB", 1_500_000: "1.5MB", 2e9: "2.0GB"} {
+		if s := FormatSize(n); s != expected {
+			t.Errorf("Expected %s for %d, got %s", expected, n, s)
+		}
+	}
+}
@@ -0,0 +1,8 @@
+// Package evccdb reads, exports, transfers and rewrites evcc SQLite databases.
+//
+// The exported API follows semantic versioning: within a major version, exported
+// identifiers are not removed or changed incompatibly, and new fields of option
+// structs default to the previous behavior. A breaking change is released under a
+// new module path, e.g. github.com/iseeberg79/evccdb/v2. The SQL helpers in
+// internal/ and the cmd/evccdb command are not part of this promise.
+package evccdb
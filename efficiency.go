@@ -0,0 +1,160 @@
+package evccdb
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// DefaultOutlierFactor is the multiple of the interquartile range outside which consumption
+// values are outliers, e.g. after charging elsewhere
+const DefaultOutlierFactor = 1.5
+
+// EfficiencyOptions configures VehicleEfficiency
+type EfficiencyOptions struct {
+	// OutlierFactor widens the accepted range of consumption values, 0 means DefaultOutlierFactor
+	// and a negative factor keeps all values
+	OutlierFactor float64
+	// Location is the time zone of the months, nil means the local time zone
+	Location *time.Location
+}
+
+// Efficiency is the energy consumption of a vehicle in a month
+type Efficiency struct {
+	Vehicle string `json:"vehicle"`
+	// Month is formatted as 2006-01
+	Month       string  `json:"month"`
+	DistanceKm  float64 `json:"distance_km"`
+	ChargedKwh  float64 `json:"charged_kwh"`
+	KwhPer100km float64 `json:"kwh_per_100km"`
+	// Intervals is the number of distances between odometer readings, Outliers the number
+	// of them that were left out
+	Intervals int `json:"intervals"`
+	Outliers  int `json:"outliers"`
+}
+
+// efficiencyInterval is the distance driven before a session and the energy charged to cover it
+type efficiencyInterval struct {
+	end      time.Time
+	distance float64
+	kwh      float64
+}
+
+// VehicleEfficiency computes the consumption per vehicle and month from the odometer of the
+// sessions. The energy charged up to a session with odometer is the energy of the distance
+// driven since the previous session with odometer. Months without accepted distances are
+// left out, results are ordered by vehicle and month.
+func (c *Client) VehicleEfficiency(ctx context.Context, opts EfficiencyOptions) ([]Efficiency, error) {
+	if opts.OutlierFactor == 0 {
+		opts.OutlierFactor = DefaultOutlierFactor
+	}
+	if opts.Location == nil {
+		opts.Location = time.Local
+	}
+
+	rows, err := c.db.QueryContext(ctx, `
+		SELECT vehicle, created, odometer, COALESCE(charged_kwh, 0)
+		FROM sessions
+		WHERE vehicle IS NOT NULL AND vehicle != '' AND created IS NOT NULL`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query sessions: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	type session struct {
+		created  time.Time
+		odometer *float64
+		kwh      float64
+	}
+	byVehicle := make(map[string][]session)
+	for rows.Next() {
+		var vehicle string
+		var created any
+		var s session
+		if err := rows.Scan(&vehicle, &created, &s.odometer, &s.kwh); err != nil {
+			return nil, fmt.Errorf("failed to scan session: %w", err)
+		}
+		if t, ok := timestampOf(created); ok {
+			s.created = t
+			byVehicle[vehicle] = append(byVehicle[vehicle], s)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	vehicles := make([]string, 0, len(byVehicle))
+	for vehicle := range byVehicle {
+		vehicles = append(vehicles, vehicle)
+	}
+	sort.Strings(vehicles)
+
+	var result []Efficiency
+	for _, vehicle := range vehicles {
+		sessions := byVehicle[vehicle]
+		sort.SliceStable(sessions, func(i, j int) bool { return sessions[i].created.Before(sessions[j].created) })
+
+		var intervals []efficiencyInterval
+		var last *float64
+		var kwh float64
+		for _, s := range sessions {
+			kwh += s.kwh
+			if s.odometer == nil || *s.odometer <= 0 {
+				continue
+			}
+			if last != nil && *s.odometer > *last && kwh > 0 {
+				intervals = append(intervals, efficiencyInterval{s.created, *s.odometer - *last, kwh})
+			}
+			last, kwh = s.odometer, 0
+		}
+
+		accepted := filterEfficiencyOutliers(intervals, opts.OutlierFactor)
+
+		var months []*Efficiency
+		for i, iv := range intervals {
+			name := iv.end.In(opts.Location).Format("2006-01")
+			if len(months) == 0 || months[len(months)-1].Month != name {
+				months = append(months, &Efficiency{Vehicle: vehicle, Month: name})
+			}
+			m := months[len(months)-1]
+			m.Intervals++
+			if !accepted[i] {
+				m.Outliers++
+				continue
+			}
+			m.DistanceKm += iv.distance
+			m.ChargedKwh += iv.kwh
+		}
+		for _, m := range months {
+			if m.DistanceKm > 0 {
+				m.KwhPer100km = 100 * m.ChargedKwh / m.DistanceKm
+				result = append(result, *m)
+			}
+		}
+	}
+	return result, nil
+}
+
+// filterEfficiencyOutliers reports which intervals have a consumption within the Tukey fences
+// of all intervals. Fewer than four intervals give no quartiles and are all accepted.
+func filterEfficiencyOutliers(intervals []efficiencyInterval, factor float64) []bool {
+	accepted := make([]bool, len(intervals))
+	values := make([]float64, len(intervals))
+	for i, iv := range intervals {
+		accepted[i] = true
+		values[i] = iv.kwh / iv.distance
+	}
+	if factor < 0 || len(values) < 4 {
+		return accepted
+	}
+
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	q1, q3 := sorted[len(sorted)/4], sorted[len(sorted)*3/4]
+	low, high := q1-factor*(q3-q1), q3+factor*(q3-q1)
+	for i, v := range values {
+		accepted[i] = v >= low && v <= high
+	}
+	return accepted
+}
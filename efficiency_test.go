@@ -0,0 +1,58 @@
+package evccdb
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestVehicleEfficiency(t *testing.T) {
+	client, cleanup := createTestDB(t)
+	defer cleanup()
+
+	// 100 km and 18 kWh between weekly sessions, one session charged elsewhere before
+	_, _ = client.db.Exec("DELETE FROM sessions")
+	start := time.Date(2024, 1, 1, 18, 0, 0, 0, time.UTC)
+	var values []string
+	for i := 0; i < 8; i++ {
+		odometer, kwh := fmt.Sprint(1000+100*i), 18.0
+		switch i {
+		case 3:
+			odometer = "NULL"
+		case 4:
+			kwh = 4
+		}
+		values = append(values, fmt.Sprintf("('%s', 'e-Golf', %s, %g)", start.AddDate(0, 0, 7*i).Format("2006-01-02 15:04:05"), odometer, kwh))
+	}
+	if _, err := client.db.Exec("INSERT INTO sessions (created, vehicle, odometer, charged_kwh) VALUES " + strings.Join(values, ",")); err != nil {
+		t.Fatal(err)
+	}
+
+	efficiency, err := client.VehicleEfficiency(context.Background(), EfficiencyOptions{Location: time.UTC})
+	if err != nil {
+		t.Fatalf("VehicleEfficiency failed: %v", err)
+	}
+	if len(efficiency) != 2 {
+		t.Fatalf("Expected 2 months, got %+v", efficiency)
+	}
+	// The session without odometer adds its energy to the next distance
+	jan, feb := efficiency[0], efficiency[1]
+	if jan.Month != "2024-01" || jan.Intervals != 3 || jan.Outliers != 1 || jan.DistanceKm != 200 || math.Abs(jan.KwhPer100km-18) > 1e-9 {
+		t.Errorf("Unexpected January %+v", jan)
+	}
+	if feb.Month != "2024-02" || feb.Intervals != 3 || feb.Outliers != 0 || math.Abs(feb.KwhPer100km-18) > 1e-9 {
+		t.Errorf("Unexpected February %+v", feb)
+	}
+
+	// Without filter the session charged elsewhere lowers the consumption
+	efficiency, err = client.VehicleEfficiency(context.Background(), EfficiencyOptions{Location: time.UTC, OutlierFactor: -1})
+	if err != nil {
+		t.Fatalf("VehicleEfficiency failed: %v", err)
+	}
+	if jan := efficiency[0]; jan.Outliers != 0 || jan.KwhPer100km >= 18 {
+		t.Errorf("Expected no outliers, got %+v", jan)
+	}
+}
@@ -0,0 +1,67 @@
+// Package evccdbtest provides in-memory evcc databases for the tests of applications using evccdb
+package evccdbtest
+
+import (
+	"testing"
+
+	"github.com/iseeberg79/evccdb"
+)
+
+// DB is an in-memory evcc database. It embeds the Client and thereby implements evccdb.Store.
+type DB struct {
+	*evccdb.Client
+	t testing.TB
+}
+
+// New returns an empty in-memory database with the evcc schema, closed when the test ends
+func New(t testing.TB) *DB {
+	t.Helper()
+
+	client, err := evccdb.OpenMemory(0)
+	if err != nil {
+		t.Fatalf("failed to open in-memory database: %v", err)
+	}
+	t.Cleanup(func() { _ = client.Close() })
+
+	return &DB{Client: client, t: t}
+}
+
+// Exec runs a statement, e.g. to insert fixtures, failing the test on error
+func (db *DB) Exec(query string, args ...any) {
+	db.t.Helper()
+	if _, err := db.DB().Exec(query, args...); err != nil {
+		db.t.Fatalf("failed to execute %q: %v", query, err)
+	}
+	db.InvalidateSchema()
+}
+
+// AddSetting inserts or replaces a setting
+func (db *DB) AddSetting(key, value string) {
+	db.t.Helper()
+	db.Exec("INSERT OR REPLACE INTO settings (key, value) VALUES (?, ?)", key, value)
+}
+
+// AddConfig inserts a device config, a zero ID is assigned automatically
+func (db *DB) AddConfig(c evccdb.Config) {
+	db.t.Helper()
+	db.Exec("INSERT INTO configs (id, class, type, value, title, icon, product) VALUES (?, ?, ?, ?, ?, ?, ?)",
+		autoID(c.ID), int(c.Class), c.Type, c.Value, c.Title, c.Icon, c.Product)
+}
+
+// AddSession inserts a charging session, a zero ID is assigned automatically
+func (db *DB) AddSession(s evccdb.Session) {
+	db.t.Helper()
+	db.Exec(`INSERT INTO sessions (id, created, finished, loadpoint, identifier, vehicle, odometer, meter_start_kwh,
+		meter_end_kwh, charged_kwh, solar_percentage, price, price_per_kwh, co2_per_kwh, charge_duration)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		autoID(s.ID), s.Created, s.Finished, s.Loadpoint, s.Identifier, s.Vehicle, s.OdometerStart, s.MeterStartKwh,
+		s.MeterEndKwh, s.ChargedKwh, s.SolarPercentage, s.Price, s.PricePerKwh, s.Co2PerKwh, s.ChargeDuration)
+}
+
+// autoID returns NULL for a zero id, so SQLite assigns the next one
+func autoID(id int) any {
+	if id == 0 {
+		return nil
+	}
+	return id
+}
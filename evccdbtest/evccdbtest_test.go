@@ -0,0 +1,49 @@
+package evccdbtest
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/iseeberg79/evccdb"
+)
+
+func TestNew(t *testing.T) {
+	ctx := context.Background()
+	vehicle := "e-Golf"
+
+	db := New(t)
+	db.AddSetting("lp1.title", "Garage")
+	db.AddSession(evccdb.Session{Created: "2024-01-01 10:00:00", Loadpoint: "Garage", Vehicle: &vehicle})
+	db.AddSession(evccdb.Session{Created: "2024-01-02 10:00:00", Loadpoint: "Garage"})
+
+	var store evccdb.Store = db
+	result, err := store.RenameLoadpoint(ctx, "Garage", "Carport")
+	if err != nil {
+		t.Fatalf("RenameLoadpoint failed: %v", err)
+	}
+	if result.Sessions != 2 || result.Settings != 1 {
+		t.Errorf("Unexpected rename result: %+v", result)
+	}
+
+	// Databases are independent of each other
+	other := New(t)
+	if n, err := other.GetRowCount("sessions"); err != nil || n != 0 {
+		t.Errorf("Expected empty database, got %d rows, err %v", n, err)
+	}
+
+	var buf bytes.Buffer
+	if err := db.ExportJSON(&buf, evccdb.TransferOptions{Mode: evccdb.TransferAll}); err != nil {
+		t.Fatalf("ExportJSON failed: %v", err)
+	}
+	if err := other.ImportJSON(&buf, evccdb.TransferOptions{Mode: evccdb.TransferAll}); err != nil {
+		t.Fatalf("ImportJSON failed: %v", err)
+	}
+	if n, err := other.CountLoadpointSessions(ctx, "Carport"); err != nil || n != 2 {
+		t.Errorf("Expected 2 imported sessions, got %d, err %v", n, err)
+	}
+
+	if err := evccdb.Transfer(ctx, db.Client, New(t).Client, evccdb.TransferOptions{Mode: evccdb.TransferAll}); err != nil {
+		t.Fatalf("Transfer failed: %v", err)
+	}
+}
@@ -0,0 +1,141 @@
+package evccdb_test
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/iseeberg79/evccdb"
+)
+
+func ExampleTransfer() {
+	ctx := context.Background()
+
+	src, err := evccdb.Open("old/evcc.db")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer func() { _ = src.Close() }()
+
+	dst, err := evccdb.Open("new/evcc.db")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer func() { _ = dst.Close() }()
+
+	// Copy the charging history, renaming the loadpoint as it is called in the new installation
+	opts := evccdb.TransferOptions{
+		Mode:             evccdb.TransferMetrics,
+		LoadpointRenames: []evccdb.RenameMapping{{OldName: "Garage", NewName: "Carport"}},
+		OnProgress: func(table string, count int) {
+			fmt.Printf("%s: %d rows\n", table, count)
+		},
+	}
+
+	// Review the plan before writing
+	plan, err := evccdb.PlanTransfer(ctx, src, dst, opts)
+	if err != nil {
+		log.Fatal(err)
+	}
+	plan.Print(os.Stdout)
+
+	if err := evccdb.Transfer(ctx, src, dst, opts); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func ExampleClient_ExportJSON() {
+	client, err := evccdb.Open("evcc.db")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer func() { _ = client.Close() }()
+
+	f, err := os.Create("config-backup.json")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer func() { _ = f.Close() }()
+
+	if err := client.ExportJSON(f, evccdb.TransferOptions{Mode: evccdb.TransferConfig}); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func ExampleClient_ImportJSONContext() {
+	client, err := evccdb.Open("evcc.db")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer func() { _ = client.Close() }()
+
+	// OpenExport also reads split exports and ImportJSONContext gzip or zstd compressed ones
+	f, err := evccdb.OpenExport("config-backup.json.zst")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer func() { _ = f.Close() }()
+
+	if err := client.ImportJSONContext(context.Background(), f, evccdb.TransferOptions{Mode: evccdb.TransferConfig}); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func ExampleClient_RenameLoadpoint() {
+	ctx := context.Background()
+
+	client, err := evccdb.Open("evcc.db")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer func() { _ = client.Close() }()
+
+	// Show what would change before renaming
+	preview, err := client.RenameLoadpointDryRun(ctx, "Garage", "Carport")
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("Would rename: sessions=%d, settings=%d, configs=%d\n", preview.Sessions, preview.Settings, preview.Configs)
+
+	result, err := client.RenameLoadpoint(ctx, "Garage", "Carport")
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("Renamed: sessions=%d, settings=%d, configs=%d\n", result.Sessions, result.Settings, result.Configs)
+}
+
+func ExampleTx_Step() {
+	ctx := context.Background()
+
+	client, err := evccdb.Open("evcc.db")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer func() { _ = client.Close() }()
+
+	// Rename a loadpoint and a vehicle together, or neither
+	tx, err := client.Begin(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if err := tx.Step(ctx, "loadpoint", func() error {
+		_, err := tx.RenameLoadpoint(ctx, "Garage", "Carport")
+		return err
+	}); err != nil {
+		log.Fatal(err)
+	}
+
+	if err := tx.Step(ctx, "vehicle", func() error {
+		_, err := tx.RenameVehicle(ctx, "e-Golf", "ID.3")
+		return err
+	}); err != nil {
+		log.Fatal(err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -1,11 +1,15 @@
 package evccdb
 
 import (
+	"context"
+	"database/sql"
 	"encoding/json"
 	"fmt"
 	"io"
 	"strings"
 	"time"
+
+	"github.com/iseeberg79/evccdb/internal/sqlbuild"
 )
 
 // ExportJSON exports selected tables to JSON
@@ -16,6 +20,7 @@ func (c *Client) ExportJSON(w io.Writer, opts TransferOptions) error {
 	}
 
 	data := make(map[string]any)
+	var exported []string
 
 	for _, table := range tables {
 		exists, err := c.TableExists(table)
@@ -25,8 +30,9 @@ func (c *Client) ExportJSON(w io.Writer, opts TransferOptions) error {
 		if !exists {
 			continue
 		}
+		exported = append(exported, table)
 
-		rows, err := c.exportTable(table)
+		rows, err := c.exportTable(table, opts)
 		if err != nil {
 			return fmt.Errorf("failed to export table %s: %w", table, err)
 		}
@@ -37,10 +43,31 @@ func (c *Client) ExportJSON(w io.Writer, opts TransferOptions) error {
 		}
 	}
 
+	userVersion, err := c.UserVersion()
+	if err != nil {
+		return err
+	}
+	applicationID, err := c.ApplicationID()
+	if err != nil {
+		return err
+	}
+	metadata, err := c.exportMetadata(exported)
+	if err != nil {
+		return err
+	}
+	if opts.SchemaObjects {
+		if metadata.Objects, err = c.schemaObjects(context.Background(), exported); err != nil {
+			return err
+		}
+	}
+
 	export := ExportFormat{
-		Version:    "1",
-		ExportedAt: time.Now().UTC().Format(time.RFC3339),
-		Tables:     data,
+		Version:       "1",
+		ExportedAt:    time.Now().UTC().Format(time.RFC3339),
+		UserVersion:   userVersion,
+		ApplicationID: applicationID,
+		Metadata:      metadata,
+		Tables:        data,
 	}
 
 	encoder := json.NewEncoder(w)
@@ -48,43 +75,221 @@ func (c *Client) ExportJSON(w io.Writer, opts TransferOptions) error {
 	return encoder.Encode(export)
 }
 
+// SQLDialect is the database a SQL dump is written for. Only BLOB columns differ, the
+// databases have no literal for binary data in common.
+type SQLDialect int
+
+const (
+	// DialectSQLite writes BLOB columns with X'0a1b' literals
+	DialectSQLite SQLDialect = iota
+	// DialectPostgres writes BYTEA columns with '\x0a1b' literals
+	DialectPostgres
+	// DialectDuckDB writes BLOB columns with '\x0a\x1b' literals
+	DialectDuckDB
+)
+
+// ParseSQLDialect parses sqlite, postgres or duckdb
+func ParseSQLDialect(s string) (SQLDialect, error) {
+	switch s {
+	case "", "sqlite":
+		return DialectSQLite, nil
+	case "postgres":
+		return DialectPostgres, nil
+	case "duckdb":
+		return DialectDuckDB, nil
+	default:
+		return 0, fmt.Errorf("unknown SQL dialect %q, expected sqlite, postgres or duckdb", s)
+	}
+}
+
+// ExportSQL writes a plain SQL dump with CREATE TABLE and INSERT statements
+// that loads into sqlite3, DuckDB and PostgreSQL as selected by opts.SQLDialect
+func (c *Client) ExportSQL(w io.Writer, opts TransferOptions) error {
+	tables, err := c.ResolveTables(opts)
+	if err != nil {
+		return fmt.Errorf("failed to resolve tables: %w", err)
+	}
+
+	if _, err := fmt.Fprintf(w, "-- evccdb dump %s\nBEGIN;\n", time.Now().UTC().Format(time.RFC3339)); err != nil {
+		return err
+	}
+
+	var exported []string
+	for _, table := range tables {
+		exists, err := c.TableExists(table)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			continue
+		}
+		exported = append(exported, table)
+
+		count, err := c.dumpTable(w, table, opts)
+		if err != nil {
+			return fmt.Errorf("failed to export table %s: %w", table, err)
+		}
+
+		if opts.OnProgress != nil {
+			opts.OnProgress(table, count)
+		}
+	}
+
+	// Views and triggers are in the SQLite dialect
+	if opts.SchemaObjects {
+		objects, err := c.schemaObjects(context.Background(), exported)
+		if err != nil {
+			return err
+		}
+		for _, o := range objects {
+			if _, err := fmt.Fprintf(w, "\n%s;\n", o.SQL); err != nil {
+				return err
+			}
+		}
+	}
+
+	_, err = fmt.Fprintln(w, "COMMIT;")
+	return err
+}
+
+// dumpTable writes the portable DDL and the rows of a table
+func (c *Client) dumpTable(w io.Writer, table string, opts TransferOptions) (int, error) {
+	cols, err := c.GetTableColumns(table)
+	if err != nil {
+		return 0, err
+	}
+
+	var defs, colNames, names, primary []string
+	var blobs []bool
+	for _, col := range cols {
+		if col.Generated {
+			continue
+		}
+		name := fmt.Sprintf(`"%s"`, col.Name)
+		colNames = append(colNames, col.Name)
+		names = append(names, name)
+		blobs = append(blobs, columnKindOf(col.Type) == kindBytes)
+		defs = append(defs, fmt.Sprintf("%s %s", name, portableType(col.Type, opts.SQLDialect)))
+		if col.Primary {
+			primary = append(primary, name)
+		}
+	}
+	if len(primary) > 0 {
+		defs = append(defs, fmt.Sprintf("PRIMARY KEY (%s)", strings.Join(primary, ", ")))
+	}
+
+	// Validates the table and column names before they are written to the dump
+	query, err := sqlbuild.Select(table, colNames)
+	if err != nil {
+		return 0, err
+	}
+
+	if _, err := fmt.Fprintf(w, "\nCREATE TABLE IF NOT EXISTS \"%s\" (\n  %s\n);\n", table, strings.Join(defs, ",\n  ")); err != nil {
+		return 0, err
+	}
+
+	rows, err := c.db.Query(query)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	count := 0
+	for rows.Next() {
+		entry, err := scanRowMap(rows, colNames)
+		if err != nil {
+			return count, err
+		}
+		if !opts.guestRow(table, entry) || !opts.classRow(table, entry) {
+			continue
+		}
+
+		values := make([]string, len(colNames))
+		for i, name := range colNames {
+			// scanRowMap returns BLOB values as strings
+			if s, ok := entry[name].(string); ok && blobs[i] {
+				values[i] = formatBlobForSQL([]byte(s), opts.SQLDialect)
+				continue
+			}
+			values[i] = formatValueForSQL(entry[name], "")
+		}
+
+		if _, err := fmt.Fprintf(w, "INSERT INTO \"%s\" (%s) VALUES (%s);\n", table, strings.Join(names, ", "), strings.Join(values, ", ")); err != nil {
+			return count, err
+		}
+		count++
+	}
+
+	return count, rows.Err()
+}
+
+// portableType maps a SQLite column type to a type understood by common SQL databases
+func portableType(colType string, dialect SQLDialect) string {
+	switch columnKindOf(colType) {
+	case kindInt:
+		return "BIGINT"
+	case kindReal:
+		return "DOUBLE PRECISION"
+	case kindTimestamp:
+		return "TIMESTAMP"
+	case kindBytes:
+		if dialect == DialectPostgres {
+			return "BYTEA"
+		}
+		return "BLOB"
+	default:
+		return "TEXT"
+	}
+}
+
 // exportTable exports a single table to a slice of maps
-func (c *Client) exportTable(table string) ([]map[string]any, error) {
-	rows, err := c.db.Query(fmt.Sprintf("SELECT * FROM `%s`", table))
+func (c *Client) exportTable(table string, opts TransferOptions) ([]map[string]any, error) {
+	cols, err := c.GetTableColumns(table)
+	if err != nil {
+		return nil, err
+	}
+	name, err := sqlbuild.QuoteIdent(table)
 	if err != nil {
 		return nil, err
 	}
-	defer func() { _ = rows.Close() }()
 
-	columns, err := rows.Columns()
+	// The driver reads timestamps without zone offset as UTC, +col returns them as
+	// stored, so they are only converted if opts.Location knows their zone
+	columns := columnNames(cols)
+	columnTypes := make(map[string]string)
+	exprs := make([]string, len(cols))
+	for i, col := range cols {
+		columnTypes[col.Name] = col.Type
+		if exprs[i], err = sqlbuild.QuoteIdent(col.Name); err != nil {
+			return nil, err
+		}
+		if columnKindOf(col.Type) == kindTimestamp {
+			exprs[i] = fmt.Sprintf("+%s AS %s", exprs[i], exprs[i])
+		}
+	}
+
+	rows, err := c.db.Query(fmt.Sprintf("SELECT %s FROM %s", strings.Join(exprs, ", "), name))
 	if err != nil {
 		return nil, err
 	}
+	defer func() { _ = rows.Close() }()
 
 	var result []map[string]any
 
 	for rows.Next() {
-		values := make([]any, len(columns))
-		valuePtrs := make([]any, len(columns))
-		for i := range columns {
-			valuePtrs[i] = &values[i]
-		}
-
-		if err := rows.Scan(valuePtrs...); err != nil {
+		entry, err := scanRowMap(rows, columns)
+		if err != nil {
 			return nil, err
 		}
 
-		entry := make(map[string]any)
-		for i, col := range columns {
-			var v any
-			val := values[i]
-			b, ok := val.([]byte)
-			if ok {
-				v = string(b)
-			} else {
-				v = val
+		// Timestamps are exported as RFC3339 UTC regardless of how evcc stored them
+		for col, val := range entry {
+			if columnKindOf(columnTypes[col]) == kindTimestamp {
+				entry[col] = normalizeTimestamp(val, opts.Location)
 			}
-			entry[col] = v
+		}
+		if !opts.guestRow(table, entry) || !opts.classRow(table, entry) {
+			continue
 		}
 		result = append(result, entry)
 	}
@@ -92,18 +297,96 @@ func (c *Client) exportTable(table string) ([]map[string]any, error) {
 	return result, rows.Err()
 }
 
-// getColumnTypesForTable gets the SQL types of columns
-func (c *Client) getColumnTypesForTable(table string) (map[string]string, error) {
-	cols, err := c.GetTableColumns(table)
-	if err != nil {
+// StreamTable sends the rows of a table to the returned channel. The error
+// channel receives at most one error and is closed together with the row channel.
+// Cancelling ctx stops the stream.
+func (c *Client) StreamTable(ctx context.Context, table string) (<-chan map[string]any, <-chan error) {
+	out := make(chan map[string]any)
+	errc := make(chan error, 1)
+
+	go func() {
+		defer close(out)
+		defer close(errc)
+
+		query, err := sqlbuild.Select(table, nil)
+		if err != nil {
+			errc <- err
+			return
+		}
+
+		rows, err := c.db.QueryContext(ctx, query)
+		if err != nil {
+			errc <- fmt.Errorf("failed to query %s: %w", table, err)
+			return
+		}
+		defer func() { _ = rows.Close() }()
+
+		columns, err := rows.Columns()
+		if err != nil {
+			errc <- err
+			return
+		}
+
+		for rows.Next() {
+			entry, err := scanRowMap(rows, columns)
+			if err != nil {
+				errc <- err
+				return
+			}
+
+			select {
+			case out <- entry:
+			case <-ctx.Done():
+				errc <- ctx.Err()
+				return
+			}
+		}
+
+		if err := rows.Err(); err != nil {
+			errc <- err
+		}
+	}()
+
+	return out, errc
+}
+
+// scanRowMap scans the current row into a map keyed by column name
+func scanRowMap(rows *sql.Rows, columns []string) (map[string]any, error) {
+	values := make([]any, len(columns))
+	valuePtrs := make([]any, len(columns))
+	for i := range columns {
+		valuePtrs[i] = &values[i]
+	}
+
+	if err := rows.Scan(valuePtrs...); err != nil {
 		return nil, err
 	}
 
+	entry := make(map[string]any)
+	for i, col := range columns {
+		var v any
+		val := values[i]
+		b, ok := val.([]byte)
+		if ok {
+			v = string(b)
+		} else {
+			v = val
+		}
+		entry[col] = v
+	}
+	return entry, nil
+}
+
+// writableColumnTypes maps the names of writable columns to their SQL types
+func writableColumnTypes(cols []ColumnInfo) map[string]string {
 	types := make(map[string]string)
 	for _, col := range cols {
-		types[col.Name] = col.Type
+		// Generated columns cannot be written on import
+		if !col.Generated {
+			types[col.Name] = col.Type
+		}
 	}
-	return types, nil
+	return types
 }
 
 // formatValueForSQL formats a value for SQL insertion
@@ -121,6 +404,10 @@ func formatValueForSQL(val any, _ string) string {
 		return fmt.Sprintf("%v", v)
 	case int:
 		return fmt.Sprintf("%d", v)
+	case int64:
+		return fmt.Sprintf("%d", v)
+	case time.Time:
+		return fmt.Sprintf("'%s'", v.UTC().Format("2006-01-02 15:04:05.999999999"))
 	case bool:
 		if v {
 			return "1"
@@ -131,6 +418,24 @@ func formatValueForSQL(val any, _ string) string {
 	}
 }
 
+// formatBlobForSQL formats binary data as a literal of the dialect
+func formatBlobForSQL(b []byte, dialect SQLDialect) string {
+	switch dialect {
+	case DialectPostgres:
+		return fmt.Sprintf(`'\x%x'`, b)
+	case DialectDuckDB:
+		var sb strings.Builder
+		sb.WriteString("'")
+		for _, c := range b {
+			fmt.Fprintf(&sb, `\x%02x`, c)
+		}
+		sb.WriteString("'")
+		return sb.String()
+	default:
+		return fmt.Sprintf("X'%x'", b)
+	}
+}
+
 // escapeSQL escapes a string for SQL by doubling single quotes
 func escapeSQL(s string) string {
 	return strings.ReplaceAll(s, "'", "''")
@@ -0,0 +1,147 @@
+package evccdb
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// FailureReportSuffix is appended to the database path to name the report of a failed write
+const FailureReportSuffix = ".evccdb-failure.json"
+
+// RowError is the failure to write a row during an import or transfer
+type RowError struct {
+	Table string
+	// Row is the position of the row in the source table or the export file, starting at 0
+	Row int
+	// Values are the values of the row by column as bound to the insert
+	Values map[string]any
+	Err    error
+}
+
+func (e *RowError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *RowError) Unwrap() error {
+	return e.Err
+}
+
+// newRowError records the values of a row that failed to insert
+func newRowError(table string, row int, cols []string, values []any, err error) *RowError {
+	e := &RowError{Table: table, Row: row, Values: make(map[string]any, len(cols)), Err: err}
+	for i, col := range cols {
+		if b, ok := values[i].([]byte); ok {
+			e.Values[col] = string(b)
+		} else {
+			e.Values[col] = values[i]
+		}
+	}
+	return e
+}
+
+// skippableRowError reports whether the database rejected a single row, which
+// TransferOptions.SkipRowErrors skips, and not e.g. a full disk failing the import
+func skippableRowError(err error) bool {
+	var sqliteErr sqlite3.Error
+	if !errors.As(err, &sqliteErr) {
+		return false
+	}
+	switch sqliteErr.Code {
+	case sqlite3.ErrConstraint, sqlite3.ErrMismatch, sqlite3.ErrRange, sqlite3.ErrTooBig:
+		return true
+	}
+	return false
+}
+
+// maxReportedFailures limits the skipped rows listed in a failure report, the counts include all
+const maxReportedFailures = 1000
+
+// RowFailure is a row skipped by TransferOptions.SkipRowErrors
+type RowFailure struct {
+	Table    string         `json:"table"`
+	Row      int            `json:"row"`
+	Values   map[string]any `json:"values"`
+	SQLError string         `json:"sql_error"`
+}
+
+// FailureReport describes a failed import or transfer, so users can share the
+// offending row instead of the wrapped error message
+type FailureReport struct {
+	Time    time.Time `json:"time"`
+	Command string    `json:"command"`
+	Source  string    `json:"source"`
+	Target  string    `json:"target"`
+	// Error is empty if the command succeeded with skipped rows
+	Error string `json:"error,omitempty"`
+	// Table, Row, Values and SQLError are set if the failure was caused by a row
+	Table    string         `json:"table,omitempty"`
+	Row      *int           `json:"row,omitempty"`
+	Values   map[string]any `json:"values,omitempty"`
+	SQLError string         `json:"sql_error,omitempty"`
+	// Skipped are the numbers of rows per table skipped by TransferOptions.SkipRowErrors
+	Skipped map[string]int `json:"skipped,omitempty"`
+	// Failures are the first skipped rows
+	Failures []RowFailure `json:"failures,omitempty"`
+}
+
+// NewFailureReport describes the error of a failed command, including the offending row if
+// known. A nil error starts a report collecting skipped rows.
+func NewFailureReport(command, source, target string, err error) *FailureReport {
+	r := &FailureReport{
+		Time:    time.Now(),
+		Command: command,
+		Source:  source,
+		Target:  target,
+	}
+	if err != nil {
+		r.SetError(err)
+	}
+	return r
+}
+
+// SetError records the error that failed the command, including the offending row if known
+func (r *FailureReport) SetError(err error) {
+	r.Time = time.Now()
+	r.Error = err.Error()
+	var rowErr *RowError
+	if errors.As(err, &rowErr) {
+		row := rowErr.Row
+		r.Table = rowErr.Table
+		r.Row = &row
+		r.Values = rowErr.Values
+		r.SQLError = rowErr.Err.Error()
+	}
+}
+
+// AddSkipped records a row skipped by TransferOptions.SkipRowErrors
+func (r *FailureReport) AddSkipped(e *RowError) {
+	if r.Skipped == nil {
+		r.Skipped = make(map[string]int)
+	}
+	r.Skipped[e.Table]++
+	if len(r.Failures) < maxReportedFailures {
+		r.Failures = append(r.Failures, RowFailure{Table: e.Table, Row: e.Row, Values: e.Values, SQLError: e.Err.Error()})
+	}
+}
+
+// WriteFile writes the report as indented JSON. Only the owner may read it, the values of
+// configs and settings rows contain passwords and tokens.
+func (r *FailureReport) WriteFile(path string) error {
+	b, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	// A report left by an earlier run keeps its mode on os.WriteFile
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to replace failure report: %w", err)
+	}
+	if err := os.WriteFile(path, append(b, '\n'), 0o600); err != nil {
+		return fmt.Errorf("failed to write failure report: %w", err)
+	}
+	return nil
+}
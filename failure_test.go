@@ -0,0 +1,87 @@
+package evccdb
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFailureReport(t *testing.T) {
+	client, cleanup := createTestDB(t)
+	defer cleanup()
+
+	// The second config has a text id, which an INTEGER PRIMARY KEY rejects
+	export := `{"version": "1", "tables": {"configs": [
+		{"id": 7, "class": 5, "type": "template", "value": "{}"},
+		{"id": "seven", "class": 5, "type": "template", "value": "{}"}
+	]}}`
+	err := client.ImportJSON(strings.NewReader(export), TransferOptions{Mode: TransferAll})
+	if err == nil {
+		t.Fatal("Expected import to fail")
+	}
+
+	var rowErr *RowError
+	if !errors.As(err, &rowErr) {
+		t.Fatalf("Expected a RowError, got %v", err)
+	}
+	if rowErr.Table != "configs" || rowErr.Row != 1 || rowErr.Values["id"] != "seven" {
+		t.Errorf("Unexpected row error %+v", rowErr)
+	}
+
+	path := filepath.Join(t.TempDir(), "failure.json")
+	if err := NewFailureReport("import", "export.json", "evcc.db", err).WriteFile(path); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0o600 {
+		t.Errorf("Expected the report readable by the owner only, got %v", info.Mode())
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var report FailureReport
+	if err := json.Unmarshal(data, &report); err != nil {
+		t.Fatalf("Invalid report: %v", err)
+	}
+	if report.Table != "configs" || report.Row == nil || *report.Row != 1 || report.SQLError == "" || report.Values["id"] != "seven" {
+		t.Errorf("Unexpected report %+v", report)
+	}
+
+	// Errors without a row are reported without row details
+	report = *NewFailureReport("import", "export.json", "evcc.db", errors.New("disk full"))
+	if report.Table != "" || report.Row != nil || report.Error != "disk full" {
+		t.Errorf("Unexpected report %+v", report)
+	}
+}
+
+func TestImportSkipRowErrors(t *testing.T) {
+	client, cleanup := createTestDB(t)
+	defer cleanup()
+
+	export := `{"version": "1", "tables": {"configs": [
+		{"id": "seven", "class": 5, "type": "template", "value": "{}"},
+		{"id": 8, "class": 5, "type": "template", "value": "{}"},
+		{"id": "nine", "class": 5, "type": "template", "value": "{}"}
+	]}}`
+	report := NewFailureReport("import", "export.json", "evcc.db", nil)
+	opts := TransferOptions{Mode: TransferAll, SkipRowErrors: true, OnRowError: report.AddSkipped}
+	if err := client.ImportJSON(strings.NewReader(export), opts); err != nil {
+		t.Fatalf("ImportJSON failed: %v", err)
+	}
+
+	var count int
+	_ = client.db.QueryRow("SELECT COUNT(*) FROM configs WHERE id = 8").Scan(&count)
+	if count != 1 {
+		t.Errorf("Expected the valid row to be imported, got %d", count)
+	}
+	if report.Skipped["configs"] != 2 || len(report.Failures) != 2 || report.Failures[1].Row != 2 || report.Error != "" {
+		t.Errorf("Unexpected report %+v", report)
+	}
+}
@@ -0,0 +1,102 @@
+package evccdb
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/iseeberg79/evccdb/internal/sqlbuild"
+)
+
+// FleetGroups are the session columns fleet statistics can be grouped by
+var FleetGroups = []string{"vehicle", "identifier"}
+
+// VehicleStats are the session totals of one vehicle across databases
+type VehicleStats struct {
+	Vehicle    string  `json:"vehicle"`
+	Sites      int     `json:"sites"`
+	Sessions   int     `json:"sessions"`
+	ChargedKwh float64 `json:"charged_kwh"`
+	SolarKwh   float64 `json:"solar_kwh"`
+	Price      float64 `json:"price"`
+}
+
+// FleetStats merges the session statistics of several databases by vehicle
+// name or RFID identifier, sessions without one are reported as empty name
+func FleetStats(ctx context.Context, clients []*Client, by string) ([]VehicleStats, error) {
+	valid := false
+	for _, group := range FleetGroups {
+		valid = valid || group == by
+	}
+	if !valid {
+		return nil, fmt.Errorf("cannot group by %q, expected vehicle or identifier", by)
+	}
+
+	totals := make(map[string]*VehicleStats)
+	for _, c := range clients {
+		stats, err := c.vehicleStats(ctx, by)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", c.path, err)
+		}
+
+		for _, s := range stats {
+			total, ok := totals[s.Vehicle]
+			if !ok {
+				total = &VehicleStats{Vehicle: s.Vehicle}
+				totals[s.Vehicle] = total
+			}
+			total.Sites++
+			total.Sessions += s.Sessions
+			total.ChargedKwh += s.ChargedKwh
+			total.SolarKwh += s.SolarKwh
+			total.Price += s.Price
+		}
+	}
+
+	result := make([]VehicleStats, 0, len(totals))
+	for _, total := range totals {
+		result = append(result, *total)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].ChargedKwh != result[j].ChargedKwh {
+			return result[i].ChargedKwh > result[j].ChargedKwh
+		}
+		return result[i].Vehicle < result[j].Vehicle
+	})
+
+	return result, nil
+}
+
+// vehicleStats returns the session totals of a database grouped by column
+func (c *Client) vehicleStats(ctx context.Context, column string) ([]VehicleStats, error) {
+	exists, err := c.TableExists("sessions")
+	if err != nil || !exists {
+		return nil, err
+	}
+
+	col, err := sqlbuild.QuoteIdent(column)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := c.db.QueryContext(ctx, fmt.Sprintf(`
+		SELECT COALESCE(%s, ''), COUNT(*), COALESCE(SUM(charged_kwh), 0),
+			COALESCE(SUM(charged_kwh * solar_percentage / 100), 0), COALESCE(SUM(price), 0)
+		FROM sessions
+		GROUP BY 1`, col))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query sessions: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var stats []VehicleStats
+	for rows.Next() {
+		var s VehicleStats
+		if err := rows.Scan(&s.Vehicle, &s.Sessions, &s.ChargedKwh, &s.SolarKwh, &s.Price); err != nil {
+			return nil, fmt.Errorf("failed to scan vehicle stats: %w", err)
+		}
+		stats = append(stats, s)
+	}
+
+	return stats, rows.Err()
+}
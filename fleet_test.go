@@ -0,0 +1,47 @@
+package evccdb
+
+import (
+	"context"
+	"testing"
+)
+
+func TestFleetStats(t *testing.T) {
+	site1, cleanup1 := createTestDB(t)
+	defer cleanup1()
+	site2, cleanup2 := createTestDB(t)
+	defer cleanup2()
+
+	_, _ = site1.db.Exec("DELETE FROM sessions")
+	_, _ = site2.db.Exec("DELETE FROM sessions")
+	_, _ = site1.db.Exec(`INSERT INTO sessions (created, loadpoint, vehicle, identifier, charged_kwh, solar_percentage, price) VALUES
+		('2024-01-01 10:00:00', 'Garage', 'e-Golf', 'tag1', 10, 50, 3),
+		('2024-01-02 10:00:00', 'Garage', 'ID.3', 'tag2', 20, 0, 6)`)
+	_, _ = site2.db.Exec(`INSERT INTO sessions (created, loadpoint, vehicle, identifier, charged_kwh, solar_percentage, price) VALUES
+		('2024-01-03 10:00:00', 'Office', 'e-Golf', 'tag1', 30, 100, 0),
+		('2024-01-04 10:00:00', 'Office', NULL, NULL, 5, 0, 2)`)
+
+	stats, err := FleetStats(context.Background(), []*Client{site1, site2}, "vehicle")
+	if err != nil {
+		t.Fatalf("FleetStats failed: %v", err)
+	}
+	if len(stats) != 3 {
+		t.Fatalf("Expected 3 vehicles, got %d: %+v", len(stats), stats)
+	}
+
+	golf := stats[0]
+	if golf.Vehicle != "e-Golf" || golf.Sites != 2 || golf.Sessions != 2 || golf.ChargedKwh != 40 || golf.SolarKwh != 35 || golf.Price != 3 {
+		t.Errorf("Unexpected e-Golf totals: %+v", golf)
+	}
+
+	byTag, err := FleetStats(context.Background(), []*Client{site1, site2}, "identifier")
+	if err != nil {
+		t.Fatalf("FleetStats by identifier failed: %v", err)
+	}
+	if byTag[0].Vehicle != "tag1" || byTag[0].ChargedKwh != 40 {
+		t.Errorf("Unexpected identifier totals: %+v", byTag[0])
+	}
+
+	if _, err := FleetStats(context.Background(), []*Client{site1}, "loadpoint; DROP TABLE sessions"); err == nil {
+		t.Error("Expected invalid grouping to fail")
+	}
+}
@@ -0,0 +1,138 @@
+package evccdb
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Defaults of GapOptions
+const (
+	DefaultGapFactor = 10
+	DefaultMinGap    = time.Hour
+)
+
+// GapOptions configures FindGaps
+type GapOptions struct {
+	// Factor is the multiple of the normal cadence a gap must exceed, 0 means DefaultGapFactor
+	Factor float64
+	// MinGap is the shortest reported gap, 0 means DefaultMinGap
+	MinGap time.Duration
+}
+
+// Gap is a period without sessions of a loadpoint or readings of a meter
+type Gap struct {
+	// Table is sessions or meters
+	Table string `json:"table"`
+	// Series is the loadpoint of sessions or the meter id of readings
+	Series   string        `json:"series"`
+	From     time.Time     `json:"from"`
+	To       time.Time     `json:"to"`
+	Duration time.Duration `json:"duration"`
+	// Cadence is the median interval between the records of the series
+	Cadence time.Duration `json:"cadence"`
+}
+
+// FindGaps finds periods in which a loadpoint recorded no sessions or a meter no readings
+// for much longer than its normal cadence, e.g. lost by a crash of evcc. Gaps are ordered
+// by table, series and time.
+func (c *Client) FindGaps(ctx context.Context, opts GapOptions) ([]Gap, error) {
+	if opts.Factor <= 0 {
+		opts.Factor = DefaultGapFactor
+	}
+	if opts.MinGap <= 0 {
+		opts.MinGap = DefaultMinGap
+	}
+
+	var gaps []Gap
+	for _, source := range []struct{ table, series, ts string }{
+		{"meters", "'meter ' || meter", "ts"},
+		{"sessions", "loadpoint", "created"},
+	} {
+		series, err := c.gapSeries(ctx, source.table, source.series, source.ts)
+		if err != nil {
+			return nil, err
+		}
+
+		names := make([]string, 0, len(series))
+		for name := range series {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			gaps = append(gaps, findSeriesGaps(source.table, name, series[name], opts)...)
+		}
+	}
+	return gaps, nil
+}
+
+// gapSeries reads the timestamps of a table by series as unix seconds in ascending order
+func (c *Client) gapSeries(ctx context.Context, table, series, ts string) (map[string][]int64, error) {
+	exists, err := c.TableExists(table)
+	if err != nil || !exists {
+		return nil, err
+	}
+
+	rows, err := c.db.QueryContext(ctx, fmt.Sprintf(
+		"SELECT %s, %s FROM %s WHERE %s IS NOT NULL AND %s IS NOT NULL", series, ts, table, series, ts))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query %s: %w", table, err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	result := make(map[string][]int64)
+	for rows.Next() {
+		var name string
+		var val any
+		if err := rows.Scan(&name, &val); err != nil {
+			return nil, fmt.Errorf("failed to scan %s: %w", table, err)
+		}
+		if t, ok := timestampOf(val); ok {
+			result[name] = append(result[name], t.Unix())
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, times := range result {
+		sort.Slice(times, func(i, j int) bool { return times[i] < times[j] })
+	}
+	return result, nil
+}
+
+// findSeriesGaps returns the intervals of sorted timestamps that exceed the cadence of the series
+func findSeriesGaps(table, series string, times []int64, opts GapOptions) []Gap {
+	var intervals []int64
+	for i := 1; i < len(times); i++ {
+		if d := times[i] - times[i-1]; d > 0 {
+			intervals = append(intervals, d)
+		}
+	}
+	// A median needs a few intervals to describe a cadence
+	if len(intervals) < 3 {
+		return nil
+	}
+
+	sorted := append([]int64(nil), intervals...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	cadence := time.Duration(sorted[len(sorted)/2]) * time.Second
+
+	limit := max(time.Duration(opts.Factor*float64(cadence)), opts.MinGap)
+
+	var gaps []Gap
+	for i := 1; i < len(times); i++ {
+		if d := time.Duration(times[i]-times[i-1]) * time.Second; d > limit {
+			gaps = append(gaps, Gap{
+				Table:    table,
+				Series:   series,
+				From:     time.Unix(times[i-1], 0).UTC(),
+				To:       time.Unix(times[i], 0).UTC(),
+				Duration: d,
+				Cadence:  cadence,
+			})
+		}
+	}
+	return gaps
+}
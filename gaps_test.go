@@ -0,0 +1,62 @@
+package evccdb
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFindGaps(t *testing.T) {
+	client, cleanup := createTestDB(t)
+	defer cleanup()
+
+	// Readings every 15 minutes for two days, missing six hours on the first day
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	var values []string
+	for ts := start; ts.Before(start.Add(48 * time.Hour)); ts = ts.Add(15 * time.Minute) {
+		if ts.After(start.Add(6*time.Hour)) && ts.Before(start.Add(12*time.Hour)) {
+			continue
+		}
+		values = append(values, fmt.Sprintf("(1, '%s', 1)", ts.Format("2006-01-02 15:04:05")))
+	}
+	if _, err := client.db.Exec("INSERT INTO meters (meter, ts, val) VALUES " + strings.Join(values, ",")); err != nil {
+		t.Fatal(err)
+	}
+
+	// Daily sessions of the garage with three weeks missing
+	_, _ = client.db.Exec("DELETE FROM sessions")
+	values = nil
+	for day := 0; day < 60; day++ {
+		if day > 10 && day < 32 {
+			continue
+		}
+		values = append(values, fmt.Sprintf("(%d, '%s', 'Garage')", day+1, start.AddDate(0, 0, day).Format("2006-01-02 15:04:05")))
+	}
+	if _, err := client.db.Exec("INSERT INTO sessions (id, created, loadpoint) VALUES " + strings.Join(values, ",")); err != nil {
+		t.Fatal(err)
+	}
+
+	gaps, err := client.FindGaps(context.Background(), GapOptions{})
+	if err != nil {
+		t.Fatalf("FindGaps failed: %v", err)
+	}
+	if len(gaps) != 2 {
+		t.Fatalf("Expected 2 gaps, got %+v", gaps)
+	}
+
+	meter := gaps[0]
+	if meter.Table != "meters" || meter.Series != "meter 1" || meter.Cadence != 15*time.Minute || meter.Duration != 6*time.Hour {
+		t.Errorf("Unexpected meter gap %+v", meter)
+	}
+	session := gaps[1]
+	if session.Table != "sessions" || session.Series != "Garage" || session.Duration != 22*24*time.Hour || !session.From.Equal(start.AddDate(0, 0, 10)) {
+		t.Errorf("Unexpected session gap %+v", session)
+	}
+
+	// Shorter gaps are not reported with a longer minimum
+	if gaps, _ := client.FindGaps(context.Background(), GapOptions{MinGap: 7 * time.Hour}); len(gaps) != 1 {
+		t.Errorf("Expected only the session gap, got %+v", gaps)
+	}
+}
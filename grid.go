@@ -0,0 +1,122 @@
+package evccdb
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Times returns the start and end of a grid session, a zero end if the limit is still active
+func (s GridSession) Times() (created, finished time.Time, err error) {
+	if created, err = ParseTimestamp(s.Created, nil); err != nil {
+		return created, finished, err
+	}
+	if s.Finished != nil {
+		finished, err = ParseTimestamp(*s.Finished, nil)
+	}
+	return created, finished, err
+}
+
+// Duration returns how long the grid power was limited, 0 if the limit is still active
+func (s GridSession) Duration() time.Duration {
+	created, finished, err := s.Times()
+	if err != nil || finished.Before(created) {
+		return 0
+	}
+	return finished.Sub(created)
+}
+
+// ShiftedEnergy returns the energy in kWh the limit held back: the grid power in W above
+// the limit over the duration of the session
+func (s GridSession) ShiftedEnergy() float64 {
+	if s.GridPower == nil || s.LimitPower == nil {
+		return 0
+	}
+	return max(*s.GridPower-*s.LimitPower, 0) * s.Duration().Hours() / 1000
+}
+
+// GridSessions returns the grid sessions, e.g. periods in which the grid operator dimmed
+// consumers, ordered by start time
+func (c *Client) GridSessions(ctx context.Context) ([]GridSession, error) {
+	exists, err := c.TableExists("grid_sessions")
+	if err != nil || !exists {
+		return nil, err
+	}
+
+	rows, err := c.db.QueryContext(ctx, `
+		SELECT id, created, finished, COALESCE(type, ''), grid_power, limit_power
+		FROM grid_sessions
+		WHERE created IS NOT NULL`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query grid sessions: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var sessions []GridSession
+	for rows.Next() {
+		var s GridSession
+		var created, finished any
+		if err := rows.Scan(&s.ID, &created, &finished, &s.Type, &s.GridPower, &s.LimitPower); err != nil {
+			return nil, fmt.Errorf("failed to scan grid session: %w", err)
+		}
+		start, ok := timestampOf(created)
+		if !ok {
+			continue
+		}
+		s.Created = FormatTimestamp(start)
+		if end, ok := timestampOf(finished); ok {
+			f := FormatTimestamp(end)
+			s.Finished = &f
+		}
+		sessions = append(sessions, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(sessions, func(i, j int) bool {
+		a, _, _ := sessions[i].Times()
+		b, _, _ := sessions[j].Times()
+		return a.Before(b)
+	})
+	return sessions, nil
+}
+
+// GridStats summarizes the grid sessions of one type
+type GridStats struct {
+	Type     string        `json:"type"`
+	Events   int           `json:"events"`
+	Duration time.Duration `json:"duration"`
+	Longest  time.Duration `json:"longest"`
+	// ShiftedKwh is the energy held back by the limits, see GridSession.ShiftedEnergy
+	ShiftedKwh float64 `json:"shifted_kwh"`
+}
+
+// GridStats summarizes the grid sessions by type, ordered by type
+func (c *Client) GridStats(ctx context.Context) ([]GridStats, error) {
+	sessions, err := c.GridSessions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	byType := make(map[string]*GridStats)
+	for _, s := range sessions {
+		stats, ok := byType[s.Type]
+		if !ok {
+			stats = &GridStats{Type: s.Type}
+			byType[s.Type] = stats
+		}
+		stats.Events++
+		stats.Duration += s.Duration()
+		stats.Longest = max(stats.Longest, s.Duration())
+		stats.ShiftedKwh += s.ShiftedEnergy()
+	}
+
+	result := make([]GridStats, 0, len(byType))
+	for _, stats := range byType {
+		result = append(result, *stats)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Type < result[j].Type })
+	return result, nil
+}
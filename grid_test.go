@@ -0,0 +1,46 @@
+package evccdb
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestGridStats(t *testing.T) {
+	client, cleanup := createTestDB(t)
+	defer cleanup()
+
+	if _, err := client.db.Exec(`INSERT INTO grid_sessions (id, created, finished, type, grid_power, limit_power) VALUES
+		(1, '2024-01-02 10:00:00', '2024-01-02 12:00:00', 'dim', 6000, 4200),
+		(2, '2024-01-01 18:00:00', '2024-01-01 18:30:00', 'dim', 3000, 4200),
+		(3, '2024-01-03 08:00:00', NULL, 'curtail', NULL, NULL)`); err != nil {
+		t.Fatal(err)
+	}
+
+	sessions, err := client.GridSessions(context.Background())
+	if err != nil {
+		t.Fatalf("GridSessions failed: %v", err)
+	}
+	if len(sessions) != 3 || sessions[0].ID != 2 || sessions[1].ID != 1 || sessions[2].ID != 3 {
+		t.Fatalf("Expected sessions ordered by start, got %+v", sessions)
+	}
+	if e := sessions[1].ShiftedEnergy(); math.Abs(e-3.6) > 1e-9 {
+		t.Errorf("Expected 3.6 kWh held back, got %v", e)
+	}
+	if d := sessions[2].Duration(); d != 0 {
+		t.Errorf("Expected no duration of an active limit, got %v", d)
+	}
+
+	stats, err := client.GridStats(context.Background())
+	if err != nil {
+		t.Fatalf("GridStats failed: %v", err)
+	}
+	if len(stats) != 2 || stats[0].Type != "curtail" || stats[1].Type != "dim" {
+		t.Fatalf("Expected stats of curtail and dim, got %+v", stats)
+	}
+	dim := stats[1]
+	if dim.Events != 2 || dim.Duration != 150*time.Minute || dim.Longest != 2*time.Hour || math.Abs(dim.ShiftedKwh-3.6) > 1e-9 {
+		t.Errorf("Unexpected dim stats %+v", dim)
+	}
+}
@@ -0,0 +1,91 @@
+package evccdb
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// guestSession applies the guest session options to the vehicle of a session,
+// it reports false for sessions to exclude
+func (opts TransferOptions) guestSession(vehicle *any) bool {
+	if settingString(*vehicle) != "" {
+		return true
+	}
+	if opts.ExcludeGuestSessions {
+		return false
+	}
+	if opts.GuestVehicle != "" {
+		*vehicle = opts.GuestVehicle
+	}
+	return true
+}
+
+// guestRow applies the guest session options to a row of table, false if it is excluded
+func (opts TransferOptions) guestRow(table string, row map[string]any) bool {
+	vehicle, ok := row["vehicle"]
+	if table != "sessions" || !ok {
+		return true
+	}
+	keep := opts.guestSession(&vehicle)
+	row["vehicle"] = vehicle
+	return keep
+}
+
+// guestOptions reports whether guest sessions are excluded or reassigned
+func (opts TransferOptions) guestOptions() bool {
+	return opts.ExcludeGuestSessions || opts.GuestVehicle != ""
+}
+
+// DefaultGuestName is the vehicle name template of AssignGuestVehicles
+const DefaultGuestName = "Guest ({identifier})"
+
+// GuestGroup is the sessions without vehicle of one identifier
+type GuestGroup struct {
+	Identifier string
+	Vehicle    string
+	Sessions   int
+}
+
+// AssignGuestVehicles assigns the sessions without vehicle to a synthetic vehicle per
+// identifier, e.g. an RFID tag, so evcc's per-vehicle statistics include them. The
+// vehicle name is made from template by replacing {identifier}. Sessions without
+// identifier are kept.
+func (c *Client) AssignGuestVehicles(ctx context.Context, template string, dryRun bool) ([]GuestGroup, error) {
+	rows, err := c.db.QueryContext(ctx, `SELECT identifier, COUNT(*) FROM sessions
+		WHERE (vehicle IS NULL OR vehicle = '') AND identifier IS NOT NULL AND identifier != ''
+		GROUP BY identifier ORDER BY identifier`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query guest sessions: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var groups []GuestGroup
+	for rows.Next() {
+		var g GuestGroup
+		if err := rows.Scan(&g.Identifier, &g.Sessions); err != nil {
+			return nil, fmt.Errorf("failed to scan guest sessions: %w", err)
+		}
+		g.Vehicle = strings.ReplaceAll(template, "{identifier}", g.Identifier)
+		groups = append(groups, g)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	// Release the read before writing
+	_ = rows.Close()
+
+	if dryRun || len(groups) == 0 {
+		return groups, nil
+	}
+
+	err = c.inTx(ctx, func(tx *Tx) error {
+		for _, g := range groups {
+			if _, err := tx.tx.ExecContext(ctx, "UPDATE sessions SET vehicle = ? WHERE (vehicle IS NULL OR vehicle = '') AND identifier = ?", g.Vehicle, g.Identifier); err != nil {
+				return fmt.Errorf("failed to assign guest vehicle %q: %w", g.Vehicle, err)
+			}
+		}
+		return nil
+	})
+	return groups, err
+}
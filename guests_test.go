@@ -0,0 +1,102 @@
+package evccdb
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+func TestTransferGuestSessions(t *testing.T) {
+	src, srcCleanup := createTestDB(t)
+	defer srcCleanup()
+	dst, dstCleanup := createTestDB(t)
+	defer dstCleanup()
+
+	if _, err := dst.db.Exec("DELETE FROM sessions"); err != nil {
+		t.Fatal(err)
+	}
+
+	opts := TransferOptions{Tables: []string{"sessions"}, ExcludeGuestSessions: true}
+	if err := Transfer(context.Background(), src, dst, opts); err != nil {
+		t.Fatalf("Transfer failed: %v", err)
+	}
+
+	var total, guests int
+	if err := dst.db.QueryRow("SELECT COUNT(*), COUNT(*) - COUNT(vehicle) FROM sessions").Scan(&total, &guests); err != nil {
+		t.Fatal(err)
+	}
+	if total != 3 || guests != 0 {
+		t.Errorf("Expected 3 sessions without guests, got %d with %d guests", total, guests)
+	}
+
+	opts.Clone = true
+	if err := CheckClone(src, dst, opts); err == nil {
+		t.Error("Expected clone to reject guest session filtering")
+	}
+}
+
+func TestExportGuestVehicle(t *testing.T) {
+	client, cleanup := createTestDB(t)
+	defer cleanup()
+
+	var buf bytes.Buffer
+	if err := client.ExportJSON(&buf, TransferOptions{Tables: []string{"sessions"}, GuestVehicle: "Guest"}); err != nil {
+		t.Fatalf("ExportJSON failed: %v", err)
+	}
+
+	var export ExportFormat
+	if err := json.Unmarshal(buf.Bytes(), &export); err != nil {
+		t.Fatal(err)
+	}
+	sessions, _ := export.Tables["sessions"].([]any)
+	if len(sessions) != 5 {
+		t.Fatalf("Expected 5 sessions, got %d", len(sessions))
+	}
+
+	guests := 0
+	for _, s := range sessions {
+		if s.(map[string]any)["vehicle"] == "Guest" {
+			guests++
+		}
+	}
+	if guests != 2 {
+		t.Errorf("Expected 2 sessions of the guest vehicle, got %d", guests)
+	}
+}
+
+func TestAssignGuestVehicles(t *testing.T) {
+	client, cleanup := createTestDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	if _, err := client.db.Exec("UPDATE sessions SET identifier = '04A1' WHERE vehicle IS NULL"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.db.Exec("UPDATE sessions SET identifier = NULL WHERE id = 5"); err != nil {
+		t.Fatal(err)
+	}
+
+	groups, err := client.AssignGuestVehicles(ctx, DefaultGuestName, true)
+	if err != nil {
+		t.Fatalf("AssignGuestVehicles dry run failed: %v", err)
+	}
+	want := GuestGroup{Identifier: "04A1", Vehicle: "Guest (04A1)", Sessions: 1}
+	if len(groups) != 1 || groups[0] != want {
+		t.Fatalf("Expected %v, got %v", want, groups)
+	}
+
+	if _, err := client.AssignGuestVehicles(ctx, DefaultGuestName, false); err != nil {
+		t.Fatalf("AssignGuestVehicles failed: %v", err)
+	}
+	count, err := client.CountVehicleSessions(ctx, "Guest (04A1)")
+	if err != nil || count != 1 {
+		t.Errorf("Expected 1 guest session, got %d, %v", count, err)
+	}
+
+	// The session without identifier keeps no vehicle
+	var guests int
+	if err := client.db.QueryRow("SELECT COUNT(*) FROM sessions WHERE vehicle IS NULL").Scan(&guests); err != nil || guests != 1 {
+		t.Errorf("Expected 1 session without vehicle, got %d, %v", guests, err)
+	}
+}
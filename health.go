@@ -0,0 +1,107 @@
+package evccdb
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/iseeberg79/evccdb/internal/sqlbuild"
+)
+
+// Health check states
+const (
+	HealthOK       = "ok"
+	HealthDegraded = "degraded"
+)
+
+// HealthOptions configures the thresholds of a health check, zero disables a check
+type HealthOptions struct {
+	MaxSessionAge time.Duration
+	MaxMeterAge   time.Duration
+	MaxSize       int64
+}
+
+// HealthStatus is the result of a health check
+type HealthStatus struct {
+	Status      string     `json:"status"`
+	CheckedAt   time.Time  `json:"checked_at"`
+	LastSession *time.Time `json:"last_session,omitempty"`
+	LastMeter   *time.Time `json:"last_meter,omitempty"`
+	Size        int64      `json:"size"`
+	Problems    []string   `json:"problems,omitempty"`
+}
+
+// CheckHealth checks whether evcc is still writing to the database
+func (c *Client) CheckHealth(ctx context.Context, opts HealthOptions) (*HealthStatus, error) {
+	now := time.Now().UTC()
+	status := &HealthStatus{Status: HealthOK, CheckedAt: now}
+
+	var err error
+	status.LastSession, err = latestTimestamp(ctx, c.db, "sessions", "created")
+	if err != nil {
+		return nil, err
+	}
+	status.LastMeter, err = latestTimestamp(ctx, c.db, "meters", "ts")
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.db.QueryRowContext(ctx, "SELECT page_count * page_size FROM pragma_page_count(), pragma_page_size()").Scan(&status.Size); err != nil {
+		return nil, fmt.Errorf("failed to read database size: %w", err)
+	}
+
+	checkAge := func(name string, last *time.Time, max time.Duration) {
+		switch {
+		case max <= 0:
+		case last == nil:
+			status.Problems = append(status.Problems, fmt.Sprintf("no %s recorded", name))
+		case now.Sub(*last) > max:
+			status.Problems = append(status.Problems, fmt.Sprintf("last %s is %s old", name, now.Sub(*last).Round(time.Minute)))
+		}
+	}
+	checkAge("session", status.LastSession, opts.MaxSessionAge)
+	checkAge("meter reading", status.LastMeter, opts.MaxMeterAge)
+
+	if opts.MaxSize > 0 && status.Size > opts.MaxSize {
+		status.Problems = append(status.Problems, fmt.Sprintf("database size %d bytes exceeds %d bytes", status.Size, opts.MaxSize))
+	}
+
+	if len(status.Problems) > 0 {
+		status.Status = HealthDegraded
+	}
+
+	return status, nil
+}
+
+// latestTimestamp returns the newest timestamp of a table, nil if the table is missing or empty
+func latestTimestamp(ctx context.Context, q querier, table, column string) (*time.Time, error) {
+	exists, err := tableExists(ctx, q, table)
+	if err != nil || !exists {
+		return nil, err
+	}
+
+	from, err := sqlbuild.QuoteIdent(table)
+	if err != nil {
+		return nil, err
+	}
+	col, err := sqlbuild.QuoteIdent(column)
+	if err != nil {
+		return nil, err
+	}
+
+	var latest sql.NullString
+	if err := q.QueryRowContext(ctx, fmt.Sprintf("SELECT MAX(%s) FROM %s", col, from)).Scan(&latest); err != nil {
+		return nil, fmt.Errorf("failed to read latest timestamp of %s: %w", table, err)
+	}
+	if !latest.Valid {
+		return nil, nil
+	}
+
+	t, err := ParseTimestamp(latest.String, nil)
+	if err != nil {
+		return nil, nil
+	}
+	t = t.UTC()
+	return &t, nil
+}
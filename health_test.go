@@ -0,0 +1,48 @@
+package evccdb
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestCheckHealth(t *testing.T) {
+	client, cleanup := createTestDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	status, err := client.CheckHealth(ctx, HealthOptions{})
+	if err != nil {
+		t.Fatalf("CheckHealth failed: %v", err)
+	}
+	if status.Status != HealthOK {
+		t.Errorf("Expected ok without thresholds, got %s: %v", status.Status, status.Problems)
+	}
+	if status.LastSession == nil || !status.LastSession.Equal(time.Date(2023, 4, 5, 10, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected last session 2023-04-05 10:00, got %v", status.LastSession)
+	}
+	if status.Size == 0 {
+		t.Error("Expected database size")
+	}
+
+	status, err = client.CheckHealth(ctx, HealthOptions{MaxSessionAge: time.Hour, MaxMeterAge: time.Hour})
+	if err != nil {
+		t.Fatalf("CheckHealth failed: %v", err)
+	}
+	if status.Status != HealthDegraded || len(status.Problems) != 2 {
+		t.Errorf("Expected degraded with 2 problems, got %s: %v", status.Status, status.Problems)
+	}
+
+	now := time.Now().UTC().Format("2006-01-02 15:04:05")
+	_, _ = client.db.Exec("INSERT INTO meters (meter, ts, val) VALUES (1, ?, 1.0)", now)
+	_, _ = client.db.Exec("INSERT INTO sessions (id, created, loadpoint) VALUES (6, ?, 'Garage')", now)
+
+	status, err = client.CheckHealth(ctx, HealthOptions{MaxSessionAge: time.Hour, MaxMeterAge: time.Hour})
+	if err != nil {
+		t.Fatalf("CheckHealth failed: %v", err)
+	}
+	if status.Status != HealthOK {
+		t.Errorf("Expected ok after recent writes, got %s: %v", status.Status, status.Problems)
+	}
+}
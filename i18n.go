@@ -0,0 +1,138 @@
+package evccdb
+
+import (
+	"strings"
+	"sync"
+)
+
+var (
+	messagesMu sync.RWMutex
+	language   string
+	// messages are the translations of user-facing messages by language, keyed by the English message
+	messages = map[string]map[string]string{
+		"de": {
+			"WARNING: Make sure evcc is stopped and not accessing the database.\n":               "WARNUNG: Stellen Sie sicher, dass evcc gestoppt ist und nicht auf die Datenbank zugreift.\n",
+			"Type 'yes' to confirm and proceed: ":                                                "Zum Bestätigen und Fortfahren 'yes' eingeben: ",
+			"Operation cancelled":                                                                "Vorgang abgebrochen",
+			"Operation cancelled, %d rows written\n":                                             "Vorgang abgebrochen, %d Zeilen geschrieben\n",
+			"Operation cancelled, no changes made":                                               "Vorgang abgebrochen, keine Änderungen vorgenommen",
+			"Dry run completed (no changes made)":                                                "Testlauf abgeschlossen (keine Änderungen vorgenommen)",
+			"Transfer completed successfully":                                                    "Übertragung erfolgreich abgeschlossen",
+			"Rename completed successfully":                                                      "Umbenennung erfolgreich abgeschlossen",
+			"Delete completed successfully":                                                      "Löschen erfolgreich abgeschlossen",
+			"DRY RUN: Would transfer %d tables\n":                                                "TESTLAUF: Würde %d Tabellen übertragen\n",
+			"Transfer plan %s -> %s:\n":                                                          "Übertragungsplan %s -> %s:\n",
+			"Exported %s: %d rows\n":                                                             "%s exportiert: %d Zeilen\n",
+			"Imported %s: %d rows\n":                                                             "%s importiert: %d Zeilen\n",
+			"Transferred %s: %d rows\n":                                                          "%s übertragen: %d Zeilen\n",
+			"Successfully exported to %s\n":                                                      "Erfolgreich nach %s exportiert\n",
+			"Successfully imported from %s\n":                                                    "Erfolgreich aus %s importiert\n",
+			"Backup written to %s\n":                                                             "Sicherung nach %s geschrieben\n",
+			"Would delete %d sessions for loadpoint %q\n":                                        "Würde %d Ladevorgänge des Ladepunkts %q löschen\n",
+			"Deleted %d sessions for loadpoint %q\n":                                             "%d Ladevorgänge des Ladepunkts %q gelöscht\n",
+			"Moved %d sessions for loadpoint %q to trash\n":                                      "%d Ladevorgänge des Ladepunkts %q in den Papierkorb verschoben\n",
+			"Would delete %d sessions for vehicle %q\n":                                          "Würde %d Ladevorgänge des Fahrzeugs %q löschen\n",
+			"Deleted %d sessions for vehicle %q\n":                                               "%d Ladevorgänge des Fahrzeugs %q gelöscht\n",
+			"Moved %d sessions for vehicle %q to trash\n":                                        "%d Ladevorgänge des Fahrzeugs %q in den Papierkorb verschoben\n",
+			"Would purge %d sessions from trash\n":                                               "Würde %d Ladevorgänge endgültig aus dem Papierkorb löschen\n",
+			"Purged %d sessions from trash\n":                                                    "%d Ladevorgänge endgültig aus dem Papierkorb gelöscht\n",
+			"Restored %d sessions from trash\n":                                                  "%d Ladevorgänge aus dem Papierkorb wiederhergestellt\n",
+			"  Session %d restored as %d, its id is used by a newer session\n":                   "  Ladevorgang %d als %d wiederhergestellt, seine ID gehört einem neueren Ladevorgang\n",
+			"Setting %s: source %q, target %q. Use source value? [y/N]: ":                        "Einstellung %s: Quelle %q, Ziel %q. Wert der Quelle übernehmen? [y/N]: ",
+			"  %s: %d rows\n":                                                                    "  %s: %d Zeilen\n",
+			"WARNING: Table %s does not exist in destination, skipping\n":                        "WARNUNG: Tabelle %s existiert im Ziel nicht, wird übersprungen\n",
+			"  WARNING: Table %s does not exist in destination\n":                                "  WARNUNG: Tabelle %s existiert im Ziel nicht\n",
+			"WARNING: Column %s.%s exists in source but not in destination, will be skipped\n":   "WARNUNG: Spalte %s.%s existiert in der Quelle, aber nicht im Ziel, wird übersprungen\n",
+			"  WARNING: Column %s.%s exists in source but not in destination, will be skipped\n": "  WARNUNG: Spalte %s.%s existiert in der Quelle, aber nicht im Ziel, wird übersprungen\n",
+			"  WARNING: %d rows of %s would replace different destination rows: %s\n":            "  WARNUNG: %d Zeilen von %s würden abweichende Zeilen im Ziel ersetzen: %s\n",
+			"WARNING: Ignoring unknown CSV column %q\n":                                          "WARNUNG: Unbekannte CSV-Spalte %q wird ignoriert\n",
+			"WARNING: Prices in %s are merged into a database using %s, use --convert-currency to convert them\n": "WARNUNG: Preise in %s werden in eine Datenbank mit %s übernommen, --convert-currency rechnet sie um\n",
+			"Converting prices from %s to %s at %g\n":                                                             "Rechne Preise von %s in %s zum Kurs %g um\n",
+			"WARNING: Export has schema version %d, target database has %d\n":                                     "WARNUNG: Der Export hat Schemaversion %d, die Zieldatenbank %d\n",
+			"WARNING: Skipping unknown table %s, use --allow-unknown-tables to import it\n":                       "WARNUNG: Unbekannte Tabelle %s wird übersprungen, --allow-unknown-tables importiert sie\n",
+			"WARNING: Table %s has a different schema than in the export, only common columns are imported\n":     "WARNUNG: Tabelle %s hat ein anderes Schema als im Export, nur gemeinsame Spalten werden importiert\n",
+			"WARNING: Clock skew of %s detected from %d sessions recorded by both databases\n":                    "WARNUNG: Zeitversatz von %s anhand von %d Ladevorgängen in beiden Datenbanken erkannt\n",
+			"WARNING: %d source sessions overlap destination sessions on the same loadpoint\n":                    "WARNUNG: %d Ladevorgänge der Quelle überschneiden sich mit Ladevorgängen des Ziels am selben Ladepunkt\n",
+			"WARNING: %s references %s of the source database, run 'config orphans --fix' on the target\n":        "WARNUNG: %s verweist auf %s der Quelldatenbank, 'config orphans --fix' auf dem Ziel ausführen\n",
+			"WARNING: failed to roll back %s: %v\n":                                                               "WARNUNG: Zurücksetzen von %s fehlgeschlagen: %v\n",
+			"WARNING: %d tokens expired, %d expire within %d days, log in again in evcc\n":                        "WARNUNG: %d Tokens abgelaufen, %d laufen in %d Tagen ab, in evcc erneut anmelden\n",
+			"WARNING: Unknown settings key %q\n":                                                                  "WARNUNG: Unbekannter Einstellungsschlüssel %q\n",
+			"WARNING: export failed: %v\n":                                                                        "WARNUNG: Export fehlgeschlagen: %v\n",
+			"WARNING: %v\n":                                                                                       "WARNUNG: %v\n",
+			"WARNING: Skipped %d rows of %s rejected by the database\n":                                           "WARNUNG: %d von der Datenbank abgelehnte Zeilen von %s übersprungen\n",
+			"Keeping existing %s %s\n":                                                                            "%s %s ist bereits vorhanden und bleibt erhalten\n",
+			"WARNING: --%s is deprecated, use --%s\n":                                                             "WARNUNG: --%s ist veraltet, stattdessen --%s verwenden\n",
+			"Consumption by vehicle and month:":                                                                   "Verbrauch nach Fahrzeug und Monat:",
+			"Solar share by month:":                                                                               "Solaranteil nach Monat:",
+			"Sessions with the most grid energy outside the PV window:":                                           "Ladevorgänge mit der meisten Netzenergie außerhalb des PV-Fensters:",
+			"Sessions by %s:":                        "Ladevorgänge nach %s:",
+			"Grid sessions by type:":                 "Netzereignisse nach Typ:",
+			"Skipped rows written to %s\n":           "Übersprungene Zeilen nach %s geschrieben\n",
+			"Database:":                              "Datenbank:",
+			"Tables:":                                "Tabellen:",
+			"Found %s (%s, %s). Inspect it? [y/N]: ": "%s gefunden (%s, %s). Untersuchen? [y/N]: ",
+			"Found evcc databases:\n":                "Gefundene evcc-Datenbanken:\n",
+			"Database to inspect [1-%d]: ":           "Zu untersuchende Datenbank [1-%d]: ",
+			"Optimized database with %s: %s -> %s, %s reclaimed\n": "Datenbank mit %s optimiert: %s -> %s, %s freigegeben\n",
+			"No orphaned device references found":                  "Keine verwaisten Geräteverweise gefunden",
+			"\n%s references %s (%s)\n":                            "\n%s verweist auf %s (%s)\n",
+			"Replace with [1-%d], ":                                "Ersetzen durch [1-%d], ",
+			"'r' to remove, Enter to skip: ":                       "'r' zum Entfernen, Enter zum Überspringen: ",
+			"Removed reference %s from %s\n":                       "Verweis %s aus %s entfernt\n",
+			"Replaced reference %s with %s in %s\n":                "Verweis %s durch %s in %s ersetzt\n",
+			" (dry run)":                                           " (Testlauf)",
+			"Step %d/%d: %s\n":                                     "Schritt %d/%d: %s\n",
+			"Batch completed successfully":                         "Stapel erfolgreich abgeschlossen",
+			"Rolled back %s\n":                                     "%s zurückgesetzt\n",
+			"Would rename loadpoint %q -> %q: sessions=%d, settings=%d, configs=%d\n":   "Würde Ladepunkt %q -> %q umbenennen: Ladevorgänge=%d, Einstellungen=%d, Konfigurationen=%d\n",
+			"Renamed loadpoint %q -> %q: sessions=%d, settings=%d, configs=%d\n":        "Ladepunkt %q -> %q umbenannt: Ladevorgänge=%d, Einstellungen=%d, Konfigurationen=%d\n",
+			"Would rename loadpoint lp%d -> %q: sessions=%d, settings=%d, configs=%d\n": "Würde Ladepunkt lp%d -> %q umbenennen: Ladevorgänge=%d, Einstellungen=%d, Konfigurationen=%d\n",
+			"Renamed loadpoint lp%d -> %q: sessions=%d, settings=%d, configs=%d\n":      "Ladepunkt lp%d -> %q umbenannt: Ladevorgänge=%d, Einstellungen=%d, Konfigurationen=%d\n",
+			"Would rename vehicle %q -> %q: sessions=%d, settings=%d, configs=%d\n":     "Würde Fahrzeug %q -> %q umbenennen: Ladevorgänge=%d, Einstellungen=%d, Konfigurationen=%d\n",
+			"Renamed vehicle %q -> %q: sessions=%d, settings=%d, configs=%d\n":          "Fahrzeug %q -> %q umbenannt: Ladevorgänge=%d, Einstellungen=%d, Konfigurationen=%d\n",
+			"Signature written to %s\n":                                         "Signatur nach %s geschrieben\n",
+			"Signature of %s verified\n":                                        "Signatur von %s geprüft\n",
+			"Setting conflict %s: source %q, target %q, keeping source value\n": "Konflikt bei Einstellung %s: Quelle %q, Ziel %q, Wert der Quelle wird übernommen\n",
+			"Setting conflict %s: source %q, target %q, keeping target value\n": "Konflikt bei Einstellung %s: Quelle %q, Ziel %q, Wert des Ziels wird beibehalten\n",
+			"Renamed template %q to %q in config db:%d\n":                       "Template %q in %q umbenannt (Konfiguration db:%d)\n",
+		},
+	}
+)
+
+// RegisterMessages adds translations of English messages for a language
+func RegisterMessages(lang string, translations map[string]string) {
+	messagesMu.Lock()
+	defer messagesMu.Unlock()
+
+	lang = strings.ToLower(lang)
+	if messages[lang] == nil {
+		messages[lang] = make(map[string]string)
+	}
+	for msg, translation := range translations {
+		messages[lang][msg] = translation
+	}
+}
+
+// SetLanguage selects the language of messages by a tag like de or de_DE.UTF-8, English if there are no translations
+func SetLanguage(tag string) {
+	messagesMu.Lock()
+	defer messagesMu.Unlock()
+	_, language = localeLanguage(tag)
+}
+
+// Translate returns a message in the selected language, the English message if there is no translation
+func Translate(msg string) string {
+	messagesMu.RLock()
+	defer messagesMu.RUnlock()
+
+	if translation, ok := messages[language][msg]; ok {
+		return translation
+	}
+	return msg
+}
+
+// localeLanguage returns the normalized tag, e.g. de-de for de_DE.UTF-8, and its language
+func localeLanguage(tag string) (string, string) {
+	norm := strings.ToLower(strings.ReplaceAll(strings.SplitN(tag, ".", 2)[0], "_", "-"))
+	return norm, strings.SplitN(norm, "-", 2)[0]
+}
@@ -0,0 +1,35 @@
+package evccdb
+
+import (
+	"regexp"
+	"slices"
+	"testing"
+)
+
+func TestTranslate(t *testing.T) {
+	defer SetLanguage("")
+
+	SetLanguage("de_DE.UTF-8")
+	if got := Translate("Operation cancelled"); got != "Vorgang abgebrochen" {
+		t.Errorf("Unexpected translation: %q", got)
+	}
+	if got := Translate("not translated"); got != "not translated" {
+		t.Errorf("Expected English fallback, got %q", got)
+	}
+
+	SetLanguage("C")
+	if got := Translate("Operation cancelled"); got != "Operation cancelled" {
+		t.Errorf("Expected English message, got %q", got)
+	}
+}
+
+func TestTranslationVerbs(t *testing.T) {
+	verbs := regexp.MustCompile(`%[-+# 0-9.]*[a-zA-Z%]`)
+	for lang, translations := range messages {
+		for msg, translation := range translations {
+			if a, b := verbs.FindAllString(msg, -1), verbs.FindAllString(translation, -1); !slices.Equal(a, b) {
+				t.Errorf("%s translation of %q has verbs %v, expected %v", lang, msg, b, a)
+			}
+		}
+	}
+}
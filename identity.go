@@ -0,0 +1,93 @@
+package evccdb
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"path"
+	"sync"
+)
+
+// identitySettings are the settings key patterns identifying an installation, in path.Match syntax
+var (
+	identitySettingsMu sync.RWMutex
+	identitySettings   = []string{
+		"plant",
+		"installation",
+		"instanceId",
+		"sponsorToken",
+		"telemetry",
+		"telemetry.*",
+	}
+)
+
+// RegisterIdentitySetting adds a settings key pattern removed by TransferOptions.StripInstanceIdentity
+func RegisterIdentitySetting(pattern string) {
+	identitySettingsMu.Lock()
+	defer identitySettingsMu.Unlock()
+	identitySettings = append(identitySettings, pattern)
+}
+
+// IdentitySettings returns the settings key patterns identifying an installation
+func IdentitySettings() []string {
+	identitySettingsMu.RLock()
+	defer identitySettingsMu.RUnlock()
+	return append([]string(nil), identitySettings...)
+}
+
+// IsIdentitySetting reports whether a settings key identifies the installation, e.g. the sponsor token
+func IsIdentitySetting(key string) bool {
+	for _, pattern := range IdentitySettings() {
+		if ok, _ := path.Match(pattern, key); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// stripSetting reports whether a setting is left out by the identity options
+func (opts TransferOptions) stripSetting(key string) bool {
+	return opts.StripInstanceIdentity && IsIdentitySetting(key)
+}
+
+// stripIdentityRows removes the identity settings from exported settings rows
+func stripIdentityRows(rows []any) []any {
+	var result []any
+	for _, row := range rows {
+		if entry, ok := row.(map[string]any); ok && IsIdentitySetting(settingString(entry["key"])) {
+			continue
+		}
+		result = append(result, row)
+	}
+	return result
+}
+
+// stripIdentitySettings deletes the identity settings from the settings table of tx
+func stripIdentitySettings(ctx context.Context, tx *sql.Tx) (int, error) {
+	rows, err := tx.QueryContext(ctx, "SELECT key FROM settings")
+	if err != nil {
+		return 0, fmt.Errorf("failed to query settings: %w", err)
+	}
+	var keys []string
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			_ = rows.Close()
+			return 0, fmt.Errorf("failed to scan setting: %w", err)
+		}
+		if IsIdentitySetting(key) {
+			keys = append(keys, key)
+		}
+	}
+	_ = rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	for _, key := range keys {
+		if _, err := tx.ExecContext(ctx, "DELETE FROM settings WHERE key = ?", key); err != nil {
+			return 0, fmt.Errorf("failed to delete setting %s: %w", key, err)
+		}
+	}
+	return len(keys), nil
+}
@@ -0,0 +1,55 @@
+package evccdb
+
+import (
+	"bytes"
+	"context"
+	"testing"
+)
+
+func TestStripInstanceIdentity(t *testing.T) {
+	ctx := context.Background()
+	opts := TransferOptions{Tables: []string{"settings"}, StripInstanceIdentity: true}
+
+	src, srcCleanup := createTestDB(t)
+	defer srcCleanup()
+	_, _ = src.db.Exec("INSERT INTO settings (key, value) VALUES ('sponsorToken', 'src-token'), ('plant', 'src-plant'), ('telemetry', 'true')")
+
+	t.Run("transfer", func(t *testing.T) {
+		dst, dstCleanup := createTestDB(t)
+		defer dstCleanup()
+		_, _ = dst.db.Exec("INSERT INTO settings (key, value) VALUES ('sponsorToken', 'dst-token')")
+
+		if err := Transfer(ctx, src, dst, opts); err != nil {
+			t.Fatalf("Transfer failed: %v", err)
+		}
+		if value := settingValue(t, dst, "sponsorToken"); value != "dst-token" {
+			t.Errorf("Expected the target sponsor token to be kept, got %q", value)
+		}
+		var count int
+		_ = dst.db.QueryRow("SELECT COUNT(*) FROM settings WHERE key IN ('plant', 'telemetry')").Scan(&count)
+		if count != 0 {
+			t.Errorf("Expected identity settings to be stripped, got %d", count)
+		}
+		if value := settingValue(t, dst, "lp1.mode"); value != "pv" {
+			t.Errorf("Expected other settings to be transferred, got %q", value)
+		}
+	})
+
+	t.Run("import", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := src.ExportJSON(&buf, opts); err != nil {
+			t.Fatalf("ExportJSON failed: %v", err)
+		}
+
+		dst, dstCleanup := createTestDB(t)
+		defer dstCleanup()
+		if err := dst.ImportJSON(&buf, opts); err != nil {
+			t.Fatalf("ImportJSON failed: %v", err)
+		}
+		var count int
+		_ = dst.db.QueryRow("SELECT COUNT(*) FROM settings WHERE key IN ('sponsorToken', 'plant', 'telemetry')").Scan(&count)
+		if count != 0 {
+			t.Errorf("Expected identity settings to be stripped, got %d", count)
+		}
+	})
+}
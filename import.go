@@ -6,12 +6,30 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/iseeberg79/evccdb/internal/sqlbuild"
 )
 
 // ImportJSON imports data from a JSON export file
 func (c *Client) ImportJSON(r io.Reader, opts TransferOptions) error {
+	return c.ImportJSONContext(context.Background(), r, opts)
+}
+
+// ImportJSONContext imports data from a JSON export file, which may be gzip or zstd compressed.
+// A cancelled context rolls the import back.
+func (c *Client) ImportJSONContext(ctx context.Context, r io.Reader, opts TransferOptions) error {
+	zr, err := NewDecompressReader(r)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = zr.Close() }()
+
 	var export ExportFormat
-	if err := json.NewDecoder(r).Decode(&export); err != nil {
+	if err := json.NewDecoder(zr).Decode(&export); err != nil {
 		return fmt.Errorf("failed to decode JSON: %w", err)
 	}
 
@@ -19,10 +37,18 @@ func (c *Client) ImportJSON(r io.Reader, opts TransferOptions) error {
 		return fmt.Errorf("unsupported export format version: %s", export.Version)
 	}
 
-	ctx := context.Background()
-	tx, err := c.db.BeginTx(ctx, nil)
+	// Warn before overwriting a different schema version of the target
+	userVersion, err := c.UserVersion()
 	if err != nil {
-		return fmt.Errorf("failed to begin transaction: %w", err)
+		return err
+	}
+	if export.UserVersion != 0 && userVersion != 0 && export.UserVersion != userVersion {
+		fmt.Printf(Translate("WARNING: Export has schema version %d, target database has %d\n"), export.UserVersion, userVersion)
+	}
+
+	tx, err := c.beginConn(ctx, nil, opts.Fast)
+	if err != nil {
+		return err
 	}
 	defer func() { _ = tx.Rollback() }()
 
@@ -31,20 +57,61 @@ func (c *Client) ImportJSON(r io.Reader, opts TransferOptions) error {
 	if len(opts.Tables) > 0 {
 		tablesToImport = opts.Tables
 	} else {
-		switch opts.Mode {
-		case TransferConfig:
-			tablesToImport = c.GetConfigTables()
-		case TransferMetrics:
-			tablesToImport = c.GetMetricsTables()
-		case TransferAll:
+		if _, ok := transferModeOverride(opts.Mode); ok || opts.Mode != TransferAll {
+			if tablesToImport, err = c.ResolveTables(opts); err != nil {
+				return err
+			}
+		} else {
 			for table := range export.Tables {
-				tablesToImport = append(tablesToImport, table)
+				if opts.IncludeCaches || !isCacheTable(table) {
+					tablesToImport = append(tablesToImport, table)
+				}
 			}
-		default:
-			return fmt.Errorf("unknown transfer mode: %d", opts.Mode)
 		}
 	}
 
+	// Untrusted export files must not overwrite tables evcc does not own
+	if !opts.AllowUnknownTables {
+		tablesToImport = c.allowedImportTables(tablesToImport, opts.AllowedTables)
+	}
+
+	// Referenced tables are imported first
+	tablesToImport, err = c.OrderTablesByDependencies(tablesToImport)
+	if err != nil {
+		return fmt.Errorf("failed to order tables: %w", err)
+	}
+
+	if err := c.printExportMetadata(&export, tablesToImport); err != nil {
+		return err
+	}
+
+	if err := deferForeignKeys(ctx, tx.tx, opts); err != nil {
+		return err
+	}
+	if err := runSQL(ctx, tx.tx, "pre", opts.PreSQL); err != nil {
+		return err
+	}
+
+	var written []string
+	for _, table := range tablesToImport {
+		if _, ok := export.Tables[table].([]any); ok {
+			written = append(written, table)
+		}
+	}
+	if err := truncateTables(ctx, tx.tx, written, opts); err != nil {
+		return err
+	}
+
+	var tracker *progressTracker
+	if opts.OnRowProgress != nil {
+		total := 0
+		for _, table := range tablesToImport {
+			rows, _ := export.Tables[table].([]any)
+			total += len(rows)
+		}
+		tracker = newProgressTracker(opts.OnRowProgress, total)
+	}
+
 	for _, table := range tablesToImport {
 		tableData, exists := export.Tables[table]
 		if !exists {
@@ -56,82 +123,195 @@ func (c *Client) ImportJSON(r io.Reader, opts TransferOptions) error {
 			continue
 		}
 
-		count, err := c.importTableWithTx(ctx, tx, table, rows)
+		if table == "settings" {
+			if opts.StripInstanceIdentity {
+				rows = stripIdentityRows(rows)
+			}
+			merger, err := newSettingsMerger(ctx, tx.tx, opts, func() (time.Time, error) { return exportActivity(&export), nil })
+			if err != nil {
+				return err
+			}
+			if merger != nil {
+				rows = merger.filterRows(rows)
+			}
+		}
+
+		if table == "sessions" {
+			var currency string
+			if export.Metadata != nil {
+				currency = export.Metadata.Currency
+			}
+			dstCurrency, err := readCurrency(ctx, tx.tx)
+			if err != nil {
+				return err
+			}
+			rate, err := priceRate(currency, dstCurrency, opts.ConvertCurrency)
+			if err != nil {
+				return err
+			}
+			if rate != 1 {
+				scaleRowPrices(rows, rate)
+			}
+		}
+
+		count, err := c.importTableWithTx(ctx, tx.tx, table, rows, opts, tracker)
 		if err != nil {
 			return fmt.Errorf("failed to import table %s: %w", table, err)
 		}
 
+		if table == "configs" && !opts.KeepTemplates {
+			if _, err := remapTemplates(ctx, tx.tx, opts.OnTemplateAlias); err != nil {
+				return err
+			}
+		}
+
 		if opts.OnProgress != nil {
 			opts.OnProgress(table, count)
 		}
 	}
 
+	if opts.SchemaObjects && export.Metadata != nil {
+		if err := restoreSchemaObjects(ctx, tx.tx, export.Metadata.Objects); err != nil {
+			return err
+		}
+	}
+
+	// Restore schema versioning so evcc does not treat the database as needing migration
+	if export.UserVersion != 0 {
+		if _, err := tx.tx.ExecContext(ctx, fmt.Sprintf("PRAGMA user_version = %d", export.UserVersion)); err != nil {
+			return fmt.Errorf("failed to restore user_version: %w", err)
+		}
+	}
+	if export.ApplicationID != 0 {
+		if _, err := tx.tx.ExecContext(ctx, fmt.Sprintf("PRAGMA application_id = %d", export.ApplicationID)); err != nil {
+			return fmt.Errorf("failed to restore application_id: %w", err)
+		}
+	}
+
+	if err := runSQL(ctx, tx.tx, "post", opts.PostSQL); err != nil {
+		return err
+	}
+
 	return tx.Commit()
 }
 
-// importTableWithTx imports a table using a transaction
+// allowedImportTables removes the tables that are not in allowed, the known evcc tables if nil
+func (c *Client) allowedImportTables(tables, allowed []string) []string {
+	if allowed == nil {
+		allowed = c.GetAllTables()
+	}
+	known := make(map[string]bool)
+	for _, table := range allowed {
+		known[table] = true
+	}
+
+	var result []string
+	for _, table := range tables {
+		if !known[table] {
+			fmt.Printf(Translate("WARNING: Skipping unknown table %s, use --allow-unknown-tables to import it\n"), table)
+			continue
+		}
+		result = append(result, table)
+	}
+	return result
+}
+
+// importTableWithTx imports a table using a transaction. Rows with the same
+// columns share one prepared statement.
 func (c *Client) importTableWithTx(ctx context.Context, tx interface {
-	ExecContext(context.Context, string, ...any) (sql.Result, error)
-}, table string, rows []any) (int, error) {
-	// Get column types for the table
-	columnTypes, err := c.getColumnTypesForTable(table)
+	querier
+	PrepareContext(context.Context, string) (*sql.Stmt, error)
+}, table string, rows []any, opts TransferOptions, tracker *progressTracker) (int, error) {
+	// Read the column types through the transaction, which may have changed the schema
+	cols, err := tableColumns(ctx, tx, table)
 	if err != nil {
 		return 0, err
 	}
+	columnTypes := writableColumnTypes(cols)
+
+	stmts := make(map[string]*sql.Stmt)
+	defer func() {
+		for _, stmt := range stmts {
+			_ = stmt.Close()
+		}
+	}()
 
 	count := 0
-	for _, rowData := range rows {
+	for row, rowData := range rows {
+		tracker.add(table, 1)
 		rowMap, ok := rowData.(map[string]any)
 		if !ok {
 			continue
 		}
 
 		// Filter columns to only those that exist in the table
-		filteredRow := make(map[string]any)
-		for key, val := range rowMap {
+		var cols []string
+		for key := range rowMap {
 			if _, exists := columnTypes[key]; exists {
-				filteredRow[key] = val
+				cols = append(cols, key)
 			}
 		}
-
-		if len(filteredRow) == 0 {
+		if len(cols) == 0 {
 			continue
 		}
+		sort.Strings(cols)
+
+		values := make([]any, len(cols))
+		for i, col := range cols {
+			val := rowMap[col]
+			if columnKindOf(columnTypes[col]) == kindTimestamp {
+				val = storeTimestamp(val, opts.Location)
+			}
+			values[i] = bindValue(val)
+		}
+
+		key := strings.Join(cols, ",")
+		stmt, ok := stmts[key]
+		if !ok {
+			query, err := sqlbuild.Insert(table, cols, true)
+			if err != nil {
+				return 0, err
+			}
+			if stmt, err = tx.PrepareContext(ctx, query); err != nil {
+				return 0, fmt.Errorf("failed to prepare insert: %w", err)
+			}
+			stmts[key] = stmt
+		}
 
-		// Build and execute INSERT
-		sql := buildInsertFromMapWithColumns(table, filteredRow, columnTypes)
-		if _, err := tx.ExecContext(ctx, sql); err != nil {
-			return 0, fmt.Errorf("failed to insert row: %w", err)
+		if _, err := stmt.ExecContext(ctx, values...); err != nil {
+			rowErr := newRowError(table, row, cols, values, err)
+			if opts.SkipRowErrors && skippableRowError(err) {
+				if opts.OnRowError != nil {
+					opts.OnRowError(rowErr)
+				}
+				continue
+			}
+			return 0, fmt.Errorf("failed to insert row %d: %w", row, rowErr)
 		}
 
 		count++
+		if count == opts.MaxRowsPerTable {
+			break
+		}
 	}
 
 	return count, nil
 }
 
-// buildInsertFromMapWithColumns builds an INSERT statement from a row map
-func buildInsertFromMapWithColumns(table string, row map[string]any, columnTypes map[string]string) string {
-	var cols []string
-	var vals []string
-
-	for col, val := range row {
-		cols = append(cols, fmt.Sprintf("`%s`", col))
-		colType := columnTypes[col]
-		vals = append(vals, formatValueForSQL(val, colType))
-	}
-
-	colsStr := "(" + cols[0]
-	for _, col := range cols[1:] {
-		colsStr += ", " + col
-	}
-	colsStr += ")"
-
-	valsStr := "(" + vals[0]
-	for _, val := range vals[1:] {
-		valsStr += ", " + val
+// bindValue converts a value decoded from JSON for binding, integral numbers
+// are stored as integers and unsupported types as NULL
+func bindValue(val any) any {
+	switch v := val.(type) {
+	case string, int, int64, bool:
+		return v
+	case float64:
+		if v == math.Trunc(v) && math.Abs(v) < 1<<53 {
+			return int64(v)
+		}
+		return v
+	case time.Time:
+		return v.UTC().Format("2006-01-02 15:04:05.999999999")
+	default:
+		return nil
 	}
-	valsStr += ")"
-
-	return fmt.Sprintf("INSERT OR REPLACE INTO `%s` %s VALUES %s", table, colsStr, valsStr)
 }
@@ -0,0 +1,83 @@
+// Package sqlbuild builds SQLite statements from validated table and column names
+package sqlbuild
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var validIdentifier = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// ValidateIdentifier checks if a string is safe to use as a SQL identifier
+func ValidateIdentifier(name string) error {
+	if !validIdentifier.MatchString(name) {
+		return fmt.Errorf("invalid identifier: %q", name)
+	}
+	return nil
+}
+
+// QuoteIdent validates a table or column name and quotes it for SQLite
+func QuoteIdent(name string) (string, error) {
+	if err := ValidateIdentifier(name); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("`%s`", name), nil
+}
+
+// QuoteColumns validates column names and returns them as quoted, comma separated list
+func QuoteColumns(columns []string) (string, error) {
+	quoted := make([]string, len(columns))
+	for i, col := range columns {
+		var err error
+		if quoted[i], err = QuoteIdent(col); err != nil {
+			return "", err
+		}
+	}
+	return strings.Join(quoted, ", "), nil
+}
+
+// Select builds a SELECT of the given columns of a table, all columns if none are given
+func Select(table string, columns []string) (string, error) {
+	from, err := QuoteIdent(table)
+	if err != nil {
+		return "", err
+	}
+
+	cols := "*"
+	if len(columns) > 0 {
+		if cols, err = QuoteColumns(columns); err != nil {
+			return "", err
+		}
+	}
+	return fmt.Sprintf("SELECT %s FROM %s", cols, from), nil
+}
+
+// Insert builds an INSERT with one placeholder per column, replacing
+// existing rows if replace is set
+func Insert(table string, columns []string, replace bool) (string, error) {
+	into, err := QuoteIdent(table)
+	if err != nil {
+		return "", err
+	}
+	cols, err := QuoteColumns(columns)
+	if err != nil {
+		return "", err
+	}
+
+	verb := "INSERT"
+	if replace {
+		verb = "INSERT OR REPLACE"
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(columns)), ", ")
+	return fmt.Sprintf("%s INTO %s (%s) VALUES (%s)", verb, into, cols, placeholders), nil
+}
+
+// Count builds a SELECT COUNT(*) of a table
+func Count(table string) (string, error) {
+	from, err := QuoteIdent(table)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("SELECT COUNT(*) FROM %s", from), nil
+}
@@ -0,0 +1,50 @@
+package sqlbuild
+
+import (
+	"testing"
+)
+
+func TestSelectSQL(t *testing.T) {
+	query, err := Select("sessions", []string{"id", "created"})
+	if err != nil {
+		t.Fatalf("Select failed: %v", err)
+	}
+	if query != "SELECT `id`, `created` FROM `sessions`" {
+		t.Errorf("Unexpected query: %s", query)
+	}
+
+	query, err = Select("sessions", nil)
+	if err != nil {
+		t.Fatalf("Select failed: %v", err)
+	}
+	if query != "SELECT * FROM `sessions`" {
+		t.Errorf("Unexpected query: %s", query)
+	}
+}
+
+func TestInsertSQL(t *testing.T) {
+	query, err := Insert("settings", []string{"key", "value"}, true)
+	if err != nil {
+		t.Fatalf("Insert failed: %v", err)
+	}
+	if query != "INSERT OR REPLACE INTO `settings` (`key`, `value`) VALUES (?, ?)" {
+		t.Errorf("Unexpected query: %s", query)
+	}
+}
+
+func TestSQLBuilderRejectsInvalidIdentifiers(t *testing.T) {
+	for _, name := range []string{"settings`; DROP TABLE sessions; --", "a b", "", "1table"} {
+		if _, err := Select(name, nil); err == nil {
+			t.Errorf("Select accepted table %q", name)
+		}
+		if _, err := Select("settings", []string{name}); err == nil {
+			t.Errorf("Select accepted column %q", name)
+		}
+		if _, err := Insert("settings", []string{"key", name}, false); err == nil {
+			t.Errorf("Insert accepted column %q", name)
+		}
+		if _, err := Count(name); err == nil {
+			t.Errorf("Count accepted table %q", name)
+		}
+	}
+}
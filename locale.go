@@ -0,0 +1,108 @@
+package evccdb
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Locale formats the numbers, timestamps and column headers of CSV output, e.g. so
+// spreadsheets of German users do not misparse decimal points. The zero Locale keeps
+// the plain formatting.
+type Locale struct {
+	Tag          string
+	DecimalComma bool
+	// Comma is the field separator, a semicolon for locales with decimal comma as spreadsheets expect
+	Comma rune
+	// TimeLayout formats timestamps, empty keeps their default format
+	TimeLayout string
+	// Headers translates column names
+	Headers map[string]string
+}
+
+// localeTimeLayouts are the timestamp layouts by language or language-region
+var localeTimeLayouts = map[string]string{
+	"de":    "02.01.2006 15:04:05",
+	"fr":    "02/01/2006 15:04:05",
+	"it":    "02/01/2006 15:04:05",
+	"es":    "02/01/2006 15:04:05",
+	"nl":    "02-01-2006 15:04:05",
+	"en-us": "01/02/2006 15:04:05",
+}
+
+// localeHeaders are the translated column headers by language
+var localeHeaders = map[string]map[string]string{
+	"de": {
+		"created":          "Beginn",
+		"finished":         "Ende",
+		"loadpoint":        "Ladepunkt",
+		"vehicle":          "Fahrzeug",
+		"identifier":       "Kennung",
+		"odometer":         "Kilometerstand",
+		"meter_start_kwh":  "Zählerstand Beginn (kWh)",
+		"meter_end_kwh":    "Zählerstand Ende (kWh)",
+		"charged_kwh":      "Geladen (kWh)",
+		"solar_kwh":        "Solar (kWh)",
+		"solar_percentage": "Solaranteil (%)",
+		"price":            "Preis",
+		"price_per_kwh":    "Preis pro kWh",
+		"co2_per_kwh":      "CO2 pro kWh (g)",
+		"charge_duration":  "Ladedauer",
+		"sessions":         "Ladevorgänge",
+		"sites":            "Standorte",
+		"ts":               "Zeitpunkt",
+		"val":              "Wert",
+	},
+}
+
+// ParseLocale returns the locale of a tag like de, de-DE or de_DE.UTF-8, the zero Locale for an empty tag
+func ParseLocale(tag string) Locale {
+	if tag == "" {
+		return Locale{}
+	}
+
+	norm, lang := localeLanguage(tag)
+
+	l := Locale{Tag: tag, Comma: ',', TimeLayout: "2006-01-02 15:04:05", Headers: localeHeaders[lang]}
+	if usesDecimalComma(lang) {
+		l.DecimalComma = true
+		l.Comma = ';'
+	}
+	if layout, ok := localeTimeLayouts[norm]; ok {
+		l.TimeLayout = layout
+	} else if layout, ok := localeTimeLayouts[lang]; ok {
+		l.TimeLayout = layout
+	}
+	return l
+}
+
+// Header returns the translated header of a column, the column name if there is none
+func (l Locale) Header(col string) string {
+	if h, ok := l.Headers[col]; ok {
+		return h
+	}
+	return col
+}
+
+// FormatValue formats a value for CSV output, NULL becomes an empty string
+func (l Locale) FormatValue(val any) string {
+	switch v := val.(type) {
+	case nil:
+		return ""
+	case float64:
+		if l.Tag == "" {
+			break
+		}
+		s := strconv.FormatFloat(v, 'f', -1, 64)
+		if l.DecimalComma {
+			s = strings.Replace(s, ".", ",", 1)
+		}
+		return s
+	case time.Time, string:
+		if t, ok := timestampOf(v); ok && l.TimeLayout != "" {
+			return t.Format(l.TimeLayout)
+		}
+	}
+	return fmt.Sprint(val)
+}
@@ -0,0 +1,86 @@
+package evccdb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// LockSuffix is appended to the database path to name its lock file
+const LockSuffix = ".evccdb.lock"
+
+// lockPollInterval is the delay between attempts of a waiting lock
+const lockPollInterval = 500 * time.Millisecond
+
+// ErrLocked is returned when another evccdb process holds the lock of a database
+var ErrLocked = errors.New("database is locked by another evccdb process")
+
+// LockOptions configures LockDatabase
+type LockOptions struct {
+	// Wait blocks until the lock is released or the context is cancelled
+	Wait bool
+	// Force removes the lock of another process, e.g. a stale lock left by a crash
+	Force bool
+}
+
+// DatabaseLock is an advisory lock file held on a database
+type DatabaseLock struct {
+	path string
+}
+
+// LockDatabase creates the lock file of the database at path. The lock is
+// advisory, it only keeps other evccdb invocations from writing concurrently.
+func LockDatabase(ctx context.Context, path string, opts LockOptions) (*DatabaseLock, error) {
+	lockPath := path + LockSuffix
+
+	host, _ := os.Hostname()
+	holder := fmt.Sprintf("pid %d on %s since %s\n", os.Getpid(), host, time.Now().Format(time.RFC3339))
+
+	for {
+		f, err := os.OpenFile(lockPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+		if err == nil {
+			_, err = f.WriteString(holder)
+			if cerr := f.Close(); err == nil {
+				err = cerr
+			}
+			if err != nil {
+				_ = os.Remove(lockPath)
+				return nil, fmt.Errorf("failed to write lock file: %w", err)
+			}
+			return &DatabaseLock{path: lockPath}, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, fmt.Errorf("failed to create lock file: %w", err)
+		}
+
+		if opts.Force {
+			if err := os.Remove(lockPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+				return nil, fmt.Errorf("failed to remove lock file: %w", err)
+			}
+			opts.Force = false
+			continue
+		}
+
+		if !opts.Wait {
+			other, _ := os.ReadFile(lockPath)
+			return nil, fmt.Errorf("%w (%s, %s)", ErrLocked, strings.TrimSpace(string(other)), lockPath)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(lockPollInterval):
+		}
+	}
+}
+
+// Release removes the lock file
+func (l *DatabaseLock) Release() error {
+	if err := os.Remove(l.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove lock file: %w", err)
+	}
+	return nil
+}
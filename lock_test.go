@@ -0,0 +1,48 @@
+package evccdb
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLockDatabase(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "evcc.db")
+	ctx := context.Background()
+
+	lock, err := LockDatabase(ctx, path, LockOptions{})
+	if err != nil {
+		t.Fatalf("LockDatabase failed: %v", err)
+	}
+
+	if _, err := LockDatabase(ctx, path, LockOptions{}); !errors.Is(err, ErrLocked) {
+		t.Errorf("Expected ErrLocked, got %v", err)
+	}
+
+	// A waiting lock is acquired once the holder releases it
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		_ = lock.Release()
+	}()
+	waiting, err := LockDatabase(ctx, path, LockOptions{Wait: true})
+	if err != nil {
+		t.Fatalf("Waiting LockDatabase failed: %v", err)
+	}
+
+	forced, err := LockDatabase(ctx, path, LockOptions{Force: true})
+	if err != nil {
+		t.Fatalf("Forced LockDatabase failed: %v", err)
+	}
+	_ = waiting.Release()
+	_ = forced.Release()
+
+	cancelled, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	held, _ := LockDatabase(ctx, path, LockOptions{})
+	defer func() { _ = held.Release() }()
+	if _, err := LockDatabase(cancelled, path, LockOptions{Wait: true}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected deadline exceeded, got %v", err)
+	}
+}
@@ -0,0 +1,47 @@
+package evccdb
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+	"strings"
+	"sync/atomic"
+)
+
+// Schema creates the tables of an evcc database
+//
+//go:embed schema.sql
+var Schema string
+
+// sharedMemory reports whether path is the URI of an in-memory database shared by the connections
+func sharedMemory(path string) bool {
+	return strings.HasPrefix(path, "file:") && strings.Contains(path, "mode=memory") && strings.Contains(path, "cache=shared")
+}
+
+// memoryDatabases numbers the in-memory databases, so every OpenMemory gets its own
+var memoryDatabases atomic.Int64
+
+// OpenMemory creates an in-memory evcc database with the evcc tables and the schema version
+// stored in PRAGMA user_version, e.g. for tests or to try a transfer. It is discarded on Close.
+func OpenMemory(schemaVersion int) (*Client, error) {
+	c, err := Open(fmt.Sprintf("file:evccdb-memory-%d?mode=memory&cache=shared", memoryDatabases.Add(1)))
+	if err != nil {
+		return nil, err
+	}
+
+	// The connections share the database, which lives as long as one of them is open
+	if c.keep, err = c.db.Conn(context.Background()); err != nil {
+		_ = c.Close()
+		return nil, fmt.Errorf("failed to get connection: %w", err)
+	}
+
+	if _, err := c.db.Exec(Schema); err != nil {
+		_ = c.Close()
+		return nil, fmt.Errorf("failed to create schema: %w", err)
+	}
+	if _, err := c.db.Exec(fmt.Sprintf("PRAGMA user_version = %d", schemaVersion)); err != nil {
+		_ = c.Close()
+		return nil, fmt.Errorf("failed to set schema version: %w", err)
+	}
+	return c, nil
+}
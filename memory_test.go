@@ -0,0 +1,58 @@
+package evccdb
+
+import (
+	"bytes"
+	"context"
+	"testing"
+)
+
+func TestOpenMemory(t *testing.T) {
+	ctx := context.Background()
+
+	src, cleanup := createTestDB(t)
+	defer cleanup()
+
+	dst, err := OpenMemory(3)
+	if err != nil {
+		t.Fatalf("OpenMemory failed: %v", err)
+	}
+	defer func() { _ = dst.Close() }()
+
+	if v, err := dst.UserVersion(); err != nil || v != 3 {
+		t.Errorf("Expected schema version 3, got %d, err %v", v, err)
+	}
+
+	var buf bytes.Buffer
+	if err := src.ExportJSON(&buf, TransferOptions{Mode: TransferAll}); err != nil {
+		t.Fatalf("ExportJSON failed: %v", err)
+	}
+	if err := dst.ImportJSON(&buf, TransferOptions{Mode: TransferAll}); err != nil {
+		t.Fatalf("ImportJSON failed: %v", err)
+	}
+	// Reads within a write transaction would find the tables locked on another connection
+	if err := Transfer(ctx, src, dst, TransferOptions{Mode: TransferAll, SettingsMerge: MergePreferNewer, Incremental: true}); err != nil {
+		t.Fatalf("Incremental transfer failed: %v", err)
+	}
+	if _, err := Consolidate(ctx, []ConsolidateSource{{Client: src}}, dst, ConsolidateOptions{DryRun: true}); err != nil {
+		t.Fatalf("Consolidate failed: %v", err)
+	}
+	if err := Transfer(ctx, src, dst, TransferOptions{Mode: TransferAll}); err != nil {
+		t.Fatalf("Transfer failed: %v", err)
+	}
+	if _, err := dst.RenameLoadpoint(ctx, "Garage", "Carport"); err != nil {
+		t.Fatalf("RenameLoadpoint failed: %v", err)
+	}
+	if n, err := dst.CountLoadpointSessions(ctx, "Carport"); err != nil || n != 3 {
+		t.Errorf("Expected 3 sessions, got %d, err %v", n, err)
+	}
+
+	// Every in-memory database is separate
+	other, err := OpenMemory(0)
+	if err != nil {
+		t.Fatalf("OpenMemory failed: %v", err)
+	}
+	defer func() { _ = other.Close() }()
+	if n, err := other.GetRowCount("sessions"); err != nil || n != 0 {
+		t.Errorf("Expected empty database, got %d rows, err %v", n, err)
+	}
+}
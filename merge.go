@@ -0,0 +1,169 @@
+package evccdb
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// SettingsMergeMode controls which value wins when a setting exists in source and target
+type SettingsMergeMode int
+
+const (
+	// MergeReplace overwrites target settings with the source values
+	MergeReplace SettingsMergeMode = iota
+	// MergePreferNewer keeps the values of the database with the more recent sessions or meter readings
+	MergePreferNewer
+	// MergePreferTarget keeps the target values and only adds missing settings
+	MergePreferTarget
+)
+
+// SettingConflict is a setting with different values in source and target
+type SettingConflict struct {
+	Key         string
+	SourceValue string
+	TargetValue string
+	// KeepSource is the resolution of the merge mode, OnSettingConflict may change it
+	KeepSource bool
+}
+
+// ParseSettingsMergeMode parses replace, prefer-newer or prefer-target
+func ParseSettingsMergeMode(s string) (SettingsMergeMode, error) {
+	switch s {
+	case "", "replace":
+		return MergeReplace, nil
+	case "prefer-newer":
+		return MergePreferNewer, nil
+	case "prefer-target":
+		return MergePreferTarget, nil
+	default:
+		return 0, fmt.Errorf("unknown settings merge mode %q, expected replace, prefer-newer or prefer-target", s)
+	}
+}
+
+// settingsMerger decides per setting whether the source value is written
+type settingsMerger struct {
+	target     map[string]string
+	keepSource bool
+	onConflict func(*SettingConflict)
+}
+
+// newSettingsMerger prepares merging settings into dst, nil if every source value is simply written.
+// sourceTime returns the latest activity recorded in the source.
+func newSettingsMerger(ctx context.Context, dst querier, opts TransferOptions, sourceTime func() (time.Time, error)) (*settingsMerger, error) {
+	if opts.SettingsMerge == MergeReplace && opts.OnSettingConflict == nil {
+		return nil, nil
+	}
+
+	m := &settingsMerger{
+		target:     make(map[string]string),
+		keepSource: opts.SettingsMerge == MergeReplace,
+		onConflict: opts.OnSettingConflict,
+	}
+
+	if opts.SettingsMerge == MergePreferNewer {
+		srcTime, err := sourceTime()
+		if err != nil {
+			return nil, err
+		}
+		targetTime, err := lastActivity(ctx, dst)
+		if err != nil {
+			return nil, err
+		}
+		m.keepSource = srcTime.After(targetTime)
+	}
+
+	rows, err := dst.QueryContext(ctx, "SELECT key, value FROM settings")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read target settings: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	for rows.Next() {
+		var key, value any
+		if err := rows.Scan(&key, &value); err != nil {
+			return nil, fmt.Errorf("failed to scan setting: %w", err)
+		}
+		m.target[settingString(key)] = settingString(value)
+	}
+
+	return m, rows.Err()
+}
+
+// write reports whether the source value of a setting is written to the target
+func (m *settingsMerger) write(key, value string) bool {
+	if m == nil {
+		return true
+	}
+
+	target, exists := m.target[key]
+	if !exists || target == value {
+		return true
+	}
+
+	conflict := &SettingConflict{Key: key, SourceValue: value, TargetValue: target, KeepSource: m.keepSource}
+	if m.onConflict != nil {
+		m.onConflict(conflict)
+	}
+	return conflict.KeepSource
+}
+
+// filterRows removes the exported settings rows whose target value is kept
+func (m *settingsMerger) filterRows(rows []any) []any {
+	var result []any
+	for _, row := range rows {
+		entry, ok := row.(map[string]any)
+		if ok && !m.write(settingString(entry["key"]), settingString(entry["value"])) {
+			continue
+		}
+		result = append(result, row)
+	}
+	return result
+}
+
+// settingString converts a scanned or decoded setting to string
+func settingString(val any) string {
+	switch v := val.(type) {
+	case nil:
+		return ""
+	case []byte:
+		return string(v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// lastActivity returns the newest session or meter timestamp of the database
+func lastActivity(ctx context.Context, q querier) (time.Time, error) {
+	var latest time.Time
+	for _, source := range [][2]string{{"sessions", "created"}, {"meters", "ts"}} {
+		ts, err := latestTimestamp(ctx, q, source[0], source[1])
+		if err != nil {
+			return time.Time{}, err
+		}
+		if ts != nil && ts.After(latest) {
+			latest = *ts
+		}
+	}
+	return latest, nil
+}
+
+// exportActivity returns the newest session or meter timestamp of an export,
+// or its export time if it contains neither
+func exportActivity(export *ExportFormat) time.Time {
+	var latest time.Time
+	for table, column := range map[string]string{"sessions": "created", "meters": "ts"} {
+		rows, _ := export.Tables[table].([]any)
+		for _, row := range rows {
+			entry, _ := row.(map[string]any)
+			if ts, ok := timestampOf(entry[column]); ok && ts.After(latest) {
+				latest = ts
+			}
+		}
+	}
+
+	if latest.IsZero() {
+		latest, _ = ParseTimestamp(export.ExportedAt, nil)
+	}
+	return latest
+}
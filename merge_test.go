@@ -0,0 +1,106 @@
+package evccdb
+
+import (
+	"bytes"
+	"context"
+	"testing"
+)
+
+// settingValue reads a setting of the test database
+func settingValue(t *testing.T, c *Client, key string) string {
+	t.Helper()
+	var value string
+	if err := c.db.QueryRow("SELECT value FROM settings WHERE key = ?", key).Scan(&value); err != nil {
+		t.Fatalf("Failed to read setting %s: %v", key, err)
+	}
+	return value
+}
+
+func TestTransferSettingsMerge(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name     string
+		mode     SettingsMergeMode
+		dstNewer bool
+		expected string
+	}{
+		{"replace", MergeReplace, true, "now"},
+		{"prefer-target", MergePreferTarget, false, "pv"},
+		{"prefer-newer source", MergePreferNewer, false, "now"},
+		{"prefer-newer target", MergePreferNewer, true, "pv"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src, srcCleanup := createTestDB(t)
+			defer srcCleanup()
+			dst, dstCleanup := createTestDB(t)
+			defer dstCleanup()
+
+			_, _ = src.db.Exec("UPDATE settings SET value = 'now' WHERE key = 'lp1.mode'")
+			_, _ = src.db.Exec("INSERT INTO settings (key, value) VALUES ('lp3.title', 'Carport')")
+			if tt.dstNewer {
+				_, _ = dst.db.Exec("INSERT INTO sessions (id, created, loadpoint) VALUES (6, '2024-01-01 10:00:00', 'Garage')")
+			} else {
+				_, _ = src.db.Exec("INSERT INTO sessions (id, created, loadpoint) VALUES (6, '2024-01-01 10:00:00', 'Garage')")
+			}
+
+			var conflicts []SettingConflict
+			opts := TransferOptions{
+				Tables:        []string{"settings"},
+				SettingsMerge: tt.mode,
+				OnSettingConflict: func(c *SettingConflict) {
+					conflicts = append(conflicts, *c)
+				},
+			}
+			if err := Transfer(ctx, src, dst, opts); err != nil {
+				t.Fatalf("Transfer failed: %v", err)
+			}
+
+			if value := settingValue(t, dst, "lp1.mode"); value != tt.expected {
+				t.Errorf("Expected lp1.mode %q, got %q", tt.expected, value)
+			}
+			if value := settingValue(t, dst, "lp3.title"); value != "Carport" {
+				t.Errorf("Expected missing setting to be added, got %q", value)
+			}
+			if len(conflicts) != 1 || conflicts[0].Key != "lp1.mode" || conflicts[0].SourceValue != "now" || conflicts[0].TargetValue != "pv" {
+				t.Errorf("Unexpected conflicts: %+v", conflicts)
+			}
+		})
+	}
+}
+
+func TestImportSettingsConflictOverride(t *testing.T) {
+	src, srcCleanup := createTestDB(t)
+	defer srcCleanup()
+	dst, dstCleanup := createTestDB(t)
+	defer dstCleanup()
+
+	_, _ = src.db.Exec("UPDATE settings SET value = 'now' WHERE key = 'lp1.mode'")
+	_, _ = src.db.Exec("UPDATE settings SET value = '30' WHERE key = 'vehicle.e-Golf.minSoc'")
+
+	var buf bytes.Buffer
+	if err := src.ExportJSON(&buf, TransferOptions{Mode: TransferConfig}); err != nil {
+		t.Fatalf("ExportJSON failed: %v", err)
+	}
+
+	// Keep the target values except for lp1.mode, as an interactive resolution would
+	opts := TransferOptions{
+		Tables:        []string{"settings"},
+		SettingsMerge: MergePreferTarget,
+		OnSettingConflict: func(c *SettingConflict) {
+			c.KeepSource = c.Key == "lp1.mode"
+		},
+	}
+	if err := dst.ImportJSON(bytes.NewReader(buf.Bytes()), opts); err != nil {
+		t.Fatalf("ImportJSON failed: %v", err)
+	}
+
+	if value := settingValue(t, dst, "lp1.mode"); value != "now" {
+		t.Errorf("Expected overridden lp1.mode, got %q", value)
+	}
+	if value := settingValue(t, dst, "vehicle.e-Golf.minSoc"); value != "25" {
+		t.Errorf("Expected target minSoc to be kept, got %q", value)
+	}
+}
@@ -0,0 +1,131 @@
+package evccdb
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"runtime/debug"
+	"sort"
+	"strings"
+)
+
+// modulePath is the import path of this module
+const modulePath = "github.com/iseeberg79/evccdb"
+
+// Version is the evccdb version written to exports. It defaults to the module
+// version of the build and can be set with -ldflags "-X github.com/iseeberg79/evccdb.Version=v1.2.3".
+var Version = moduleVersion()
+
+// ExportMetadata describes where and how an export was created
+type ExportMetadata struct {
+	Hostname      string            `json:"hostname,omitempty"`
+	EvccdbVersion string            `json:"evccdb_version,omitempty"`
+	SchemaHashes  map[string]string `json:"schema_hashes,omitempty"`
+	// Currency is the currency of the session prices, empty if unknown
+	Currency string `json:"currency,omitempty"`
+	// Objects are the views and triggers, exported with TransferOptions.SchemaObjects
+	Objects []SchemaObject `json:"objects,omitempty"`
+}
+
+// moduleVersion returns the version of this module from the build info
+func moduleVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "dev"
+	}
+	if info.Main.Path == modulePath && info.Main.Version != "" && info.Main.Version != "(devel)" {
+		return info.Main.Version
+	}
+	for _, dep := range info.Deps {
+		if dep.Path == modulePath {
+			return dep.Version
+		}
+	}
+	return "dev"
+}
+
+// exportMetadata collects the metadata of an export of the given tables
+func (c *Client) exportMetadata(tables []string) (*ExportMetadata, error) {
+	hostname, _ := os.Hostname()
+	meta := &ExportMetadata{
+		Hostname:      hostname,
+		EvccdbVersion: Version,
+		SchemaHashes:  make(map[string]string),
+	}
+
+	currency, err := c.Currency(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	meta.Currency = currency
+
+	for _, table := range tables {
+		hash, err := c.schemaHash(table)
+		if err != nil {
+			return nil, err
+		}
+		if hash != "" {
+			meta.SchemaHashes[table] = hash
+		}
+	}
+
+	return meta, nil
+}
+
+// schemaHash returns a hash of the CREATE statement of a table, empty if the table does not exist
+func (c *Client) schemaHash(table string) (string, error) {
+	var ddl string
+	err := c.db.QueryRow("SELECT COALESCE(sql, '') FROM sqlite_master WHERE type = 'table' AND name = ?", table).Scan(&ddl)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read schema of %s: %w", table, err)
+	}
+
+	// Whitespace differs between databases created and migrated by different tools
+	sum := sha256.Sum256([]byte(strings.Join(strings.Fields(ddl), " ")))
+	return hex.EncodeToString(sum[:8]), nil
+}
+
+// printExportMetadata shows the origin of an export and warns about tables
+// whose schema differs from the target database
+func (c *Client) printExportMetadata(export *ExportFormat, tables []string) error {
+	if meta := export.Metadata; meta != nil {
+		fmt.Printf("Export from %s created %s by evccdb %s (schema version %d)\n",
+			valueOr(meta.Hostname, "unknown host"), export.ExportedAt, valueOr(meta.EvccdbVersion, "unknown"), export.UserVersion)
+	}
+
+	if export.Metadata == nil || len(export.Metadata.SchemaHashes) == 0 {
+		return nil
+	}
+
+	sorted := append([]string(nil), tables...)
+	sort.Strings(sorted)
+	for _, table := range sorted {
+		expected, ok := export.Metadata.SchemaHashes[table]
+		if !ok {
+			continue
+		}
+		hash, err := c.schemaHash(table)
+		if err != nil {
+			return err
+		}
+		if hash != "" && hash != expected {
+			fmt.Printf(Translate("WARNING: Table %s has a different schema than in the export, only common columns are imported\n"), table)
+		}
+	}
+
+	return nil
+}
+
+// valueOr returns s, or fallback if s is empty
+func valueOr(s, fallback string) string {
+	if s == "" {
+		return fallback
+	}
+	return s
+}
@@ -0,0 +1,92 @@
+package evccdb
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// NamedQuery is a saved read-only query
+type NamedQuery struct {
+	Name        string
+	Description string
+	SQL         string
+}
+
+var (
+	namedQueriesMu sync.RWMutex
+	namedQueries   = map[string]NamedQuery{}
+)
+
+func init() {
+	for _, q := range []NamedQuery{
+		{
+			Name:        "monthly-kwh",
+			Description: "Charged energy per month and vehicle",
+			SQL: `SELECT strftime('%Y-%m', created) AS month, COALESCE(vehicle, '') AS vehicle,
+				COUNT(*) AS sessions, ROUND(SUM(charged_kwh), 2) AS kwh
+				FROM sessions GROUP BY month, vehicle ORDER BY month, vehicle`,
+		},
+		{
+			Name:        "loadpoint-kwh",
+			Description: "Charged energy and cost per loadpoint",
+			SQL: `SELECT loadpoint, COUNT(*) AS sessions, ROUND(SUM(charged_kwh), 2) AS kwh, ROUND(SUM(price), 2) AS price
+				FROM sessions GROUP BY loadpoint ORDER BY kwh DESC`,
+		},
+		{
+			Name:        "top-sessions",
+			Description: "Ten most expensive sessions",
+			SQL: `SELECT id, created, loadpoint, vehicle, ROUND(charged_kwh, 2) AS kwh, ROUND(price, 2) AS price
+				FROM sessions WHERE price IS NOT NULL ORDER BY price DESC LIMIT 10`,
+		},
+		{
+			Name:        "solar-share",
+			Description: "Energy weighted solar share per month",
+			SQL: `SELECT strftime('%Y-%m', created) AS month, ROUND(SUM(charged_kwh), 2) AS kwh,
+				ROUND(SUM(charged_kwh * solar_percentage) / SUM(charged_kwh), 1) AS solar_percentage
+				FROM sessions WHERE charged_kwh > 0 AND solar_percentage IS NOT NULL
+				GROUP BY month ORDER BY month`,
+		},
+	} {
+		RegisterNamedQuery(q)
+	}
+}
+
+// RegisterNamedQuery adds or replaces a named query
+func RegisterNamedQuery(q NamedQuery) {
+	namedQueriesMu.Lock()
+	defer namedQueriesMu.Unlock()
+	namedQueries[q.Name] = q
+}
+
+// NamedQueries returns the registered named queries sorted by name
+func NamedQueries() []NamedQuery {
+	namedQueriesMu.RLock()
+	defer namedQueriesMu.RUnlock()
+
+	queries := make([]NamedQuery, 0, len(namedQueries))
+	for _, q := range namedQueries {
+		queries = append(queries, q)
+	}
+	sort.Slice(queries, func(i, j int) bool { return queries[i].Name < queries[j].Name })
+	return queries
+}
+
+// QueryNamed runs a registered named query with the restrictions of Query
+func (c *Client) QueryNamed(ctx context.Context, name string) (*QueryResult, error) {
+	namedQueriesMu.RLock()
+	q, ok := namedQueries[name]
+	namedQueriesMu.RUnlock()
+
+	if !ok {
+		var names []string
+		for _, q := range NamedQueries() {
+			names = append(names, q.Name)
+		}
+		return nil, fmt.Errorf("unknown named query %q, available: %s", name, strings.Join(names, ", "))
+	}
+
+	return c.Query(ctx, q.SQL, nil)
+}
@@ -0,0 +1,100 @@
+package evccdb
+
+import (
+	"context"
+	"fmt"
+)
+
+// DefaultVacuumRatio is the share of free pages from which Optimize rebuilds the database with VACUUM
+const DefaultVacuumRatio = 0.1
+
+// Optimization methods reported by Optimize
+const (
+	OptimizeAnalyze     = "optimize"
+	OptimizeIncremental = "incremental_vacuum"
+	OptimizeVacuum      = "vacuum"
+)
+
+// OptimizeOptions configures Optimize
+type OptimizeOptions struct {
+	// VacuumRatio is the share of free pages from which the database is vacuumed, zero means
+	// DefaultVacuumRatio and a negative ratio always vacuums
+	VacuumRatio float64
+}
+
+// OptimizeResult tells how Optimize changed the database file
+type OptimizeResult struct {
+	Method     string `json:"method"`
+	FreePages  int    `json:"free_pages"`
+	SizeBefore int64  `json:"size_before"`
+	SizeAfter  int64  `json:"size_after"`
+}
+
+// Reclaimed returns the bytes the database file shrank
+func (r *OptimizeResult) Reclaimed() int64 {
+	return r.SizeBefore - r.SizeAfter
+}
+
+// Optimize updates the query planner statistics with PRAGMA optimize and returns the free
+// pages left by deletes to the filesystem: with PRAGMA incremental_vacuum if the database
+// uses auto_vacuum=incremental, otherwise with VACUUM if at least VacuumRatio of the pages
+// are free. VACUUM rewrites the whole file, so it fails with ErrInsufficientSpace up front
+// if the filesystem cannot hold a copy of the data.
+func (c *Client) Optimize(ctx context.Context, opts OptimizeOptions) (*OptimizeResult, error) {
+	ratio := opts.VacuumRatio
+	if ratio == 0 {
+		ratio = DefaultVacuumRatio
+	}
+
+	result := &OptimizeResult{Method: OptimizeAnalyze}
+	var err error
+	if result.SizeBefore, err = c.FileSize(); err != nil {
+		return nil, err
+	}
+	if result.FreePages, err = c.pragmaInt("freelist_count"); err != nil {
+		return nil, err
+	}
+	pages, err := c.pragmaInt("page_count")
+	if err != nil {
+		return nil, err
+	}
+	autoVacuum, err := c.pragmaInt("auto_vacuum")
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case result.FreePages == 0:
+	case autoVacuum == 2:
+		result.Method = OptimizeIncremental
+		if _, err := c.db.ExecContext(ctx, "PRAGMA incremental_vacuum"); err != nil {
+			return nil, fmt.Errorf("failed to run incremental_vacuum: %w", err)
+		}
+	case ratio < 0 || float64(result.FreePages) >= ratio*float64(pages):
+		result.Method = OptimizeVacuum
+		used, err := c.UsedSize()
+		if err != nil {
+			return nil, err
+		}
+		file, err := c.File()
+		if err != nil {
+			return nil, err
+		}
+		if file != "" {
+			if err := CheckFreeSpace(file, used); err != nil {
+				return nil, err
+			}
+		}
+		if _, err := c.db.ExecContext(ctx, "VACUUM"); err != nil {
+			return nil, fmt.Errorf("failed to vacuum database: %w", err)
+		}
+	}
+
+	if _, err := c.db.ExecContext(ctx, "PRAGMA optimize"); err != nil {
+		return nil, fmt.Errorf("failed to optimize database: %w", err)
+	}
+	if result.SizeAfter, err = c.FileSize(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
@@ -0,0 +1,62 @@
+package evccdb
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+)
+
+func TestOptimize(t *testing.T) {
+	client, cleanup := createTestDB(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	result, err := client.Optimize(ctx, OptimizeOptions{})
+	if err != nil {
+		t.Fatalf("Optimize failed: %v", err)
+	}
+	// PRAGMA optimize may add statistics, so the file does not shrink
+	if result.Method != OptimizeAnalyze || result.FreePages != 0 {
+		t.Errorf("Expected only optimize without free pages, got %+v", result)
+	}
+
+	fillAndDelete := func(c *Client) {
+		t.Helper()
+		if _, err := c.db.Exec(`WITH RECURSIVE n(i) AS (SELECT 1 UNION ALL SELECT i + 1 FROM n WHERE i < 2000)
+			INSERT INTO meters (meter, ts, val) SELECT 1, datetime('2024-01-01', '+' || i || ' minutes'), i FROM n`); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := c.db.Exec("DELETE FROM meters"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	fillAndDelete(client)
+	result, err = client.Optimize(ctx, OptimizeOptions{})
+	if err != nil {
+		t.Fatalf("Optimize failed: %v", err)
+	}
+	if result.Method != OptimizeVacuum || result.FreePages == 0 || result.Reclaimed() <= 0 {
+		t.Errorf("Expected vacuum to reclaim the free pages, got %+v", result)
+	}
+	if free, _ := client.pragmaInt("freelist_count"); free != 0 {
+		t.Errorf("Expected no free pages after vacuum, got %d", free)
+	}
+
+	incremental, err := Open(filepath.Join(t.TempDir(), "incremental.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = incremental.Close() }()
+	if _, err := incremental.db.Exec("PRAGMA auto_vacuum = INCREMENTAL; CREATE TABLE meters (meter INTEGER, ts DATETIME, val REAL)"); err != nil {
+		t.Fatal(err)
+	}
+	fillAndDelete(incremental)
+	result, err = incremental.Optimize(ctx, OptimizeOptions{})
+	if err != nil {
+		t.Fatalf("Optimize failed: %v", err)
+	}
+	if result.Method != OptimizeIncremental || result.Reclaimed() <= 0 {
+		t.Errorf("Expected incremental vacuum to reclaim the free pages, got %+v", result)
+	}
+}
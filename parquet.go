@@ -0,0 +1,184 @@
+package evccdb
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/iseeberg79/evccdb/internal/sqlbuild"
+	"github.com/parquet-go/parquet-go"
+)
+
+// ExportParquet writes a single table as a Parquet file and returns the number of rows written.
+// Of opts only the guest session options apply.
+func (c *Client) ExportParquet(ctx context.Context, w io.Writer, table string, opts TransferOptions) (int, error) {
+	if err := ValidateIdentifier(table); err != nil {
+		return 0, err
+	}
+
+	cols, err := c.GetTableColumns(table)
+	if err != nil {
+		return 0, err
+	}
+
+	var names []string
+	kinds := make(map[string]columnKind)
+	group := parquet.Group{}
+	for _, col := range cols {
+		if col.Generated {
+			continue
+		}
+		kind := columnKindOf(col.Type)
+		names = append(names, col.Name)
+		kinds[col.Name] = kind
+		group[col.Name] = parquet.Optional(parquetNode(kind))
+	}
+	if len(names) == 0 {
+		return 0, fmt.Errorf("table %s has no columns", table)
+	}
+
+	schema := parquet.NewSchema(table, group)
+
+	// Parquet orders the leaf columns of a group by name
+	index := make(map[string]int)
+	for i, field := range schema.Fields() {
+		index[field.Name()] = i
+	}
+
+	query, err := sqlbuild.Select(table, names)
+	if err != nil {
+		return 0, err
+	}
+
+	rows, err := c.db.QueryContext(ctx, query)
+	if err != nil {
+		return 0, fmt.Errorf("failed to query %s: %w", table, err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	writer := parquet.NewWriter(w, schema)
+
+	count := 0
+	for rows.Next() {
+		entry, err := scanRowMap(rows, names)
+		if err != nil {
+			return count, err
+		}
+		if !opts.guestRow(table, entry) || !opts.classRow(table, entry) {
+			continue
+		}
+
+		row := make(parquet.Row, len(names))
+		for _, name := range names {
+			value, err := parquetValue(entry[name], kinds[name])
+			if err != nil {
+				return count, fmt.Errorf("failed to convert %s.%s: %w", table, name, err)
+			}
+			row[index[name]] = value.Level(0, definitionLevel(entry[name]), index[name])
+		}
+
+		if _, err := writer.WriteRows([]parquet.Row{row}); err != nil {
+			return count, fmt.Errorf("failed to write parquet row: %w", err)
+		}
+		count++
+	}
+	if err := rows.Err(); err != nil {
+		return count, err
+	}
+
+	if err := writer.Close(); err != nil {
+		return count, fmt.Errorf("failed to finish parquet file: %w", err)
+	}
+
+	return count, nil
+}
+
+// parquetNode returns the schema node for a Parquet representation
+func parquetNode(kind columnKind) parquet.Node {
+	switch kind {
+	case kindInt:
+		return parquet.Int(64)
+	case kindReal:
+		return parquet.Leaf(parquet.DoubleType)
+	case kindTimestamp:
+		return parquet.Timestamp(parquet.Millisecond)
+	case kindBytes:
+		return parquet.Leaf(parquet.ByteArrayType)
+	default:
+		return parquet.String()
+	}
+}
+
+// definitionLevel returns the definition level of an optional value
+func definitionLevel(val any) int {
+	if val == nil {
+		return 0
+	}
+	return 1
+}
+
+// parquetValue converts a scanned SQLite value into a Parquet value
+func parquetValue(val any, kind columnKind) (parquet.Value, error) {
+	if val == nil {
+		return parquet.NullValue(), nil
+	}
+
+	switch kind {
+	case kindInt:
+		switch v := val.(type) {
+		case int64:
+			return parquet.Int64Value(v), nil
+		case float64:
+			return parquet.Int64Value(int64(v)), nil
+		case bool:
+			if v {
+				return parquet.Int64Value(1), nil
+			}
+			return parquet.Int64Value(0), nil
+		case string:
+			n, err := strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				return parquet.Value{}, err
+			}
+			return parquet.Int64Value(n), nil
+		}
+	case kindReal:
+		switch v := val.(type) {
+		case float64:
+			return parquet.DoubleValue(v), nil
+		case int64:
+			return parquet.DoubleValue(float64(v)), nil
+		case string:
+			f, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				return parquet.Value{}, err
+			}
+			return parquet.DoubleValue(f), nil
+		}
+	case kindTimestamp:
+		switch v := val.(type) {
+		case time.Time:
+			return parquet.Int64Value(v.UnixMilli()), nil
+		case int64:
+			return parquet.Int64Value(v * 1000), nil
+		}
+	case kindBytes:
+		switch v := val.(type) {
+		case string:
+			return parquet.ByteArrayValue([]byte(v)), nil
+		case []byte:
+			return parquet.ByteArrayValue(v), nil
+		}
+	default:
+		switch v := val.(type) {
+		case time.Time:
+			return parquet.ByteArrayValue([]byte(v.UTC().Format(time.RFC3339Nano))), nil
+		default:
+			return parquet.ByteArrayValue([]byte(fmt.Sprint(v))), nil
+		}
+	}
+
+	return parquet.Value{}, fmt.Errorf("unsupported value %v (%T)", val, val)
+}
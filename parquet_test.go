@@ -0,0 +1,67 @@
+package evccdb
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+func TestExportParquet(t *testing.T) {
+	client, cleanup := createTestDB(t)
+	defer cleanup()
+
+	var buf bytes.Buffer
+	count, err := client.ExportParquet(context.Background(), &buf, "sessions", TransferOptions{})
+	if err != nil {
+		t.Fatalf("ExportParquet failed: %v", err)
+	}
+	if count != 5 {
+		t.Errorf("Expected 5 rows written, got %d", count)
+	}
+
+	file, err := parquet.OpenFile(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("Failed to open parquet output: %v", err)
+	}
+	if file.NumRows() != 5 {
+		t.Errorf("Expected 5 rows in parquet file, got %d", file.NumRows())
+	}
+
+	type session struct {
+		ID        *int64  `parquet:"id"`
+		Loadpoint *string `parquet:"loadpoint"`
+		Vehicle   *string `parquet:"vehicle"`
+		Created   *int64  `parquet:"created"`
+	}
+
+	rows := make([]session, 5)
+	reader := parquet.NewGenericReader[session](bytes.NewReader(buf.Bytes()))
+	defer func() { _ = reader.Close() }()
+	n, _ := reader.Read(rows)
+	if n != 5 {
+		t.Fatalf("Expected to read 5 rows, got %d", n)
+	}
+
+	if rows[0].Loadpoint == nil || *rows[0].Loadpoint != "Garage" {
+		t.Errorf("Expected loadpoint Garage, got %v", rows[0].Loadpoint)
+	}
+	if want := time.Date(2023, 4, 1, 10, 0, 0, 0, time.UTC).UnixMilli(); rows[0].Created == nil || *rows[0].Created != want {
+		t.Errorf("Expected created %d, got %v", want, rows[0].Created)
+	}
+	if rows[2].Vehicle != nil {
+		t.Errorf("Expected NULL vehicle for session 3, got %q", *rows[2].Vehicle)
+	}
+}
+
+func TestExportParquetInvalidTable(t *testing.T) {
+	client, cleanup := createTestDB(t)
+	defer cleanup()
+
+	var buf bytes.Buffer
+	if _, err := client.ExportParquet(context.Background(), &buf, "bad table", TransferOptions{}); err == nil {
+		t.Error("Expected error for invalid table name")
+	}
+}
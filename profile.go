@@ -0,0 +1,272 @@
+package evccdb
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+)
+
+// Profile bundles the settings and config of a device into a portable, human-editable
+// form that can be applied to another instance or device
+type Profile struct {
+	// Class is the device class name, e.g. vehicle
+	Class string `json:"class" yaml:"class"`
+	Name  string `json:"name" yaml:"name"`
+	// Settings are the setting values by key suffix, e.g. minSoc
+	Settings map[string]string `json:"settings,omitempty" yaml:"settings,omitempty"`
+	// Config is the device config, nil for devices of evcc.yaml
+	Config *ProfileConfig `json:"config,omitempty" yaml:"config,omitempty"`
+}
+
+// ProfileConfig is the config row of a profile
+type ProfileConfig struct {
+	Type  string         `json:"type" yaml:"type"`
+	Value map[string]any `json:"value" yaml:"value"`
+}
+
+// ProfileResult reports a profile applied to a database
+type ProfileResult struct {
+	Settings int
+	// ConfigID is the id of the written config, 0 if the profile has none
+	ConfigID int
+	// Created is set if the config was inserted instead of updated
+	Created bool
+}
+
+// VehicleProfile returns the settings and config of the named vehicle
+func (c *Client) VehicleProfile(ctx context.Context, name string) (*Profile, error) {
+	keys, err := vehicleSettingsKeys(ctx, c.db, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query settings: %w", err)
+	}
+	return c.profile(ctx, ClassVehicle, name, keys)
+}
+
+// LoadpointProfile returns the lp<n>.* settings and the config of the named loadpoint.
+// The config is stripped of its device references, so the profile can be applied
+// to a loadpoint with other devices.
+func (c *Client) LoadpointProfile(ctx context.Context, name string) (*Profile, error) {
+	indices, err := loadpointIndices(ctx, c.db, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve loadpoint indices: %w", err)
+	}
+	if len(indices) > 1 {
+		return nil, fmt.Errorf("loadpoint %q is ambiguous, found indices %v", name, indices)
+	}
+
+	var keys []SettingsKey
+	if len(indices) == 1 {
+		rows, err := c.db.QueryContext(ctx, "SELECT key FROM settings WHERE key LIKE ?", fmt.Sprintf("lp%d.%%", indices[0]))
+		if err != nil {
+			return nil, fmt.Errorf("failed to query settings: %w", err)
+		}
+		defer func() { _ = rows.Close() }()
+		for rows.Next() {
+			var key string
+			if err := rows.Scan(&key); err != nil {
+				return nil, err
+			}
+			// The title is the name of the profile
+			if k := ParseSettingsKey(key); k.Suffix != "title" {
+				keys = append(keys, k)
+			}
+		}
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	p, err := c.profile(ctx, ClassLoadpoint, name, keys)
+	if err != nil {
+		return nil, err
+	}
+	if p.Config != nil {
+		for key := range deviceRefKeys {
+			delete(p.Config.Value, key)
+		}
+	}
+	return p, nil
+}
+
+// profile reads the settings keys and the config titled name into a profile
+func (c *Client) profile(ctx context.Context, class ConfigClass, name string, keys []SettingsKey) (*Profile, error) {
+	p := &Profile{Class: class.ClassName(), Name: name, Settings: make(map[string]string)}
+
+	for _, k := range keys {
+		var value sql.NullString
+		if err := c.db.QueryRowContext(ctx, "SELECT value FROM settings WHERE key = ?", k.String()).Scan(&value); err != nil {
+			return nil, fmt.Errorf("failed to read setting %s: %w", k, err)
+		}
+		p.Settings[k.Suffix] = value.String
+	}
+
+	cfg, err := c.findTitledConfig(ctx, class, name)
+	if err != nil {
+		return nil, err
+	}
+	if cfg != nil {
+		p.Config = &ProfileConfig{Type: cfg.Type}
+		if err := json.Unmarshal([]byte(cfg.Value), &p.Config.Value); err != nil {
+			return nil, fmt.Errorf("failed to parse config %s%d: %w", dbRefPrefix, cfg.ID, err)
+		}
+	}
+
+	if len(keys) == 0 && cfg == nil {
+		return nil, fmt.Errorf("%s %q not found", class, name)
+	}
+	return p, nil
+}
+
+// findTitledConfig returns the config of a class with the given title, nil if there is none
+func (c *Client) findTitledConfig(ctx context.Context, class ConfigClass, title string) (*Config, error) {
+	g, err := c.DeviceGraph(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var found *Config
+	for _, cfg := range g.DevicesOfClass(class) {
+		if cfg.Title != title {
+			continue
+		}
+		if found != nil {
+			return nil, fmt.Errorf("%s %q is ambiguous, found %s%d and %s%d", class, title, dbRefPrefix, found.ID, dbRefPrefix, cfg.ID)
+		}
+		match := cfg
+		found = &match
+	}
+	return found, nil
+}
+
+// ApplyProfile writes the settings and config of a profile for the named device,
+// the profile's name if name is empty. An existing config of the device is updated
+// and keeps its device references. A loadpoint that does not exist yet gets the next
+// free lp<n> index.
+func (c *Client) ApplyProfile(ctx context.Context, p *Profile, name string) (ProfileResult, error) {
+	var result ProfileResult
+	if name == "" {
+		name = p.Name
+	}
+	if name == "" {
+		return result, fmt.Errorf("profile has no name")
+	}
+
+	class, err := ParseClass(p.Class)
+	if err != nil {
+		return result, err
+	}
+	if class != ClassVehicle && class != ClassLoadpoint {
+		return result, fmt.Errorf("profiles of class %s are not supported", class)
+	}
+
+	existing, err := c.findTitledConfig(ctx, class, name)
+	if err != nil {
+		return result, err
+	}
+
+	err = c.inTx(ctx, func(tx *Tx) error {
+		settingsKey := func(suffix string) SettingsKey {
+			return SettingsKey{Namespace: "vehicle", Name: name, Suffix: suffix}
+		}
+		if class == ClassLoadpoint {
+			index, err := profileLoadpointIndex(ctx, tx.tx, name)
+			if err != nil {
+				return err
+			}
+			if _, err := tx.tx.ExecContext(ctx, "INSERT OR REPLACE INTO settings (key, value) VALUES (?, ?)", loadpointTitleKey(index), name); err != nil {
+				return fmt.Errorf("failed to write loadpoint title: %w", err)
+			}
+			settingsKey = func(suffix string) SettingsKey {
+				return SettingsKey{Namespace: fmt.Sprintf("lp%d", index), Suffix: suffix}
+			}
+		}
+
+		for suffix, value := range p.Settings {
+			key := settingsKey(suffix)
+			if _, err := tx.tx.ExecContext(ctx, "INSERT OR REPLACE INTO settings (key, value) VALUES (?, ?)", key.String(), value); err != nil {
+				return fmt.Errorf("failed to write setting %s: %w", key, err)
+			}
+			result.Settings++
+		}
+
+		if p.Config == nil {
+			return nil
+		}
+		value := make(map[string]any, len(p.Config.Value)+1)
+		for k, v := range p.Config.Value {
+			value[k] = v
+		}
+		value["title"] = name
+
+		if existing != nil {
+			var current map[string]any
+			if json.Unmarshal([]byte(existing.Value), &current) == nil {
+				for key := range deviceRefKeys {
+					if ref, ok := current[key]; ok {
+						if _, set := value[key]; !set {
+							value[key] = ref
+						}
+					}
+				}
+			}
+		}
+
+		data, err := json.Marshal(value)
+		if err != nil {
+			return fmt.Errorf("failed to encode config: %w", err)
+		}
+
+		if existing != nil {
+			result.ConfigID = existing.ID
+			if _, err := tx.tx.ExecContext(ctx, "UPDATE configs SET type = ?, value = ? WHERE id = ?", p.Config.Type, string(data), existing.ID); err != nil {
+				return fmt.Errorf("failed to update config: %w", err)
+			}
+			return nil
+		}
+
+		res, err := tx.tx.ExecContext(ctx, "INSERT INTO configs (class, type, value) VALUES (?, ?, ?)", class, p.Config.Type, string(data))
+		if err != nil {
+			return fmt.Errorf("failed to insert config: %w", err)
+		}
+		id, err := res.LastInsertId()
+		if err != nil {
+			return err
+		}
+		result.ConfigID, result.Created = int(id), true
+		return nil
+	})
+	return result, err
+}
+
+// profileLoadpointIndex returns the index of the named loadpoint, the next free index if there is none
+func profileLoadpointIndex(ctx context.Context, q querier, name string) (int, error) {
+	indices, err := loadpointIndices(ctx, q, name)
+	if err != nil {
+		return 0, fmt.Errorf("failed to resolve loadpoint indices: %w", err)
+	}
+	if len(indices) > 1 {
+		return 0, fmt.Errorf("loadpoint %q is ambiguous, found indices %v", name, indices)
+	}
+	if len(indices) == 1 {
+		return indices[0], nil
+	}
+
+	rows, err := q.QueryContext(ctx, "SELECT key FROM settings WHERE key LIKE 'lp%'")
+	if err != nil {
+		return 0, fmt.Errorf("failed to query settings: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	next := 1
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			return 0, err
+		}
+		if index, ok := ParseSettingsKey(key).LoadpointIndex(); ok && index >= next {
+			next = index + 1
+		}
+	}
+	return next, rows.Err()
+}
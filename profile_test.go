@@ -0,0 +1,105 @@
+package evccdb
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestVehicleProfile(t *testing.T) {
+	src, srcCleanup := createTestDB(t)
+	defer srcCleanup()
+	dst, dstCleanup := createTestDB(t)
+	defer dstCleanup()
+
+	ctx := context.Background()
+
+	p, err := src.VehicleProfile(ctx, "e-Golf")
+	if err != nil {
+		t.Fatalf("VehicleProfile failed: %v", err)
+	}
+	if p.Class != "vehicle" || p.Settings["minSoc"] != "25" || len(p.Settings) != 3 {
+		t.Errorf("Unexpected profile %+v", p)
+	}
+	if p.Config == nil || p.Config.Value["type"] != "vw" {
+		t.Fatalf("Expected vehicle config, got %+v", p.Config)
+	}
+
+	if _, err := src.VehicleProfile(ctx, "unknown"); err == nil {
+		t.Error("Expected error for unknown vehicle")
+	}
+
+	// Applying to the same name updates the existing config
+	result, err := dst.ApplyProfile(ctx, p, "")
+	if err != nil {
+		t.Fatalf("ApplyProfile failed: %v", err)
+	}
+	if result.Settings != 3 || result.ConfigID != 2 || result.Created {
+		t.Errorf("Unexpected result %+v", result)
+	}
+
+	p.Settings["minSoc"] = "40"
+	if result, err = dst.ApplyProfile(ctx, p, "ID.4"); err != nil {
+		t.Fatalf("ApplyProfile failed: %v", err)
+	}
+	if !result.Created {
+		t.Errorf("Expected new config, got %+v", result)
+	}
+
+	applied, err := dst.VehicleProfile(ctx, "ID.4")
+	if err != nil {
+		t.Fatalf("VehicleProfile failed: %v", err)
+	}
+	if applied.Settings["minSoc"] != "40" || applied.Config == nil || applied.Config.Value["title"] != "ID.4" {
+		t.Errorf("Unexpected applied profile %+v", applied)
+	}
+}
+
+func TestLoadpointProfile(t *testing.T) {
+	client, cleanup := createTestDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	p, err := client.LoadpointProfile(ctx, "Garage")
+	if err != nil {
+		t.Fatalf("LoadpointProfile failed: %v", err)
+	}
+	if p.Settings["mode"] != "pv" || len(p.Settings) != 1 {
+		t.Errorf("Expected only the mode setting, got %v", p.Settings)
+	}
+	if p.Config == nil || p.Config.Value["charger"] != nil {
+		t.Fatalf("Expected config without device references, got %+v", p.Config)
+	}
+
+	// A new loadpoint gets the next free index and a config without charger
+	p.Settings["mode"] = "minpv"
+	result, err := client.ApplyProfile(ctx, p, "Carport")
+	if err != nil {
+		t.Fatalf("ApplyProfile failed: %v", err)
+	}
+	if result.Settings != 1 || !result.Created {
+		t.Errorf("Unexpected result %+v", result)
+	}
+
+	var mode string
+	if err := client.db.QueryRow("SELECT value FROM settings WHERE key = 'lp3.mode'").Scan(&mode); err != nil || mode != "minpv" {
+		t.Errorf("Expected lp3.mode minpv, got %q, %v", mode, err)
+	}
+	carport, err := client.LoadpointProfile(ctx, "Carport")
+	if err != nil || carport.Settings["mode"] != "minpv" {
+		t.Fatalf("Expected Carport profile, got %+v, %v", carport, err)
+	}
+
+	// Updating the existing loadpoint keeps its charger
+	if _, err := client.ApplyProfile(ctx, p, "Garage"); err != nil {
+		t.Fatalf("ApplyProfile failed: %v", err)
+	}
+	var value string
+	if err := client.db.QueryRow("SELECT value FROM configs WHERE id = 1").Scan(&value); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(value, `"charger":"db:1"`) {
+		t.Errorf("Expected charger reference to be kept, got %s", value)
+	}
+}
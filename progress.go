@@ -0,0 +1,65 @@
+package evccdb
+
+import "time"
+
+// progressInterval is the minimum time between two row progress reports
+const progressInterval = time.Second
+
+// Progress reports the rows processed so far by a transfer or import
+type Progress struct {
+	Table string `json:"table"`
+	// Rows is the number of rows processed of all tables
+	Rows int `json:"rows"`
+	// Total is the number of rows to process of all tables
+	Total   int           `json:"total"`
+	Elapsed time.Duration `json:"elapsed"`
+}
+
+// Rate returns the rows processed per second so far
+func (p Progress) Rate() float64 {
+	if p.Elapsed <= 0 {
+		return 0
+	}
+	return float64(p.Rows) / p.Elapsed.Seconds()
+}
+
+// Remaining estimates the time until all rows are processed from the rate so far, 0 if unknown
+func (p Progress) Remaining() time.Duration {
+	rate := p.Rate()
+	if rate == 0 || p.Rows >= p.Total {
+		return 0
+	}
+	return time.Duration(float64(p.Total-p.Rows) / rate * float64(time.Second))
+}
+
+// progressTracker counts the processed rows and reports them at most every progressInterval
+type progressTracker struct {
+	fn       func(Progress)
+	progress Progress
+	start    time.Time
+	last     time.Time
+}
+
+// newProgressTracker returns a tracker for total rows, nil if fn is nil
+func newProgressTracker(fn func(Progress), total int) *progressTracker {
+	if fn == nil {
+		return nil
+	}
+	now := time.Now()
+	return &progressTracker{fn: fn, progress: Progress{Total: total}, start: now, last: now}
+}
+
+// add counts n processed rows of table
+func (t *progressTracker) add(table string, n int) {
+	if t == nil {
+		return
+	}
+	t.progress.Table = table
+	t.progress.Rows += n
+
+	if now := time.Now(); now.Sub(t.last) >= progressInterval || t.progress.Rows == t.progress.Total {
+		t.last = now
+		t.progress.Elapsed = now.Sub(t.start)
+		t.fn(t.progress)
+	}
+}
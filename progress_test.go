@@ -0,0 +1,42 @@
+package evccdb
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestProgressRemaining(t *testing.T) {
+	p := Progress{Rows: 100, Total: 400, Elapsed: 2 * time.Second}
+	if p.Rate() != 50 {
+		t.Errorf("Expected 50 rows/s, got %v", p.Rate())
+	}
+	if p.Remaining() != 6*time.Second {
+		t.Errorf("Expected 6s remaining, got %v", p.Remaining())
+	}
+	if (Progress{Rows: 10, Total: 10, Elapsed: time.Second}).Remaining() != 0 {
+		t.Error("Expected no remaining time when done")
+	}
+}
+
+func TestTransferRowProgress(t *testing.T) {
+	src, srcCleanup := createTestDB(t)
+	defer srcCleanup()
+
+	dst, dstCleanup := createTestDB(t)
+	defer dstCleanup()
+
+	var last Progress
+	opts := TransferOptions{
+		Mode:          TransferMetrics,
+		OnRowProgress: func(p Progress) { last = p },
+	}
+	if err := Transfer(context.Background(), src, dst, opts); err != nil {
+		t.Fatalf("Transfer failed: %v", err)
+	}
+
+	// The final report is sent when all rows are processed
+	if last.Total != 5 || last.Rows != 5 {
+		t.Errorf("Expected final progress of 5/5 rows, got %+v", last)
+	}
+}
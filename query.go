@@ -0,0 +1,193 @@
+package evccdb
+
+import (
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// sqliteRecursive is the authorizer action code of WITH RECURSIVE, not exported by the driver
+const sqliteRecursive = 33
+
+// DefaultQueryTables are the tables ad-hoc queries may read. Configs and settings are
+// excluded because they contain device credentials, tokens and passwords.
+var DefaultQueryTables = []string{"meters", "sessions", "grid_sessions"}
+
+// QueryResult holds the rows of a read-only query
+type QueryResult struct {
+	Columns []string
+	Rows    [][]any
+}
+
+// Query runs a single SELECT statement that may only read the given tables,
+// DefaultQueryTables if none are given. Any other statement is rejected by SQLite.
+func (c *Client) Query(ctx context.Context, query string, tables []string) (*QueryResult, error) {
+	fields := strings.Fields(query)
+	if len(fields) == 0 || (!strings.EqualFold(fields[0], "SELECT") && !strings.EqualFold(fields[0], "WITH")) {
+		return nil, fmt.Errorf("only SELECT statements are allowed")
+	}
+
+	if tables == nil {
+		tables = DefaultQueryTables
+	}
+	allowed := make(map[string]bool)
+	for _, table := range tables {
+		allowed[strings.ToLower(table)] = true
+	}
+
+	conn, err := c.db.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get connection: %w", err)
+	}
+	defer func() { _ = conn.Close() }()
+
+	var denied string
+	authorize := func(op int, arg1, arg2, arg3 string) int {
+		switch op {
+		case sqlite3.SQLITE_SELECT, sqlite3.SQLITE_FUNCTION, sqliteRecursive:
+			return sqlite3.SQLITE_OK
+		case sqlite3.SQLITE_READ:
+			if allowed[strings.ToLower(arg1)] {
+				return sqlite3.SQLITE_OK
+			}
+			denied = fmt.Sprintf("table %s is not allowed", arg1)
+		default:
+			denied = "only SELECT statements are allowed"
+		}
+		return sqlite3.SQLITE_DENY
+	}
+
+	if err := setAuthorizer(conn, authorize); err != nil {
+		return nil, err
+	}
+	defer func() { _ = setAuthorizer(conn, nil) }()
+
+	rows, err := conn.QueryContext(ctx, query)
+	if err != nil {
+		if denied != "" {
+			return nil, fmt.Errorf("query rejected: %s", denied)
+		}
+		return nil, fmt.Errorf("query failed: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &QueryResult{Columns: columns}
+	for rows.Next() {
+		values := make([]any, len(columns))
+		valuePtrs := make([]any, len(columns))
+		for i := range values {
+			valuePtrs[i] = &values[i]
+		}
+		if err := rows.Scan(valuePtrs...); err != nil {
+			return nil, err
+		}
+
+		for i, val := range values {
+			switch v := val.(type) {
+			case []byte:
+				values[i] = string(v)
+			case time.Time:
+				values[i] = FormatTimestamp(v)
+			}
+		}
+		result.Rows = append(result.Rows, values)
+	}
+
+	return result, rows.Err()
+}
+
+// setAuthorizer installs or removes the SQLite authorizer of a connection
+func setAuthorizer(conn *sql.Conn, fn func(int, string, string, string) int) error {
+	return conn.Raw(func(driverConn any) error {
+		sc, ok := driverConn.(*sqlite3.SQLiteConn)
+		if !ok {
+			return fmt.Errorf("unexpected driver connection %T", driverConn)
+		}
+		sc.RegisterAuthorizer(fn)
+		return nil
+	})
+}
+
+// WriteTable writes the result as aligned text table
+func (r *QueryResult) WriteTable(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, strings.Join(r.Columns, "\t"))
+	for _, row := range r.Rows {
+		_, _ = fmt.Fprintln(tw, strings.Join(r.formatRow(row), "\t"))
+	}
+	return tw.Flush()
+}
+
+// WriteCSV writes the result as CSV with a header line
+func (r *QueryResult) WriteCSV(w io.Writer) error {
+	return r.WriteCSVLocale(w, Locale{})
+}
+
+// WriteCSVLocale writes the result as CSV with the separator, number and timestamp
+// format and the translated headers of the locale
+func (r *QueryResult) WriteCSVLocale(w io.Writer, l Locale) error {
+	cw := csv.NewWriter(w)
+	if l.Comma != 0 {
+		cw.Comma = l.Comma
+	}
+
+	header := make([]string, len(r.Columns))
+	for i, col := range r.Columns {
+		header[i] = l.Header(col)
+	}
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+
+	for _, row := range r.Rows {
+		record := make([]string, len(row))
+		for i, val := range row {
+			record[i] = l.FormatValue(val)
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteJSON writes the result as JSON array of objects keyed by column
+func (r *QueryResult) WriteJSON(w io.Writer) error {
+	objects := make([]map[string]any, 0, len(r.Rows))
+	for _, row := range r.Rows {
+		obj := make(map[string]any, len(r.Columns))
+		for i, col := range r.Columns {
+			obj[col] = row[i]
+		}
+		objects = append(objects, obj)
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(objects)
+}
+
+// formatRow formats a row for text output, NULL becomes an empty string
+func (r *QueryResult) formatRow(row []any) []string {
+	out := make([]string, len(row))
+	for i, val := range row {
+		if val != nil {
+			out[i] = fmt.Sprint(val)
+		}
+	}
+	return out
+}
@@ -0,0 +1,122 @@
+package evccdb
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestQuery(t *testing.T) {
+	client, cleanup := createTestDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	result, err := client.Query(ctx, "SELECT loadpoint, COUNT(*) AS n FROM sessions GROUP BY loadpoint ORDER BY loadpoint", nil)
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+
+	if len(result.Rows) != 2 || result.Rows[0][0] != "Garage" || result.Rows[0][1] != int64(3) {
+		t.Errorf("Unexpected result: %v", result.Rows)
+	}
+
+	var buf bytes.Buffer
+	if err := result.WriteCSV(&buf); err != nil {
+		t.Fatalf("WriteCSV failed: %v", err)
+	}
+	if buf.String() != "loadpoint,n\nGarage,3\neBikes,2\n" {
+		t.Errorf("Unexpected CSV output: %q", buf.String())
+	}
+}
+
+func TestWriteCSVLocale(t *testing.T) {
+	result := &QueryResult{
+		Columns: []string{"created", "loadpoint", "charged_kwh", "n"},
+		Rows:    [][]any{{"2024-01-31T18:30:00Z", "Garage", 12.5, int64(3)}, {nil, "eBikes", nil, int64(0)}},
+	}
+
+	var buf bytes.Buffer
+	if err := result.WriteCSVLocale(&buf, ParseLocale("de_DE.UTF-8")); err != nil {
+		t.Fatalf("WriteCSVLocale failed: %v", err)
+	}
+	expected := "Beginn;Ladepunkt;Geladen (kWh);n\n31.01.2024 18:30:00;Garage;12,5;3\n;eBikes;;0\n"
+	if buf.String() != expected {
+		t.Errorf("Unexpected CSV output: %q", buf.String())
+	}
+
+	buf.Reset()
+	if err := result.WriteCSVLocale(&buf, ParseLocale("en")); err != nil {
+		t.Fatalf("WriteCSVLocale failed: %v", err)
+	}
+	if !strings.HasPrefix(buf.String(), "created,loadpoint,charged_kwh,n\n2024-01-31 18:30:00,Garage,12.5,3\n") {
+		t.Errorf("Unexpected CSV output: %q", buf.String())
+	}
+}
+
+func TestQueryRejected(t *testing.T) {
+	client, cleanup := createTestDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	queries := []string{
+		"DELETE FROM sessions",
+		"SELECT * FROM configs",
+		"SELECT COUNT(*) FROM configs",
+		"SELECT key, value FROM settings",
+		"SELECT * FROM sessions; DELETE FROM sessions",
+		"WITH x AS (SELECT 1) UPDATE sessions SET vehicle = 'x'",
+		"SELECT * FROM sqlite_master",
+	}
+
+	for _, query := range queries {
+		if _, err := client.Query(ctx, query, nil); err == nil {
+			t.Errorf("Expected %q to be rejected", query)
+		}
+	}
+
+	count, _ := client.GetRowCount("sessions")
+	if count != 5 {
+		t.Errorf("Expected sessions to be untouched, got %d rows", count)
+	}
+
+	// Connections return to the pool without the authorizer
+	if _, err := client.db.Exec("UPDATE sessions SET vehicle = vehicle"); err != nil {
+		t.Errorf("Expected writes outside of Query to work: %v", err)
+	}
+
+	// Custom whitelists allow other tables
+	if _, err := client.Query(ctx, "SELECT title FROM configs", []string{"configs"}); err != nil {
+		t.Errorf("Expected query on whitelisted configs to work: %v", err)
+	}
+
+	if _, err := client.Query(ctx, "WITH RECURSIVE n(i) AS (SELECT 1 UNION ALL SELECT i+1 FROM n WHERE i < 3) SELECT i FROM n", nil); err != nil {
+		t.Errorf("Expected recursive CTE to work: %v", err)
+	}
+}
+
+func TestQueryNamed(t *testing.T) {
+	client, cleanup := createTestDB(t)
+	defer cleanup()
+
+	_, _ = client.db.Exec("UPDATE sessions SET charged_kwh = 10, solar_percentage = 50, price = 3")
+
+	ctx := context.Background()
+	for _, q := range NamedQueries() {
+		if _, err := client.QueryNamed(ctx, q.Name); err != nil {
+			t.Errorf("Named query %s failed: %v", q.Name, err)
+		}
+	}
+
+	result, err := client.QueryNamed(ctx, "monthly-kwh")
+	if err != nil {
+		t.Fatalf("QueryNamed failed: %v", err)
+	}
+	if len(result.Rows) != 3 || result.Rows[0][0] != "2023-04" {
+		t.Errorf("Unexpected monthly-kwh result: %v", result.Rows)
+	}
+
+	if _, err := client.QueryNamed(ctx, "unknown"); err == nil {
+		t.Error("Expected error for unknown named query")
+	}
+}
@@ -6,110 +6,170 @@ import (
 	"encoding/json"
 	"fmt"
 	"strings"
+
+	"github.com/iseeberg79/evccdb/internal/sqlbuild"
 )
 
 // RenameResult contains the counts of renamed rows per table
 type RenameResult struct {
-	Sessions int
-	Settings int
-	Configs  int
+	Sessions int `json:"sessions"`
+	Settings int `json:"settings"`
+	Configs  int `json:"configs"`
 }
 
 // RenameLoadpoint updates a loadpoint name across all tables
 func (c *Client) RenameLoadpoint(ctx context.Context, oldName, newName string) (RenameResult, error) {
 	var result RenameResult
+	err := c.inTx(ctx, func(tx *Tx) (err error) {
+		result, err = tx.RenameLoadpoint(ctx, oldName, newName)
+		return err
+	})
+	return result, err
+}
 
-	tx, err := c.db.BeginTx(ctx, nil)
-	if err != nil {
-		return result, fmt.Errorf("failed to begin transaction: %w", err)
-	}
-	defer func() { _ = tx.Rollback() }()
+// RenameLoadpoint updates a loadpoint name across all tables within the transaction
+func (t *Tx) RenameLoadpoint(ctx context.Context, oldName, newName string) (RenameResult, error) {
+	var result RenameResult
 
 	// 1. Rename in sessions table
-	count, err := c.renameInSessions(ctx, tx, "loadpoint", oldName, newName)
+	count, err := t.c.renameInSessions(ctx, t.tx, "loadpoint", oldName, newName)
 	if err != nil {
 		return result, fmt.Errorf("failed to rename loadpoint in sessions: %w", err)
 	}
 	result.Sessions = count
 
 	// 2. Rename in settings (lp<n>.title values)
-	count, err = c.renameSettingsValue(ctx, tx, "lp%.title", oldName, newName)
+	indices, err := loadpointIndices(ctx, t.tx, oldName)
+	if err != nil {
+		return result, fmt.Errorf("failed to resolve loadpoint indices: %w", err)
+	}
+	count, err = t.c.renameLoadpointTitles(ctx, t.tx, indices, newName)
 	if err != nil {
 		return result, fmt.Errorf("failed to rename loadpoint in settings: %w", err)
 	}
 	result.Settings = count
 
-	// 3. Rename in configs JSON (class 5 = loadpoints)
-	count, err = c.renameInConfigsJSON(ctx, tx, 5, oldName, newName)
+	// 3. Rename in configs JSON of loadpoints
+	count, err = t.c.renameInConfigsJSON(ctx, t.tx, ClassLoadpoint, oldName, newName)
 	if err != nil {
 		return result, fmt.Errorf("failed to rename loadpoint in configs: %w", err)
 	}
 	result.Configs = count
 
-	if err := tx.Commit(); err != nil {
-		return result, fmt.Errorf("failed to commit transaction: %w", err)
+	return result, nil
+}
+
+// RenameLoadpointIndex renames the loadpoint with the given lp<n> index, see Tx.RenameLoadpointIndex
+func (c *Client) RenameLoadpointIndex(ctx context.Context, index int, newName string) (RenameResult, error) {
+	var result RenameResult
+	err := c.inTx(ctx, func(tx *Tx) (err error) {
+		result, err = tx.RenameLoadpointIndex(ctx, index, newName)
+		return err
+	})
+	return result, err
+}
+
+// RenameLoadpointIndex renames the loadpoint with the given lp<n> index within the transaction.
+// Sessions and configs only carry the title, so they are renamed only if no
+// other loadpoint shares the old title.
+func (t *Tx) RenameLoadpointIndex(ctx context.Context, index int, newName string) (RenameResult, error) {
+	var result RenameResult
+
+	oldName, shared, err := loadpointTitle(ctx, t.tx, index)
+	if err != nil {
+		return result, err
+	}
+
+	count, err := t.c.renameLoadpointTitles(ctx, t.tx, []int{index}, newName)
+	if err != nil {
+		return result, fmt.Errorf("failed to rename loadpoint in settings: %w", err)
+	}
+	result.Settings = count
+
+	if !shared {
+		count, err = t.c.renameInSessions(ctx, t.tx, "loadpoint", oldName, newName)
+		if err != nil {
+			return result, fmt.Errorf("failed to rename loadpoint in sessions: %w", err)
+		}
+		result.Sessions = count
+
+		count, err = t.c.renameInConfigsJSON(ctx, t.tx, ClassLoadpoint, oldName, newName)
+		if err != nil {
+			return result, fmt.Errorf("failed to rename loadpoint in configs: %w", err)
+		}
+		result.Configs = count
 	}
 
 	return result, nil
 }
 
+// loadpointTitle returns the title of a loadpoint index and whether other loadpoints share it
+func loadpointTitle(ctx context.Context, q querier, index int) (string, bool, error) {
+	var title string
+	err := q.QueryRowContext(ctx, "SELECT value FROM settings WHERE key = ?", loadpointTitleKey(index)).Scan(&title)
+	if err == sql.ErrNoRows {
+		return "", false, fmt.Errorf("loadpoint lp%d not found", index)
+	}
+	if err != nil {
+		return "", false, fmt.Errorf("failed to read loadpoint title: %w", err)
+	}
+
+	indices, err := loadpointIndices(ctx, q, title)
+	if err != nil {
+		return "", false, fmt.Errorf("failed to resolve loadpoint indices: %w", err)
+	}
+
+	return title, len(indices) > 1, nil
+}
+
 // RenameVehicle updates a vehicle name across all tables
 func (c *Client) RenameVehicle(ctx context.Context, oldName, newName string) (RenameResult, error) {
 	var result RenameResult
+	err := c.inTx(ctx, func(tx *Tx) (err error) {
+		result, err = tx.RenameVehicle(ctx, oldName, newName)
+		return err
+	})
+	return result, err
+}
 
-	tx, err := c.db.BeginTx(ctx, nil)
-	if err != nil {
-		return result, fmt.Errorf("failed to begin transaction: %w", err)
-	}
-	defer func() { _ = tx.Rollback() }()
+// RenameVehicle updates a vehicle name across all tables within the transaction
+func (t *Tx) RenameVehicle(ctx context.Context, oldName, newName string) (RenameResult, error) {
+	var result RenameResult
 
 	// 1. Rename in sessions table
-	count, err := c.renameInSessions(ctx, tx, "vehicle", oldName, newName)
+	count, err := t.c.renameInSessions(ctx, t.tx, "vehicle", oldName, newName)
 	if err != nil {
 		return result, fmt.Errorf("failed to rename vehicle in sessions: %w", err)
 	}
 	result.Sessions = count
 
 	// 2. Rename vehicle settings keys (vehicle.OldName.* -> vehicle.NewName.*)
-	oldPrefix := "vehicle." + oldName + "."
-	newPrefix := "vehicle." + newName + "."
-	count, err = c.renameSettingsKeys(ctx, tx, oldPrefix, newPrefix)
+	count, err = t.c.renameVehicleSettingsKeys(ctx, t.tx, oldName, newName)
 	if err != nil {
 		return result, fmt.Errorf("failed to rename vehicle settings keys: %w", err)
 	}
 	result.Settings = count
 
-	// 3. Rename in configs JSON/YAML (class 3 = vehicles)
-	count, err = c.renameInConfigsJSON(ctx, tx, 3, oldName, newName)
+	// 3. Rename in configs JSON/YAML of vehicles
+	count, err = t.c.renameInConfigsJSON(ctx, t.tx, ClassVehicle, oldName, newName)
 	if err != nil {
 		return result, fmt.Errorf("failed to rename vehicle in configs: %w", err)
 	}
 	result.Configs = count
 
-	if err := tx.Commit(); err != nil {
-		return result, fmt.Errorf("failed to commit transaction: %w", err)
-	}
-
 	return result, nil
 }
 
 // renameInSessions updates a column value in the sessions table
 func (c *Client) renameInSessions(ctx context.Context, tx *sql.Tx, column, oldName, newName string) (int, error) {
-	result, err := tx.ExecContext(ctx,
-		fmt.Sprintf("UPDATE sessions SET `%s` = ? WHERE `%s` = ?", column, column),
-		newName, oldName)
+	col, err := sqlbuild.QuoteIdent(column)
 	if err != nil {
 		return 0, err
 	}
-	affected, err := result.RowsAffected()
-	return int(affected), err
-}
 
-// renameSettingsValue updates settings value where key matches pattern and value matches oldName
-func (c *Client) renameSettingsValue(ctx context.Context, tx *sql.Tx, keyPattern, oldValue, newValue string) (int, error) {
 	result, err := tx.ExecContext(ctx,
-		"UPDATE settings SET value = ? WHERE key LIKE ? AND value = ?",
-		newValue, keyPattern, oldValue)
+		fmt.Sprintf("UPDATE sessions SET %s = ? WHERE %s = ?", col, col),
+		newName, oldName)
 	if err != nil {
 		return 0, err
 	}
@@ -117,53 +177,53 @@ func (c *Client) renameSettingsValue(ctx context.Context, tx *sql.Tx, keyPattern
 	return int(affected), err
 }
 
-// renameSettingsKeys renames settings keys by replacing prefix
-func (c *Client) renameSettingsKeys(ctx context.Context, tx *sql.Tx, oldPrefix, newPrefix string) (int, error) {
-	// First, get all keys matching the old prefix
-	rows, err := tx.QueryContext(ctx, "SELECT key, value FROM settings WHERE key LIKE ?", oldPrefix+"%")
-	if err != nil {
-		return 0, err
-	}
-	defer func() { _ = rows.Close() }()
-
-	type keyValue struct {
-		key   string
-		value string
-	}
-	var kvs []keyValue
-	for rows.Next() {
-		var kv keyValue
-		if err := rows.Scan(&kv.key, &kv.value); err != nil {
-			return 0, err
+// renameLoadpointTitles updates the lp<n>.title settings of the given loadpoint indices
+func (c *Client) renameLoadpointTitles(ctx context.Context, tx *sql.Tx, indices []int, newTitle string) (int, error) {
+	count := 0
+	for _, index := range indices {
+		result, err := tx.ExecContext(ctx, "UPDATE settings SET value = ? WHERE key = ?", newTitle, loadpointTitleKey(index))
+		if err != nil {
+			return count, err
 		}
-		kvs = append(kvs, kv)
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return count, err
+		}
+		count += int(affected)
 	}
-	if err := rows.Err(); err != nil {
+	return count, nil
+}
+
+// renameVehicleSettingsKeys renames vehicle settings keys by exact vehicle name
+func (c *Client) renameVehicleSettingsKeys(ctx context.Context, tx *sql.Tx, oldName, newName string) (int, error) {
+	keys, err := vehicleSettingsKeys(ctx, tx, oldName)
+	if err != nil {
 		return 0, err
 	}
 
-	// Insert with new prefix and delete old
-	for _, kv := range kvs {
-		newKey := newPrefix + strings.TrimPrefix(kv.key, oldPrefix)
+	// Insert with new name and delete old
+	for _, key := range keys {
+		oldKey := key.String()
+		key.Name = newName
 
 		// Insert or replace with new key
-		_, err := tx.ExecContext(ctx, "INSERT OR REPLACE INTO settings (key, value) VALUES (?, ?)", newKey, kv.value)
+		_, err := tx.ExecContext(ctx, "INSERT OR REPLACE INTO settings (key, value) SELECT ?, value FROM settings WHERE key = ?", key.String(), oldKey)
 		if err != nil {
 			return 0, err
 		}
 
 		// Delete old key
-		_, err = tx.ExecContext(ctx, "DELETE FROM settings WHERE key = ?", kv.key)
+		_, err = tx.ExecContext(ctx, "DELETE FROM settings WHERE key = ?", oldKey)
 		if err != nil {
 			return 0, err
 		}
 	}
 
-	return len(kvs), nil
+	return len(keys), nil
 }
 
 // renameInConfigsJSON updates title field in configs JSON for specified class
-func (c *Client) renameInConfigsJSON(ctx context.Context, tx *sql.Tx, class int, oldTitle, newTitle string) (int, error) {
+func (c *Client) renameInConfigsJSON(ctx context.Context, tx *sql.Tx, class ConfigClass, oldTitle, newTitle string) (int, error) {
 	// Query configs for the specified class
 	rows, err := tx.QueryContext(ctx, "SELECT id, value FROM configs WHERE class = ?", class)
 	if err != nil {
@@ -240,14 +300,14 @@ func (c *Client) RenameLoadpointDryRun(ctx context.Context, oldName, newName str
 	result.Sessions = count
 
 	// Count settings
-	err = c.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM settings WHERE key LIKE 'lp%.title' AND value = ?", oldName).Scan(&count)
+	indices, err := loadpointIndices(ctx, c.db, oldName)
 	if err != nil {
 		return result, err
 	}
-	result.Settings = count
+	result.Settings = len(indices)
 
 	// Count configs
-	count, err = c.countConfigsWithTitle(ctx, 5, oldName)
+	count, err = c.countConfigsWithTitle(ctx, ClassLoadpoint, oldName)
 	if err != nil {
 		return result, err
 	}
@@ -256,6 +316,29 @@ func (c *Client) RenameLoadpointDryRun(ctx context.Context, oldName, newName str
 	return result, nil
 }
 
+// RenameLoadpointIndexDryRun returns the counts of what RenameLoadpointIndex would rename
+func (c *Client) RenameLoadpointIndexDryRun(ctx context.Context, index int, newName string) (RenameResult, error) {
+	oldName, shared, err := loadpointTitle(ctx, c.db, index)
+	if err != nil {
+		return RenameResult{}, err
+	}
+
+	result := RenameResult{Settings: 1}
+	if shared {
+		return result, nil
+	}
+
+	// Count sessions
+	err = c.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM sessions WHERE loadpoint = ?", oldName).Scan(&result.Sessions)
+	if err != nil {
+		return result, err
+	}
+
+	// Count configs
+	result.Configs, err = c.countConfigsWithTitle(ctx, ClassLoadpoint, oldName)
+	return result, err
+}
+
 // RenameVehicleDryRun returns the counts of what would be renamed without making changes
 func (c *Client) RenameVehicleDryRun(ctx context.Context, oldName, newName string) (RenameResult, error) {
 	var result RenameResult
@@ -269,15 +352,14 @@ func (c *Client) RenameVehicleDryRun(ctx context.Context, oldName, newName strin
 	result.Sessions = count
 
 	// Count settings keys
-	oldPrefix := "vehicle." + oldName + ".%"
-	err = c.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM settings WHERE key LIKE ?", oldPrefix).Scan(&count)
+	keys, err := vehicleSettingsKeys(ctx, c.db, oldName)
 	if err != nil {
 		return result, err
 	}
-	result.Settings = count
+	result.Settings = len(keys)
 
 	// Count configs
-	count, err = c.countConfigsWithTitle(ctx, 3, oldName)
+	count, err = c.countConfigsWithTitle(ctx, ClassVehicle, oldName)
 	if err != nil {
 		return result, err
 	}
@@ -287,7 +369,7 @@ func (c *Client) RenameVehicleDryRun(ctx context.Context, oldName, newName strin
 }
 
 // countConfigsWithTitle counts configs in a class with matching title
-func (c *Client) countConfigsWithTitle(ctx context.Context, class int, title string) (int, error) {
+func (c *Client) countConfigsWithTitle(ctx context.Context, class ConfigClass, title string) (int, error) {
 	rows, err := c.db.QueryContext(ctx, "SELECT value FROM configs WHERE class = ?", class)
 	if err != nil {
 		return 0, err
@@ -321,17 +403,34 @@ func (c *Client) countConfigsWithTitle(ctx context.Context, class int, title str
 
 // DeleteLoadpointSessions deletes all sessions for a specific loadpoint
 func (c *Client) DeleteLoadpointSessions(ctx context.Context, loadpoint string) (int, error) {
-	result, err := c.db.ExecContext(ctx, "DELETE FROM sessions WHERE loadpoint = ?", loadpoint)
-	if err != nil {
-		return 0, fmt.Errorf("failed to delete sessions: %w", err)
-	}
-	affected, err := result.RowsAffected()
-	return int(affected), err
+	return deleteSessions(ctx, c.db, "loadpoint", loadpoint)
 }
 
 // DeleteVehicleSessions deletes all sessions for a specific vehicle
 func (c *Client) DeleteVehicleSessions(ctx context.Context, vehicle string) (int, error) {
-	result, err := c.db.ExecContext(ctx, "DELETE FROM sessions WHERE vehicle = ?", vehicle)
+	return deleteSessions(ctx, c.db, "vehicle", vehicle)
+}
+
+// DeleteLoadpointSessions deletes all sessions for a specific loadpoint within the transaction
+func (t *Tx) DeleteLoadpointSessions(ctx context.Context, loadpoint string) (int, error) {
+	return deleteSessions(ctx, t.tx, "loadpoint", loadpoint)
+}
+
+// DeleteVehicleSessions deletes all sessions for a specific vehicle within the transaction
+func (t *Tx) DeleteVehicleSessions(ctx context.Context, vehicle string) (int, error) {
+	return deleteSessions(ctx, t.tx, "vehicle", vehicle)
+}
+
+// deleteSessions deletes all sessions where column matches value
+func deleteSessions(ctx context.Context, exec interface {
+	ExecContext(context.Context, string, ...any) (sql.Result, error)
+}, column, value string) (int, error) {
+	col, err := sqlbuild.QuoteIdent(column)
+	if err != nil {
+		return 0, err
+	}
+
+	result, err := exec.ExecContext(ctx, fmt.Sprintf("DELETE FROM sessions WHERE %s = ?", col), value)
 	if err != nil {
 		return 0, fmt.Errorf("failed to delete sessions: %w", err)
 	}
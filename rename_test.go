@@ -635,3 +635,99 @@ func TestCountLoadpointSessions(t *testing.T) {
 		t.Errorf("CountLoadpointSessions=%d does not match direct count=%d", count, directCount)
 	}
 }
+
+func TestRenameVehicleDottedNames(t *testing.T) {
+	client, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	_, err := client.db.Exec(`INSERT INTO settings (key, value) VALUES
+		('vehicle.ID.minSoc', '10'),
+		('vehicle.ID.4.minSoc', '20'),
+		('vehicle.ID.4.limitSoc', '80')`)
+	if err != nil {
+		t.Fatalf("Failed to insert settings: %v", err)
+	}
+
+	dryRun, err := client.RenameVehicleDryRun(ctx, "ID", "Zoe")
+	if err != nil {
+		t.Fatalf("RenameVehicleDryRun failed: %v", err)
+	}
+	if dryRun.Settings != 1 {
+		t.Errorf("Expected dry run to report 1 setting, got %d", dryRun.Settings)
+	}
+
+	result, err := client.RenameVehicle(ctx, "ID", "Zoe")
+	if err != nil {
+		t.Fatalf("RenameVehicle failed: %v", err)
+	}
+	if result.Settings != 1 {
+		t.Errorf("Expected 1 setting renamed, got %d", result.Settings)
+	}
+
+	var count int
+	err = client.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM settings WHERE key LIKE 'vehicle.ID.4.%'").Scan(&count)
+	if err != nil {
+		t.Fatalf("Failed to count settings: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("Expected 2 untouched ID.4 settings, got %d", count)
+	}
+
+	var value string
+	err = client.db.QueryRowContext(ctx, "SELECT value FROM settings WHERE key = 'vehicle.Zoe.minSoc'").Scan(&value)
+	if err != nil {
+		t.Fatalf("Failed to get renamed setting: %v", err)
+	}
+	if value != "10" {
+		t.Errorf("Expected value '10', got %q", value)
+	}
+}
+
+func TestRenameLoadpointIndex(t *testing.T) {
+	client, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	// lp3 shares the title of lp1
+	_, err := client.db.Exec("INSERT INTO settings (key, value) VALUES ('lp3.title', 'Garage')")
+	if err != nil {
+		t.Fatalf("Failed to insert setting: %v", err)
+	}
+
+	result, err := client.RenameLoadpointIndex(ctx, 3, "Carport")
+	if err != nil {
+		t.Fatalf("RenameLoadpointIndex failed: %v", err)
+	}
+
+	if result.Settings != 1 || result.Sessions != 0 || result.Configs != 0 {
+		t.Errorf("Expected only lp3.title renamed, got sessions=%d, settings=%d, configs=%d",
+			result.Sessions, result.Settings, result.Configs)
+	}
+
+	var lp1, lp3 string
+	if err := client.db.QueryRowContext(ctx, "SELECT value FROM settings WHERE key = 'lp1.title'").Scan(&lp1); err != nil {
+		t.Fatalf("Failed to get lp1.title: %v", err)
+	}
+	if err := client.db.QueryRowContext(ctx, "SELECT value FROM settings WHERE key = 'lp3.title'").Scan(&lp3); err != nil {
+		t.Fatalf("Failed to get lp3.title: %v", err)
+	}
+	if lp1 != "Garage" || lp3 != "Carport" {
+		t.Errorf("Expected lp1=Garage and lp3=Carport, got lp1=%q and lp3=%q", lp1, lp3)
+	}
+
+	// lp2 has a unique title, so sessions follow
+	result, err = client.RenameLoadpointIndex(ctx, 2, "E-Bikes")
+	if err != nil {
+		t.Fatalf("RenameLoadpointIndex failed: %v", err)
+	}
+	if result.Sessions != 2 {
+		t.Errorf("Expected 2 sessions renamed, got %d", result.Sessions)
+	}
+
+	if _, err := client.RenameLoadpointIndex(ctx, 9, "Missing"); err == nil {
+		t.Error("Expected error for unknown loadpoint index")
+	}
+}
@@ -0,0 +1,190 @@
+package evccdb
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Section is a titled result of a command with one or several results
+type Section struct {
+	// Name keys the section in JSON output of several sections
+	Name string
+	// Title heads the section in text, markdown and HTML output
+	Title  string
+	Result *QueryResult
+}
+
+// Renderer writes the results of a command in an output format
+type Renderer interface {
+	Render(w io.Writer, sections ...Section) error
+}
+
+var (
+	renderersMu sync.RWMutex
+	renderers   = map[string]func(Locale) Renderer{}
+)
+
+func init() {
+	RegisterRenderer("table", func(Locale) Renderer { return tableRenderer{} })
+	RegisterRenderer("json", func(Locale) Renderer { return jsonRenderer{} })
+	RegisterRenderer("csv", func(l Locale) Renderer { return csvRenderer{l} })
+	RegisterRenderer("markdown", func(l Locale) Renderer { return markdownRenderer{l} })
+	RegisterRenderer("html", func(l Locale) Renderer { return htmlRenderer{l} })
+}
+
+// RegisterRenderer adds or replaces an output format. The locale formats values of formats
+// meant for spreadsheets and documents.
+func RegisterRenderer(name string, fn func(Locale) Renderer) {
+	renderersMu.Lock()
+	defer renderersMu.Unlock()
+	renderers[name] = fn
+}
+
+// Renderers returns the names of the registered output formats sorted by name
+func Renderers() []string {
+	renderersMu.RLock()
+	defer renderersMu.RUnlock()
+
+	names := make([]string, 0, len(renderers))
+	for name := range renderers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NewRenderer returns the renderer of an output format
+func NewRenderer(format string, l Locale) (Renderer, error) {
+	renderersMu.RLock()
+	fn, ok := renderers[format]
+	renderersMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown format %q, available: %s", format, strings.Join(Renderers(), ", "))
+	}
+	return fn(l), nil
+}
+
+// tableRenderer writes aligned text tables below their titles
+type tableRenderer struct{}
+
+func (tableRenderer) Render(w io.Writer, sections ...Section) error {
+	for i, s := range sections {
+		if i > 0 {
+			_, _ = fmt.Fprintln(w)
+		}
+		if s.Title != "" {
+			_, _ = fmt.Fprintln(w, s.Title)
+		}
+		if err := s.Result.WriteTable(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// jsonRenderer writes a single result as array, several as object keyed by section name
+type jsonRenderer struct{}
+
+func (jsonRenderer) Render(w io.Writer, sections ...Section) error {
+	if len(sections) == 1 && sections[0].Name == "" {
+		return sections[0].Result.WriteJSON(w)
+	}
+
+	out := make(map[string]json.RawMessage, len(sections))
+	for _, s := range sections {
+		var buf bytes.Buffer
+		if err := s.Result.WriteJSON(&buf); err != nil {
+			return err
+		}
+		out[s.Name] = buf.Bytes()
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(out)
+}
+
+// csvRenderer writes CSV blocks separated by an empty line
+type csvRenderer struct{ locale Locale }
+
+func (r csvRenderer) Render(w io.Writer, sections ...Section) error {
+	for i, s := range sections {
+		if i > 0 {
+			_, _ = fmt.Fprintln(w)
+		}
+		if err := s.Result.WriteCSVLocale(w, r.locale); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// markdownRenderer writes pipe tables below level two headings
+type markdownRenderer struct{ locale Locale }
+
+func (r markdownRenderer) Render(w io.Writer, sections ...Section) error {
+	escape := strings.NewReplacer("|", `\|`, "\r\n", "<br>", "\n", "<br>")
+	for i, s := range sections {
+		if i > 0 {
+			_, _ = fmt.Fprintln(w)
+		}
+		if s.Title != "" {
+			_, _ = fmt.Fprintf(w, "## %s\n\n", escape.Replace(strings.TrimSuffix(s.Title, ":")))
+		}
+
+		cells := make([]string, len(s.Result.Columns))
+		for j, col := range s.Result.Columns {
+			cells[j] = escape.Replace(r.locale.Header(col))
+		}
+		_, _ = fmt.Fprintf(w, "| %s |\n", strings.Join(cells, " | "))
+		_, _ = fmt.Fprintf(w, "|%s\n", strings.Repeat(" --- |", len(cells)))
+
+		for _, row := range s.Result.Rows {
+			for j, val := range row {
+				cells[j] = escape.Replace(r.locale.FormatValue(val))
+			}
+			if _, err := fmt.Fprintf(w, "| %s |\n", strings.Join(cells[:len(row)], " | ")); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// htmlRenderer writes a standalone HTML document with a table per section
+type htmlRenderer struct{ locale Locale }
+
+func (r htmlRenderer) Render(w io.Writer, sections ...Section) error {
+	var b strings.Builder
+	b.WriteString("<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n<title>evccdb</title>\n")
+	b.WriteString("<style>table{border-collapse:collapse;margin-bottom:1em}th,td{border:1px solid #ccc;padding:2px 8px;text-align:left}</style>\n")
+	b.WriteString("</head>\n<body>\n")
+
+	for _, s := range sections {
+		if s.Title != "" {
+			fmt.Fprintf(&b, "<h2>%s</h2>\n", html.EscapeString(strings.TrimSuffix(s.Title, ":")))
+		}
+		b.WriteString("<table>\n<thead><tr>")
+		for _, col := range s.Result.Columns {
+			fmt.Fprintf(&b, "<th>%s</th>", html.EscapeString(r.locale.Header(col)))
+		}
+		b.WriteString("</tr></thead>\n<tbody>\n")
+		for _, row := range s.Result.Rows {
+			b.WriteString("<tr>")
+			for _, val := range row {
+				fmt.Fprintf(&b, "<td>%s</td>", html.EscapeString(r.locale.FormatValue(val)))
+			}
+			b.WriteString("</tr>\n")
+		}
+		b.WriteString("</tbody>\n</table>\n")
+	}
+
+	b.WriteString("</body>\n</html>\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
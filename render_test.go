@@ -0,0 +1,62 @@
+package evccdb
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestRenderers(t *testing.T) {
+	sessions := &QueryResult{Columns: []string{"loadpoint", "charged_kwh"}, Rows: [][]any{{"Garage|1", 1.5}, {"<Carport>", nil}}}
+	grid := &QueryResult{Columns: []string{"type"}, Rows: [][]any{{"dim"}}}
+	sections := []Section{{Name: "sessions", Title: "Sessions:", Result: sessions}, {Name: "grid", Title: "Grid:", Result: grid}}
+
+	for _, tc := range []struct {
+		format   string
+		locale   string
+		contains []string
+	}{
+		{"table", "", []string{"Sessions:\nloadpoint", "\n\nGrid:\ntype"}},
+		{"csv", "de-DE", []string{"Ladepunkt;Geladen (kWh)\nGarage|1;1,5\n", "\n\ntype\ndim\n"}},
+		{"markdown", "", []string{"## Sessions\n\n| loadpoint | charged_kwh |\n| --- | --- |\n| Garage\\|1 | 1.5 |\n| <Carport> |  |\n", "## Grid"}},
+		{"html", "", []string{"<h2>Sessions</h2>", "<td>&lt;Carport&gt;</td><td></td>", "</html>\n"}},
+	} {
+		renderer, err := NewRenderer(tc.format, ParseLocale(tc.locale))
+		if err != nil {
+			t.Fatalf("NewRenderer(%s) failed: %v", tc.format, err)
+		}
+		var buf bytes.Buffer
+		if err := renderer.Render(&buf, sections...); err != nil {
+			t.Fatalf("Render(%s) failed: %v", tc.format, err)
+		}
+		for _, s := range tc.contains {
+			if !strings.Contains(buf.String(), s) {
+				t.Errorf("Expected %s output to contain %q, got:\n%s", tc.format, s, buf.String())
+			}
+		}
+	}
+
+	// JSON keys several sections by name, a single unnamed result is an array
+	renderer, _ := NewRenderer("json", Locale{})
+	var buf bytes.Buffer
+	if err := renderer.Render(&buf, sections...); err != nil {
+		t.Fatal(err)
+	}
+	var object map[string][]map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &object); err != nil || len(object["sessions"]) != 2 || len(object["grid"]) != 1 {
+		t.Errorf("Unexpected JSON %s: %v", buf.String(), err)
+	}
+	buf.Reset()
+	if err := renderer.Render(&buf, Section{Result: grid}); err != nil {
+		t.Fatal(err)
+	}
+	var array []map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &array); err != nil || len(array) != 1 {
+		t.Errorf("Unexpected JSON %s: %v", buf.String(), err)
+	}
+
+	if _, err := NewRenderer("xml", Locale{}); err == nil {
+		t.Error("Expected an error for an unknown format")
+	}
+}
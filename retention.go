@@ -0,0 +1,177 @@
+package evccdb
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PolicyTable holds the retention policy of the database
+const PolicyTable = "evccdb_policy"
+
+// Retention is a period like 90d, 8w, 36m or 2y, the zero value keeps rows forever
+type Retention struct {
+	Months int
+	Days   int
+}
+
+// retentionUnits maps the period units to months and days
+var retentionUnits = map[byte]Retention{
+	'd': {Days: 1},
+	'w': {Days: 7},
+	'm': {Months: 1},
+	'y': {Months: 12},
+}
+
+// ParseRetention parses a period of days (d), weeks (w), months (m) or years (y),
+// an empty string or 0 keeps rows forever
+func ParseRetention(s string) (Retention, error) {
+	s = strings.TrimSpace(s)
+	if s == "" || s == "0" {
+		return Retention{}, nil
+	}
+
+	unit, ok := retentionUnits[s[len(s)-1]]
+	n, err := strconv.Atoi(s[:len(s)-1])
+	if !ok || err != nil || n < 0 {
+		return Retention{}, fmt.Errorf("invalid retention %q, expected e.g. 90d, 8w, 36m or 2y", s)
+	}
+	return Retention{Months: n * unit.Months, Days: n * unit.Days}, nil
+}
+
+// String formats the period as parsed by ParseRetention
+func (r Retention) String() string {
+	switch {
+	case r.Months == 0 && r.Days == 0:
+		return "0"
+	case r.Days == 0 && r.Months%12 == 0:
+		return fmt.Sprintf("%dy", r.Months/12)
+	case r.Days == 0:
+		return fmt.Sprintf("%dm", r.Months)
+	case r.Months == 0 && r.Days%7 == 0:
+		return fmt.Sprintf("%dw", r.Days/7)
+	case r.Months == 0:
+		return fmt.Sprintf("%dd", r.Days)
+	default:
+		return fmt.Sprintf("%dm%dd", r.Months, r.Days)
+	}
+}
+
+// Cutoff returns the time before which rows are expired, the zero time if rows are kept forever
+func (r Retention) Cutoff(now time.Time) time.Time {
+	if r == (Retention{}) {
+		return time.Time{}
+	}
+	return now.AddDate(0, -r.Months, -r.Days)
+}
+
+// RetentionPolicy is the retention per table
+type RetentionPolicy struct {
+	Sessions     Retention
+	Meters       Retention
+	GridSessions Retention
+}
+
+// retentionColumns are the tables a retention policy applies to with their timestamp column
+var retentionColumns = []struct{ table, column string }{
+	{"sessions", "created"},
+	{"meters", "ts"},
+	{"grid_sessions", "created"},
+}
+
+// periods returns the retention of each table in retentionColumns order
+func (p *RetentionPolicy) periods() []*Retention {
+	return []*Retention{&p.Sessions, &p.Meters, &p.GridSessions}
+}
+
+// RetentionPolicy reads the stored retention policy, the zero policy if none is stored
+func (c *Client) RetentionPolicy(ctx context.Context) (RetentionPolicy, error) {
+	var p RetentionPolicy
+	exists, err := c.TableExists(PolicyTable)
+	if err != nil || !exists {
+		return p, err
+	}
+
+	var values [3]string
+	err = c.db.QueryRowContext(ctx, fmt.Sprintf("SELECT sessions, meters, grid_sessions FROM `%s` WHERE id = 1", PolicyTable)).
+		Scan(&values[0], &values[1], &values[2])
+	if errors.Is(err, sql.ErrNoRows) {
+		return p, nil
+	}
+	if err != nil {
+		return p, fmt.Errorf("failed to read retention policy: %w", err)
+	}
+
+	for i, r := range p.periods() {
+		if *r, err = ParseRetention(values[i]); err != nil {
+			return p, fmt.Errorf("invalid %s retention: %w", retentionColumns[i].table, err)
+		}
+	}
+	return p, nil
+}
+
+// SetRetentionPolicy stores the retention policy in the database
+func (c *Client) SetRetentionPolicy(ctx context.Context, p RetentionPolicy) error {
+	_, err := c.db.ExecContext(ctx, fmt.Sprintf(
+		"CREATE TABLE IF NOT EXISTS `%s` (id INTEGER PRIMARY KEY CHECK (id = 1), sessions TEXT, meters TEXT, grid_sessions TEXT, updated DATETIME)", PolicyTable))
+	if err != nil {
+		return fmt.Errorf("failed to create policy table: %w", err)
+	}
+	c.InvalidateSchema()
+
+	_, err = c.db.ExecContext(ctx, fmt.Sprintf(
+		"INSERT OR REPLACE INTO `%s` (id, sessions, meters, grid_sessions, updated) VALUES (1, ?, ?, ?, CURRENT_TIMESTAMP)", PolicyTable),
+		p.Sessions.String(), p.Meters.String(), p.GridSessions.String())
+	if err != nil {
+		return fmt.Errorf("failed to write retention policy: %w", err)
+	}
+	return nil
+}
+
+// ApplyRetention deletes the rows older than the policy allows in one transaction and
+// returns the deleted rows per table. A dry run only counts them.
+func (c *Client) ApplyRetention(ctx context.Context, p RetentionPolicy, now time.Time, dryRun bool) (map[string]int, error) {
+	var queries []struct{ table, where, cutoff string }
+	for i, r := range p.periods() {
+		cutoff := r.Cutoff(now)
+		if cutoff.IsZero() {
+			continue
+		}
+
+		table, column := retentionColumns[i].table, retentionColumns[i].column
+		exists, err := c.TableExists(table)
+		if err != nil {
+			return nil, err
+		}
+		if exists {
+			where := fmt.Sprintf("FROM `%s` WHERE julianday(`%s`) < julianday(?)", table, column)
+			queries = append(queries, struct{ table, where, cutoff string }{table, where, FormatTimestamp(cutoff)})
+		}
+	}
+
+	deleted := make(map[string]int)
+	err := c.inTx(ctx, func(tx *Tx) error {
+		for _, q := range queries {
+			var n int64
+			var err error
+			if dryRun {
+				err = tx.tx.QueryRowContext(ctx, "SELECT COUNT(*) "+q.where, q.cutoff).Scan(&n)
+			} else {
+				var result sql.Result
+				if result, err = tx.tx.ExecContext(ctx, "DELETE "+q.where, q.cutoff); err == nil {
+					n, err = result.RowsAffected()
+				}
+			}
+			if err != nil {
+				return fmt.Errorf("failed to expire %s: %w", q.table, err)
+			}
+			deleted[q.table] = int(n)
+		}
+		return nil
+	})
+	return deleted, err
+}
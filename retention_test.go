@@ -0,0 +1,83 @@
+package evccdb
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestParseRetention(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Retention
+	}{
+		{"", Retention{}},
+		{"0", Retention{}},
+		{"90d", Retention{Days: 90}},
+		{"8w", Retention{Days: 56}},
+		{"36m", Retention{Months: 36}},
+		{"2y", Retention{Months: 24}},
+	}
+	for _, tt := range tests {
+		got, err := ParseRetention(tt.in)
+		if err != nil || got != tt.want {
+			t.Errorf("ParseRetention(%q) = %v, %v, want %v", tt.in, got, err, tt.want)
+		}
+	}
+
+	for _, in := range []string{"36", "m", "-1y", "3h"} {
+		if _, err := ParseRetention(in); err == nil {
+			t.Errorf("Expected error for %q", in)
+		}
+	}
+
+	if s := (Retention{Months: 24}).String(); s != "2y" {
+		t.Errorf("Expected 2y, got %s", s)
+	}
+}
+
+func TestRetentionPolicy(t *testing.T) {
+	client, cleanup := createTestDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	p, err := client.RetentionPolicy(ctx)
+	if err != nil || p != (RetentionPolicy{}) {
+		t.Fatalf("Expected empty policy, got %v, %v", p, err)
+	}
+
+	want := RetentionPolicy{Sessions: Retention{Days: 2}, Meters: Retention{Months: 12}}
+	if err := client.SetRetentionPolicy(ctx, want); err != nil {
+		t.Fatalf("SetRetentionPolicy failed: %v", err)
+	}
+	if p, err = client.RetentionPolicy(ctx); err != nil || p != want {
+		t.Fatalf("Expected %v, got %v, %v", want, p, err)
+	}
+
+	// Sessions were created from 2023-04-01 to 2023-04-05
+	now := time.Date(2023, 4, 5, 12, 0, 0, 0, time.UTC)
+
+	deleted, err := client.ApplyRetention(ctx, p, now, true)
+	if err != nil {
+		t.Fatalf("ApplyRetention dry run failed: %v", err)
+	}
+	if deleted["sessions"] != 3 {
+		t.Errorf("Expected 3 expired sessions, got %v", deleted)
+	}
+	if _, ok := deleted["grid_sessions"]; ok {
+		t.Error("Expected grid sessions without retention to be kept")
+	}
+
+	if _, err := client.ApplyRetention(ctx, p, now, false); err != nil {
+		t.Fatalf("ApplyRetention failed: %v", err)
+	}
+
+	var remaining int
+	if err := client.db.QueryRow("SELECT COUNT(*) FROM sessions").Scan(&remaining); err != nil {
+		t.Fatal(err)
+	}
+	if remaining != 2 {
+		t.Errorf("Expected 2 remaining sessions, got %d", remaining)
+	}
+}
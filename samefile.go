@@ -0,0 +1,73 @@
+package evccdb
+
+import (
+	"errors"
+	"fmt"
+	"os"
+)
+
+// ErrSameDatabase is returned when the source and the target of an operation are the same database file
+var ErrSameDatabase = errors.New("source and target are the same database file")
+
+// SameFile reports whether the paths name the same file, following symlinks and comparing
+// the device and inode so that hard links match too. Paths that do not exist are not the same.
+func SameFile(a, b string) (bool, error) {
+	infoA, err := os.Stat(a)
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to stat %s: %w", a, err)
+	}
+	infoB, err := os.Stat(b)
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to stat %s: %w", b, err)
+	}
+	return os.SameFile(infoA, infoB), nil
+}
+
+// File returns the file SQLite opened for the main database, empty for in-memory databases
+func (c *Client) File() (string, error) {
+	var file string
+	if err := c.db.QueryRow("SELECT file FROM pragma_database_list WHERE name = 'main'").Scan(&file); err != nil {
+		return "", fmt.Errorf("failed to read database file: %w", err)
+	}
+	return file, nil
+}
+
+// SameDatabase reports whether both clients open the same database, also if the paths differ
+// by symlinks, hard links or URI parameters
+func (c *Client) SameDatabase(other *Client) (bool, error) {
+	if c == other {
+		return true, nil
+	}
+	fileA, err := c.File()
+	if err != nil {
+		return false, err
+	}
+	fileB, err := other.File()
+	if err != nil {
+		return false, err
+	}
+	if fileA == "" || fileB == "" {
+		// Named shared memory databases are the same if their names are
+		return sharedMemory(c.path) && c.path == other.path, nil
+	}
+	return SameFile(fileA, fileB)
+}
+
+// CheckDistinct returns ErrSameDatabase if src and dst are the same database, which Transfer,
+// Consolidate and CopyConfig refuse
+func CheckDistinct(src, dst *Client) error {
+	same, err := src.SameDatabase(dst)
+	if err != nil {
+		return err
+	}
+	if same {
+		return fmt.Errorf("%w: %s", ErrSameDatabase, dst.path)
+	}
+	return nil
+}
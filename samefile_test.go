@@ -0,0 +1,61 @@
+package evccdb
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSameDatabase(t *testing.T) {
+	client, cleanup := createTestDB(t)
+	defer cleanup()
+
+	dir := t.TempDir()
+	symlink := filepath.Join(dir, "symlink.db")
+	if err := os.Symlink(client.Path(), symlink); err != nil {
+		t.Fatal(err)
+	}
+	hardlink := filepath.Join(dir, "hardlink.db")
+	if err := os.Link(client.Path(), hardlink); err != nil {
+		t.Skipf("Hard links not supported: %v", err)
+	}
+
+	for _, path := range []string{symlink, hardlink, "file:" + hardlink + "?mode=ro"} {
+		other, err := Open(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		same, err := client.SameDatabase(other)
+		if err != nil || !same {
+			t.Errorf("Expected %s to be the same database, got %v, %v", path, same, err)
+		}
+		if err := Transfer(context.Background(), client, other, TransferOptions{Mode: TransferConfig}); !errors.Is(err, ErrSameDatabase) {
+			t.Errorf("Expected transfer to %s to fail with ErrSameDatabase, got %v", path, err)
+		}
+		_ = other.Close()
+	}
+
+	other, err := Open(filepath.Join(dir, "other.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = other.Close() }()
+	if same, err := client.SameDatabase(other); err != nil || same {
+		t.Errorf("Expected another database, got %v, %v", same, err)
+	}
+
+	memory, err := Open(":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = memory.Close() }()
+	if same, err := memory.SameDatabase(other); err != nil || same {
+		t.Errorf("Expected an in-memory database to differ, got %v, %v", same, err)
+	}
+
+	if same, err := SameFile(filepath.Join(dir, "missing.db"), client.Path()); err != nil || same {
+		t.Errorf("Expected a missing file to differ, got %v, %v", same, err)
+	}
+}
@@ -0,0 +1,31 @@
+-- Tables of an evcc database
+CREATE TABLE settings (key TEXT PRIMARY KEY, value TEXT);
+CREATE TABLE configs (id INTEGER PRIMARY KEY, class INTEGER, type TEXT, value TEXT, title TEXT, icon TEXT, product TEXT);
+CREATE TABLE caches (key TEXT PRIMARY KEY, value TEXT);
+CREATE TABLE meters (meter INTEGER, ts DATETIME, val REAL);
+CREATE UNIQUE INDEX meter_ts ON meters(meter, ts);
+CREATE TABLE sessions (
+	id INTEGER PRIMARY KEY,
+	created DATETIME,
+	finished DATETIME,
+	loadpoint TEXT,
+	identifier TEXT,
+	vehicle TEXT,
+	odometer REAL,
+	meter_start_kwh REAL,
+	meter_end_kwh REAL,
+	charged_kwh REAL,
+	solar_percentage REAL,
+	price REAL,
+	price_per_kwh REAL,
+	co2_per_kwh REAL,
+	charge_duration INTEGER
+);
+CREATE TABLE grid_sessions (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	created DATETIME,
+	finished DATETIME,
+	type TEXT,
+	grid_power REAL,
+	limit_power REAL
+);
@@ -0,0 +1,75 @@
+package evccdb
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// SchemaObject is a view or trigger whose DDL is kept in the export metadata
+type SchemaObject struct {
+	Type  string `json:"type"`
+	Name  string `json:"name"`
+	Table string `json:"table"`
+	SQL   string `json:"sql"`
+}
+
+// schemaObjects returns the views and the triggers of the given tables, views first
+// because INSTEAD OF triggers depend on them
+func (c *Client) schemaObjects(ctx context.Context, tables []string) ([]SchemaObject, error) {
+	rows, err := c.db.QueryContext(ctx, `SELECT type, name, tbl_name, sql FROM sqlite_master
+		WHERE type IN ('view', 'trigger') AND sql IS NOT NULL AND name NOT LIKE 'sqlite_%'
+		ORDER BY type = 'trigger', rowid`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query views and triggers: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var objects []SchemaObject
+	for rows.Next() {
+		var o SchemaObject
+		if err := rows.Scan(&o.Type, &o.Name, &o.Table, &o.SQL); err != nil {
+			return nil, fmt.Errorf("failed to scan schema: %w", err)
+		}
+		// Triggers of tables that are not exported would fail to restore
+		if o.Type == "trigger" && !slices.Contains(tables, o.Table) && !slices.ContainsFunc(objects, func(v SchemaObject) bool { return v.Name == o.Table }) {
+			continue
+		}
+		objects = append(objects, o)
+	}
+	return objects, rows.Err()
+}
+
+// restoreSchemaObjects creates the views and triggers of an export that the target does not
+// have. It runs after the rows are written, so the triggers do not fire for imported rows.
+func restoreSchemaObjects(ctx context.Context, tx *sql.Tx, objects []SchemaObject) error {
+	for _, o := range objects {
+		fields := strings.Fields(strings.ToUpper(o.SQL))
+		if (o.Type != "view" && o.Type != "trigger") || len(fields) < 2 || fields[0] != "CREATE" || fields[1] != strings.ToUpper(o.Type) {
+			return fmt.Errorf("invalid %s %s in export", o.Type, o.Name)
+		}
+
+		var count int
+		if err := tx.QueryRowContext(ctx, "SELECT COUNT(*) FROM sqlite_master WHERE name = ?", o.Name).Scan(&count); err != nil {
+			return fmt.Errorf("failed to query schema: %w", err)
+		}
+		if count > 0 {
+			fmt.Printf(Translate("Keeping existing %s %s\n"), o.Type, o.Name)
+			continue
+		}
+
+		// A prepared statement runs only the first statement of the SQL
+		stmt, err := tx.PrepareContext(ctx, o.SQL)
+		if err != nil {
+			return fmt.Errorf("failed to create %s %s: %w", o.Type, o.Name, err)
+		}
+		_, err = stmt.ExecContext(ctx)
+		_ = stmt.Close()
+		if err != nil {
+			return fmt.Errorf("failed to create %s %s: %w", o.Type, o.Name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package evccdb
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestSchemaObjects(t *testing.T) {
+	src, srcCleanup := createTestDB(t)
+	defer srcCleanup()
+	dst, dstCleanup := createTestDB(t)
+	defer dstCleanup()
+
+	for _, stmt := range []string{
+		"CREATE VIEW lp_settings AS SELECT key, value FROM settings WHERE key LIKE 'lp%'",
+		"CREATE TABLE settings_log (key TEXT)",
+		"CREATE TRIGGER log_settings AFTER INSERT ON settings BEGIN INSERT INTO settings_log VALUES (NEW.key); END",
+	} {
+		if _, err := src.db.Exec(stmt); err != nil {
+			t.Fatal(err)
+		}
+	}
+	src.InvalidateSchema()
+
+	var buf bytes.Buffer
+	opts := TransferOptions{Mode: TransferConfig, SchemaObjects: true}
+	if err := src.ExportJSON(&buf, opts); err != nil {
+		t.Fatalf("ExportJSON failed: %v", err)
+	}
+	var export ExportFormat
+	if err := json.Unmarshal(buf.Bytes(), &export); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(export.Metadata.Objects); n != 2 || export.Metadata.Objects[0].Name != "lp_settings" {
+		t.Fatalf("Expected the view followed by the trigger, got %+v", export.Metadata.Objects)
+	}
+
+	// The trigger is created after the rows are written, so it does not fire for them
+	_, _ = dst.db.Exec("CREATE TABLE settings_log (key TEXT)")
+	if err := dst.ImportJSON(bytes.NewReader(buf.Bytes()), opts); err != nil {
+		t.Fatalf("ImportJSON failed: %v", err)
+	}
+	var settings, logged int
+	if err := dst.db.QueryRow("SELECT COUNT(*) FROM lp_settings").Scan(&settings); err != nil || settings == 0 {
+		t.Errorf("Expected the view to be restored, got %d rows: %v", settings, err)
+	}
+	_ = dst.db.QueryRow("SELECT COUNT(*) FROM settings_log").Scan(&logged)
+	if logged != 0 {
+		t.Errorf("Expected the trigger not to fire on import, got %d", logged)
+	}
+	_, _ = dst.db.Exec("INSERT INTO settings (key, value) VALUES ('new', 'x')")
+	_ = dst.db.QueryRow("SELECT COUNT(*) FROM settings_log").Scan(&logged)
+	if logged != 1 {
+		t.Errorf("Expected the trigger to be restored, got %d logged rows", logged)
+	}
+
+	// Existing objects are kept and other statements are rejected
+	if err := dst.ImportJSON(bytes.NewReader(buf.Bytes()), opts); err != nil {
+		t.Fatalf("Repeated ImportJSON failed: %v", err)
+	}
+	export.Metadata.Objects = []SchemaObject{{Type: "view", Name: "evil", SQL: "DROP TABLE sessions"}}
+	data, _ := json.Marshal(export)
+	if err := dst.ImportJSON(bytes.NewReader(data), opts); err == nil {
+		t.Error("Expected an error for a statement other than CREATE VIEW")
+	}
+}
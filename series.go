@@ -0,0 +1,81 @@
+package evccdb
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// SeriesPoint is a labelled value of a chart series
+type SeriesPoint struct {
+	Label string
+	Value float64
+}
+
+// SeriesFilter restricts the sessions a series is computed from
+type SeriesFilter struct {
+	Vehicle   string
+	Loadpoint string
+}
+
+// seriesMetrics maps metric names to their aggregate over sessions
+var seriesMetrics = map[string]struct {
+	period    string
+	aggregate string
+}{
+	"kwh-per-month":      {"%Y-%m", "SUM(charged_kwh)"},
+	"kwh-per-day":        {"%Y-%m-%d", "SUM(charged_kwh)"},
+	"sessions-per-month": {"%Y-%m", "COUNT(*)"},
+	"cost-per-month":     {"%Y-%m", "SUM(price)"},
+	"solar-per-month":    {"%Y-%m", "SUM(charged_kwh * solar_percentage) / SUM(charged_kwh)"},
+}
+
+// SeriesMetrics returns the names of the available series metrics
+func SeriesMetrics() []string {
+	names := make([]string, 0, len(seriesMetrics))
+	for name := range seriesMetrics {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Series computes a metric over sessions grouped by period
+func (c *Client) Series(ctx context.Context, metric string, filter SeriesFilter) ([]SeriesPoint, error) {
+	m, ok := seriesMetrics[metric]
+	if !ok {
+		return nil, fmt.Errorf("unknown metric %q, available: %s", metric, strings.Join(SeriesMetrics(), ", "))
+	}
+
+	where := []string{"created IS NOT NULL"}
+	var args []any
+	if filter.Vehicle != "" {
+		where = append(where, "vehicle = ?")
+		args = append(args, filter.Vehicle)
+	}
+	if filter.Loadpoint != "" {
+		where = append(where, "loadpoint = ?")
+		args = append(args, filter.Loadpoint)
+	}
+
+	query := fmt.Sprintf("SELECT strftime('%s', created) AS period, COALESCE(%s, 0) FROM sessions WHERE %s GROUP BY period ORDER BY period",
+		m.period, m.aggregate, strings.Join(where, " AND "))
+
+	rows, err := c.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute %s: %w", metric, err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var points []SeriesPoint
+	for rows.Next() {
+		var p SeriesPoint
+		if err := rows.Scan(&p.Label, &p.Value); err != nil {
+			return nil, fmt.Errorf("failed to scan %s: %w", metric, err)
+		}
+		points = append(points, p)
+	}
+
+	return points, rows.Err()
+}
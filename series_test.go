@@ -0,0 +1,35 @@
+package evccdb
+
+import (
+	"context"
+	"testing"
+)
+
+func TestSeries(t *testing.T) {
+	client, cleanup := createTestDB(t)
+	defer cleanup()
+
+	_, _ = client.db.Exec("UPDATE sessions SET charged_kwh = id")
+	_, _ = client.db.Exec("UPDATE sessions SET created = '2023-05-01 10:00:00' WHERE id = 5")
+
+	ctx := context.Background()
+	points, err := client.Series(ctx, "kwh-per-month", SeriesFilter{})
+	if err != nil {
+		t.Fatalf("Series failed: %v", err)
+	}
+	if len(points) != 2 || points[0] != (SeriesPoint{"2023-04", 10}) || points[1] != (SeriesPoint{"2023-05", 5}) {
+		t.Errorf("Unexpected series: %v", points)
+	}
+
+	points, err = client.Series(ctx, "sessions-per-month", SeriesFilter{Vehicle: "e-Golf"})
+	if err != nil {
+		t.Fatalf("Series failed: %v", err)
+	}
+	if len(points) != 1 || points[0].Value != 2 {
+		t.Errorf("Unexpected filtered series: %v", points)
+	}
+
+	if _, err := client.Series(ctx, "unknown", SeriesFilter{}); err == nil {
+		t.Error("Expected error for unknown metric")
+	}
+}
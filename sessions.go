@@ -0,0 +1,94 @@
+package evccdb
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// sessionColumns are the columns scanned into a Session, in field order
+var sessionColumns = []string{
+	"id", "created", "finished", "loadpoint", "identifier", "vehicle", "odometer", "meter_start_kwh",
+	"meter_end_kwh", "charged_kwh", "solar_percentage", "price", "price_per_kwh", "co2_per_kwh", "charge_duration",
+}
+
+// Sessions returns the sessions matching the filter ordered by start time, with timestamps
+// formatted by FormatTimestamp. Columns that older evcc versions lack are left nil.
+func (c *Client) Sessions(ctx context.Context, filter SeriesFilter) ([]Session, error) {
+	cols, err := tableColumns(ctx, c.db, "sessions")
+	if err != nil {
+		return nil, err
+	}
+	if len(cols) == 0 {
+		return nil, nil
+	}
+
+	existing := make(map[string]bool, len(cols))
+	for _, col := range cols {
+		existing[col.Name] = true
+	}
+	selects := make([]string, len(sessionColumns))
+	for i, name := range sessionColumns {
+		selects[i] = "NULL"
+		if existing[name] {
+			selects[i] = name
+		}
+	}
+
+	where := []string{"created IS NOT NULL"}
+	var args []any
+	if filter.Vehicle != "" {
+		where = append(where, "vehicle = ?")
+		args = append(args, filter.Vehicle)
+	}
+	if filter.Loadpoint != "" {
+		where = append(where, "loadpoint = ?")
+		args = append(args, filter.Loadpoint)
+	}
+
+	rows, err := c.db.QueryContext(ctx, fmt.Sprintf("SELECT %s FROM sessions WHERE %s",
+		strings.Join(selects, ", "), strings.Join(where, " AND ")), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query sessions: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var sessions []Session
+	for rows.Next() {
+		var s Session
+		var id *int
+		var created, finished any
+		var loadpoint *string
+		if err := rows.Scan(&id, &created, &finished, &loadpoint, &s.Identifier, &s.Vehicle, &s.OdometerStart, &s.MeterStartKwh,
+			&s.MeterEndKwh, &s.ChargedKwh, &s.SolarPercentage, &s.Price, &s.PricePerKwh, &s.Co2PerKwh, &s.ChargeDuration); err != nil {
+			return nil, fmt.Errorf("failed to scan session: %w", err)
+		}
+		start, ok := timestampOf(created)
+		if !ok {
+			continue
+		}
+		if id != nil {
+			s.ID = *id
+		}
+		if loadpoint != nil {
+			s.Loadpoint = *loadpoint
+		}
+		s.Created = FormatTimestamp(start)
+		if end, ok := timestampOf(finished); ok {
+			f := FormatTimestamp(end)
+			s.Finished = &f
+		}
+		sessions = append(sessions, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(sessions, func(i, j int) bool {
+		a, _ := ParseTimestamp(sessions[i].Created, nil)
+		b, _ := ParseTimestamp(sessions[j].Created, nil)
+		return a.Before(b)
+	})
+	return sessions, nil
+}
@@ -0,0 +1,116 @@
+package evccdb
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// querier is implemented by both *sql.DB and *sql.Tx
+type querier interface {
+	QueryContext(context.Context, string, ...any) (*sql.Rows, error)
+	QueryRowContext(context.Context, string, ...any) *sql.Row
+}
+
+// SettingsKey is a parsed settings key of the form namespace.name.suffix
+type SettingsKey struct {
+	Namespace string
+	Name      string
+	Suffix    string
+}
+
+// ParseSettingsKey splits a settings key into namespace, name and suffix.
+// Vehicle keys (vehicle.<name>.<suffix>) keep dots inside the name, so
+// "vehicle.ID.4.minSoc" parses to name "ID.4" and suffix "minSoc".
+// Keys without a name part only carry namespace and suffix.
+func ParseSettingsKey(key string) SettingsKey {
+	namespace, rest, found := strings.Cut(key, ".")
+	if !found {
+		return SettingsKey{Namespace: key}
+	}
+
+	if namespace == "vehicle" {
+		if i := strings.LastIndex(rest, "."); i > 0 {
+			return SettingsKey{Namespace: namespace, Name: rest[:i], Suffix: rest[i+1:]}
+		}
+	}
+
+	return SettingsKey{Namespace: namespace, Suffix: rest}
+}
+
+// String returns the settings key in its stored form
+func (k SettingsKey) String() string {
+	parts := []string{k.Namespace}
+	if k.Name != "" {
+		parts = append(parts, k.Name)
+	}
+	if k.Suffix != "" {
+		parts = append(parts, k.Suffix)
+	}
+	return strings.Join(parts, ".")
+}
+
+// vehicleSettingsKeys returns the settings keys belonging exactly to the named vehicle
+func vehicleSettingsKeys(ctx context.Context, q querier, name string) ([]SettingsKey, error) {
+	rows, err := q.QueryContext(ctx, "SELECT key FROM settings WHERE key LIKE 'vehicle.%'")
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	var keys []SettingsKey
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			return nil, err
+		}
+		if k := ParseSettingsKey(key); k.Name == name {
+			keys = append(keys, k)
+		}
+	}
+
+	return keys, rows.Err()
+}
+
+// LoadpointIndex returns the loadpoint index of an lp<n>.* key
+func (k SettingsKey) LoadpointIndex() (int, bool) {
+	digits, ok := strings.CutPrefix(k.Namespace, "lp")
+	if !ok {
+		return 0, false
+	}
+	index, err := strconv.Atoi(digits)
+	if err != nil || index < 1 {
+		return 0, false
+	}
+	return index, true
+}
+
+// loadpointTitleKey returns the settings key holding the title of a loadpoint
+func loadpointTitleKey(index int) string {
+	return fmt.Sprintf("lp%d.title", index)
+}
+
+// loadpointIndices returns the indices of all loadpoints with the given title
+func loadpointIndices(ctx context.Context, q querier, title string) ([]int, error) {
+	rows, err := q.QueryContext(ctx, "SELECT key FROM settings WHERE key LIKE 'lp%.title' AND value = ?", title)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	var indices []int
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			return nil, err
+		}
+		k := ParseSettingsKey(key)
+		if index, ok := k.LoadpointIndex(); ok && k.Suffix == "title" {
+			indices = append(indices, index)
+		}
+	}
+
+	return indices, rows.Err()
+}
@@ -0,0 +1,29 @@
+package evccdb
+
+import (
+	"testing"
+)
+
+func TestParseSettingsKey(t *testing.T) {
+	tests := []struct {
+		key      string
+		expected SettingsKey
+	}{
+		{"vehicle.e-Golf.minSoc", SettingsKey{Namespace: "vehicle", Name: "e-Golf", Suffix: "minSoc"}},
+		{"vehicle.ID.4.minSoc", SettingsKey{Namespace: "vehicle", Name: "ID.4", Suffix: "minSoc"}},
+		{"lp1.title", SettingsKey{Namespace: "lp1", Suffix: "title"}},
+		{"telemetry", SettingsKey{Namespace: "telemetry"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			result := ParseSettingsKey(tt.key)
+			if result != tt.expected {
+				t.Errorf("Expected %+v, got %+v", tt.expected, result)
+			}
+			if result.String() != tt.key {
+				t.Errorf("Expected String() %q, got %q", tt.key, result.String())
+			}
+		})
+	}
+}
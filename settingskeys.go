@@ -0,0 +1,134 @@
+package evccdb
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sort"
+	"sync"
+)
+
+// SettingsKeyPattern is a known settings key pattern of evcc. Pattern uses
+// path.Match syntax, where * also matches dots.
+type SettingsKeyPattern struct {
+	Namespace   string
+	Pattern     string
+	Description string
+}
+
+var (
+	settingsKeyPatternsMu sync.RWMutex
+	settingsKeyPatterns   []SettingsKeyPattern
+)
+
+func init() {
+	patterns := []SettingsKeyPattern{
+		{Namespace: "lpN", Pattern: "lp[0-9]*.*", Description: "Loadpoint settings by index"},
+		{Namespace: "vehicle", Pattern: "vehicle.*", Description: "Vehicle settings by name"},
+		{Namespace: "savings", Pattern: "savings.*", Description: "Savings statistics"},
+		{Namespace: "plan", Pattern: "plan.*", Description: "Charge plans"},
+		{Namespace: "tariff", Pattern: "tariffs", Description: "Tariff configuration"},
+		{Namespace: "tariff", Pattern: "tariff.*", Description: "Tariff configuration"},
+		{Namespace: "telemetry", Pattern: "telemetry", Description: "Telemetry opt-in"},
+		{Namespace: "telemetry", Pattern: "telemetry.*", Description: "Telemetry opt-in"},
+	}
+	for _, key := range []string{"title", "gridMeter", "pvMeters", "batteryMeters", "auxMeters", "extMeters",
+		"bufferSoc", "bufferStartSoc", "prioritySoc", "residualPower", "batteryDischargeControl",
+		"batteryGridChargeLimit", "smartCostLimit"} {
+		patterns = append(patterns, SettingsKeyPattern{Namespace: "site", Pattern: key, Description: "Site settings"})
+	}
+	for _, key := range []string{"network", "mqtt", "influx", "hems", "eebus", "modbusproxy", "messaging",
+		"sponsorToken", "interval", "circuits"} {
+		patterns = append(patterns, SettingsKeyPattern{Namespace: "system", Pattern: key, Description: "Service configuration"})
+	}
+
+	for _, p := range patterns {
+		RegisterSettingsKeyPattern(p)
+	}
+}
+
+// RegisterSettingsKeyPattern adds a known settings key pattern
+func RegisterSettingsKeyPattern(p SettingsKeyPattern) {
+	settingsKeyPatternsMu.Lock()
+	defer settingsKeyPatternsMu.Unlock()
+	settingsKeyPatterns = append(settingsKeyPatterns, p)
+}
+
+// SettingsKeyPatterns returns the known settings key patterns in registration order
+func SettingsKeyPatterns() []SettingsKeyPattern {
+	settingsKeyPatternsMu.RLock()
+	defer settingsKeyPatternsMu.RUnlock()
+	return append([]SettingsKeyPattern(nil), settingsKeyPatterns...)
+}
+
+// MatchSettingsKey returns the first known pattern matching the key
+func MatchSettingsKey(key string) (SettingsKeyPattern, bool) {
+	for _, p := range SettingsKeyPatterns() {
+		if ok, _ := path.Match(p.Pattern, key); ok {
+			return p, true
+		}
+	}
+	return SettingsKeyPattern{}, false
+}
+
+// ValidateSettingsKey returns an error for keys matching no known pattern
+func ValidateSettingsKey(key string) error {
+	if _, ok := MatchSettingsKey(key); !ok {
+		return fmt.Errorf("unknown settings key %q", key)
+	}
+	return nil
+}
+
+// SettingsGroup is the settings keys of one namespace
+type SettingsGroup struct {
+	Namespace   string
+	Description string
+	Keys        []string
+}
+
+// SettingsDoc groups the settings keys by namespace
+type SettingsDoc struct {
+	Groups  []SettingsGroup
+	Unknown []string
+}
+
+// SettingsDoc groups the stored settings keys by their known namespace and lists unknown keys
+func (c *Client) SettingsDoc(ctx context.Context) (*SettingsDoc, error) {
+	rows, err := c.db.QueryContext(ctx, "SELECT key FROM settings ORDER BY key")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query settings: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	doc := &SettingsDoc{}
+	groups := make(map[string]*SettingsGroup)
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			return nil, fmt.Errorf("failed to scan setting: %w", err)
+		}
+
+		p, ok := MatchSettingsKey(key)
+		if !ok {
+			doc.Unknown = append(doc.Unknown, key)
+			continue
+		}
+
+		g, ok := groups[p.Namespace]
+		if !ok {
+			g = &SettingsGroup{Namespace: p.Namespace, Description: p.Description}
+			groups[p.Namespace] = g
+		}
+		g.Keys = append(g.Keys, key)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, g := range groups {
+		doc.Groups = append(doc.Groups, *g)
+	}
+	sort.Slice(doc.Groups, func(i, j int) bool { return doc.Groups[i].Namespace < doc.Groups[j].Namespace })
+
+	return doc, nil
+}
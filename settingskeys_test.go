@@ -0,0 +1,44 @@
+package evccdb
+
+import (
+	"context"
+	"testing"
+)
+
+func TestValidateSettingsKey(t *testing.T) {
+	for _, key := range []string{"lp1.title", "lp12.mode", "vehicle.ID.4.minSoc", "savings.started", "gridMeter", "telemetry"} {
+		if err := ValidateSettingsKey(key); err != nil {
+			t.Errorf("ValidateSettingsKey(%q) failed: %v", key, err)
+		}
+	}
+	for _, key := range []string{"lp.title", "foo", "gridmeter"} {
+		if err := ValidateSettingsKey(key); err == nil {
+			t.Errorf("ValidateSettingsKey(%q) accepted unknown key", key)
+		}
+	}
+}
+
+func TestSettingsDoc(t *testing.T) {
+	client, cleanup := createTestDB(t)
+	defer cleanup()
+
+	if _, err := client.db.Exec("INSERT INTO settings (key, value) VALUES ('legacy.flag', '1'), ('pvMeters', 'db:2')"); err != nil {
+		t.Fatalf("Failed to insert settings: %v", err)
+	}
+
+	doc, err := client.SettingsDoc(context.Background())
+	if err != nil {
+		t.Fatalf("SettingsDoc failed: %v", err)
+	}
+
+	counts := make(map[string]int)
+	for _, g := range doc.Groups {
+		counts[g.Namespace] = len(g.Keys)
+	}
+	if counts["lpN"] != 3 || counts["vehicle"] != 3 || counts["site"] != 1 || len(counts) != 3 {
+		t.Errorf("Unexpected groups: %v", counts)
+	}
+	if len(doc.Unknown) != 1 || doc.Unknown[0] != "legacy.flag" {
+		t.Errorf("Unexpected unknown keys: %v", doc.Unknown)
+	}
+}
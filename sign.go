@@ -0,0 +1,99 @@
+package evccdb
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/sha512"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// SignatureSuffix is appended to an export file name to name its detached signature
+const SignatureSuffix = ".sig"
+
+// LoadSigningKey reads a PEM encoded PKCS#8 ed25519 private key, as written by
+// openssl genpkey -algorithm ed25519
+func LoadSigningKey(path string) (ed25519.PrivateKey, error) {
+	parsed, err := loadPEM(path, func(der []byte) (any, error) { return x509.ParsePKCS8PrivateKey(der) })
+	if err != nil {
+		return nil, err
+	}
+	key, ok := parsed.(ed25519.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("%s is no ed25519 private key", path)
+	}
+	return key, nil
+}
+
+// LoadVerifyKey reads a PEM encoded ed25519 public key, as written by openssl pkey -pubout
+func LoadVerifyKey(path string) (ed25519.PublicKey, error) {
+	parsed, err := loadPEM(path, x509.ParsePKIXPublicKey)
+	if err != nil {
+		return nil, err
+	}
+	key, ok := parsed.(ed25519.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("%s is no ed25519 public key", path)
+	}
+	return key, nil
+}
+
+// loadPEM reads the first PEM block of a file and parses it
+func loadPEM(path string, parse func([]byte) (any, error)) (any, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key: %w", err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%s contains no PEM data", path)
+	}
+	key, err := parse(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse key %s: %w", path, err)
+	}
+	return key, nil
+}
+
+// SignExport signs the content of r with Ed25519ph and returns the base64 encoded signature
+func SignExport(r io.Reader, key ed25519.PrivateKey) (string, error) {
+	digest, err := exportDigest(r)
+	if err != nil {
+		return "", err
+	}
+	sig, err := key.Sign(nil, digest, &ed25519.Options{Hash: crypto.SHA512})
+	if err != nil {
+		return "", fmt.Errorf("failed to sign export: %w", err)
+	}
+	return base64.StdEncoding.EncodeToString(sig), nil
+}
+
+// VerifyExport checks the base64 encoded signature of the content of r
+func VerifyExport(r io.Reader, signature string, key ed25519.PublicKey) error {
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(signature))
+	if err != nil {
+		return fmt.Errorf("invalid signature encoding: %w", err)
+	}
+	digest, err := exportDigest(r)
+	if err != nil {
+		return err
+	}
+	if err := ed25519.VerifyWithOptions(key, digest, sig, &ed25519.Options{Hash: crypto.SHA512}); err != nil {
+		return fmt.Errorf("signature verification failed: export was modified or signed with another key")
+	}
+	return nil
+}
+
+// exportDigest hashes the content of r for Ed25519ph
+func exportDigest(r io.Reader) ([]byte, error) {
+	h := sha512.New()
+	if _, err := io.Copy(h, r); err != nil {
+		return nil, fmt.Errorf("failed to read export: %w", err)
+	}
+	return h.Sum(nil), nil
+}
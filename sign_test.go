@@ -0,0 +1,54 @@
+package evccdb
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSignAndVerifyExport(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+
+	dir := t.TempDir()
+	privDER, _ := x509.MarshalPKCS8PrivateKey(priv)
+	pubDER, _ := x509.MarshalPKIXPublicKey(pub)
+	privPath := filepath.Join(dir, "key.pem")
+	pubPath := filepath.Join(dir, "key.pub.pem")
+	_ = os.WriteFile(privPath, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privDER}), 0o600)
+	_ = os.WriteFile(pubPath, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubDER}), 0o644)
+
+	signKey, err := LoadSigningKey(privPath)
+	if err != nil {
+		t.Fatalf("LoadSigningKey failed: %v", err)
+	}
+	verifyKey, err := LoadVerifyKey(pubPath)
+	if err != nil {
+		t.Fatalf("LoadVerifyKey failed: %v", err)
+	}
+	if _, err := LoadVerifyKey(privPath); err == nil {
+		t.Error("Expected private key to be rejected as public key")
+	}
+
+	export := `{"version": "1", "tables": {"sessions": [{"charged_kwh": 12.5}]}}`
+	sig, err := SignExport(strings.NewReader(export), signKey)
+	if err != nil {
+		t.Fatalf("SignExport failed: %v", err)
+	}
+
+	if err := VerifyExport(strings.NewReader(export), sig, verifyKey); err != nil {
+		t.Errorf("VerifyExport failed: %v", err)
+	}
+
+	tampered := strings.Replace(export, "12.5", "125", 1)
+	if err := VerifyExport(strings.NewReader(tampered), sig, verifyKey); err == nil {
+		t.Error("Expected tampered export to fail verification")
+	}
+}
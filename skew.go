@@ -0,0 +1,214 @@
+package evccdb
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"time"
+)
+
+// skewMatchWindow is the largest clock difference considered when pairing sessions
+const skewMatchWindow = time.Hour
+
+// skewTolerance is the clock difference below which two hosts count as in sync
+const skewTolerance = 2 * time.Second
+
+// SessionOverlap describes two sessions of the same loadpoint that overlap in time
+type SessionOverlap struct {
+	Loadpoint string
+	SourceID  int64
+	DestID    int64
+	Overlap   time.Duration
+}
+
+// ClockSkewReport describes the clock difference between two databases
+type ClockSkewReport struct {
+	// Matches is the number of sessions recorded by both hosts
+	Matches int
+	// Offset is the median difference destination minus source of matched sessions
+	Offset time.Duration
+	// Overlaps lists unmatched sessions that overlap on the same loadpoint
+	Overlaps []SessionOverlap
+}
+
+// Skewed reports whether the source clock differs from the destination clock
+func (r *ClockSkewReport) Skewed() bool {
+	return r.Matches > 0 && (r.Offset > skewTolerance || r.Offset < -skewTolerance)
+}
+
+// Print writes a human readable summary of the report
+func (r *ClockSkewReport) Print(w io.Writer) {
+	if r.Matches == 0 && len(r.Overlaps) == 0 {
+		_, _ = fmt.Fprintln(w, "Clock skew: no overlapping sessions found")
+		return
+	}
+
+	if r.Skewed() {
+		_, _ = fmt.Fprintf(w, Translate("WARNING: Clock skew of %s detected from %d sessions recorded by both databases\n"), r.Offset, r.Matches)
+		_, _ = fmt.Fprintf(w, "  Use --time-offset %s to align source timestamps\n", r.Offset)
+	} else if r.Matches > 0 {
+		_, _ = fmt.Fprintf(w, "Clock skew: none (%d sessions recorded by both databases)\n", r.Matches)
+	}
+
+	if len(r.Overlaps) > 0 {
+		_, _ = fmt.Fprintf(w, Translate("WARNING: %d source sessions overlap destination sessions on the same loadpoint\n"), len(r.Overlaps))
+		for i, o := range r.Overlaps {
+			if i == 10 {
+				_, _ = fmt.Fprintf(w, "  ... and %d more\n", len(r.Overlaps)-i)
+				break
+			}
+			_, _ = fmt.Fprintf(w, "  %s: source session %d overlaps destination session %d by %s\n", o.Loadpoint, o.SourceID, o.DestID, o.Overlap)
+		}
+	}
+}
+
+// skewSession is the part of a session needed for skew detection
+type skewSession struct {
+	id        int64
+	loadpoint string
+	created   time.Time
+	finished  time.Time
+	charged   float64
+}
+
+// DetectClockSkew compares the sessions of two databases to find clock
+// differences between the hosts that recorded them. Sessions of the same
+// loadpoint with equal charged energy that started within an hour of each
+// other are treated as the same session seen by both hosts.
+func DetectClockSkew(ctx context.Context, src, dst *Client) (*ClockSkewReport, error) {
+	srcSessions, err := src.skewSessions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	dstSessions, err := dst.skewSessions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	byLoadpoint := make(map[string][]skewSession)
+	for _, s := range dstSessions {
+		byLoadpoint[s.loadpoint] = append(byLoadpoint[s.loadpoint], s)
+	}
+
+	report := &ClockSkewReport{}
+	var offsets []time.Duration
+	for _, s := range srcSessions {
+		matched := false
+		for _, d := range byLoadpoint[s.loadpoint] {
+			diff := d.created.Sub(s.created)
+			if s.charged > 0 && diff.Abs() <= skewMatchWindow && sameEnergy(s.charged, d.charged) {
+				offsets = append(offsets, diff)
+				matched = true
+				break
+			}
+		}
+		if matched {
+			continue
+		}
+
+		for _, d := range byLoadpoint[s.loadpoint] {
+			if overlap := sessionOverlap(s, d); overlap > 0 {
+				report.Overlaps = append(report.Overlaps, SessionOverlap{
+					Loadpoint: s.loadpoint,
+					SourceID:  s.id,
+					DestID:    d.id,
+					Overlap:   overlap,
+				})
+			}
+		}
+	}
+
+	report.Matches = len(offsets)
+	if len(offsets) > 0 {
+		sort.Slice(offsets, func(i, j int) bool { return offsets[i] < offsets[j] })
+		report.Offset = offsets[len(offsets)/2].Round(time.Second)
+	}
+
+	return report, nil
+}
+
+// skewSessions reads the finished sessions of a database
+func (c *Client) skewSessions(ctx context.Context) ([]skewSession, error) {
+	exists, err := c.TableExists("sessions")
+	if err != nil || !exists {
+		return nil, err
+	}
+
+	rows, err := c.db.QueryContext(ctx, `
+		SELECT id, loadpoint, created, finished, COALESCE(charged_kwh, 0)
+		FROM sessions
+		WHERE created IS NOT NULL AND finished IS NOT NULL AND loadpoint IS NOT NULL
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query sessions: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var sessions []skewSession
+	for rows.Next() {
+		var s skewSession
+		var created, finished any
+		if err := rows.Scan(&s.id, &s.loadpoint, &created, &finished, &s.charged); err != nil {
+			return nil, fmt.Errorf("failed to scan session: %w", err)
+		}
+
+		var ok bool
+		if s.created, ok = timestampOf(created); !ok {
+			continue
+		}
+		if s.finished, ok = timestampOf(finished); !ok {
+			continue
+		}
+		sessions = append(sessions, s)
+	}
+
+	return sessions, rows.Err()
+}
+
+// timestampOf converts a scanned timestamp value to time
+func timestampOf(val any) (time.Time, bool) {
+	switch v := val.(type) {
+	case time.Time:
+		return v, true
+	case string:
+		t, err := ParseTimestamp(v, nil)
+		return t, err == nil
+	default:
+		return time.Time{}, false
+	}
+}
+
+// sameEnergy reports whether two charged energies are equal within rounding
+func sameEnergy(a, b float64) bool {
+	return math.Abs(a-b) <= math.Max(0.01, 0.01*math.Max(a, b))
+}
+
+// sessionOverlap returns how long two sessions overlap
+func sessionOverlap(a, b skewSession) time.Duration {
+	start, end := a.created, a.finished
+	if b.created.After(start) {
+		start = b.created
+	}
+	if b.finished.Before(end) {
+		end = b.finished
+	}
+	if end.After(start) {
+		return end.Sub(start)
+	}
+	return 0
+}
+
+// shiftTimestamp moves a timestamp value by offset, other values are returned unchanged
+func shiftTimestamp(val any, offset time.Duration) any {
+	switch v := val.(type) {
+	case time.Time:
+		return v.Add(offset)
+	case string:
+		if t, err := ParseTimestamp(v, nil); err == nil {
+			return t.Add(offset).UTC().Format(sqliteTimestampFormat)
+		}
+	}
+	return val
+}
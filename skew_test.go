@@ -0,0 +1,59 @@
+package evccdb
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestDetectClockSkew(t *testing.T) {
+	src, srcCleanup := createEmptyDB(t, sessionsSchema)
+	defer srcCleanup()
+	dst, dstCleanup := createEmptyDB(t, sessionsSchema)
+	defer dstCleanup()
+
+	// Source clock is 90 seconds behind the destination
+	_, _ = src.db.Exec(`INSERT INTO sessions (id, created, finished, loadpoint, charged_kwh) VALUES
+		(1, '2024-03-01 08:00:00', '2024-03-01 09:00:00', 'Garage', 10.0),
+		(2, '2024-03-02 08:00:00', '2024-03-02 09:00:00', 'Garage', 12.5),
+		(3, '2024-03-04 08:00:00', '2024-03-04 09:00:00', 'Garage', 3.0)`)
+	_, _ = dst.db.Exec(`INSERT INTO sessions (id, created, finished, loadpoint, charged_kwh) VALUES
+		(11, '2024-03-01 08:01:30', '2024-03-01 09:01:30', 'Garage', 10.0),
+		(12, '2024-03-02 08:01:30', '2024-03-02 09:01:30', 'Garage', 12.5),
+		(13, '2024-03-04 08:30:00', '2024-03-04 10:00:00', 'Garage', 7.0)`)
+
+	report, err := DetectClockSkew(context.Background(), src, dst)
+	if err != nil {
+		t.Fatalf("DetectClockSkew failed: %v", err)
+	}
+
+	if report.Matches != 2 {
+		t.Errorf("Expected 2 matched sessions, got %d", report.Matches)
+	}
+	if report.Offset != 90*time.Second || !report.Skewed() {
+		t.Errorf("Expected skew of 90s, got %s", report.Offset)
+	}
+	if len(report.Overlaps) != 1 || report.Overlaps[0].SourceID != 3 || report.Overlaps[0].DestID != 13 {
+		t.Errorf("Expected overlap of source 3 and destination 13, got %+v", report.Overlaps)
+	}
+}
+
+func TestTransferTimeOffset(t *testing.T) {
+	src, srcCleanup := createTestDB(t)
+	defer srcCleanup()
+	dst, dstCleanup := createEmptyDB(t, sessionsSchema)
+	defer dstCleanup()
+
+	opts := TransferOptions{Tables: []string{"sessions"}, TimeOffset: 90 * time.Second}
+	if err := Transfer(context.Background(), src, dst, opts); err != nil {
+		t.Fatalf("Transfer failed: %v", err)
+	}
+
+	var created time.Time
+	if err := dst.db.QueryRow("SELECT created FROM sessions WHERE id = 1").Scan(&created); err != nil {
+		t.Fatalf("Failed to read session: %v", err)
+	}
+	if want := time.Date(2023, 4, 1, 10, 1, 30, 0, time.UTC); !created.Equal(want) {
+		t.Errorf("Expected created %v, got %v", want, created)
+	}
+}
@@ -0,0 +1,174 @@
+package evccdb
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Defaults of SolarOptions, the hours around noon in which PV typically covers charging
+const (
+	DefaultSolarWindowStart = 10 * time.Hour
+	DefaultSolarWindowEnd   = 16 * time.Hour
+	DefaultWorstSessions    = 10
+)
+
+// SolarOptions configures SolarReport
+type SolarOptions struct {
+	// WindowStart and WindowEnd are the time of day of the typical PV window, both 0 mean
+	// DefaultSolarWindowStart and DefaultSolarWindowEnd
+	WindowStart time.Duration
+	WindowEnd   time.Duration
+	// Location is the time zone of the window and the months, nil means the local time zone
+	Location *time.Location
+	// Worst is the number of ranked sessions, 0 means DefaultWorstSessions
+	Worst int
+}
+
+// SolarMonth is the solar share of the sessions of a month
+type SolarMonth struct {
+	// Month is formatted as 2006-01
+	Month      string  `json:"month"`
+	Sessions   int     `json:"sessions"`
+	ChargedKwh float64 `json:"charged_kwh"`
+	SolarKwh   float64 `json:"solar_kwh"`
+	// SolarShare is the solar energy in percent of the charged energy
+	SolarShare float64 `json:"solar_share"`
+	// ShiftableKwh is the grid energy charged outside the PV window
+	ShiftableKwh float64 `json:"shiftable_kwh"`
+}
+
+// SolarSession is the solar and grid energy of a session
+type SolarSession struct {
+	ID         int64     `json:"id"`
+	Created    time.Time `json:"created"`
+	Loadpoint  string    `json:"loadpoint"`
+	Vehicle    string    `json:"vehicle"`
+	ChargedKwh float64   `json:"charged_kwh"`
+	SolarKwh   float64   `json:"solar_kwh"`
+	// ShiftableKwh is the grid energy of the part of the session outside the PV window
+	ShiftableKwh float64 `json:"shiftable_kwh"`
+}
+
+// SolarReport is the solar share per month and the sessions that could have used the most
+// solar energy by charging in the PV window
+type SolarReport struct {
+	Months []SolarMonth   `json:"months"`
+	Worst  []SolarSession `json:"worst_sessions"`
+}
+
+// SolarReport computes the solar share of the sessions per month and the grid energy that
+// could have been shifted into the PV window. The grid energy of a session is assumed to
+// be charged evenly over the session.
+func (c *Client) SolarReport(ctx context.Context, opts SolarOptions) (*SolarReport, error) {
+	if opts.WindowStart == 0 && opts.WindowEnd == 0 {
+		opts.WindowStart, opts.WindowEnd = DefaultSolarWindowStart, DefaultSolarWindowEnd
+	}
+	if opts.WindowStart < 0 || opts.WindowEnd > 24*time.Hour || opts.WindowStart >= opts.WindowEnd {
+		return nil, fmt.Errorf("invalid PV window %s to %s", opts.WindowStart, opts.WindowEnd)
+	}
+	if opts.Location == nil {
+		opts.Location = time.Local
+	}
+	if opts.Worst <= 0 {
+		opts.Worst = DefaultWorstSessions
+	}
+
+	rows, err := c.db.QueryContext(ctx, `
+		SELECT id, created, finished, COALESCE(loadpoint, ''), COALESCE(vehicle, ''),
+			charged_kwh, COALESCE(solar_percentage, 0)
+		FROM sessions
+		WHERE charged_kwh > 0 AND created IS NOT NULL`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query sessions: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	report := &SolarReport{}
+	var sessions []SolarSession
+	months := make(map[string]*SolarMonth)
+	for rows.Next() {
+		var s SolarSession
+		var created, finished any
+		var solarPercentage float64
+		if err := rows.Scan(&s.ID, &created, &finished, &s.Loadpoint, &s.Vehicle, &s.ChargedKwh, &solarPercentage); err != nil {
+			return nil, fmt.Errorf("failed to scan session: %w", err)
+		}
+		start, ok := timestampOf(created)
+		if !ok {
+			continue
+		}
+		end, ok := timestampOf(finished)
+		if !ok {
+			end = start
+		}
+
+		s.Created = start
+		s.SolarKwh = s.ChargedKwh * min(max(solarPercentage, 0), 100) / 100
+		s.ShiftableKwh = (s.ChargedKwh - s.SolarKwh) * (1 - windowShare(start, end, opts))
+		sessions = append(sessions, s)
+
+		name := start.In(opts.Location).Format("2006-01")
+		m, ok := months[name]
+		if !ok {
+			m = &SolarMonth{Month: name}
+			months[name] = m
+		}
+		m.Sessions++
+		m.ChargedKwh += s.ChargedKwh
+		m.SolarKwh += s.SolarKwh
+		m.ShiftableKwh += s.ShiftableKwh
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, m := range months {
+		m.SolarShare = 100 * m.SolarKwh / m.ChargedKwh
+		report.Months = append(report.Months, *m)
+	}
+	sort.Slice(report.Months, func(i, j int) bool { return report.Months[i].Month < report.Months[j].Month })
+
+	sort.SliceStable(sessions, func(i, j int) bool {
+		if sessions[i].ShiftableKwh != sessions[j].ShiftableKwh {
+			return sessions[i].ShiftableKwh > sessions[j].ShiftableKwh
+		}
+		return sessions[i].Created.Before(sessions[j].Created)
+	})
+	for _, s := range sessions[:min(opts.Worst, len(sessions))] {
+		if s.ShiftableKwh > 0 {
+			report.Worst = append(report.Worst, s)
+		}
+	}
+	return report, nil
+}
+
+// windowShare returns the part of the period from start to end that lies in the daily PV
+// window, for a period without duration 1 if it starts in the window
+func windowShare(start, end time.Time, opts SolarOptions) float64 {
+	start, end = start.In(opts.Location), end.In(opts.Location)
+	if !end.After(start) {
+		day := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, opts.Location)
+		if offset := start.Sub(day); offset >= opts.WindowStart && offset < opts.WindowEnd {
+			return 1
+		}
+		return 0
+	}
+
+	var inside time.Duration
+	for day := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, opts.Location); day.Before(end); day = day.AddDate(0, 0, 1) {
+		from := day.Add(opts.WindowStart)
+		to := day.Add(opts.WindowEnd)
+		if from.Before(start) {
+			from = start
+		}
+		if to.After(end) {
+			to = end
+		}
+		if to.After(from) {
+			inside += to.Sub(from)
+		}
+	}
+	return float64(inside) / float64(end.Sub(start))
+}
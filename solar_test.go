@@ -0,0 +1,48 @@
+package evccdb
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestSolarReport(t *testing.T) {
+	client, cleanup := createTestDB(t)
+	defer cleanup()
+
+	_, _ = client.db.Exec("DELETE FROM sessions")
+	if _, err := client.db.Exec(`INSERT INTO sessions (id, created, finished, loadpoint, charged_kwh, solar_percentage) VALUES
+		(1, '2024-01-01 12:00:00', '2024-01-01 14:00:00', 'Garage', 10, 100),
+		(2, '2024-01-05 14:00:00', '2024-01-05 18:00:00', 'Garage', 20, 25),
+		(3, '2024-01-06 20:00:00', '2024-01-07 06:00:00', 'Garage', 30, 0),
+		(4, '2024-02-01 11:00:00', NULL, 'eBikes', 1, 0)`); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := client.SolarReport(context.Background(), SolarOptions{Location: time.UTC, Worst: 2})
+	if err != nil {
+		t.Fatalf("SolarReport failed: %v", err)
+	}
+
+	if len(report.Months) != 2 {
+		t.Fatalf("Expected 2 months, got %+v", report.Months)
+	}
+	jan := report.Months[0]
+	// Session 2 charged half of its 15 kWh grid energy after 16:00, session 3 all of it at night
+	if jan.Month != "2024-01" || jan.Sessions != 3 || jan.SolarKwh != 15 || math.Abs(jan.SolarShare-25) > 1e-9 || math.Abs(jan.ShiftableKwh-37.5) > 1e-9 {
+		t.Errorf("Unexpected January %+v", jan)
+	}
+	// An unfinished session in the window is not shiftable
+	if feb := report.Months[1]; feb.ShiftableKwh != 0 {
+		t.Errorf("Unexpected February %+v", feb)
+	}
+
+	if len(report.Worst) != 2 || report.Worst[0].ID != 3 || report.Worst[1].ID != 2 {
+		t.Errorf("Expected sessions 3 and 2 as worst, got %+v", report.Worst)
+	}
+
+	if _, err := client.SolarReport(context.Background(), SolarOptions{WindowStart: 16 * time.Hour, WindowEnd: 10 * time.Hour}); err == nil {
+		t.Error("Expected an error for an inverted window")
+	}
+}
@@ -0,0 +1,221 @@
+package evccdb
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// SplitManifestSuffix is appended to an export file name to name the manifest of its parts
+const SplitManifestSuffix = ".manifest.json"
+
+// SplitManifest lists the parts of an export split into several files
+type SplitManifest struct {
+	Size  int64       `json:"size"`
+	Parts []SplitPart `json:"parts"`
+}
+
+// SplitPart is a file of a split export
+type SplitPart struct {
+	Name   string `json:"name"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// SplitPartName returns the file name of part n of an export, counting from 1, e.g. backup.json.gz.001
+func SplitPartName(path string, n int) string {
+	return fmt.Sprintf("%s.%03d", path, n)
+}
+
+// SplitWriter splits the written data into parts of at most size bytes
+type SplitWriter struct {
+	path     string
+	size     int64
+	create   func(name string) (io.Writer, error)
+	part     io.Writer
+	written  int64
+	hash     hash.Hash
+	manifest SplitManifest
+}
+
+// NewSplitWriter returns a writer splitting into the parts of path, each opened by create
+func NewSplitWriter(path string, size int64, create func(name string) (io.Writer, error)) *SplitWriter {
+	return &SplitWriter{path: path, size: size, create: create}
+}
+
+// Write writes p to the current part, starting new parts as they fill up
+func (w *SplitWriter) Write(p []byte) (int, error) {
+	var n int
+	for len(p) > 0 {
+		if w.part == nil || w.written == w.size {
+			if err := w.next(); err != nil {
+				return n, err
+			}
+		}
+
+		chunk := p[:min(int64(len(p)), w.size-w.written)]
+		m, err := w.part.Write(chunk)
+		w.hash.Write(chunk[:m])
+		w.written += int64(m)
+		n += m
+		if err != nil {
+			return n, err
+		}
+		p = p[m:]
+	}
+	return n, nil
+}
+
+// next finishes the current part and creates the next one
+func (w *SplitWriter) next() error {
+	w.finish()
+
+	name := SplitPartName(w.path, len(w.manifest.Parts)+1)
+	part, err := w.create(name)
+	if err != nil {
+		return err
+	}
+	w.part, w.written, w.hash = part, 0, sha256.New()
+	w.manifest.Parts = append(w.manifest.Parts, SplitPart{Name: filepath.Base(name)})
+	return nil
+}
+
+// finish records size and checksum of the current part in the manifest
+func (w *SplitWriter) finish() {
+	if w.part == nil {
+		return
+	}
+	part := &w.manifest.Parts[len(w.manifest.Parts)-1]
+	part.Size = w.written
+	part.SHA256 = hex.EncodeToString(w.hash.Sum(nil))
+	w.manifest.Size += w.written
+	w.part = nil
+}
+
+// Manifest returns the manifest of the parts written so far
+func (w *SplitWriter) Manifest() SplitManifest {
+	w.finish()
+	return w.manifest
+}
+
+// OpenExport opens an export file for reading. If path does not exist but the manifest
+// path+SplitManifestSuffix does, or path is the manifest, the parts are read in turn and
+// their checksums verified.
+func OpenExport(path string) (io.ReadCloser, error) {
+	manifestPath := path + SplitManifestSuffix
+	if strings.HasSuffix(path, SplitManifestSuffix) {
+		manifestPath = path
+	} else {
+		f, err := os.Open(path)
+		if err == nil {
+			return f, nil
+		}
+		if !errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("failed to open export: %w", err)
+		}
+	}
+
+	data, err := os.ReadFile(manifestPath)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("export %s does not exist", path)
+		}
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+
+	var manifest SplitManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("failed to decode manifest %s: %w", manifestPath, err)
+	}
+	if len(manifest.Parts) == 0 {
+		return nil, fmt.Errorf("manifest %s lists no parts", manifestPath)
+	}
+
+	return &splitReader{dir: filepath.Dir(manifestPath), parts: manifest.Parts}, nil
+}
+
+// ExportSize returns the size in bytes of the export that OpenExport reads, the total of the
+// parts of a split export
+func ExportSize(path string) (int64, error) {
+	if !strings.HasSuffix(path, SplitManifestSuffix) {
+		info, err := os.Stat(path)
+		if err == nil {
+			return info.Size(), nil
+		}
+		if !errors.Is(err, os.ErrNotExist) {
+			return 0, fmt.Errorf("failed to stat export: %w", err)
+		}
+		path += SplitManifestSuffix
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read manifest: %w", err)
+	}
+	var manifest SplitManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return 0, fmt.Errorf("failed to decode manifest %s: %w", path, err)
+	}
+	return manifest.Size, nil
+}
+
+// splitReader reads the parts of a split export, verifying each part at its end
+type splitReader struct {
+	dir   string
+	parts []SplitPart
+	file  *os.File
+	read  int64
+	hash  hash.Hash
+}
+
+func (r *splitReader) Read(p []byte) (int, error) {
+	for {
+		if r.file == nil {
+			if len(r.parts) == 0 {
+				return 0, io.EOF
+			}
+			f, err := os.Open(filepath.Join(r.dir, r.parts[0].Name))
+			if err != nil {
+				return 0, fmt.Errorf("failed to open part: %w", err)
+			}
+			r.file, r.read, r.hash = f, 0, sha256.New()
+		}
+
+		n, err := r.file.Read(p)
+		r.hash.Write(p[:n])
+		r.read += int64(n)
+		if err == io.EOF {
+			err = r.nextPart()
+		}
+		if n > 0 || err != nil {
+			return n, err
+		}
+	}
+}
+
+// nextPart verifies and closes the current part
+func (r *splitReader) nextPart() error {
+	part := r.parts[0]
+	_ = r.file.Close()
+	r.file = nil
+	r.parts = r.parts[1:]
+
+	if r.read != part.Size || hex.EncodeToString(r.hash.Sum(nil)) != part.SHA256 {
+		return fmt.Errorf("part %s is damaged or incomplete", part.Name)
+	}
+	return nil
+}
+
+func (r *splitReader) Close() error {
+	if r.file != nil {
+		return r.file.Close()
+	}
+	return nil
+}
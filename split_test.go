@@ -0,0 +1,71 @@
+package evccdb
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSplitExport(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "backup.json")
+	data := bytes.Repeat([]byte("0123456789"), 25)
+
+	var files []*os.File
+	w := NewSplitWriter(path, 100, func(name string) (io.Writer, error) {
+		f, err := os.Create(name)
+		files = append(files, f)
+		return f, err
+	})
+	if _, err := w.Write(data); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	for _, f := range files {
+		_ = f.Close()
+	}
+
+	manifest := w.Manifest()
+	if len(manifest.Parts) != 3 || manifest.Parts[2].Size != 50 || manifest.Size != int64(len(data)) {
+		t.Fatalf("Unexpected manifest: %+v", manifest)
+	}
+	if manifest.Parts[0].Name != "backup.json.001" {
+		t.Errorf("Unexpected part name %s", manifest.Parts[0].Name)
+	}
+
+	b, _ := json.Marshal(manifest)
+	if err := os.WriteFile(path+SplitManifestSuffix, b, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := OpenExport(path)
+	if err != nil {
+		t.Fatalf("OpenExport failed: %v", err)
+	}
+	got, err := io.ReadAll(r)
+	_ = r.Close()
+	if err != nil {
+		t.Fatalf("ReadAll failed: %v", err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Errorf("Reassembled export differs")
+	}
+	if size, err := ExportSize(path); err != nil || size != int64(len(data)) {
+		t.Errorf("Expected export size %d, got %d, %v", len(data), size, err)
+	}
+
+	// A damaged part is detected
+	if err := os.WriteFile(SplitPartName(path, 2), bytes.Repeat([]byte("x"), 100), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	r, err = OpenExport(path)
+	if err != nil {
+		t.Fatalf("OpenExport failed: %v", err)
+	}
+	if _, err := io.ReadAll(r); err == nil {
+		t.Error("Expected error for damaged part")
+	}
+	_ = r.Close()
+}
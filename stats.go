@@ -0,0 +1,144 @@
+package evccdb
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// SessionStats are the totals of the charging sessions of a group
+type SessionStats struct {
+	Group      string  `json:"group"`
+	Sessions   int     `json:"sessions"`
+	ChargedKwh float64 `json:"charged_kwh"`
+	SolarKwh   float64 `json:"solar_kwh"`
+	Price      float64 `json:"price"`
+}
+
+// AggregateOptions configures AggregateSessions
+type AggregateOptions struct {
+	// Location is the time zone of the time groupings, nil means the local time zone
+	Location *time.Location
+	// Holidays are the dates formatted as 2006-01-02 the daytype grouping counts as holiday
+	Holidays []string
+}
+
+// sessionGrouping assigns a session starting at the given local time to a group and returns
+// a key that orders the groups besides the group name
+type sessionGrouping func(s Session, start time.Time, opts AggregateOptions) (key, group string)
+
+// sessionGroupings are the groupings of AggregateSessions by name
+var sessionGroupings = map[string]sessionGrouping{
+	"loadpoint": func(s Session, _ time.Time, _ AggregateOptions) (string, string) {
+		return s.Loadpoint, s.Loadpoint
+	},
+	"vehicle": func(s Session, _ time.Time, _ AggregateOptions) (string, string) {
+		if s.Vehicle == nil {
+			return "", ""
+		}
+		return *s.Vehicle, *s.Vehicle
+	},
+	"month": func(_ Session, start time.Time, _ AggregateOptions) (string, string) {
+		month := start.Format("2006-01")
+		return month, month
+	},
+	"weekday": func(_ Session, start time.Time, _ AggregateOptions) (string, string) {
+		// Weeks start on Monday
+		return fmt.Sprint(int(start.Weekday()+6) % 7), start.Weekday().String()
+	},
+	"hour": func(_ Session, start time.Time, _ AggregateOptions) (string, string) {
+		hour := start.Format("15")
+		return hour, hour
+	},
+	"daytype": func(_ Session, start time.Time, opts AggregateOptions) (string, string) {
+		switch {
+		case slices.Contains(opts.Holidays, start.Format("2006-01-02")):
+			return "2", "holiday"
+		case start.Weekday() == time.Saturday || start.Weekday() == time.Sunday:
+			return "1", "weekend"
+		default:
+			return "0", "workday"
+		}
+	},
+}
+
+// SessionGroupings returns the names of the groupings of AggregateSessions
+func SessionGroupings() []string {
+	names := make([]string, 0, len(sessionGroupings))
+	for name := range sessionGroupings {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// AggregateSessions sums the sessions by a grouping: loadpoint, vehicle, or the month,
+// weekday, hour or daytype of their start. Groups are ordered by their natural order,
+// Monday first and workdays before weekends and holidays.
+func AggregateSessions(sessions []Session, by string, opts AggregateOptions) ([]SessionStats, error) {
+	grouping, ok := sessionGroupings[by]
+	if !ok {
+		return nil, fmt.Errorf("unknown grouping %q, available: %s", by, strings.Join(SessionGroupings(), ", "))
+	}
+	if opts.Location == nil {
+		opts.Location = time.Local
+	}
+
+	groups := make(map[string]*SessionStats)
+	for _, s := range sessions {
+		start, err := ParseTimestamp(s.Created, nil)
+		if err != nil {
+			return nil, fmt.Errorf("session %d: %w", s.ID, err)
+		}
+		key, group := grouping(s, start.In(opts.Location), opts)
+
+		stats, ok := groups[key]
+		if !ok {
+			stats = &SessionStats{Group: group}
+			groups[key] = stats
+		}
+		stats.Sessions++
+		if s.ChargedKwh != nil {
+			stats.ChargedKwh += *s.ChargedKwh
+			if s.SolarPercentage != nil {
+				stats.SolarKwh += *s.ChargedKwh * *s.SolarPercentage / 100
+			}
+		}
+		if s.Price != nil {
+			stats.Price += *s.Price
+		}
+	}
+
+	sorted := make([]string, 0, len(groups))
+	for key := range groups {
+		sorted = append(sorted, key)
+	}
+	sort.Strings(sorted)
+
+	result := make([]SessionStats, 0, len(sorted))
+	for _, key := range sorted {
+		result = append(result, *groups[key])
+	}
+	return result, nil
+}
+
+// SessionStatsBy returns the session totals grouped as by AggregateSessions
+func (c *Client) SessionStatsBy(ctx context.Context, by string, opts AggregateOptions) ([]SessionStats, error) {
+	if _, ok := sessionGroupings[by]; !ok {
+		return nil, fmt.Errorf("unknown grouping %q, available: %s", by, strings.Join(SessionGroupings(), ", "))
+	}
+
+	sessions, err := c.Sessions(ctx, SeriesFilter{})
+	if err != nil {
+		return nil, err
+	}
+	return AggregateSessions(sessions, by, opts)
+}
+
+// LoadpointStats returns the session totals per loadpoint, ordered by loadpoint
+func (c *Client) LoadpointStats(ctx context.Context) ([]SessionStats, error) {
+	return c.SessionStatsBy(ctx, "loadpoint", AggregateOptions{})
+}
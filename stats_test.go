@@ -0,0 +1,78 @@
+package evccdb
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestLoadpointStats(t *testing.T) {
+	client, cleanup := createTestDB(t)
+	defer cleanup()
+
+	stats, err := client.LoadpointStats(context.Background())
+	if err != nil {
+		t.Fatalf("LoadpointStats failed: %v", err)
+	}
+	if len(stats) != 2 || stats[0].Group != "Garage" || stats[0].Sessions != 3 || stats[1].Group != "eBikes" || stats[1].Sessions != 2 {
+		t.Errorf("Unexpected loadpoint stats %+v", stats)
+	}
+}
+
+func TestAggregateSessions(t *testing.T) {
+	client, cleanup := createTestDB(t)
+	defer cleanup()
+
+	// Sessions 1 to 5 start daily at 10:00 from Saturday 2023-04-01
+	_, _ = client.db.Exec("UPDATE sessions SET charged_kwh = id, solar_percentage = 50, price = 1")
+	sessions, err := client.Sessions(context.Background(), SeriesFilter{})
+	if err != nil {
+		t.Fatalf("Sessions failed: %v", err)
+	}
+	if len(sessions) != 5 || sessions[0].ID != 1 || sessions[0].Created != "2023-04-01T10:00:00Z" || sessions[4].Vehicle != nil {
+		t.Fatalf("Unexpected sessions %+v", sessions)
+	}
+
+	opts := AggregateOptions{Location: time.UTC, Holidays: []string{"2023-04-03"}}
+	for _, tc := range []struct {
+		by     string
+		groups []string
+	}{
+		{"weekday", []string{"Monday", "Tuesday", "Wednesday", "Saturday", "Sunday"}},
+		{"daytype", []string{"workday", "weekend", "holiday"}},
+		{"hour", []string{"10"}},
+		{"vehicle", []string{"", "e-Bike", "e-Golf"}},
+	} {
+		stats, err := AggregateSessions(sessions, tc.by, opts)
+		if err != nil {
+			t.Fatalf("AggregateSessions(%s) failed: %v", tc.by, err)
+		}
+		var groups []string
+		for _, s := range stats {
+			groups = append(groups, s.Group)
+		}
+		if len(groups) != len(tc.groups) {
+			t.Errorf("Expected %s groups %v, got %v", tc.by, tc.groups, groups)
+			continue
+		}
+		for i := range groups {
+			if groups[i] != tc.groups[i] {
+				t.Errorf("Expected %s groups %v, got %v", tc.by, tc.groups, groups)
+				break
+			}
+		}
+	}
+
+	stats, err := AggregateSessions(sessions, "daytype", opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Tuesday and Wednesday are workdays, Monday a holiday
+	if workday := stats[0]; workday.Sessions != 2 || workday.ChargedKwh != 9 || workday.SolarKwh != 4.5 || workday.Price != 2 {
+		t.Errorf("Unexpected workday stats %+v", workday)
+	}
+
+	if _, err := client.SessionStatsBy(context.Background(), "year", opts); err == nil {
+		t.Error("Expected an error for an unknown grouping")
+	}
+}
@@ -0,0 +1,220 @@
+package evccdb
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// minRenameSimilarity is the similarity a name of the destination needs to be suggested as rename target
+const minRenameSimilarity = 0.5
+
+// RenameSuggestion is a likely rename of a source name that the destination does not know
+type RenameSuggestion struct {
+	RenameMapping
+	// Similarity is 1 for names differing only in case, 0 for completely different names
+	Similarity float64
+}
+
+// RenameSuggestions are the suggested loadpoint and vehicle renames of a transfer
+type RenameSuggestions struct {
+	Loadpoints []RenameSuggestion
+	Vehicles   []RenameSuggestion
+}
+
+// SuggestRenames compares the loadpoint and vehicle names of both databases and suggests
+// renames of source names missing in the destination to similar destination names that
+// are missing in the source. Names covered by the given renames are skipped.
+func SuggestRenames(ctx context.Context, src, dst *Client, opts TransferOptions) (RenameSuggestions, error) {
+	var s RenameSuggestions
+
+	srcNames, err := src.loadpointNames(ctx)
+	if err != nil {
+		return s, err
+	}
+	dstNames, err := dst.loadpointNames(ctx)
+	if err != nil {
+		return s, err
+	}
+	s.Loadpoints = suggestRenames(srcNames, dstNames, opts.LoadpointRenames)
+
+	if srcNames, err = src.vehicleNames(ctx); err != nil {
+		return s, err
+	}
+	if dstNames, err = dst.vehicleNames(ctx); err != nil {
+		return s, err
+	}
+	s.Vehicles = suggestRenames(srcNames, dstNames, opts.VehicleRenames)
+
+	return s, nil
+}
+
+// suggestRenames pairs the unmatched names by decreasing similarity, each name is used once
+func suggestRenames(srcNames, dstNames []string, renames []RenameMapping) []RenameSuggestion {
+	srcOnly := nameSet(srcNames)
+	dstOnly := nameSet(dstNames)
+	for name := range srcOnly {
+		if dstOnly[name] {
+			delete(srcOnly, name)
+			delete(dstOnly, name)
+		}
+	}
+	for _, r := range renames {
+		delete(srcOnly, r.OldName)
+		delete(dstOnly, r.NewName)
+	}
+
+	var candidates []RenameSuggestion
+	for from := range srcOnly {
+		for to := range dstOnly {
+			if sim := similarity(from, to); sim >= minRenameSimilarity {
+				candidates = append(candidates, RenameSuggestion{RenameMapping: RenameMapping{OldName: from, NewName: to}, Similarity: sim})
+			}
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if a.Similarity != b.Similarity {
+			return a.Similarity > b.Similarity
+		}
+		if a.OldName != b.OldName {
+			return a.OldName < b.OldName
+		}
+		return a.NewName < b.NewName
+	})
+
+	var suggestions []RenameSuggestion
+	for _, c := range candidates {
+		if srcOnly[c.OldName] && dstOnly[c.NewName] {
+			suggestions = append(suggestions, c)
+			delete(srcOnly, c.OldName)
+			delete(dstOnly, c.NewName)
+		}
+	}
+	sort.Slice(suggestions, func(i, j int) bool { return suggestions[i].OldName < suggestions[j].OldName })
+	return suggestions
+}
+
+// similarity returns 1 minus the case-insensitive Levenshtein distance relative to the longer name
+func similarity(a, b string) float64 {
+	ra, rb := []rune(strings.ToLower(a)), []rune(strings.ToLower(b))
+	n := max(len(ra), len(rb))
+	if n == 0 {
+		return 1
+	}
+	return 1 - float64(levenshtein(ra, rb))/float64(n)
+}
+
+// levenshtein returns the number of single rune edits turning a into b
+func levenshtein(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+// nameSet returns the set of names
+func nameSet(names []string) map[string]bool {
+	set := make(map[string]bool, len(names))
+	for _, name := range names {
+		set[name] = true
+	}
+	return set
+}
+
+// loadpointNames returns the loadpoint names of the configs, settings titles and sessions
+func (c *Client) loadpointNames(ctx context.Context) ([]string, error) {
+	names, err := c.configTitles(ctx, ClassLoadpoint)
+	if err != nil {
+		return nil, err
+	}
+	titles, err := c.queryNames(ctx, "settings", "SELECT value FROM settings WHERE key LIKE 'lp%.title'")
+	if err != nil {
+		return nil, err
+	}
+	sessions, err := c.queryNames(ctx, "sessions", "SELECT DISTINCT loadpoint FROM sessions")
+	if err != nil {
+		return nil, err
+	}
+	return append(append(names, titles...), sessions...), nil
+}
+
+// vehicleNames returns the vehicle names of the configs, settings keys and sessions
+func (c *Client) vehicleNames(ctx context.Context) ([]string, error) {
+	names, err := c.configTitles(ctx, ClassVehicle)
+	if err != nil {
+		return nil, err
+	}
+	keys, err := c.queryNames(ctx, "settings", "SELECT key FROM settings WHERE key LIKE 'vehicle.%'")
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range keys {
+		if name := ParseSettingsKey(key).Name; name != "" {
+			names = append(names, name)
+		}
+	}
+	sessions, err := c.queryNames(ctx, "sessions", "SELECT DISTINCT vehicle FROM sessions")
+	if err != nil {
+		return nil, err
+	}
+	return append(names, sessions...), nil
+}
+
+// configTitles returns the titles of the configs of a class
+func (c *Client) configTitles(ctx context.Context, class ConfigClass) ([]string, error) {
+	if exists, err := c.TableExists("configs"); err != nil || !exists {
+		return nil, err
+	}
+	g, err := c.DeviceGraph(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var titles []string
+	for _, cfg := range g.DevicesOfClass(class) {
+		if cfg.Title != "" {
+			titles = append(titles, cfg.Title)
+		}
+	}
+	return titles, nil
+}
+
+// queryNames returns the non-empty names of a single column query, nil if the table does not exist
+func (c *Client) queryNames(ctx context.Context, table, query string) ([]string, error) {
+	if exists, err := c.TableExists(table); err != nil || !exists {
+		return nil, err
+	}
+	rows, err := c.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query names: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var names []string
+	for rows.Next() {
+		var name sql.NullString
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		if name.String != "" {
+			names = append(names, name.String)
+		}
+	}
+	return names, rows.Err()
+}
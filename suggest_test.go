@@ -0,0 +1,65 @@
+package evccdb
+
+import (
+	"context"
+	"testing"
+)
+
+func TestLevenshtein(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"Garage", "Garage", 0},
+		{"Garage", "Garage 1", 2},
+		{"kitten", "sitting", 3},
+		{"Zoë", "Zoe", 1},
+	}
+	for _, tt := range tests {
+		if got := levenshtein([]rune(tt.a), []rune(tt.b)); got != tt.want {
+			t.Errorf("levenshtein(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+
+	if s := similarity("garage", "Garage"); s != 1 {
+		t.Errorf("Expected case-insensitive similarity 1, got %v", s)
+	}
+}
+
+func TestSuggestRenames(t *testing.T) {
+	src, srcCleanup := createTestDB(t)
+	defer srcCleanup()
+	dst, dstCleanup := createTestDB(t)
+	defer dstCleanup()
+
+	ctx := context.Background()
+	if _, err := dst.RenameLoadpoint(ctx, "Garage", "Garage 1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := dst.RenameVehicle(ctx, "e-Golf", "eGolf"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := dst.RenameLoadpoint(ctx, "eBikes", "Carport"); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := SuggestRenames(ctx, src, dst, TransferOptions{})
+	if err != nil {
+		t.Fatalf("SuggestRenames failed: %v", err)
+	}
+
+	// eBikes and Carport are too different to be suggested
+	if len(s.Loadpoints) != 1 || s.Loadpoints[0].RenameMapping != (RenameMapping{OldName: "Garage", NewName: "Garage 1"}) {
+		t.Errorf("Unexpected loadpoint suggestions %+v", s.Loadpoints)
+	}
+	if len(s.Vehicles) != 1 || s.Vehicles[0].NewName != "eGolf" {
+		t.Errorf("Unexpected vehicle suggestions %+v", s.Vehicles)
+	}
+
+	// Explicit renames are not suggested again
+	opts := TransferOptions{VehicleRenames: []RenameMapping{{OldName: "e-Golf", NewName: "eGolf"}}}
+	if s, err = SuggestRenames(ctx, src, dst, opts); err != nil || len(s.Vehicles) != 0 {
+		t.Errorf("Expected no vehicle suggestions, got %+v, %v", s.Vehicles, err)
+	}
+}
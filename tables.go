@@ -0,0 +1,137 @@
+package evccdb
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// TableCategory groups the evcc tables for the transfer modes
+type TableCategory int
+
+const (
+	CategoryConfig TableCategory = iota
+	CategoryMetrics
+	// CategoryCache holds volatile data like API tokens, only transferred on request
+	CategoryCache
+)
+
+// tableCategory assigns a table to its category
+type tableCategory struct {
+	table    string
+	category TableCategory
+}
+
+var (
+	tableCategoriesMu sync.RWMutex
+	tableCategories   = []tableCategory{
+		{"settings", CategoryConfig},
+		{"configs", CategoryConfig},
+		{"caches", CategoryCache},
+		{"meters", CategoryMetrics},
+		{"sessions", CategoryMetrics},
+		{"grid_sessions", CategoryMetrics},
+	}
+)
+
+// SetTableCategory moves a table into a category, unknown tables are added
+func SetTableCategory(table string, category TableCategory) {
+	tableCategoriesMu.Lock()
+	defer tableCategoriesMu.Unlock()
+
+	for i := range tableCategories {
+		if tableCategories[i].table == table {
+			tableCategories[i].category = category
+			return
+		}
+	}
+	tableCategories = append(tableCategories, tableCategory{table, category})
+}
+
+// TablesOf returns the tables of the categories in registration order
+func TablesOf(categories ...TableCategory) []string {
+	tableCategoriesMu.RLock()
+	defer tableCategoriesMu.RUnlock()
+
+	var tables []string
+	for _, tc := range tableCategories {
+		for _, category := range categories {
+			if tc.category == category {
+				tables = append(tables, tc.table)
+				break
+			}
+		}
+	}
+	return tables
+}
+
+// isCacheTable reports whether the table holds volatile cache data
+func isCacheTable(table string) bool {
+	for _, t := range TablesOf(CategoryCache) {
+		if t == table {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	transferModesMu sync.RWMutex
+	// transferModeNames are indexed by TransferMode
+	transferModeNames = []string{"config", "metrics", "all"}
+	// transferModeTables overrides the table set of a mode
+	transferModeTables = map[TransferMode][]string{}
+)
+
+// RegisterTransferMode defines the tables of a named mode. Built-in modes are
+// overridden, new names get a new TransferMode.
+func RegisterTransferMode(name string, tables []string) TransferMode {
+	transferModesMu.Lock()
+	defer transferModesMu.Unlock()
+
+	mode := TransferMode(len(transferModeNames))
+	for i, n := range transferModeNames {
+		if n == name {
+			mode = TransferMode(i)
+		}
+	}
+	if int(mode) == len(transferModeNames) {
+		transferModeNames = append(transferModeNames, name)
+	}
+
+	transferModeTables[mode] = append([]string(nil), tables...)
+	return mode
+}
+
+// ParseTransferMode returns the built-in or registered mode of the given name
+func ParseTransferMode(name string) (TransferMode, error) {
+	transferModesMu.RLock()
+	defer transferModesMu.RUnlock()
+
+	for i, n := range transferModeNames {
+		if n == name {
+			return TransferMode(i), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown transfer mode %q, expected one of %s", name, strings.Join(transferModeNames, ", "))
+}
+
+// String returns the name of the mode
+func (m TransferMode) String() string {
+	transferModesMu.RLock()
+	defer transferModesMu.RUnlock()
+
+	if m >= 0 && int(m) < len(transferModeNames) {
+		return transferModeNames[m]
+	}
+	return fmt.Sprintf("mode %d", int(m))
+}
+
+// transferModeOverride returns the registered tables of a mode
+func transferModeOverride(mode TransferMode) ([]string, bool) {
+	transferModesMu.RLock()
+	defer transferModesMu.RUnlock()
+
+	tables, ok := transferModeTables[mode]
+	return tables, ok
+}
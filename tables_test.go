@@ -0,0 +1,52 @@
+package evccdb
+
+import "testing"
+
+func TestSetTableCategory(t *testing.T) {
+	SetTableCategory("caches", CategoryConfig)
+	defer SetTableCategory("caches", CategoryCache)
+
+	client, cleanup := createTestDB(t)
+	defer cleanup()
+
+	tables, err := client.ResolveTables(TransferOptions{Mode: TransferConfig})
+	if err != nil {
+		t.Fatalf("Failed to resolve tables: %v", err)
+	}
+	if len(tables) != 3 || tables[2] != "caches" {
+		t.Errorf("Expected caches in config tables, got %v", tables)
+	}
+	if len(client.GetCacheTables()) != 0 {
+		t.Errorf("Expected no cache tables, got %v", client.GetCacheTables())
+	}
+}
+
+func TestRegisterTransferMode(t *testing.T) {
+	mode := RegisterTransferMode("test-archive", []string{"sessions", "grid_sessions"})
+	if mode <= TransferAll || mode.String() != "test-archive" {
+		t.Errorf("Unexpected mode %d (%s)", mode, mode)
+	}
+
+	parsed, err := ParseTransferMode("test-archive")
+	if err != nil || parsed != mode {
+		t.Fatalf("ParseTransferMode = %d, %v", parsed, err)
+	}
+	if again := RegisterTransferMode("test-archive", []string{"sessions"}); again != mode {
+		t.Errorf("Re-registering created new mode %d", again)
+	}
+
+	client, cleanup := createTestDB(t)
+	defer cleanup()
+
+	tables, err := client.ResolveTables(TransferOptions{Mode: mode})
+	if err != nil {
+		t.Fatalf("Failed to resolve tables: %v", err)
+	}
+	if len(tables) != 1 || tables[0] != "sessions" {
+		t.Errorf("Expected [sessions], got %v", tables)
+	}
+
+	if _, err := ParseTransferMode("unknown"); err == nil {
+		t.Error("ParseTransferMode accepted unknown mode")
+	}
+}
@@ -0,0 +1,278 @@
+package evccdb
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+// TariffPrice is the price per kWh of a tariff from Start until the start of the next price
+type TariffPrice struct {
+	Start time.Time
+	Price float64
+}
+
+// Tariff is a list of prices ordered by start. A single price applies forever, the last of
+// several prices as long as the interval before it.
+type Tariff []TariffPrice
+
+// ReadTariffCSV reads a tariff from CSV rows of a start timestamp and a price per kWh, e.g.
+// the hourly prices of a dynamic tariff. A header row is skipped, its price or preis column
+// selects the price, else the last column is used. Timestamps without zone offset are
+// interpreted in loc, or the local time zone if loc is nil.
+func ReadTariffCSV(r io.Reader, loc *time.Location) (Tariff, error) {
+	if loc == nil {
+		loc = time.Local
+	}
+
+	br := bufio.NewReader(r)
+	first, err := br.ReadString('\n')
+	if err != nil && err != io.EOF {
+		return nil, fmt.Errorf("failed to read tariff: %w", err)
+	}
+
+	delimiter := ','
+	if strings.Count(first, ";") > strings.Count(first, ",") {
+		delimiter = ';'
+	}
+
+	reader := csv.NewReader(io.MultiReader(strings.NewReader(first), br))
+	reader.Comma = delimiter
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse tariff: %w", err)
+	}
+
+	priceCol := -1
+	if len(records) > 0 && len(records[0]) > 0 {
+		if _, err := ParseTimestamp(records[0][0], loc); err != nil {
+			for i, h := range records[0] {
+				if h := strings.ToLower(h); strings.Contains(h, "price") || strings.Contains(h, "preis") {
+					priceCol = i
+					break
+				}
+			}
+			records = records[1:]
+		}
+	}
+
+	var tariff Tariff
+	for i, record := range records {
+		if len(record) < 2 {
+			continue
+		}
+		col := priceCol
+		if col < 0 || col >= len(record) {
+			col = len(record) - 1
+		}
+
+		start, err := ParseTimestamp(record[0], loc)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", i+1, err)
+		}
+		price, err := parseLocaleNumber(record[col], delimiter == ';')
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", i+1, err)
+		}
+		tariff = append(tariff, TariffPrice{Start: start, Price: price})
+	}
+	if len(tariff) == 0 {
+		return nil, fmt.Errorf("tariff has no prices")
+	}
+
+	sort.SliceStable(tariff, func(i, j int) bool { return tariff[i].Start.Before(tariff[j].Start) })
+	return tariff, nil
+}
+
+// end returns the end of the last price, zero if the tariff has a single price
+func (t Tariff) end() time.Time {
+	if len(t) < 2 {
+		return time.Time{}
+	}
+	last := t[len(t)-1].Start
+	return last.Add(last.Sub(t[len(t)-2].Start))
+}
+
+// PriceAt returns the price valid at the given time, false outside the tariff
+func (t Tariff) PriceAt(at time.Time) (float64, bool) {
+	i := sort.Search(len(t), func(i int) bool { return t[i].Start.After(at) }) - 1
+	if i < 0 {
+		return 0, false
+	}
+	if end := t.end(); !end.IsZero() && !at.Before(end) {
+		return 0, false
+	}
+	return t[i].Price, true
+}
+
+// energyCost prices energy consumed evenly between from and to and returns the cost and the
+// energy outside the tariff
+func (t Tariff) energyCost(from, to time.Time, kwh float64) (cost, uncovered float64) {
+	if !to.After(from) {
+		if price, ok := t.PriceAt(from); ok {
+			return kwh * price, 0
+		}
+		return 0, kwh
+	}
+
+	end := t.end()
+	for at := from; at.Before(to); {
+		next := to
+		if i := sort.Search(len(t), func(i int) bool { return t[i].Start.After(at) }); i < len(t) && t[i].Start.Before(next) {
+			next = t[i].Start
+		}
+		if !end.IsZero() && at.Before(end) && end.Before(next) {
+			next = end
+		}
+
+		share := kwh * float64(next.Sub(at)) / float64(to.Sub(from))
+		if price, ok := t.PriceAt(at); ok {
+			cost += share * price
+		} else {
+			uncovered += share
+		}
+		at = next
+	}
+	return cost, uncovered
+}
+
+// TariffOptions configures SimulateTariff
+type TariffOptions struct {
+	// Meters spreads the energy of a session by the readings of its loadpoint's meter,
+	// else evenly over the session
+	Meters bool
+	// SolarPrice is the price per kWh of the solar share, e.g. the feed-in tariff foregone
+	SolarPrice float64
+}
+
+// TariffSession is the actual and the simulated price of a session
+type TariffSession struct {
+	ID         int64     `json:"id"`
+	Created    time.Time `json:"created"`
+	Loadpoint  string    `json:"loadpoint"`
+	Vehicle    string    `json:"vehicle"`
+	ChargedKwh float64   `json:"charged_kwh"`
+	SolarKwh   float64   `json:"solar_kwh"`
+	Price      float64   `json:"price"`
+	Simulated  float64   `json:"simulated"`
+	// UncoveredKwh is the energy charged outside the tariff, which is not priced
+	UncoveredKwh float64 `json:"uncovered_kwh"`
+}
+
+// SimulateTariff computes what the sessions would have cost under another tariff. The grid
+// share of the charged energy is priced by the tariff at the time it was charged, the solar
+// share at the solar price. Sessions are ordered by start time.
+func (c *Client) SimulateTariff(ctx context.Context, tariff Tariff, opts TariffOptions) ([]TariffSession, error) {
+	if len(tariff) == 0 {
+		return nil, fmt.Errorf("tariff has no prices")
+	}
+
+	rows, err := c.db.QueryContext(ctx, `
+		SELECT id, created, finished, COALESCE(loadpoint, ''), COALESCE(vehicle, ''),
+			charged_kwh, COALESCE(solar_percentage, 0), COALESCE(price, 0)
+		FROM sessions
+		WHERE charged_kwh > 0 AND created IS NOT NULL`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query sessions: %w", err)
+	}
+
+	type period struct{ start, end time.Time }
+	var sessions []TariffSession
+	var periods []period
+	for rows.Next() {
+		var s TariffSession
+		var created, finished any
+		var solarPercentage float64
+		if err := rows.Scan(&s.ID, &created, &finished, &s.Loadpoint, &s.Vehicle, &s.ChargedKwh, &solarPercentage, &s.Price); err != nil {
+			_ = rows.Close()
+			return nil, fmt.Errorf("failed to scan session: %w", err)
+		}
+		start, ok := timestampOf(created)
+		if !ok {
+			continue
+		}
+		end, ok := timestampOf(finished)
+		if !ok {
+			end = start
+		}
+		s.Created = start
+		s.SolarKwh = s.ChargedKwh * min(max(solarPercentage, 0), 100) / 100
+		sessions = append(sessions, s)
+		periods = append(periods, period{start, end})
+	}
+	_ = rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	meters := make(map[string]int64)
+	for i := range sessions {
+		s := &sessions[i]
+		gridKwh := s.ChargedKwh - s.SolarKwh
+		s.Simulated = s.SolarKwh * opts.SolarPrice
+
+		var curve []PowerPoint
+		if opts.Meters {
+			meter, ok := meters[s.Loadpoint]
+			if !ok {
+				indices, err := loadpointIndices(ctx, c.db, s.Loadpoint)
+				if err != nil {
+					return nil, fmt.Errorf("failed to resolve loadpoint indices: %w", err)
+				}
+				if len(indices) == 1 {
+					meter = int64(indices[0])
+				}
+				meters[s.Loadpoint] = meter
+			}
+			if meter > 0 && periods[i].end.After(periods[i].start) {
+				if curve, err = c.MeterPowerCurve(ctx, meter, periods[i].start, periods[i].end); err != nil {
+					return nil, err
+				}
+			}
+		}
+
+		// The meter readings give the shape of the session, the session its energy
+		if total := curveEnergy(curve); total > 0 {
+			var prev float64
+			for _, p := range curve {
+				kwh := p.Energy - prev
+				prev = p.Energy
+				if kwh <= 0 || p.Power <= 0 {
+					continue
+				}
+				from := p.Time.Add(-time.Duration(kwh / p.Power * float64(time.Hour)))
+				cost, uncovered := tariff.energyCost(from, p.Time, gridKwh*kwh/total)
+				s.Simulated += cost
+				s.UncoveredKwh += uncovered
+			}
+			continue
+		}
+
+		cost, uncovered := tariff.energyCost(periods[i].start, periods[i].end, gridKwh)
+		s.Simulated += cost
+		s.UncoveredKwh += uncovered
+	}
+
+	sort.SliceStable(sessions, func(i, j int) bool { return sessions[i].Created.Before(sessions[j].Created) })
+	return sessions, nil
+}
+
+// curveEnergy returns the positive energy of a power curve
+func curveEnergy(curve []PowerPoint) float64 {
+	var total, prev float64
+	for _, p := range curve {
+		if kwh := p.Energy - prev; kwh > 0 && p.Power > 0 {
+			total += kwh
+		}
+		prev = p.Energy
+	}
+	return total
+}
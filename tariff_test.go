@@ -0,0 +1,98 @@
+package evccdb
+
+import (
+	"context"
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReadTariffCSV(t *testing.T) {
+	tariff, err := ReadTariffCSV(strings.NewReader("Start;Preis;Einheit\n2024-01-01 01:00;0,30;EUR\n2024-01-01 00:00;0,10;EUR\n"), time.UTC)
+	if err != nil {
+		t.Fatalf("ReadTariffCSV failed: %v", err)
+	}
+	if len(tariff) != 2 || tariff[0].Price != 0.1 || tariff[1].Price != 0.3 {
+		t.Fatalf("Expected prices ordered by start, got %+v", tariff)
+	}
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, tc := range []struct {
+		at    time.Time
+		price float64
+		ok    bool
+	}{
+		{start.Add(-time.Minute), 0, false},
+		{start.Add(30 * time.Minute), 0.1, true},
+		{start.Add(90 * time.Minute), 0.3, true},
+		{start.Add(2 * time.Hour), 0, false},
+	} {
+		if price, ok := tariff.PriceAt(tc.at); price != tc.price || ok != tc.ok {
+			t.Errorf("PriceAt(%s) = %v, %v, expected %v, %v", tc.at, price, ok, tc.price, tc.ok)
+		}
+	}
+
+	// A single price applies forever
+	flat, err := ReadTariffCSV(strings.NewReader("2020-01-01,0.25\n"), time.UTC)
+	if err != nil {
+		t.Fatalf("ReadTariffCSV failed: %v", err)
+	}
+	if price, ok := flat.PriceAt(time.Now()); !ok || price != 0.25 {
+		t.Errorf("Expected the flat price, got %v, %v", price, ok)
+	}
+}
+
+func TestSimulateTariff(t *testing.T) {
+	client, cleanup := createTestDB(t)
+	defer cleanup()
+
+	// 10 kWh with 50% solar from 10:00 to 12:00, all charged in the first hour
+	_, _ = client.db.Exec("DELETE FROM sessions")
+	if _, err := client.db.Exec(`INSERT INTO sessions (id, created, finished, loadpoint, charged_kwh, solar_percentage, price)
+		VALUES (1, '2024-01-01 10:00:00', '2024-01-01 12:00:00', 'Garage', 10, 50, 2)`); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.db.Exec(`INSERT INTO meters (meter, ts, val) VALUES
+		(1, '2024-01-01 10:00:00', 0), (1, '2024-01-01 10:30:00', 5), (1, '2024-01-01 11:00:00', 5),
+		(1, '2024-01-01 11:30:00', 0), (1, '2024-01-01 12:00:00', 0)`); err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	tariff := Tariff{{start, 0.1}, {start.Add(time.Hour), 0.3}}
+
+	for _, tc := range []struct {
+		name      string
+		opts      TariffOptions
+		simulated float64
+	}{
+		{"even", TariffOptions{}, 1},
+		{"meters", TariffOptions{Meters: true}, 0.5},
+		{"solar price", TariffOptions{SolarPrice: 0.08}, 1.4},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			sessions, err := client.SimulateTariff(context.Background(), tariff, tc.opts)
+			if err != nil {
+				t.Fatalf("SimulateTariff failed: %v", err)
+			}
+			if len(sessions) != 1 {
+				t.Fatalf("Expected 1 session, got %+v", sessions)
+			}
+			s := sessions[0]
+			if math.Abs(s.Simulated-tc.simulated) > 1e-9 || s.Price != 2 || s.SolarKwh != 5 || s.UncoveredKwh != 0 {
+				t.Errorf("Unexpected simulation %+v", s)
+			}
+		})
+	}
+
+	// The last of several prices lasts as long as the one before, the rest is not priced
+	tariff = Tariff{{start.Add(-time.Hour), 0.2}, {start, 0.1}}
+	sessions, err := client.SimulateTariff(context.Background(), tariff, TariffOptions{})
+	if err != nil {
+		t.Fatalf("SimulateTariff failed: %v", err)
+	}
+	if s := sessions[0]; math.Abs(s.Simulated-0.25) > 1e-9 || math.Abs(s.UncoveredKwh-2.5) > 1e-9 {
+		t.Errorf("Expected 2.5 kWh outside the tariff, got %+v", s)
+	}
+}
@@ -0,0 +1,124 @@
+package evccdb
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// templateDefaultsJSON holds the parameter defaults of common evcc templates
+//
+//go:embed templatedefaults.json
+var templateDefaultsJSON []byte
+
+var (
+	templateDefaultsMu sync.RWMutex
+	templateDefaults   = map[string]map[string]any{}
+)
+
+func init() {
+	if err := json.Unmarshal(templateDefaultsJSON, &templateDefaults); err != nil {
+		panic(fmt.Sprintf("invalid embedded template defaults: %v", err))
+	}
+}
+
+// RegisterTemplateDefaults adds or replaces the parameter defaults of a template
+func RegisterTemplateDefaults(template string, defaults map[string]any) {
+	templateDefaultsMu.Lock()
+	defer templateDefaultsMu.Unlock()
+	templateDefaults[template] = defaults
+}
+
+// TemplateDefaults returns the parameter defaults of a template, false if they are not known
+func TemplateDefaults(template string) (map[string]any, bool) {
+	templateDefaultsMu.RLock()
+	defer templateDefaultsMu.RUnlock()
+
+	defaults, ok := templateDefaults[template]
+	if !ok {
+		return nil, false
+	}
+	result := make(map[string]any, len(defaults))
+	for key, value := range defaults {
+		result[key] = value
+	}
+	return result, true
+}
+
+// FieldStatus describes a config parameter compared to the template default
+type FieldStatus string
+
+const (
+	// FieldDefault is set to the template default
+	FieldDefault FieldStatus = "default"
+	// FieldOverridden differs from the template default
+	FieldOverridden FieldStatus = "overridden"
+	// FieldCustom has no template default, e.g. the host of a charger
+	FieldCustom FieldStatus = "custom"
+	// FieldImplicit is not set, so evcc uses the template default
+	FieldImplicit FieldStatus = "implicit"
+)
+
+// ConfigField is a parameter of a config value
+type ConfigField struct {
+	Key     string      `json:"key"`
+	Value   any         `json:"value,omitempty"`
+	Default any         `json:"default,omitempty"`
+	Status  FieldStatus `json:"status"`
+}
+
+// ConfigDiff compares the parameters of a config with the defaults of its template
+type ConfigDiff struct {
+	ID       int            `json:"id"`
+	Class    ConfigClass    `json:"class"`
+	Title    string         `json:"title,omitempty"`
+	Values   map[string]any `json:"values"`
+	Template string         `json:"template,omitempty"`
+	// KnownTemplate is false if no defaults are registered for the template
+	KnownTemplate bool          `json:"known_template"`
+	Fields        []ConfigField `json:"fields"`
+}
+
+// DiffConfig compares the config of a class matching name with the defaults of its template
+func (c *Client) DiffConfig(ctx context.Context, class ConfigClass, name string) (*ConfigDiff, error) {
+	cfg, err := c.FindConfig(ctx, class, name)
+	if err != nil {
+		return nil, err
+	}
+
+	var values map[string]any
+	if err := json.Unmarshal([]byte(cfg.Value), &values); err != nil {
+		return nil, fmt.Errorf("config %s%d has no JSON value: %w", dbRefPrefix, cfg.ID, err)
+	}
+
+	d := &ConfigDiff{ID: cfg.ID, Class: cfg.Class, Title: cfg.Title, Values: values}
+	d.Template, _ = values["template"].(string)
+	defaults, ok := TemplateDefaults(ResolveTemplate(d.Template))
+	d.KnownTemplate = ok
+
+	for key, value := range values {
+		if key == "template" {
+			continue
+		}
+		field := ConfigField{Key: key, Value: value, Status: FieldCustom}
+		if def, ok := defaults[key]; ok {
+			field.Default = def
+			field.Status = FieldOverridden
+			if settingString(value) == settingString(def) {
+				field.Status = FieldDefault
+			}
+		}
+		d.Fields = append(d.Fields, field)
+	}
+	for key, def := range defaults {
+		if _, ok := values[key]; !ok {
+			d.Fields = append(d.Fields, ConfigField{Key: key, Default: def, Status: FieldImplicit})
+		}
+	}
+
+	sort.Slice(d.Fields, func(i, j int) bool { return d.Fields[i].Key < d.Fields[j].Key })
+	return d, nil
+}
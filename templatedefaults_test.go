@@ -0,0 +1,53 @@
+package evccdb
+
+import (
+	"context"
+	"testing"
+)
+
+func TestDiffConfig(t *testing.T) {
+	client, cleanup := createTestDB(t)
+	defer cleanup()
+
+	_, _ = client.db.Exec(`INSERT INTO configs (id, class, type, value) VALUES
+		(3, 3, 'template', '{"template":"vw","title":"ID.3","user":"me","cache":"5m","timeout":"10s"}')`)
+
+	d, err := client.DiffConfig(context.Background(), ClassVehicle, "ID.3")
+	if err != nil {
+		t.Fatalf("DiffConfig failed: %v", err)
+	}
+	if !d.KnownTemplate || d.Template != "vw" {
+		t.Fatalf("Expected known template vw, got %q", d.Template)
+	}
+
+	expected := map[string]FieldStatus{
+		"cache":   FieldOverridden,
+		"timeout": FieldDefault,
+		"title":   FieldCustom,
+		"user":    FieldCustom,
+	}
+	if len(d.Fields) != len(expected) {
+		t.Fatalf("Expected %d fields, got %+v", len(expected), d.Fields)
+	}
+	for _, f := range d.Fields {
+		if f.Status != expected[f.Key] {
+			t.Errorf("Expected %s to be %s, got %s", f.Key, expected[f.Key], f.Status)
+		}
+	}
+
+	RegisterTemplateDefaults("test-charger", map[string]any{"timeout": "20s"})
+	t.Cleanup(func() {
+		templateDefaultsMu.Lock()
+		delete(templateDefaults, "test-charger")
+		templateDefaultsMu.Unlock()
+	})
+	_, _ = client.db.Exec(`INSERT INTO configs (id, class, type, value) VALUES (4, 1, 'template', '{"template":"test-charger","host":"wallbox"}')`)
+
+	d, err = client.DiffConfig(context.Background(), ClassCharger, "db:4")
+	if err != nil {
+		t.Fatalf("DiffConfig failed: %v", err)
+	}
+	if len(d.Fields) != 2 || d.Fields[1].Key != "timeout" || d.Fields[1].Status != FieldImplicit {
+		t.Errorf("Expected the implicit timeout default, got %+v", d.Fields)
+	}
+}
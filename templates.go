@@ -0,0 +1,117 @@
+package evccdb
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// TemplateAlias maps a device template renamed by evcc to its new name
+type TemplateAlias struct {
+	From string
+	To   string
+}
+
+// templateAliases holds the built-in template renames, the config file adds more
+var (
+	templateAliasesMu sync.RWMutex
+	templateAliases   = map[string]string{}
+)
+
+// RegisterTemplateAlias adds or replaces the alias of a template name
+func RegisterTemplateAlias(a TemplateAlias) {
+	templateAliasesMu.Lock()
+	defer templateAliasesMu.Unlock()
+	templateAliases[a.From] = a.To
+}
+
+// TemplateAliases returns the registered template aliases sorted by old name
+func TemplateAliases() []TemplateAlias {
+	templateAliasesMu.RLock()
+	defer templateAliasesMu.RUnlock()
+
+	aliases := make([]TemplateAlias, 0, len(templateAliases))
+	for from, to := range templateAliases {
+		aliases = append(aliases, TemplateAlias{From: from, To: to})
+	}
+	sort.Slice(aliases, func(i, j int) bool { return aliases[i].From < aliases[j].From })
+	return aliases
+}
+
+// ResolveTemplate returns the current name of a template, following chained renames
+func ResolveTemplate(name string) string {
+	templateAliasesMu.RLock()
+	defer templateAliasesMu.RUnlock()
+
+	seen := map[string]bool{name: true}
+	for {
+		to, ok := templateAliases[name]
+		if !ok || seen[to] {
+			return name
+		}
+		seen[to] = true
+		name = to
+	}
+}
+
+// remapTemplates rewrites renamed templates in the configs table and returns the number of updated configs
+func remapTemplates(ctx context.Context, tx interface {
+	querier
+	ExecContext(context.Context, string, ...any) (sql.Result, error)
+}, onAlias func(id int, from, to string)) (int, error) {
+	rows, err := tx.QueryContext(ctx, "SELECT id, value FROM configs")
+	if err != nil {
+		return 0, fmt.Errorf("failed to query configs: %w", err)
+	}
+
+	type update struct {
+		id       int
+		from, to string
+		value    string
+	}
+	var updates []update
+	for rows.Next() {
+		var id int
+		var value sql.NullString
+		if err := rows.Scan(&id, &value); err != nil {
+			_ = rows.Close()
+			return 0, fmt.Errorf("failed to scan config: %w", err)
+		}
+
+		var data map[string]any
+		if json.Unmarshal([]byte(value.String), &data) != nil {
+			continue
+		}
+		from, _ := data["template"].(string)
+		to := ResolveTemplate(from)
+		if from == "" || to == from {
+			continue
+		}
+
+		data["template"] = to
+		b, err := json.Marshal(data)
+		if err != nil {
+			_ = rows.Close()
+			return 0, err
+		}
+		updates = append(updates, update{id: id, from: from, to: to, value: string(b)})
+	}
+	_ = rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	for _, u := range updates {
+		if _, err := tx.ExecContext(ctx, "UPDATE configs SET value = ? WHERE id = ?", u.value, u.id); err != nil {
+			return 0, fmt.Errorf("failed to update config %d: %w", u.id, err)
+		}
+		if onAlias != nil {
+			onAlias(u.id, u.from, u.to)
+		}
+	}
+
+	return len(updates), nil
+}
@@ -0,0 +1,62 @@
+package evccdb
+
+import (
+	"context"
+	"testing"
+)
+
+func TestResolveTemplate(t *testing.T) {
+	RegisterTemplateAlias(TemplateAlias{From: "test-old", To: "test-mid"})
+	RegisterTemplateAlias(TemplateAlias{From: "test-mid", To: "test-new"})
+	RegisterTemplateAlias(TemplateAlias{From: "test-loop-a", To: "test-loop-b"})
+	RegisterTemplateAlias(TemplateAlias{From: "test-loop-b", To: "test-loop-a"})
+
+	tests := map[string]string{
+		"test-old":    "test-new",
+		"test-mid":    "test-new",
+		"test-new":    "test-new",
+		"test-loop-a": "test-loop-b",
+	}
+	for name, want := range tests {
+		if got := ResolveTemplate(name); got != want {
+			t.Errorf("ResolveTemplate(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestTransferRemapsTemplates(t *testing.T) {
+	RegisterTemplateAlias(TemplateAlias{From: "test-charger-v1", To: "test-charger"})
+
+	src, cleanup := createTestDB(t)
+	defer cleanup()
+	if _, err := src.db.Exec(`INSERT INTO configs (id, class, type, value) VALUES (3, 1, 'template', '{"template":"test-charger-v1","token":"secret"}')`); err != nil {
+		t.Fatalf("Failed to insert config: %v", err)
+	}
+
+	dst, cleanupDst := createEmptyDB(t, `
+		CREATE TABLE settings (key TEXT PRIMARY KEY, value TEXT);
+		CREATE TABLE configs (id INTEGER PRIMARY KEY, class INTEGER, type TEXT, value TEXT, title TEXT, icon TEXT, product TEXT);
+		CREATE TABLE caches (key TEXT PRIMARY KEY, value TEXT);
+	`)
+	defer cleanupDst()
+
+	var renamed []string
+	opts := TransferOptions{
+		Mode:            TransferConfig,
+		OnTemplateAlias: func(id int, from, to string) { renamed = append(renamed, from) },
+	}
+	if err := Transfer(context.Background(), src, dst, opts); err != nil {
+		t.Fatalf("Transfer failed: %v", err)
+	}
+
+	var value string
+	if err := dst.db.QueryRow("SELECT value FROM configs WHERE id = 3").Scan(&value); err != nil {
+		t.Fatalf("Failed to read config: %v", err)
+	}
+	if value != `{"template":"test-charger","token":"secret"}` {
+		t.Errorf("Unexpected config value: %s", value)
+	}
+	if len(renamed) != 1 || renamed[0] != "test-charger-v1" {
+		t.Errorf("Unexpected renamed templates: %v", renamed)
+	}
+}
@@ -2,6 +2,7 @@ package evccdb
 
 import (
 	"os"
+	"path/filepath"
 	"testing"
 )
 
@@ -94,3 +95,21 @@ func createTestDB(t *testing.T) (*Client, func()) {
 
 	return client, cleanup
 }
+
+// createEmptyDB creates a temporary database with the given schema and no data
+func createEmptyDB(t *testing.T, schema string) (*Client, func()) {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "evccdb-empty.db")
+	client, err := Open(path)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+
+	if _, err := client.db.Exec(schema); err != nil {
+		_ = client.Close()
+		t.Fatalf("Failed to create schema: %v", err)
+	}
+
+	return client, func() { _ = client.Close() }
+}
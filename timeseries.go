@@ -0,0 +1,116 @@
+package evccdb
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/iseeberg79/evccdb/internal/sqlbuild"
+)
+
+// TimePoint is a value at a point in time
+type TimePoint struct {
+	Time  time.Time
+	Value float64
+}
+
+// sessionSeriesColumns are the sessions columns available as time series
+var sessionSeriesColumns = []string{"charged_kwh", "charge_duration", "odometer", "price", "price_per_kwh", "co2_per_kwh", "solar_percentage"}
+
+// TimeSeriesTargets lists the available time series: sessions.<column> for
+// session values and meter.<id> for the readings of each meter
+func (c *Client) TimeSeriesTargets(ctx context.Context) ([]string, error) {
+	var targets []string
+	for _, col := range sessionSeriesColumns {
+		targets = append(targets, "sessions."+col)
+	}
+
+	exists, err := c.TableExists("meters")
+	if err != nil || !exists {
+		return targets, err
+	}
+
+	rows, err := c.db.QueryContext(ctx, "SELECT DISTINCT meter FROM meters ORDER BY meter")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query meters: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	for rows.Next() {
+		var meter int64
+		if err := rows.Scan(&meter); err != nil {
+			return nil, fmt.Errorf("failed to scan meter: %w", err)
+		}
+		targets = append(targets, fmt.Sprintf("meter.%d", meter))
+	}
+
+	return targets, rows.Err()
+}
+
+// TimeSeries returns the points of a target between from and to, ordered by time
+func (c *Client) TimeSeries(ctx context.Context, target string, from, to time.Time) ([]TimePoint, error) {
+	kind, name, ok := strings.Cut(target, ".")
+	if !ok {
+		return nil, fmt.Errorf("invalid target %q", target)
+	}
+
+	var query string
+	var args []any
+	switch kind {
+	case "sessions":
+		found := false
+		for _, col := range sessionSeriesColumns {
+			found = found || col == name
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown sessions series %q", name)
+		}
+		col, err := sqlbuild.QuoteIdent(name)
+		if err != nil {
+			return nil, err
+		}
+		query = fmt.Sprintf("SELECT created, %s FROM sessions WHERE %s IS NOT NULL AND created >= ? AND created <= ?", col, col)
+	case "meter":
+		meter, err := strconv.ParseInt(name, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid meter %q", name)
+		}
+		query = "SELECT ts, val FROM meters WHERE meter = ? AND val IS NOT NULL AND ts >= ? AND ts <= ?"
+		args = append(args, meter)
+	default:
+		return nil, fmt.Errorf("unknown target %q", target)
+	}
+
+	// Stored timestamps differ in zone offset, so the range is widened for the
+	// text comparison in SQL and applied exactly after parsing
+	args = append(args, from.UTC().AddDate(0, 0, -1).Format("2006-01-02"), to.UTC().AddDate(0, 0, 1).Format("2006-01-02"))
+
+	rows, err := c.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query %s: %w", target, err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var points []TimePoint
+	for rows.Next() {
+		var ts any
+		var p TimePoint
+		if err := rows.Scan(&ts, &p.Value); err != nil {
+			return nil, fmt.Errorf("failed to scan %s: %w", target, err)
+		}
+
+		if p.Time, ok = timestampOf(ts); !ok || p.Time.Before(from) || p.Time.After(to) {
+			continue
+		}
+		points = append(points, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(points, func(i, j int) bool { return points[i].Time.Before(points[j].Time) })
+	return points, nil
+}
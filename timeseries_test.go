@@ -0,0 +1,53 @@
+package evccdb
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestTimeSeries(t *testing.T) {
+	client, cleanup := createTestDB(t)
+	defer cleanup()
+
+	_, _ = client.db.Exec(`INSERT INTO meters (meter, ts, val) VALUES
+		(1, '2023-04-01 10:00:00+02:00', 1.5),
+		(1, '2023-04-01 09:15:00', 2.5),
+		(1, '2023-04-03 10:00:00', 3.5),
+		(2, '2023-04-01 10:00:00', 9.0)`)
+	_, _ = client.db.Exec("UPDATE sessions SET charged_kwh = id")
+
+	ctx := context.Background()
+	targets, err := client.TimeSeriesTargets(ctx)
+	if err != nil {
+		t.Fatalf("TimeSeriesTargets failed: %v", err)
+	}
+	if targets[len(targets)-1] != "meter.2" {
+		t.Errorf("Expected meter targets, got %v", targets)
+	}
+
+	from := time.Date(2023, 4, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2023, 4, 2, 0, 0, 0, 0, time.UTC)
+
+	points, err := client.TimeSeries(ctx, "meter.1", from, to)
+	if err != nil {
+		t.Fatalf("TimeSeries failed: %v", err)
+	}
+	if len(points) != 2 || points[0].Value != 1.5 || points[1].Value != 2.5 {
+		t.Errorf("Unexpected meter points: %v", points)
+	}
+
+	points, err = client.TimeSeries(ctx, "sessions.charged_kwh", from, to.AddDate(0, 0, 2))
+	if err != nil {
+		t.Fatalf("TimeSeries failed: %v", err)
+	}
+	if len(points) != 3 {
+		t.Errorf("Expected 3 session points, got %v", points)
+	}
+
+	for _, target := range []string{"sessions.loadpoint", "meter.x", "configs"} {
+		if _, err := client.TimeSeries(ctx, target, from, to); err == nil {
+			t.Errorf("Expected error for target %q", target)
+		}
+	}
+}
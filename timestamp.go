@@ -0,0 +1,96 @@
+package evccdb
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// sqliteTimestampFormat is the format the SQLite driver writes time values in
+const sqliteTimestampFormat = "2006-01-02 15:04:05.999999999-07:00"
+
+// zonedTimestampLayouts are timestamp formats that carry a zone offset
+var zonedTimestampLayouts = []string{
+	"2006-01-02 15:04:05.999999999Z07:00",
+	time.RFC3339Nano,
+}
+
+// localTimestampLayouts are timestamp formats without zone offset
+var localTimestampLayouts = []string{
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04",
+	"2006-01-02T15:04",
+	"2006-01-02",
+}
+
+// ParseTimestamp parses a timestamp as stored by evcc. Timestamps without
+// zone offset are interpreted in loc, or UTC if loc is nil.
+func ParseTimestamp(s string, loc *time.Location) (time.Time, error) {
+	if loc == nil {
+		loc = time.UTC
+	}
+
+	s = strings.TrimSpace(s)
+	if t, ok := parseZonedTimestamp(s); ok {
+		return t, nil
+	}
+	for _, layout := range localTimestampLayouts {
+		if t, err := time.ParseInLocation(layout, s, loc); err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("invalid timestamp %q", s)
+}
+
+// parseZonedTimestamp parses a timestamp with zone offset
+func parseZonedTimestamp(s string) (time.Time, bool) {
+	for _, layout := range zonedTimestampLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// FormatTimestamp formats a time as RFC3339 in UTC
+func FormatTimestamp(t time.Time) string {
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+// normalizeTimestamp converts a timestamp value to RFC3339 UTC, values that
+// are no timestamps are returned unchanged. Timestamps without zone offset are
+// interpreted in loc and also returned unchanged if loc is nil.
+func normalizeTimestamp(val any, loc *time.Location) any {
+	switch v := val.(type) {
+	case time.Time:
+		return FormatTimestamp(v)
+	case string:
+		if t, ok := parseZonedTimestamp(strings.TrimSpace(v)); ok {
+			return FormatTimestamp(t)
+		}
+		if loc == nil {
+			return val
+		}
+		if t, err := ParseTimestamp(v, loc); err == nil {
+			return FormatTimestamp(t)
+		}
+	}
+	return val
+}
+
+// storeTimestamp converts a timestamp value to the format written by the
+// SQLite driver, values that are no timestamps are returned unchanged
+func storeTimestamp(val any, loc *time.Location) any {
+	s, ok := val.(string)
+	if !ok {
+		return val
+	}
+
+	t, err := ParseTimestamp(s, loc)
+	if err != nil {
+		return val
+	}
+	return t.UTC().Format(sqliteTimestampFormat)
+}
@@ -0,0 +1,145 @@
+package evccdb
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestParseTimestamp(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skipf("time zone database not available: %v", err)
+	}
+
+	want := time.Date(2023, 4, 1, 8, 0, 0, 0, time.UTC)
+	tests := []struct {
+		input string
+		loc   *time.Location
+	}{
+		{"2023-04-01 08:00:00", nil},
+		{"2023-04-01 10:00:00", berlin},
+		{"2023-04-01 10:00:00+02:00", nil},
+		{"2023-04-01 10:00:00.000+02:00", berlin},
+		{"2023-04-01T08:00:00Z", berlin},
+		{"2023-04-01T10:00:00", berlin},
+	}
+
+	for _, tt := range tests {
+		result, err := ParseTimestamp(tt.input, tt.loc)
+		if err != nil {
+			t.Errorf("ParseTimestamp(%q) failed: %v", tt.input, err)
+			continue
+		}
+		if !result.Equal(want) {
+			t.Errorf("ParseTimestamp(%q) = %v, want %v", tt.input, result, want)
+		}
+	}
+
+	if _, err := ParseTimestamp("yesterday", nil); err == nil {
+		t.Error("Expected error for invalid timestamp")
+	}
+}
+
+func TestExportNormalizesTimestamps(t *testing.T) {
+	client, cleanup := createTestDB(t)
+	defer cleanup()
+
+	_, _ = client.db.Exec("UPDATE sessions SET created = '2023-04-01 12:00:00+02:00' WHERE id = 1")
+
+	var buf bytes.Buffer
+	if err := client.ExportJSON(&buf, TransferOptions{Tables: []string{"sessions"}}); err != nil {
+		t.Fatalf("ExportJSON failed: %v", err)
+	}
+
+	var export struct {
+		Tables map[string][]map[string]any `json:"tables"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &export); err != nil {
+		t.Fatalf("Failed to unmarshal exported JSON: %v", err)
+	}
+
+	for _, row := range export.Tables["sessions"] {
+		if row["id"] == float64(1) && row["created"] != "2023-04-01T10:00:00Z" {
+			t.Errorf("Expected created normalized to UTC, got %v", row["created"])
+		}
+	}
+}
+
+func TestExportTimezone(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skipf("time zone database not available: %v", err)
+	}
+
+	client, cleanup := createTestDB(t)
+	defer cleanup()
+
+	// Older evcc versions store local time without zone offset
+	_, _ = client.db.Exec("UPDATE sessions SET created = '2023-04-01 12:00:00' WHERE id = 1")
+
+	for _, tt := range []struct {
+		loc      *time.Location
+		expected string
+	}{
+		{nil, "2023-04-01 12:00:00"},
+		{berlin, "2023-04-01T10:00:00Z"},
+	} {
+		var buf bytes.Buffer
+		if err := client.ExportJSON(&buf, TransferOptions{Tables: []string{"sessions"}, Location: tt.loc}); err != nil {
+			t.Fatalf("ExportJSON failed: %v", err)
+		}
+
+		var export struct {
+			Tables map[string][]map[string]any `json:"tables"`
+		}
+		if err := json.Unmarshal(buf.Bytes(), &export); err != nil {
+			t.Fatalf("Failed to unmarshal exported JSON: %v", err)
+		}
+		for _, row := range export.Tables["sessions"] {
+			if row["id"] == float64(1) && row["created"] != tt.expected {
+				t.Errorf("Expected created %s with location %v, got %v", tt.expected, tt.loc, row["created"])
+			}
+		}
+
+		// Without a zone the import can still interpret the timestamp
+		dst, dstCleanup := createEmptyDB(t, sessionsSchema)
+		if err := dst.ImportJSON(&buf, TransferOptions{Tables: []string{"sessions"}, Location: berlin}); err != nil {
+			t.Fatalf("ImportJSON failed: %v", err)
+		}
+		var created time.Time
+		if err := dst.db.QueryRow("SELECT created FROM sessions WHERE id = 1").Scan(&created); err != nil {
+			t.Fatalf("Failed to read imported session: %v", err)
+		}
+		if want := time.Date(2023, 4, 1, 10, 0, 0, 0, time.UTC); !created.Equal(want) {
+			t.Errorf("Expected created %v with location %v, got %v", want, tt.loc, created)
+		}
+		dstCleanup()
+	}
+}
+
+func TestImportTimezone(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skipf("time zone database not available: %v", err)
+	}
+
+	data := `{"version":"1","tables":{"sessions":[{"id":1,"created":"2023-04-01 10:00:00","loadpoint":"Garage"}]}}`
+
+	client, cleanup := createEmptyDB(t, sessionsSchema)
+	defer cleanup()
+
+	opts := TransferOptions{Tables: []string{"sessions"}, Location: berlin}
+	if err := client.ImportJSON(bytes.NewReader([]byte(data)), opts); err != nil {
+		t.Fatalf("ImportJSON failed: %v", err)
+	}
+
+	var created time.Time
+	if err := client.db.QueryRow("SELECT created FROM sessions WHERE id = 1").Scan(&created); err != nil {
+		t.Fatalf("Failed to read imported session: %v", err)
+	}
+	if want := time.Date(2023, 4, 1, 8, 0, 0, 0, time.UTC); !created.Equal(want) {
+		t.Errorf("Expected created %v, got %v", want, created)
+	}
+}
@@ -4,140 +4,501 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"io"
+	"os"
+	"slices"
+	"sort"
 	"strings"
+	"time"
+
+	"github.com/iseeberg79/evccdb/internal/sqlbuild"
 )
 
 // Transfer transfers data from source to destination database based on options
 func Transfer(ctx context.Context, src, dst *Client, opts TransferOptions) error {
+	if err := CheckDistinct(src, dst); err != nil {
+		return err
+	}
+
 	tables, err := src.ResolveTables(opts)
 	if err != nil {
 		return fmt.Errorf("failed to resolve tables: %w", err)
 	}
 
 	if opts.DryRun {
-		fmt.Printf("DRY RUN: Would transfer %d tables\n", len(tables))
-		for _, table := range tables {
-			exists, err := dst.TableExists(table)
-			if err != nil {
-				return err
-			}
-			if !exists {
-				fmt.Printf("  WARNING: Table %s does not exist in destination\n", table)
-				continue
+		plan, err := PlanTransfer(ctx, src, dst, opts)
+		if err != nil {
+			return err
+		}
+		fmt.Printf(Translate("DRY RUN: Would transfer %d tables\n"), len(tables))
+		plan.Print(os.Stdout)
+		if opts.Clone {
+			if err := CheckClone(src, dst, opts); err != nil {
+				fmt.Printf("Cannot clone: %v\n", err)
+			} else {
+				fmt.Println("Destination can be cloned")
 			}
+		}
+		return nil
+	}
 
-			count, err := src.GetRowCount(table)
-			if err != nil {
-				return err
+	if opts.Clone {
+		return cloneTransfer(ctx, src, dst, tables, opts)
+	}
+
+	// Referenced tables are copied first
+	tables, err = dst.OrderTablesByDependencies(tables)
+	if err != nil {
+		return fmt.Errorf("failed to order tables: %w", err)
+	}
+
+	if slices.Contains(tables, "sessions") {
+		if opts.priceRate, err = mergePriceRate(ctx, src, dst.db, opts.ConvertCurrency); err != nil {
+			return err
+		}
+	}
+
+	// Start a transaction on destination, attaching the source file for the fast path
+	var attach *Client
+	if opts.Attach && !opts.Incremental && opts.TimeOffset == 0 && !opts.guestOptions() && !opts.convertsPrices() && !opts.StripInstanceIdentity && len(opts.Classes) == 0 && opts.MaxRowsPerTable == 0 && opts.SettingsMerge == MergeReplace && opts.OnSettingConflict == nil && src.attachable() {
+		attach = src
+	}
+	tx, err := dst.beginConn(ctx, attach, opts.Fast)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var tracker *progressTracker
+	if opts.OnRowProgress != nil {
+		total := 0
+		for _, table := range tables {
+			if count, err := src.GetRowCount(table); err == nil {
+				total += count
 			}
-			fmt.Printf("  %s: %d rows\n", table, count)
 		}
+		tracker = newProgressTracker(opts.OnRowProgress, total)
+	}
 
-		// Show rename previews
-		for _, rename := range opts.LoadpointRenames {
-			result, err := src.RenameLoadpointDryRun(ctx, rename.OldName, rename.NewName)
-			if err != nil {
-				return err
+	if err := deferForeignKeys(ctx, tx.tx, opts); err != nil {
+		return err
+	}
+	if err := runSQL(ctx, tx.tx, "pre", opts.PreSQL); err != nil {
+		return err
+	}
+
+	var written []string
+	for _, table := range tables {
+		exists, err := tableExists(ctx, tx.tx, table)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			fmt.Printf(Translate("WARNING: Table %s does not exist in destination, skipping\n"), table)
+			continue
+		}
+		written = append(written, table)
+	}
+	if err := truncateTables(ctx, tx.tx, written, opts); err != nil {
+		return err
+	}
+
+	for _, table := range written {
+		var count int
+		if tx.attached {
+			if count, err = copyTableAttached(ctx, tx, src, dst, table); err == nil {
+				tracker.add(table, count)
 			}
-			fmt.Printf("  Loadpoint rename %q -> %q: sessions=%d, settings=%d, configs=%d\n",
-				rename.OldName, rename.NewName, result.Sessions, result.Settings, result.Configs)
+		} else {
+			count, err = copyTableWithTx(ctx, tx.tx, src, dst, table, opts, tracker)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to copy table %s: %w", table, err)
 		}
 
-		for _, rename := range opts.VehicleRenames {
-			result, err := src.RenameVehicleDryRun(ctx, rename.OldName, rename.NewName)
-			if err != nil {
+		if table == "configs" && !opts.KeepTemplates {
+			if _, err := remapTemplates(ctx, tx.tx, opts.OnTemplateAlias); err != nil {
 				return err
 			}
-			fmt.Printf("  Vehicle rename %q -> %q: sessions=%d, settings=%d, configs=%d\n",
-				rename.OldName, rename.NewName, result.Sessions, result.Settings, result.Configs)
 		}
 
+		if opts.OnProgress != nil {
+			opts.OnProgress(table, count)
+		}
+	}
+
+	// Apply renames within the same transaction
+	for _, rename := range opts.LoadpointRenames {
+		if _, err := tx.RenameLoadpoint(ctx, rename.OldName, rename.NewName); err != nil {
+			return fmt.Errorf("failed to rename loadpoint %q to %q: %w", rename.OldName, rename.NewName, err)
+		}
+	}
+
+	for _, rename := range opts.VehicleRenames {
+		if _, err := tx.RenameVehicle(ctx, rename.OldName, rename.NewName); err != nil {
+			return fmt.Errorf("failed to rename vehicle %q to %q: %w", rename.OldName, rename.NewName, err)
+		}
+	}
+
+	if err := runSQL(ctx, tx.tx, "post", opts.PostSQL); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// deferForeignKeys postpones foreign key checks until commit if requested
+func deferForeignKeys(ctx context.Context, tx interface {
+	ExecContext(context.Context, string, ...any) (sql.Result, error)
+}, opts TransferOptions) error {
+	if !opts.DeferForeignKeys {
 		return nil
 	}
+	if _, err := tx.ExecContext(ctx, "PRAGMA defer_foreign_keys = ON"); err != nil {
+		return fmt.Errorf("failed to defer foreign keys: %w", err)
+	}
+	return nil
+}
 
-	// Start a transaction on destination
-	tx, err := dst.db.BeginTx(ctx, nil)
+// runSQL executes the statements of a pre or post SQL hook, an empty script is a no-op
+func runSQL(ctx context.Context, tx interface {
+	ExecContext(context.Context, string, ...any) (sql.Result, error)
+}, stage, script string) error {
+	if strings.TrimSpace(script) == "" {
+		return nil
+	}
+	if _, err := tx.ExecContext(ctx, script); err != nil {
+		return fmt.Errorf("failed to run %s SQL: %w", stage, err)
+	}
+	return nil
+}
+
+// TruncateAll in TransferOptions.Truncate deletes the destination rows of all written tables
+const TruncateAll = "*"
+
+// truncates reports whether the destination rows of a table are deleted before writing
+func (opts TransferOptions) truncates(table string) bool {
+	return slices.Contains(opts.Truncate, TruncateAll) || slices.Contains(opts.Truncate, table)
+}
+
+// truncateTables deletes the destination rows of the tables to write that opts.Truncate
+// names. The tables are in write order, so referencing tables are emptied first.
+func truncateTables(ctx context.Context, tx *sql.Tx, tables []string, opts TransferOptions) error {
+	for i := len(tables) - 1; i >= 0; i-- {
+		if !opts.truncates(tables[i]) {
+			continue
+		}
+		name, err := sqlbuild.QuoteIdent(tables[i])
+		if err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, "DELETE FROM "+name); err != nil {
+			return fmt.Errorf("failed to truncate table %s: %w", tables[i], err)
+		}
+	}
+	return nil
+}
+
+// TablePlan describes what a transfer would do with a single table
+type TablePlan struct {
+	Table          string
+	Rows           int
+	Missing        bool
+	SkippedColumns []string
+	Conflicts      []string // primary keys of destination rows that would be replaced with different content
+	Samples        []RowDiff
+}
+
+// RowDiff is the field-level difference of a destination row that a transfer would replace
+type RowDiff struct {
+	Key     string
+	Changes []FieldChange
+}
+
+// FieldChange is a column whose destination value would be replaced by the source value
+type FieldChange struct {
+	Column string
+	From   string
+	To     string
+}
+
+// RenamePlan describes the effect of a rename applied after a transfer
+type RenamePlan struct {
+	RenameMapping
+	Result RenameResult
+}
+
+// TransferPlan is the computed set of actions of a transfer
+type TransferPlan struct {
+	Tables           []TablePlan
+	LoadpointRenames []RenamePlan
+	VehicleRenames   []RenamePlan
+	// Suggestions are likely renames of names the destination does not know
+	Suggestions RenameSuggestions
+}
+
+// PlanTransfer computes what Transfer would do without making changes
+func PlanTransfer(ctx context.Context, src, dst *Client, opts TransferOptions) (*TransferPlan, error) {
+	if err := CheckDistinct(src, dst); err != nil {
+		return nil, err
+	}
+
+	tables, err := src.ResolveTables(opts)
 	if err != nil {
-		return fmt.Errorf("failed to begin transaction: %w", err)
+		return nil, fmt.Errorf("failed to resolve tables: %w", err)
 	}
-	defer func() { _ = tx.Rollback() }()
 
+	plan := &TransferPlan{}
 	for _, table := range tables {
 		exists, err := dst.TableExists(table)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		if !exists {
-			fmt.Printf("WARNING: Table %s does not exist in destination, skipping\n", table)
+			plan.Tables = append(plan.Tables, TablePlan{Table: table, Missing: true})
 			continue
 		}
 
-		count, err := copyTableWithTx(ctx, tx, src, dst, table)
+		count, err := src.GetRowCount(table)
 		if err != nil {
-			return fmt.Errorf("failed to copy table %s: %w", table, err)
+			return nil, err
 		}
 
-		if opts.OnProgress != nil {
-			opts.OnProgress(table, count)
+		srcCols, err := src.GetTableColumns(table)
+		if err != nil {
+			return nil, err
 		}
-	}
+		dstCols, err := dst.GetTableColumns(table)
+		if err != nil {
+			return nil, err
+		}
+		common := intersectColumns(srcCols, dstCols)
 
-	if err := tx.Commit(); err != nil {
-		return err
+		tp := TablePlan{Table: table, Rows: count}
+		for _, col := range srcCols {
+			if !containsColumn(common, col.Name) {
+				tp.SkippedColumns = append(tp.SkippedColumns, col.Name)
+			}
+		}
+
+		keys, err := findConflicts(ctx, src, dst, table, common)
+		if err != nil {
+			return nil, err
+		}
+		for _, key := range keys {
+			tp.Conflicts = append(tp.Conflicts, strings.ReplaceAll(key, "\x1f", ", "))
+		}
+
+		if opts.SampleRows > 0 {
+			tp.Samples, err = sampleDiffs(ctx, src, dst, table, common, keys[:min(opts.SampleRows, len(keys))])
+			if err != nil {
+				return nil, err
+			}
+		}
+		plan.Tables = append(plan.Tables, tp)
 	}
 
-	// Apply renames after transfer completes
 	for _, rename := range opts.LoadpointRenames {
-		if _, err := dst.RenameLoadpoint(ctx, rename.OldName, rename.NewName); err != nil {
-			return fmt.Errorf("failed to rename loadpoint %q to %q: %w", rename.OldName, rename.NewName, err)
+		result, err := src.RenameLoadpointDryRun(ctx, rename.OldName, rename.NewName)
+		if err != nil {
+			return nil, err
 		}
+		plan.LoadpointRenames = append(plan.LoadpointRenames, RenamePlan{RenameMapping: rename, Result: result})
 	}
 
 	for _, rename := range opts.VehicleRenames {
-		if _, err := dst.RenameVehicle(ctx, rename.OldName, rename.NewName); err != nil {
-			return fmt.Errorf("failed to rename vehicle %q to %q: %w", rename.OldName, rename.NewName, err)
+		result, err := src.RenameVehicleDryRun(ctx, rename.OldName, rename.NewName)
+		if err != nil {
+			return nil, err
 		}
+		plan.VehicleRenames = append(plan.VehicleRenames, RenamePlan{RenameMapping: rename, Result: result})
 	}
 
-	return nil
+	if plan.Suggestions, err = SuggestRenames(ctx, src, dst, opts); err != nil {
+		return nil, fmt.Errorf("failed to suggest renames: %w", err)
+	}
+
+	return plan, nil
 }
 
-// copyTableWithTx copies a table using a destination transaction
-func copyTableWithTx(ctx context.Context, tx interface {
-	ExecContext(context.Context, string, ...any) (sql.Result, error)
-}, src, dst *Client, table string) (int, error) {
-	// Get column information from both databases
-	srcCols, err := src.GetTableColumns(table)
+// Print writes a human-readable summary of the plan
+func (p *TransferPlan) Print(w io.Writer) {
+	for _, table := range p.Tables {
+		if table.Missing {
+			fmt.Fprintf(w, Translate("  WARNING: Table %s does not exist in destination\n"), table.Table)
+			continue
+		}
+		fmt.Fprintf(w, Translate("  %s: %d rows\n"), table.Table, table.Rows)
+		for _, col := range table.SkippedColumns {
+			fmt.Fprintf(w, Translate("  WARNING: Column %s.%s exists in source but not in destination, will be skipped\n"), table.Table, col)
+		}
+		if len(table.Conflicts) > 0 {
+			fmt.Fprintf(w, Translate("  WARNING: %d rows of %s would replace different destination rows: %s\n"),
+				len(table.Conflicts), table.Table, summarizeKeys(table.Conflicts, 10))
+		}
+		for _, diff := range table.Samples {
+			fmt.Fprintf(w, "    %s [%s]:\n", table.Table, diff.Key)
+			for _, c := range diff.Changes {
+				fmt.Fprintf(w, "      %s: %s -> %s\n", c.Column, c.From, c.To)
+			}
+		}
+	}
+
+	for _, rename := range p.LoadpointRenames {
+		fmt.Fprintf(w, "  Loadpoint rename %q -> %q: sessions=%d, settings=%d, configs=%d\n",
+			rename.OldName, rename.NewName, rename.Result.Sessions, rename.Result.Settings, rename.Result.Configs)
+	}
+
+	for _, rename := range p.VehicleRenames {
+		fmt.Fprintf(w, "  Vehicle rename %q -> %q: sessions=%d, settings=%d, configs=%d\n",
+			rename.OldName, rename.NewName, rename.Result.Sessions, rename.Result.Settings, rename.Result.Configs)
+	}
+
+	printSuggestions(w, "loadpoint", p.Suggestions.Loadpoints)
+	printSuggestions(w, "vehicle", p.Suggestions.Vehicles)
+}
+
+// printSuggestions writes the suggested renames with the flag applying them
+func printSuggestions(w io.Writer, kind string, suggestions []RenameSuggestion) {
+	if len(suggestions) == 0 {
+		return
+	}
+
+	pairs := make([]string, len(suggestions))
+	for i, s := range suggestions {
+		fmt.Fprintf(w, "  Suggested %s rename %q -> %q (%.0f%% similar)\n", kind, s.OldName, s.NewName, 100*s.Similarity)
+		pairs[i] = s.OldName + ":" + s.NewName
+	}
+	fmt.Fprintf(w, "  Apply with: --rename-%s %q\n", kind, strings.Join(pairs, ","))
+}
+
+// findConflicts returns the primary keys present in source and destination with differing content.
+// The values of composite keys are separated by \x1f.
+func findConflicts(ctx context.Context, src, dst *Client, table string, cols []ColumnInfo) ([]string, error) {
+	hasKey := false
+	for _, col := range cols {
+		hasKey = hasKey || col.Primary
+	}
+	if !hasKey {
+		return nil, nil
+	}
+
+	srcSums, err := rowChecksums(ctx, src.db, table, cols)
 	if err != nil {
-		return 0, err
+		return nil, err
+	}
+	dstSums, err := rowChecksums(ctx, dst.db, table, cols)
+	if err != nil {
+		return nil, err
 	}
 
-	dstCols, err := dst.GetTableColumns(table)
+	var conflicts []string
+	for key, sum := range srcSums {
+		if dstSum, exists := dstSums[key]; exists && dstSum != sum {
+			conflicts = append(conflicts, key)
+		}
+	}
+	sort.Strings(conflicts)
+
+	return conflicts, nil
+}
+
+// sampleDiffs compares the source and destination rows of the given primary keys field by field
+func sampleDiffs(ctx context.Context, src, dst *Client, table string, cols []ColumnInfo, keys []string) ([]RowDiff, error) {
+	query, err := sqlbuild.Select(table, columnNames(cols))
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
 
-	// Find common columns
-	commonCols := intersectColumns(srcCols, dstCols)
-	if len(commonCols) == 0 {
-		return 0, fmt.Errorf("no common columns found between source and destination for table %s", table)
+	var where []string
+	for _, col := range cols {
+		if col.Primary {
+			name, err := sqlbuild.QuoteIdent(col.Name)
+			if err != nil {
+				return nil, err
+			}
+			where = append(where, name+" = ?")
+		}
 	}
+	query += " WHERE " + strings.Join(where, " AND ")
 
-	// Check for columns in source that are missing in destination
-	srcColMap := make(map[string]bool)
-	for _, col := range srcCols {
-		srcColMap[col.Name] = true
+	var diffs []RowDiff
+	for _, key := range keys {
+		var args []any
+		for _, part := range strings.Split(key, "\x1f") {
+			args = append(args, part)
+		}
+
+		srcValues, err := scanRow(ctx, src.db, query, args, len(cols))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s row %s: %w", table, key, err)
+		}
+		dstValues, err := scanRow(ctx, dst.db, query, args, len(cols))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s row %s: %w", table, key, err)
+		}
+
+		diff := RowDiff{Key: strings.ReplaceAll(key, "\x1f", ", ")}
+		for i, col := range cols {
+			from, to := displayValue(dstValues[i]), displayValue(srcValues[i])
+			if from != to {
+				diff.Changes = append(diff.Changes, FieldChange{Column: col.Name, From: from, To: to})
+			}
+		}
+		diffs = append(diffs, diff)
 	}
-	dstColMap := make(map[string]bool)
-	for _, col := range dstCols {
-		dstColMap[col.Name] = true
+
+	return diffs, nil
+}
+
+// scanRow runs a query returning a single row of n columns
+func scanRow(ctx context.Context, q querier, query string, args []any, n int) ([]any, error) {
+	values := make([]any, n)
+	ptrs := make([]any, n)
+	for i := range values {
+		ptrs[i] = &values[i]
 	}
+	if err := q.QueryRowContext(ctx, query, args...).Scan(ptrs...); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
 
-	for _, col := range srcCols {
-		if !dstColMap[col.Name] {
-			fmt.Printf("WARNING: Column %s.%s exists in source but not in destination, will be skipped\n", table, col.Name)
+// displayValue renders a driver value for the plan output
+func displayValue(val any) string {
+	if val == nil {
+		return "NULL"
+	}
+	return checksumValue(val)
+}
+
+// summarizeKeys joins up to limit keys and notes how many were left out
+func summarizeKeys(keys []string, limit int) string {
+	if len(keys) <= limit {
+		return strings.Join(keys, "; ")
+	}
+	return fmt.Sprintf("%s; ... and %d more", strings.Join(keys[:limit], "; "), len(keys)-limit)
+}
+
+// containsColumn reports whether a column with the given name is in the list
+func containsColumn(cols []ColumnInfo, name string) bool {
+	for _, col := range cols {
+		if col.Name == name {
+			return true
 		}
 	}
+	return false
+}
+
+// copyTableWithTx copies a table using a destination transaction
+func copyTableWithTx(ctx context.Context, tx interface {
+	querier
+	PrepareContext(context.Context, string) (*sql.Stmt, error)
+}, src, dst *Client, table string, opts TransferOptions, tracker *progressTracker) (int, error) {
+	commonCols, dstCols, err := commonColumns(ctx, src, tx, table)
+	if err != nil {
+		return 0, err
+	}
 
 	// Get row count first
 	count, err := src.GetRowCount(table)
@@ -149,23 +510,80 @@ func copyTableWithTx(ctx context.Context, tx interface {
 		return 0, nil
 	}
 
+	// STRICT destinations reject values that cannot be converted losslessly
+	strict, err := isStrictTable(ctx, tx, table)
+	if err != nil {
+		return 0, err
+	}
+	dstTypes := make(map[string]string)
+	for _, col := range dstCols {
+		dstTypes[col.Name] = col.Type
+	}
+
+	// Incremental transfers skip rows whose content already matches the destination
+	var dstSums map[string]string
+	if opts.Incremental {
+		dstSums, err = rowChecksums(ctx, tx, table, commonCols)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	// Settings that exist in the destination may be kept depending on the merge mode
+	var merger *settingsMerger
+	if table == "settings" {
+		merger, err = newSettingsMerger(ctx, tx, opts, func() (time.Time, error) { return lastActivity(ctx, src.db) })
+		if err != nil {
+			return 0, err
+		}
+	}
+
 	// Build column names and copy rows using raw SQL from source
-	colNames := make([]string, len(commonCols))
-	colNameList := make([]string, len(commonCols))
-	for i, col := range commonCols {
-		colNames[i] = col.Name
-		colNameList[i] = fmt.Sprintf("`%s`", col.Name)
+	colNames := columnNames(commonCols)
+	keyIdx, valueIdx, vehicleIdx, classIdx := -1, -1, -1, -1
+	var priceIdx []int
+	for i, name := range colNames {
+		if table == "sessions" && opts.convertsPrices() && slices.Contains(priceColumns, name) {
+			priceIdx = append(priceIdx, i)
+		}
+		switch name {
+		case "key":
+			keyIdx = i
+		case "value":
+			valueIdx = i
+		case "vehicle":
+			if table == "sessions" {
+				vehicleIdx = i
+			}
+		case "class":
+			if table == "configs" {
+				classIdx = i
+			}
+		}
+	}
+	selectQuery, err := sqlbuild.Select(table, colNames)
+	if err != nil {
+		return 0, err
+	}
+	insertQuery, err := sqlbuild.Insert(table, colNames, true)
+	if err != nil {
+		return 0, err
 	}
+	insert, err := tx.PrepareContext(ctx, insertQuery)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prepare insert: %w", err)
+	}
+	defer func() { _ = insert.Close() }()
 
 	// Get all data from source and copy to destination
-	srcRows, err := src.db.QueryContext(ctx, fmt.Sprintf("SELECT %s FROM `%s`", strings.Join(colNameList, ", "), table))
+	srcRows, err := src.db.QueryContext(ctx, selectQuery)
 	if err != nil {
 		return 0, fmt.Errorf("failed to query source data: %w", err)
 	}
 	defer func() { _ = srcRows.Close() }()
 
 	copied := 0
-	for srcRows.Next() {
+	for row := 0; srcRows.Next(); row++ {
 		values := make([]any, len(colNames))
 		scanPtrs := make([]any, len(colNames))
 		for i := range colNames {
@@ -175,28 +593,142 @@ func copyTableWithTx(ctx context.Context, tx interface {
 		if err := srcRows.Scan(scanPtrs...); err != nil {
 			return copied, fmt.Errorf("failed to scan row: %w", err)
 		}
+		tracker.add(table, 1)
+
+		if vehicleIdx >= 0 && !opts.guestSession(&values[vehicleIdx]) {
+			continue
+		}
+		for _, i := range priceIdx {
+			values[i] = scalePrice(values[i], opts.priceRate)
+		}
 
-		// Build INSERT statement
-		placeholders := make([]string, len(colNames))
-		for i := range placeholders {
-			placeholders[i] = "?"
+		if opts.TimeOffset != 0 {
+			for i, name := range colNames {
+				if columnKindOf(dstTypes[name]) == kindTimestamp {
+					values[i] = shiftTimestamp(values[i], opts.TimeOffset)
+				}
+			}
 		}
 
-		insertSQL := fmt.Sprintf("INSERT OR REPLACE INTO `%s` (%s) VALUES (%s)",
-			table, strings.Join(colNameList, ", "), strings.Join(placeholders, ", "))
+		if keyIdx >= 0 && table == "settings" && (opts.stripSetting(settingString(values[keyIdx])) || !opts.classSetting(settingString(values[keyIdx]))) {
+			continue
+		}
+		if classIdx >= 0 && !opts.classConfig(values[classIdx]) {
+			continue
+		}
+		if merger != nil && keyIdx >= 0 && valueIdx >= 0 && !merger.write(settingString(values[keyIdx]), settingString(values[valueIdx])) {
+			continue
+		}
 
-		_, err := tx.ExecContext(ctx, insertSQL, values...)
+		if dstSums != nil {
+			key, sum := rowKeyChecksum(commonCols, values)
+			if dstSums[key] == sum {
+				continue
+			}
+		}
+
+		if strict {
+			for i, name := range colNames {
+				values[i] = coerceStrictValue(values[i], dstTypes[name])
+			}
+		}
+
+		_, err := insert.ExecContext(ctx, values...)
 		if err != nil {
-			return copied, fmt.Errorf("failed to insert row: %w", err)
+			return copied, fmt.Errorf("failed to insert row %d: %w", row, newRowError(table, row, colNames, values, err))
 		}
 
 		copied++
+		if copied == opts.MaxRowsPerTable {
+			break
+		}
 	}
 
 	return copied, srcRows.Err()
 }
 
-// intersectColumns finds the intersection of columns by name
+// copyTableAttached copies a table with a single INSERT ... SELECT from the attached source
+func copyTableAttached(ctx context.Context, tx *Tx, src, dst *Client, table string) (int, error) {
+	commonCols, _, err := commonColumns(ctx, src, tx.tx, table)
+	if err != nil {
+		return 0, err
+	}
+
+	name, err := sqlbuild.QuoteIdent(table)
+	if err != nil {
+		return 0, err
+	}
+	cols, err := sqlbuild.QuoteColumns(columnNames(commonCols))
+	if err != nil {
+		return 0, err
+	}
+
+	result, err := tx.tx.ExecContext(ctx, fmt.Sprintf("INSERT OR REPLACE INTO main.%s (%s) SELECT %s FROM %s.%s",
+		name, cols, cols, attachedSchema, name))
+	if err != nil {
+		return 0, fmt.Errorf("failed to copy rows: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	return int(affected), err
+}
+
+// commonColumns returns the columns to copy and the destination columns of a table,
+// warning about source columns missing in the destination. The destination columns
+// are read through dst, the transaction writing the table.
+func commonColumns(ctx context.Context, src *Client, dst querier, table string) ([]ColumnInfo, []ColumnInfo, error) {
+	// Get column information from both databases
+	srcCols, err := src.GetTableColumns(table)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	dstCols, err := tableColumns(ctx, dst, table)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Find common columns
+	commonCols := intersectColumns(srcCols, dstCols)
+	if len(commonCols) == 0 {
+		return nil, nil, fmt.Errorf("no common columns found between source and destination for table %s", table)
+	}
+
+	// Check for columns in source that are missing in destination
+	dstColMap := make(map[string]bool)
+	for _, col := range dstCols {
+		dstColMap[col.Name] = true
+	}
+
+	for _, col := range srcCols {
+		if !dstColMap[col.Name] {
+			fmt.Printf(Translate("WARNING: Column %s.%s exists in source but not in destination, will be skipped\n"), table, col.Name)
+		}
+	}
+
+	return commonCols, dstCols, nil
+}
+
+// coerceStrictValue converts driver values a STRICT column of the given type would reject
+func coerceStrictValue(val any, colType string) any {
+	switch v := val.(type) {
+	case time.Time:
+		switch strings.ToUpper(colType) {
+		case "INT", "INTEGER":
+			return v.Unix()
+		case "REAL":
+			return float64(v.UnixMilli()) / 1000
+		}
+	case bool:
+		if v {
+			return int64(1)
+		}
+		return int64(0)
+	}
+	return val
+}
+
+// intersectColumns finds the intersection of writable columns by name
 func intersectColumns(src, dst []ColumnInfo) []ColumnInfo {
 	dstMap := make(map[string]ColumnInfo)
 	for _, col := range dst {
@@ -205,7 +737,8 @@ func intersectColumns(src, dst []ColumnInfo) []ColumnInfo {
 
 	var result []ColumnInfo
 	for _, col := range src {
-		if _, exists := dstMap[col.Name]; exists {
+		// Generated columns are computed by the destination and cannot be written
+		if dstCol, exists := dstMap[col.Name]; exists && !dstCol.Generated {
 			result = append(result, col)
 		}
 	}
@@ -214,6 +747,10 @@ func intersectColumns(src, dst []ColumnInfo) []ColumnInfo {
 
 // CopyTablesTo copies specific tables from source to destination
 func (c *Client) CopyTablesTo(ctx context.Context, dst *Client, tables []string) error {
+	if err := CheckDistinct(c, dst); err != nil {
+		return err
+	}
+
 	tx, err := dst.db.BeginTx(ctx, nil)
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
@@ -221,7 +758,7 @@ func (c *Client) CopyTablesTo(ctx context.Context, dst *Client, tables []string)
 	defer func() { _ = tx.Rollback() }()
 
 	for _, table := range tables {
-		_, err := copyTableWithTx(ctx, tx, c, dst, table)
+		_, err := copyTableWithTx(ctx, tx, c, dst, table, TransferOptions{}, nil)
 		if err != nil {
 			return err
 		}
@@ -3,6 +3,8 @@ package evccdb
 import (
 	"context"
 	"database/sql"
+	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -61,6 +63,75 @@ func TestTransferMetricsTables(t *testing.T) {
 	}
 }
 
+func TestTransferMaxRowsPerTable(t *testing.T) {
+	src, srcCleanup := createTestDB(t)
+	defer srcCleanup()
+
+	dst, dstCleanup := createTestDB(t)
+	defer dstCleanup()
+
+	_, _ = dst.db.Exec("DELETE FROM sessions")
+	_, _ = dst.db.Exec("DELETE FROM settings")
+
+	ctx := context.Background()
+	opts := TransferOptions{Mode: TransferAll, MaxRowsPerTable: 2, Attach: true}
+	if err := Transfer(ctx, src, dst, opts); err != nil {
+		t.Fatalf("Transfer failed: %v", err)
+	}
+	for _, table := range []string{"sessions", "settings"} {
+		if count, _ := dst.GetRowCount(table); count != 2 {
+			t.Errorf("Expected 2 rows of %s, got %d", table, count)
+		}
+	}
+
+	// Imports are limited the same way
+	export := `{"version": "1", "tables": {"settings": [{"key": "a", "value": "1"}, {"key": "b", "value": "2"}, {"key": "c", "value": "3"}]}}`
+	_, _ = dst.db.Exec("DELETE FROM settings")
+	if err := dst.ImportJSON(strings.NewReader(export), opts); err != nil {
+		t.Fatalf("ImportJSON failed: %v", err)
+	}
+	if count, _ := dst.GetRowCount("settings"); count != 2 {
+		t.Errorf("Expected 2 imported settings, got %d", count)
+	}
+}
+
+func TestTransferTruncate(t *testing.T) {
+	src, srcCleanup := createTestDB(t)
+	defer srcCleanup()
+
+	dst, dstCleanup := createTestDB(t)
+	defer dstCleanup()
+
+	// Stale destination rows survive a replace by primary key
+	_, _ = dst.db.Exec("INSERT INTO settings (key, value) VALUES ('stale', 'x')")
+	_, _ = dst.db.Exec("INSERT INTO sessions (id, loadpoint) VALUES (99, 'Stale')")
+
+	ctx := context.Background()
+	opts := TransferOptions{Mode: TransferAll, Truncate: []string{"settings"}}
+	if err := Transfer(ctx, src, dst, opts); err != nil {
+		t.Fatalf("Transfer failed: %v", err)
+	}
+	var settings, sessions int
+	_ = dst.db.QueryRow("SELECT COUNT(*) FROM settings WHERE key = 'stale'").Scan(&settings)
+	_ = dst.db.QueryRow("SELECT COUNT(*) FROM sessions WHERE id = 99").Scan(&sessions)
+	if settings != 0 || sessions != 1 {
+		t.Errorf("Expected only the settings to be truncated, got %d stale settings and %d stale sessions", settings, sessions)
+	}
+
+	// Imports truncate only the tables of the export
+	export := `{"version": "1", "tables": {"sessions": [{"id": 1, "loadpoint": "Garage"}]}}`
+	opts.Truncate = []string{TruncateAll}
+	if err := dst.ImportJSON(strings.NewReader(export), opts); err != nil {
+		t.Fatalf("ImportJSON failed: %v", err)
+	}
+	srcSettings, _ := src.GetRowCount("settings")
+	dstSettings, _ := dst.GetRowCount("settings")
+	dstSessions, _ := dst.GetRowCount("sessions")
+	if dstSessions != 1 || dstSettings != srcSettings {
+		t.Errorf("Expected 1 session and %d settings, got %d and %d", srcSettings, dstSessions, dstSettings)
+	}
+}
+
 func TestTransferWithExtraColumnInDest(t *testing.T) {
 	src, srcCleanup := createTestDB(t)
 	defer srcCleanup()
@@ -183,3 +254,323 @@ func TestIntersectColumns(t *testing.T) {
 		})
 	}
 }
+
+func TestPlanTransfer(t *testing.T) {
+	src, srcCleanup := createTestDB(t)
+	defer srcCleanup()
+
+	dst, dstCleanup := createTestDB(t)
+	defer dstCleanup()
+
+	_, _ = src.db.Exec("ALTER TABLE settings ADD COLUMN extra TEXT")
+	_, _ = dst.db.Exec("DROP TABLE caches")
+
+	ctx := context.Background()
+	opts := TransferOptions{
+		Mode:          TransferConfig,
+		IncludeCaches: true,
+		LoadpointRenames: []RenameMapping{
+			{OldName: "Garage", NewName: "Carport"},
+		},
+	}
+
+	plan, err := PlanTransfer(ctx, src, dst, opts)
+	if err != nil {
+		t.Fatalf("PlanTransfer failed: %v", err)
+	}
+
+	if len(plan.Tables) != 3 {
+		t.Fatalf("Expected 3 tables in plan, got %d", len(plan.Tables))
+	}
+
+	settings := plan.Tables[0]
+	if settings.Rows != 6 {
+		t.Errorf("Expected 6 settings rows, got %d", settings.Rows)
+	}
+	if len(settings.SkippedColumns) != 1 || settings.SkippedColumns[0] != "extra" {
+		t.Errorf("Expected skipped column extra, got %v", settings.SkippedColumns)
+	}
+
+	if !plan.Tables[2].Missing {
+		t.Error("Expected caches to be missing in destination")
+	}
+
+	if len(plan.LoadpointRenames) != 1 || plan.LoadpointRenames[0].Result.Sessions != 3 {
+		t.Errorf("Expected loadpoint rename of 3 sessions, got %+v", plan.LoadpointRenames)
+	}
+
+	// Planning must not write anything
+	count, _ := dst.GetRowCount("settings")
+	if count != 6 {
+		t.Errorf("Plan modified destination: expected 6 settings, got %d", count)
+	}
+}
+
+func TestTransferToStrictTableWithGeneratedColumn(t *testing.T) {
+	src, srcCleanup := createTestDB(t)
+	defer srcCleanup()
+
+	dst, dstCleanup := createEmptyDB(t, `
+		CREATE TABLE sessions (
+			id INTEGER PRIMARY KEY,
+			created INTEGER,
+			loadpoint TEXT,
+			vehicle TEXT,
+			label TEXT GENERATED ALWAYS AS (loadpoint || '/' || coalesce(vehicle, '-')) STORED
+		) STRICT;
+	`)
+	defer dstCleanup()
+
+	strict, err := dst.IsStrictTable("sessions")
+	if err != nil {
+		t.Fatalf("IsStrictTable failed: %v", err)
+	}
+	if !strict {
+		t.Fatal("Expected sessions to be a STRICT table")
+	}
+
+	cols, _ := dst.GetTableColumns("sessions")
+	if !cols[4].Generated {
+		t.Error("Expected label to be reported as generated column")
+	}
+
+	ctx := context.Background()
+	if err := Transfer(ctx, src, dst, TransferOptions{Tables: []string{"sessions"}}); err != nil {
+		t.Fatalf("Transfer failed: %v", err)
+	}
+
+	var created int64
+	var label string
+	err = dst.db.QueryRow("SELECT created, label FROM sessions WHERE id = 1").Scan(&created, &label)
+	if err != nil {
+		t.Fatalf("Failed to query transferred session: %v", err)
+	}
+	if created != 1680343200 {
+		t.Errorf("Expected created as unix timestamp 1680343200, got %d", created)
+	}
+	if label != "Garage/e-Golf" {
+		t.Errorf("Expected generated label 'Garage/e-Golf', got %q", label)
+	}
+}
+
+func TestTransferForeignKeyOrder(t *testing.T) {
+	schema := `
+		CREATE TABLE vehicles (id INTEGER PRIMARY KEY, name TEXT);
+		CREATE TABLE charges (id INTEGER PRIMARY KEY, vehicle_id INTEGER REFERENCES vehicles(id));
+	`
+	src, srcCleanup := createEmptyDB(t, schema+`
+		INSERT INTO vehicles VALUES (1, 'e-Golf');
+		INSERT INTO charges VALUES (1, 1);
+	`)
+	defer srcCleanup()
+
+	path := filepath.Join(t.TempDir(), "fk.db")
+	dst, err := Open(path + "?_foreign_keys=on")
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer func() { _ = dst.Close() }()
+	if _, err := dst.db.Exec(schema); err != nil {
+		t.Fatalf("Failed to create schema: %v", err)
+	}
+
+	ordered, err := dst.OrderTablesByDependencies([]string{"charges", "vehicles"})
+	if err != nil {
+		t.Fatalf("OrderTablesByDependencies failed: %v", err)
+	}
+	if len(ordered) != 2 || ordered[0] != "vehicles" || ordered[1] != "charges" {
+		t.Errorf("Expected [vehicles charges], got %v", ordered)
+	}
+
+	ctx := context.Background()
+	opts := TransferOptions{Tables: []string{"charges", "vehicles"}}
+	if err := Transfer(ctx, src, dst, opts); err != nil {
+		t.Fatalf("Transfer failed: %v", err)
+	}
+
+	count, _ := dst.GetRowCount("charges")
+	if count != 1 {
+		t.Errorf("Expected 1 charge, got %d", count)
+	}
+}
+
+func TestTransferAttached(t *testing.T) {
+	src, srcCleanup := createTestDB(t)
+	defer srcCleanup()
+
+	dst, dstCleanup := createTestDB(t)
+	defer dstCleanup()
+
+	_, _ = dst.db.Exec("DELETE FROM sessions")
+	_, _ = dst.db.Exec("ALTER TABLE sessions ADD COLUMN extra TEXT DEFAULT 'x'")
+
+	var copied int
+	ctx := context.Background()
+	opts := TransferOptions{
+		Mode:   TransferMetrics,
+		Attach: true,
+		OnProgress: func(table string, count int) {
+			if table == "sessions" {
+				copied = count
+			}
+		},
+		LoadpointRenames: []RenameMapping{
+			{OldName: "Garage", NewName: "Carport"},
+		},
+	}
+
+	if err := Transfer(ctx, src, dst, opts); err != nil {
+		t.Fatalf("Transfer failed: %v", err)
+	}
+
+	if copied != 5 {
+		t.Errorf("Expected 5 sessions copied, got %d", copied)
+	}
+
+	count, _ := dst.CountLoadpointSessions(ctx, "Carport")
+	if count != 3 {
+		t.Errorf("Expected 3 renamed sessions, got %d", count)
+	}
+
+	// The source is detached after the transfer
+	var attached int
+	_ = dst.db.QueryRow("SELECT COUNT(*) FROM pragma_database_list WHERE name = 'evccdb_src'").Scan(&attached)
+	if attached != 0 {
+		t.Error("Expected source database to be detached")
+	}
+}
+
+func TestTransferFast(t *testing.T) {
+	src, srcCleanup := createTestDB(t)
+	defer srcCleanup()
+
+	dst, dstCleanup := createTestDB(t)
+	defer dstCleanup()
+
+	ctx := context.Background()
+	_, _ = dst.db.Exec("DELETE FROM sessions")
+
+	if err := Transfer(ctx, src, dst, TransferOptions{Mode: TransferMetrics, Fast: true}); err != nil {
+		t.Fatalf("Transfer failed: %v", err)
+	}
+	var count int
+	_ = dst.db.QueryRow("SELECT COUNT(*) FROM sessions").Scan(&count)
+	if count != 5 {
+		t.Errorf("Expected 5 sessions, got %d", count)
+	}
+
+	// With a single connection the pragmas of the bulk write must be reset afterwards
+	dst.db.SetMaxOpenConns(1)
+	tx, err := dst.beginConn(ctx, nil, true)
+	if err != nil {
+		t.Fatalf("beginConn failed: %v", err)
+	}
+	var sync int
+	_ = tx.tx.QueryRow("PRAGMA synchronous").Scan(&sync)
+	if sync != 0 {
+		t.Errorf("Expected synchronous OFF during bulk write, got %d", sync)
+	}
+	_ = tx.Rollback()
+
+	_ = dst.db.QueryRow("PRAGMA synchronous").Scan(&sync)
+	if sync == 0 {
+		t.Error("Expected synchronous to be restored")
+	}
+}
+
+func TestPlanTransferConflicts(t *testing.T) {
+	src, srcCleanup := createTestDB(t)
+	defer srcCleanup()
+
+	dst, dstCleanup := createTestDB(t)
+	defer dstCleanup()
+
+	_, _ = dst.db.Exec("UPDATE settings SET value = 'off' WHERE key = 'lp1.mode'")
+	_, _ = dst.db.Exec("UPDATE sessions SET vehicle = 'ID.4' WHERE id IN (1, 2)")
+
+	ctx := context.Background()
+	plan, err := PlanTransfer(ctx, src, dst, TransferOptions{Tables: []string{"settings", "sessions"}})
+	if err != nil {
+		t.Fatalf("PlanTransfer failed: %v", err)
+	}
+
+	settings := plan.Tables[0].Conflicts
+	if len(settings) != 1 || settings[0] != "lp1.mode" {
+		t.Errorf("Expected conflict on lp1.mode, got %v", settings)
+	}
+
+	sessions := plan.Tables[1].Conflicts
+	if len(sessions) != 2 || sessions[0] != "1" || sessions[1] != "2" {
+		t.Errorf("Expected conflicts on sessions 1 and 2, got %v", sessions)
+	}
+}
+
+func TestPlanTransferSamples(t *testing.T) {
+	src, srcCleanup := createTestDB(t)
+	defer srcCleanup()
+
+	dst, dstCleanup := createTestDB(t)
+	defer dstCleanup()
+
+	_, _ = dst.db.Exec("UPDATE sessions SET vehicle = 'ID.4', charged_kwh = 12.5 WHERE id IN (1, 2, 3)")
+
+	ctx := context.Background()
+	plan, err := PlanTransfer(ctx, src, dst, TransferOptions{Tables: []string{"sessions"}, SampleRows: 2})
+	if err != nil {
+		t.Fatalf("PlanTransfer failed: %v", err)
+	}
+
+	samples := plan.Tables[0].Samples
+	if len(samples) != 2 || samples[0].Key != "1" {
+		t.Fatalf("Expected samples of sessions 1 and 2, got %+v", samples)
+	}
+
+	want := []FieldChange{
+		{Column: "vehicle", From: "ID.4", To: "e-Golf"},
+		{Column: "charged_kwh", From: "12.5", To: "NULL"},
+	}
+	if len(samples[0].Changes) != len(want) {
+		t.Fatalf("Expected %d changes, got %+v", len(want), samples[0].Changes)
+	}
+	for i, c := range want {
+		if samples[0].Changes[i] != c {
+			t.Errorf("Expected change %+v, got %+v", c, samples[0].Changes[i])
+		}
+	}
+}
+
+func TestTransferClone(t *testing.T) {
+	src, srcCleanup := createTestDB(t)
+	defer srcCleanup()
+
+	ctx := context.Background()
+	opts := TransferOptions{
+		Mode:             TransferMetrics,
+		Clone:            true,
+		LoadpointRenames: []RenameMapping{{OldName: "Garage", NewName: "Carport"}},
+	}
+
+	// A destination with data cannot be cloned into
+	full, fullCleanup := createTestDB(t)
+	defer fullCleanup()
+	if err := Transfer(ctx, src, full, opts); err == nil {
+		t.Error("Expected clone into a non-empty destination to fail")
+	}
+
+	dst, dstCleanup := createEmptyDB(t, "")
+	defer dstCleanup()
+
+	if err := Transfer(ctx, src, dst, opts); err != nil {
+		t.Fatalf("Clone failed: %v", err)
+	}
+
+	count, _ := dst.CountLoadpointSessions(ctx, "Carport")
+	if count != 3 {
+		t.Errorf("Expected 3 renamed sessions, got %d", count)
+	}
+
+	// Config tables are not part of the metrics mode and are dropped from the fresh destination
+	if exists, _ := dst.TableExists("settings"); exists {
+		t.Error("Expected settings table to be dropped")
+	}
+}
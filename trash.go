@@ -0,0 +1,209 @@
+package evccdb
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/iseeberg79/evccdb/internal/sqlbuild"
+)
+
+// TrashTable holds sessions removed with TrashLoadpointSessions or TrashVehicleSessions
+const TrashTable = "evccdb_trash_sessions"
+
+// TrashLoadpointSessions moves all sessions for a specific loadpoint into the trash table
+func (c *Client) TrashLoadpointSessions(ctx context.Context, loadpoint string) (int, error) {
+	var count int
+	err := c.inTx(ctx, func(tx *Tx) (err error) {
+		count, err = tx.TrashLoadpointSessions(ctx, loadpoint)
+		return err
+	})
+	return count, err
+}
+
+// TrashVehicleSessions moves all sessions for a specific vehicle into the trash table
+func (c *Client) TrashVehicleSessions(ctx context.Context, vehicle string) (int, error) {
+	var count int
+	err := c.inTx(ctx, func(tx *Tx) (err error) {
+		count, err = tx.TrashVehicleSessions(ctx, vehicle)
+		return err
+	})
+	return count, err
+}
+
+// TrashLoadpointSessions moves all sessions for a specific loadpoint into the trash table within the transaction
+func (t *Tx) TrashLoadpointSessions(ctx context.Context, loadpoint string) (int, error) {
+	return t.trashSessions(ctx, "loadpoint", loadpoint)
+}
+
+// TrashVehicleSessions moves all sessions for a specific vehicle into the trash table within the transaction
+func (t *Tx) TrashVehicleSessions(ctx context.Context, vehicle string) (int, error) {
+	return t.trashSessions(ctx, "vehicle", vehicle)
+}
+
+// trashSessions moves sessions matching column = value into the trash table
+func (t *Tx) trashSessions(ctx context.Context, column, value string) (int, error) {
+	// The trash table mirrors the sessions columns plus the deletion timestamp
+	_, err := t.tx.ExecContext(ctx, fmt.Sprintf(
+		"CREATE TABLE IF NOT EXISTS `%s` AS SELECT *, CAST(NULL AS DATETIME) AS deleted_at FROM sessions WHERE 0", TrashTable))
+	if err != nil {
+		return 0, fmt.Errorf("failed to create trash table: %w", err)
+	}
+
+	names, err := trashColumns(ctx, t.tx)
+	if err != nil {
+		return 0, err
+	}
+	cols, err := sqlbuild.QuoteColumns(names)
+	if err != nil {
+		return 0, err
+	}
+	col, err := sqlbuild.QuoteIdent(column)
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = t.tx.ExecContext(ctx, fmt.Sprintf(
+		"INSERT INTO `%s` (%s, deleted_at) SELECT %s, CURRENT_TIMESTAMP FROM sessions WHERE %s = ?",
+		TrashTable, cols, cols, col), value)
+	if err != nil {
+		return 0, fmt.Errorf("failed to move sessions to trash: %w", err)
+	}
+
+	return deleteSessions(ctx, t.tx, column, value)
+}
+
+// RestoreTrash moves all trashed sessions back into the sessions table. Sessions whose id
+// was taken by a newer session in the meantime get a new id, see RestoreTrashIDs.
+func (c *Client) RestoreTrash(ctx context.Context) (int, error) {
+	count, _, err := c.RestoreTrashIDs(ctx)
+	return count, err
+}
+
+// RestoreTrashIDs moves all trashed sessions back into the sessions table and returns the
+// sessions that were restored with a new id because their id was reused by a newer session
+func (c *Client) RestoreTrashIDs(ctx context.Context) (int, []IDMapping, error) {
+	exists, err := c.TableExists(TrashTable)
+	if err != nil || !exists {
+		return 0, nil, err
+	}
+
+	var (
+		count    int
+		mappings []IDMapping
+	)
+	err = c.inTx(ctx, func(t *Tx) error {
+		names, err := trashColumns(ctx, t.tx)
+		if err != nil {
+			return err
+		}
+		cols, err := sqlbuild.QuoteColumns(names)
+		if err != nil {
+			return err
+		}
+		// Without the id column the sessions table assigns the next free id
+		newIDCols, err := sqlbuild.QuoteColumns(slices.DeleteFunc(slices.Clone(names), func(name string) bool {
+			return name == "id"
+		}))
+		if err != nil {
+			return err
+		}
+
+		// Collect the trashed rows first, the restore inserts run on the same connection
+		query := fmt.Sprintf("SELECT rowid, id, EXISTS (SELECT 1 FROM sessions s WHERE s.id = t.id) FROM `%s` t ORDER BY rowid", TrashTable)
+		rows, err := t.tx.QueryContext(ctx, query)
+		if err != nil {
+			return fmt.Errorf("failed to read trash: %w", err)
+		}
+		type trashedRow struct {
+			rowid, id int64
+			taken     bool
+		}
+		var trashed []trashedRow
+		for rows.Next() {
+			var r trashedRow
+			if err := rows.Scan(&r.rowid, &r.id, &r.taken); err != nil {
+				rows.Close()
+				return fmt.Errorf("failed to read trash: %w", err)
+			}
+			trashed = append(trashed, r)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("failed to read trash: %w", err)
+		}
+
+		keepID := fmt.Sprintf("INSERT INTO sessions (%s) SELECT %s FROM `%s` WHERE rowid = ?", cols, cols, TrashTable)
+		newID := fmt.Sprintf("INSERT INTO sessions (%s) SELECT %s FROM `%s` WHERE rowid = ?", newIDCols, newIDCols, TrashTable)
+		// Restore the sessions keeping their id first, so the new ids do not take any of them.
+		// An id trashed twice is kept by the session trashed first.
+		restored := make(map[int64]bool, len(trashed))
+		var renumber []trashedRow
+		for _, r := range trashed {
+			if r.taken || restored[r.id] {
+				renumber = append(renumber, r)
+				continue
+			}
+			restored[r.id] = true
+			if _, err := t.tx.ExecContext(ctx, keepID, r.rowid); err != nil {
+				return fmt.Errorf("failed to restore session %d: %w", r.id, err)
+			}
+		}
+		for _, r := range renumber {
+			result, err := t.tx.ExecContext(ctx, newID, r.rowid)
+			if err != nil {
+				return fmt.Errorf("failed to restore session %d: %w", r.id, err)
+			}
+			id, err := result.LastInsertId()
+			if err != nil {
+				return err
+			}
+			mappings = append(mappings, IDMapping{Table: "sessions", OldID: r.id, NewID: id})
+		}
+		count = len(trashed)
+
+		if _, err := t.tx.ExecContext(ctx, fmt.Sprintf("DELETE FROM `%s`", TrashTable)); err != nil {
+			return fmt.Errorf("failed to empty trash: %w", err)
+		}
+		return nil
+	})
+	return count, mappings, err
+}
+
+// PurgeTrash permanently deletes all trashed sessions
+func (c *Client) PurgeTrash(ctx context.Context) (int, error) {
+	exists, err := c.TableExists(TrashTable)
+	if err != nil || !exists {
+		return 0, err
+	}
+
+	result, err := c.db.ExecContext(ctx, fmt.Sprintf("DELETE FROM `%s`", TrashTable))
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge trash: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	return int(affected), err
+}
+
+// CountTrash counts the sessions in the trash table
+func (c *Client) CountTrash(ctx context.Context) (int, error) {
+	exists, err := c.TableExists(TrashTable)
+	if err != nil || !exists {
+		return 0, err
+	}
+	return c.GetRowCount(TrashTable)
+}
+
+// trashColumns returns the columns shared by sessions and the trash table
+func trashColumns(ctx context.Context, q querier) ([]string, error) {
+	sessionCols, err := tableColumns(ctx, q, "sessions")
+	if err != nil {
+		return nil, err
+	}
+	trashCols, err := tableColumns(ctx, q, TrashTable)
+	if err != nil {
+		return nil, err
+	}
+
+	return columnNames(intersectColumns(sessionCols, trashCols)), nil
+}
@@ -0,0 +1,141 @@
+package evccdb
+
+import (
+	"context"
+	"testing"
+)
+
+func TestTrashAndRestoreSessions(t *testing.T) {
+	client, cleanup := createTestDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	moved, err := client.TrashLoadpointSessions(ctx, "Garage")
+	if err != nil {
+		t.Fatalf("TrashLoadpointSessions failed: %v", err)
+	}
+	if moved != 3 {
+		t.Errorf("Expected 3 sessions moved to trash, got %d", moved)
+	}
+
+	remaining, _ := client.CountLoadpointSessions(ctx, "Garage")
+	if remaining != 0 {
+		t.Errorf("Expected 0 remaining sessions, got %d", remaining)
+	}
+
+	trashed, err := client.CountTrash(ctx)
+	if err != nil {
+		t.Fatalf("CountTrash failed: %v", err)
+	}
+	if trashed != 3 {
+		t.Errorf("Expected 3 sessions in trash, got %d", trashed)
+	}
+
+	var deletedAt *string
+	err = client.db.QueryRowContext(ctx, "SELECT deleted_at FROM evccdb_trash_sessions LIMIT 1").Scan(&deletedAt)
+	if err != nil {
+		t.Fatalf("Failed to read deletion timestamp: %v", err)
+	}
+	if deletedAt == nil {
+		t.Error("Expected deletion timestamp to be set")
+	}
+
+	restored, err := client.RestoreTrash(ctx)
+	if err != nil {
+		t.Fatalf("RestoreTrash failed: %v", err)
+	}
+	if restored != 3 {
+		t.Errorf("Expected 3 sessions restored, got %d", restored)
+	}
+
+	remaining, _ = client.CountLoadpointSessions(ctx, "Garage")
+	if remaining != 3 {
+		t.Errorf("Expected 3 sessions after restore, got %d", remaining)
+	}
+
+	trashed, _ = client.CountTrash(ctx)
+	if trashed != 0 {
+		t.Errorf("Expected empty trash after restore, got %d", trashed)
+	}
+}
+
+func TestRestoreTrashReusedID(t *testing.T) {
+	client, cleanup := createTestDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	if _, err := client.TrashLoadpointSessions(ctx, "eBikes"); err != nil {
+		t.Fatalf("TrashLoadpointSessions failed: %v", err)
+	}
+
+	// Without AUTOINCREMENT the next session reuses the id of the trashed session 4
+	result, err := client.db.Exec("INSERT INTO sessions (created, loadpoint, vehicle, charged_kwh) VALUES ('2024-03-01 10:00:00', 'Garage', 'New', 5.0)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id, _ := result.LastInsertId(); id != 4 {
+		t.Fatalf("Expected the new session to reuse id 4, got %d", id)
+	}
+
+	restored, mappings, err := client.RestoreTrashIDs(ctx)
+	if err != nil {
+		t.Fatalf("RestoreTrashIDs failed: %v", err)
+	}
+	if restored != 2 {
+		t.Errorf("Expected 2 sessions restored, got %d", restored)
+	}
+	if len(mappings) != 1 || mappings[0].OldID != 4 || mappings[0].NewID != 6 {
+		t.Fatalf("Expected session 4 restored as 6, got %+v", mappings)
+	}
+
+	var vehicle string
+	if err := client.db.QueryRow("SELECT vehicle FROM sessions WHERE id = 4").Scan(&vehicle); err != nil {
+		t.Fatal(err)
+	}
+	if vehicle != "New" {
+		t.Errorf("Expected the newer session 4 to be kept, got vehicle %q", vehicle)
+	}
+
+	var loadpoint string
+	if err := client.db.QueryRow("SELECT loadpoint FROM sessions WHERE id = 6").Scan(&loadpoint); err != nil {
+		t.Fatal(err)
+	}
+	if loadpoint != "eBikes" {
+		t.Errorf("Expected the trashed session as 6, got loadpoint %q", loadpoint)
+	}
+}
+
+func TestPurgeTrash(t *testing.T) {
+	client, cleanup := createTestDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	// Purging without a trash table is a no-op
+	purged, err := client.PurgeTrash(ctx)
+	if err != nil {
+		t.Fatalf("PurgeTrash failed: %v", err)
+	}
+	if purged != 0 {
+		t.Errorf("Expected 0 purged sessions, got %d", purged)
+	}
+
+	if _, err := client.TrashVehicleSessions(ctx, "e-Golf"); err != nil {
+		t.Fatalf("TrashVehicleSessions failed: %v", err)
+	}
+
+	purged, err = client.PurgeTrash(ctx)
+	if err != nil {
+		t.Fatalf("PurgeTrash failed: %v", err)
+	}
+	if purged != 2 {
+		t.Errorf("Expected 2 purged sessions, got %d", purged)
+	}
+
+	count, _ := client.CountVehicleSessions(ctx, "e-Golf")
+	if count != 0 {
+		t.Errorf("Expected purged sessions to stay deleted, got %d", count)
+	}
+}
@@ -0,0 +1,173 @@
+package evccdb
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// Tx groups several operations into a single database transaction.
+// Steps run inside named savepoints, so a failing step is undone on its own
+// before the caller decides whether to roll back the whole transaction.
+type Tx struct {
+	c        *Client
+	tx       *sql.Tx
+	conn     *sql.Conn
+	attached bool
+	// restore are the statements resetting the pragmas of a bulk write connection
+	restore []string
+}
+
+// attachedSchema is the schema name of a source database attached to a transaction
+const attachedSchema = "evccdb_src"
+
+// Begin starts a transaction for multi-step operations
+func (c *Client) Begin(ctx context.Context) (*Tx, error) {
+	tx, err := c.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	return &Tx{c: c, tx: tx}, nil
+}
+
+// bulkPragmas speed up bulk writes at the cost of durability on power loss
+var bulkPragmas = []struct{ name, value string }{
+	{"synchronous", "OFF"},
+	{"cache_size", "-65536"},
+	{"journal_mode", "MEMORY"},
+}
+
+// beginConn starts a transaction on a dedicated connection, attaching src if not nil
+// and setting the bulk write pragmas if fast is set. ATTACH and journal_mode are not
+// allowed inside a transaction, so they run before BEGIN.
+func (c *Client) beginConn(ctx context.Context, src *Client, fast bool) (*Tx, error) {
+	if src == nil && !fast {
+		return c.Begin(ctx)
+	}
+
+	conn, err := c.db.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get connection: %w", err)
+	}
+	t := &Tx{c: c, conn: conn}
+
+	if src != nil {
+		if _, err := conn.ExecContext(ctx, fmt.Sprintf("ATTACH DATABASE ? AS %s", attachedSchema), src.path); err != nil {
+			t.release()
+			return nil, fmt.Errorf("failed to attach source database: %w", err)
+		}
+		t.attached = true
+	}
+
+	if fast {
+		for _, p := range bulkPragmas {
+			var current string
+			if err := conn.QueryRowContext(ctx, "PRAGMA "+p.name).Scan(&current); err != nil {
+				t.release()
+				return nil, fmt.Errorf("failed to read pragma %s: %w", p.name, err)
+			}
+			// Leaving WAL mode needs exclusive access, WAL is fast enough for bulk writes
+			if p.name == "journal_mode" && strings.EqualFold(current, "wal") {
+				continue
+			}
+			if _, err := conn.ExecContext(ctx, fmt.Sprintf("PRAGMA %s = %s", p.name, p.value)); err != nil {
+				t.release()
+				return nil, fmt.Errorf("failed to set pragma %s: %w", p.name, err)
+			}
+			t.restore = append(t.restore, fmt.Sprintf("PRAGMA %s = %s", p.name, current))
+		}
+	}
+
+	if t.tx, err = conn.BeginTx(ctx, nil); err != nil {
+		t.release()
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	return t, nil
+}
+
+// Commit commits the transaction
+func (t *Tx) Commit() error {
+	defer t.release()
+	// Steps may have created tables, e.g. the trash table
+	defer t.c.InvalidateSchema()
+	if err := clearChecksumCache(context.Background(), t.c, t.tx); err != nil {
+		return err
+	}
+	if err := t.tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// Rollback aborts the transaction. It is a no-op after Commit.
+func (t *Tx) Rollback() error {
+	defer t.release()
+	defer t.c.InvalidateSchema()
+	if err := t.tx.Rollback(); err != nil && err != sql.ErrTxDone {
+		return err
+	}
+	return nil
+}
+
+// release resets the pragmas, detaches the source database and returns the dedicated connection
+func (t *Tx) release() {
+	if t.conn == nil {
+		return
+	}
+	for _, stmt := range t.restore {
+		_, _ = t.conn.ExecContext(context.Background(), stmt)
+	}
+	if t.attached {
+		_, _ = t.conn.ExecContext(context.Background(), "DETACH DATABASE "+attachedSchema)
+	}
+	_ = t.conn.Close()
+	t.conn = nil
+}
+
+// Step runs fn inside the savepoint name. If fn fails, the changes of this
+// step are rolled back and the error is returned.
+func (t *Tx) Step(ctx context.Context, name string, fn func() error) error {
+	if err := ValidateIdentifier(name); err != nil {
+		return err
+	}
+
+	if _, err := t.tx.ExecContext(ctx, fmt.Sprintf("SAVEPOINT `%s`", name)); err != nil {
+		return fmt.Errorf("failed to create savepoint %s: %w", name, err)
+	}
+
+	if err := fn(); err != nil {
+		_, _ = t.tx.ExecContext(ctx, fmt.Sprintf("ROLLBACK TO `%s`", name))
+		_, _ = t.tx.ExecContext(ctx, fmt.Sprintf("RELEASE `%s`", name))
+		return err
+	}
+
+	if _, err := t.tx.ExecContext(ctx, fmt.Sprintf("RELEASE `%s`", name)); err != nil {
+		return fmt.Errorf("failed to release savepoint %s: %w", name, err)
+	}
+	return nil
+}
+
+// RunInTx runs custom statements of fn in a new transaction, which is committed if fn succeeds
+// and rolled back if it fails or panics. The schema cache is refreshed afterwards, so fn may
+// create or alter tables. Hold LockDatabase as the evccdb command does while evcc may write.
+func (c *Client) RunInTx(ctx context.Context, fn func(*sql.Tx) error) error {
+	return c.inTx(ctx, func(tx *Tx) error {
+		return fn(tx.tx)
+	})
+}
+
+// inTx runs fn in a new transaction and commits it if fn succeeds
+func (c *Client) inTx(ctx context.Context, fn func(tx *Tx) error) error {
+	tx, err := c.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,140 @@
+package evccdb
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestTxStepRollsBackFailedStep(t *testing.T) {
+	client, cleanup := createTestDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	tx, err := client.Begin(ctx)
+	if err != nil {
+		t.Fatalf("Begin failed: %v", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	err = tx.Step(ctx, "rename_1", func() error {
+		_, err := tx.RenameLoadpoint(ctx, "Garage", "Carport")
+		return err
+	})
+	if err != nil {
+		t.Fatalf("First step failed: %v", err)
+	}
+
+	errStep := errors.New("step failed")
+	err = tx.Step(ctx, "delete_1", func() error {
+		if _, err := tx.DeleteLoadpointSessions(ctx, "eBikes"); err != nil {
+			return err
+		}
+		return errStep
+	})
+	if !errors.Is(err, errStep) {
+		t.Fatalf("Expected step error, got %v", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("Commit failed: %v", err)
+	}
+
+	carport, _ := client.CountLoadpointSessions(ctx, "Carport")
+	if carport != 3 {
+		t.Errorf("Expected first step to be committed with 3 sessions, got %d", carport)
+	}
+
+	eBikes, _ := client.CountLoadpointSessions(ctx, "eBikes")
+	if eBikes != 2 {
+		t.Errorf("Expected failed step to be rolled back with 2 sessions, got %d", eBikes)
+	}
+}
+
+func TestTxRollbackRevertsAllSteps(t *testing.T) {
+	client, cleanup := createTestDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	tx, err := client.Begin(ctx)
+	if err != nil {
+		t.Fatalf("Begin failed: %v", err)
+	}
+
+	if _, err := tx.RenameVehicle(ctx, "e-Golf", "ID.4"); err != nil {
+		t.Fatalf("RenameVehicle failed: %v", err)
+	}
+	if _, err := tx.TrashLoadpointSessions(ctx, "eBikes"); err != nil {
+		t.Fatalf("TrashLoadpointSessions failed: %v", err)
+	}
+
+	if err := tx.Rollback(); err != nil {
+		t.Fatalf("Rollback failed: %v", err)
+	}
+
+	count, _ := client.CountVehicleSessions(ctx, "e-Golf")
+	if count != 2 {
+		t.Errorf("Expected vehicle rename to be reverted, got %d e-Golf sessions", count)
+	}
+
+	exists, _ := client.TableExists(TrashTable)
+	if exists {
+		t.Error("Expected trash table creation to be reverted")
+	}
+}
+
+func TestTxStepInvalidName(t *testing.T) {
+	client, cleanup := createTestDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	tx, err := client.Begin(ctx)
+	if err != nil {
+		t.Fatalf("Begin failed: %v", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if err := tx.Step(ctx, "bad name", func() error { return nil }); err == nil {
+		t.Error("Expected error for invalid savepoint name")
+	}
+}
+
+func TestRunInTx(t *testing.T) {
+	client, cleanup := createTestDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	err := client.RunInTx(ctx, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, "CREATE TABLE notes (id INTEGER PRIMARY KEY, text TEXT)"); err != nil {
+			return err
+		}
+		_, err := tx.ExecContext(ctx, "INSERT INTO notes (text) VALUES ('new charger')")
+		return err
+	})
+	if err != nil {
+		t.Fatalf("RunInTx failed: %v", err)
+	}
+	if exists, _ := client.TableExists("notes"); !exists {
+		t.Error("Expected the schema cache to contain the new table")
+	}
+
+	// A failing function rolls back its changes
+	err = client.RunInTx(ctx, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, "DELETE FROM sessions"); err != nil {
+			return err
+		}
+		return fmt.Errorf("abort")
+	})
+	if err == nil {
+		t.Fatal("Expected error")
+	}
+	var n int
+	if err := client.DB().QueryRow("SELECT COUNT(*) FROM sessions").Scan(&n); err != nil || n != 5 {
+		t.Errorf("Expected 5 sessions after rollback, got %d, err %v", n, err)
+	}
+}
@@ -1,6 +1,10 @@
 package evccdb
 
-import "io"
+import (
+	"context"
+	"io"
+	"time"
+)
 
 // TransferMode specifies which tables to transfer
 type TransferMode int
@@ -25,6 +29,71 @@ type TransferOptions struct {
 	OnProgress       func(table string, count int)
 	LoadpointRenames []RenameMapping
 	VehicleRenames   []RenameMapping
+	DeferForeignKeys bool
+	Attach           bool
+	Incremental      bool
+	// Clone copies the whole source database into an empty destination with the same
+	// schema and removes the tables that are not transferred
+	Clone bool
+	// Fast relaxes durability of the destination with temporary pragmas during the write
+	Fast bool
+	// IncludeCaches adds the cache tables to the config and all modes
+	IncludeCaches bool
+	// SampleRows shows the field-level changes of up to this many replaced rows per table in a dry run
+	SampleRows int
+	// Location interprets imported timestamps without zone offset, nil means UTC. Exports
+	// convert those timestamps from Location to UTC and keep them without offset if nil.
+	Location *time.Location
+	// TimeOffset is added to timestamps copied from the source to correct clock skew
+	TimeOffset time.Duration
+	// AllowedTables limits the tables an import may write, nil means the known evcc tables
+	AllowedTables []string
+	// AllowUnknownTables imports tables of the export file that are not allowed
+	AllowUnknownTables bool
+	// SettingsMerge decides which value wins for settings that exist in both databases
+	SettingsMerge SettingsMergeMode
+	// OnSettingConflict is called for every setting with different values and may change the resolution
+	OnSettingConflict func(*SettingConflict)
+	// KeepTemplates disables renaming config templates by the registered template aliases
+	KeepTemplates bool
+	// OnTemplateAlias is called for every config whose template was renamed
+	OnTemplateAlias func(id int, from, to string)
+	// OnRowProgress is called about every second with the rows processed so far to estimate the remaining time
+	OnRowProgress func(Progress)
+	// ExcludeGuestSessions skips sessions without vehicle, e.g. of guests charging
+	ExcludeGuestSessions bool
+	// GuestVehicle is assigned to sessions without vehicle if set
+	GuestVehicle string
+	// ConvertCurrency converts the session prices of a source using another currency by the registered exchange rate
+	ConvertCurrency bool
+	// Classes limits the configs and their settings to these config classes, nil means all
+	Classes []ConfigClass
+	// StripInstanceIdentity leaves out the settings identifying the source installation, see IdentitySettings
+	StripInstanceIdentity bool
+	// PreSQL runs in the write transaction before the tables are written, e.g. for site-specific fixups
+	PreSQL string
+	// PostSQL runs in the write transaction after the tables are written and renamed
+	PostSQL string
+	// Truncate names the tables whose destination rows are deleted before writing, TruncateAll
+	// for all written tables. Otherwise rows replace destination rows by primary key and
+	// destination rows missing in the source are kept.
+	Truncate []string
+	// SchemaObjects exports the DDL of the views and triggers in the metadata and recreates
+	// those missing in the target on import
+	SchemaObjects bool
+	// SQLDialect selects the BLOB type and literals of ExportSQL
+	SQLDialect SQLDialect
+	// MaxRowsPerTable writes at most this many rows of every table, e.g. for a trial restore
+	// into a scratch database, 0 means all
+	MaxRowsPerTable int
+	// SkipRowErrors skips the rows of an import the database rejects, e.g. for a constraint
+	// or datatype mismatch, instead of failing the import
+	SkipRowErrors bool
+	// OnRowError is called for every row skipped by SkipRowErrors
+	OnRowError func(*RowError)
+
+	// priceRate is the factor for the session prices of the source, 0 keeps them
+	priceRate float64
 }
 
 // Setting represents a key-value configuration pair
@@ -36,7 +105,7 @@ type Setting struct {
 // Config represents a device or service configuration
 type Config struct {
 	ID      int
-	Class   int
+	Class   ConfigClass
 	Type    string
 	Value   string
 	Title   string
@@ -82,9 +151,12 @@ type GridSession struct {
 
 // ExportFormat is the JSON structure for export/import
 type ExportFormat struct {
-	Version    string         `json:"version"`
-	ExportedAt string         `json:"exported_at"`
-	Tables     map[string]any `json:"tables"`
+	Version       string          `json:"version"`
+	ExportedAt    string          `json:"exported_at"`
+	UserVersion   int             `json:"user_version,omitempty"`
+	ApplicationID int             `json:"application_id,omitempty"`
+	Metadata      *ExportMetadata `json:"metadata,omitempty"`
+	Tables        map[string]any  `json:"tables"`
 }
 
 // Exporter defines the export interface
@@ -96,3 +168,25 @@ type Exporter interface {
 type Importer interface {
 	ImportJSON(r io.Reader, opts TransferOptions) error
 }
+
+// Store is the database API of Client that applications typically depend on, so
+// their tests can substitute it, e.g. by the in-memory database of package evccdbtest
+type Store interface {
+	Exporter
+	Importer
+	ImportJSONContext(ctx context.Context, r io.Reader, opts TransferOptions) error
+	GetTables() ([]string, error)
+	TableExists(name string) (bool, error)
+	GetRowCount(table string) (int, error)
+	Query(ctx context.Context, query string, tables []string) (*QueryResult, error)
+	RenameLoadpoint(ctx context.Context, oldName, newName string) (RenameResult, error)
+	RenameVehicle(ctx context.Context, oldName, newName string) (RenameResult, error)
+	CountLoadpointSessions(ctx context.Context, loadpoint string) (int, error)
+	CountVehicleSessions(ctx context.Context, vehicle string) (int, error)
+	DeleteLoadpointSessions(ctx context.Context, loadpoint string) (int, error)
+	DeleteVehicleSessions(ctx context.Context, vehicle string) (int, error)
+	Begin(ctx context.Context) (*Tx, error)
+	Close() error
+}
+
+var _ Store = (*Client)(nil)
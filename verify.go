@@ -0,0 +1,398 @@
+package evccdb
+
+import (
+	"context"
+	"database/sql"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/iseeberg79/evccdb/internal/sqlbuild"
+)
+
+// ChecksumTable caches the row count and checksum of the settled rows of each table
+const ChecksumTable = "evccdb_checksums"
+
+// checksumRecheckRows are the newest rows of a table that are hashed on every run,
+// evcc still updates some of them, e.g. the session of a running charge
+const checksumRecheckRows = 1000
+
+// TableSummary is the row count and content checksum of a table. The checksum
+// does not depend on the order of the rows.
+type TableSummary struct {
+	Table    string
+	Rows     int
+	Checksum string
+	// Cached is the number of rows whose checksum was read from ChecksumTable
+	Cached int
+}
+
+// checksumSum adds up row hashes, so rows can be added in any order
+type checksumSum [4]uint64
+
+// add adds the hex encoded hash of a row
+func (s *checksumSum) add(sum string) {
+	b, _ := hex.DecodeString(sum)
+	if len(b) < 32 {
+		b = append(b, make([]byte, 32-len(b))...)
+	}
+	for i := range s {
+		s[i] += binary.BigEndian.Uint64(b[i*8:])
+	}
+}
+
+// plus returns the sum of both
+func (s checksumSum) plus(o checksumSum) checksumSum {
+	for i := range s {
+		s[i] += o[i]
+	}
+	return s
+}
+
+// String returns the sum as hex
+func (s checksumSum) String() string {
+	b := make([]byte, 32)
+	for i, v := range s {
+		binary.BigEndian.PutUint64(b[i*8:], v)
+	}
+	return hex.EncodeToString(b)
+}
+
+// parseChecksumSum parses a sum formatted by String
+func parseChecksumSum(s string) (checksumSum, error) {
+	var sum checksumSum
+	b, err := hex.DecodeString(s)
+	if err != nil || len(b) != 32 {
+		return sum, fmt.Errorf("invalid checksum %q", s)
+	}
+	for i := range sum {
+		sum[i] = binary.BigEndian.Uint64(b[i*8:])
+	}
+	return sum, nil
+}
+
+// checksumEntry is a row of ChecksumTable
+type checksumEntry struct {
+	table   string
+	columns string
+	settled int64
+	rows    int
+	sum     checksumSum
+}
+
+// SummarizeTable counts and hashes the rows of a table. With cache, the checksum of all
+// but the newest rows is kept in ChecksumTable, so repeated runs only hash the rows
+// added since. Rows of the cached range that are changed by other programs than evccdb
+// are not noticed, evccdb clears the cache whenever it writes the database.
+func (c *Client) SummarizeTable(ctx context.Context, table string, cache bool) (TableSummary, error) {
+	s, update, err := c.summarizeTable(ctx, table, cache)
+	if err != nil || update == nil {
+		return s, err
+	}
+	return s, c.storeChecksumEntry(ctx, *update)
+}
+
+// summarizeTable hashes a table like SummarizeTable without writing the cache. It returns
+// the cache entry to store if more rows have settled since the cached run.
+func (c *Client) summarizeTable(ctx context.Context, table string, cache bool) (TableSummary, *checksumEntry, error) {
+	s := TableSummary{Table: table}
+
+	cols, err := c.GetTableColumns(table)
+	if err != nil {
+		return s, nil, err
+	}
+	if len(cols) == 0 {
+		return s, nil, fmt.Errorf("table %s does not exist", table)
+	}
+	name, err := sqlbuild.QuoteIdent(table)
+	if err != nil {
+		return s, nil, err
+	}
+
+	entry := checksumEntry{table: table, columns: strings.Join(columnNames(cols), ",")}
+	var maxRowid int64
+	if cache {
+		if err := c.db.QueryRowContext(ctx, "SELECT COALESCE(MAX(rowid), 0) FROM "+name).Scan(&maxRowid); err != nil {
+			return s, nil, fmt.Errorf("failed to query %s: %w", table, err)
+		}
+		if err := c.readChecksumEntry(ctx, name, &entry); err != nil {
+			return s, nil, err
+		}
+	}
+
+	// Rows up to the settled rowid go into the cache, newer rows are hashed every time
+	var update *checksumEntry
+	if settled := maxRowid - checksumRecheckRows; settled > entry.settled {
+		rows, sum, err := hashRows(ctx, c.db, table, cols, entry.settled, settled)
+		if err != nil {
+			return s, nil, err
+		}
+		entry.rows += rows
+		entry.sum = entry.sum.plus(sum)
+		entry.settled = settled
+		update = &entry
+	}
+
+	rows, sum, err := hashRows(ctx, c.db, table, cols, entry.settled, -1)
+	if err != nil {
+		return s, nil, err
+	}
+	s.Cached = entry.rows
+	s.Rows = entry.rows + rows
+	s.Checksum = entry.sum.plus(sum).String()
+	return s, update, nil
+}
+
+// readChecksumEntry reads the cached checksum of the table with the quoted name into
+// entry, which is left empty if there is none or rows of the cached range were deleted
+func (c *Client) readChecksumEntry(ctx context.Context, name string, entry *checksumEntry) error {
+	exists, err := c.TableExists(ChecksumTable)
+	if err != nil || !exists {
+		return err
+	}
+
+	var cached checksumEntry
+	var cols, sum string
+	err = c.db.QueryRowContext(ctx, fmt.Sprintf("SELECT columns, settled_rowid, rows, checksum FROM `%s` WHERE tbl = ?", ChecksumTable), entry.table).
+		Scan(&cols, &cached.settled, &cached.rows, &sum)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read checksum cache: %w", err)
+	}
+	if cols != entry.columns {
+		return nil
+	}
+	if cached.sum, err = parseChecksumSum(sum); err != nil {
+		return nil
+	}
+
+	var count int
+	if err := c.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM "+name+" WHERE rowid <= ?", cached.settled).Scan(&count); err != nil {
+		return fmt.Errorf("failed to count %s: %w", entry.table, err)
+	}
+	if count == cached.rows {
+		entry.settled, entry.rows, entry.sum = cached.settled, cached.rows, cached.sum
+	}
+	return nil
+}
+
+// storeChecksumEntry writes the cached checksum of a table
+func (c *Client) storeChecksumEntry(ctx context.Context, entry checksumEntry) error {
+	_, err := c.db.ExecContext(ctx, fmt.Sprintf(
+		"CREATE TABLE IF NOT EXISTS `%s` (tbl TEXT PRIMARY KEY, columns TEXT, settled_rowid INTEGER, rows INTEGER, checksum TEXT, updated DATETIME)", ChecksumTable))
+	if err != nil {
+		return fmt.Errorf("failed to create checksum cache: %w", err)
+	}
+	c.InvalidateSchema()
+
+	_, err = c.db.ExecContext(ctx, fmt.Sprintf(
+		"INSERT OR REPLACE INTO `%s` (tbl, columns, settled_rowid, rows, checksum, updated) VALUES (?, ?, ?, ?, ?, CURRENT_TIMESTAMP)", ChecksumTable),
+		entry.table, entry.columns, entry.settled, entry.rows, entry.sum.String())
+	if err != nil {
+		return fmt.Errorf("failed to write checksum cache: %w", err)
+	}
+	return nil
+}
+
+// ClearChecksumCache removes the cached checksums, e.g. after changing old rows with another program
+func (c *Client) ClearChecksumCache(ctx context.Context) error {
+	return clearChecksumCache(ctx, c, c.db)
+}
+
+// clearChecksumCache deletes the cached checksums of the database of c using q
+func clearChecksumCache(ctx context.Context, c *Client, q interface {
+	ExecContext(context.Context, string, ...any) (sql.Result, error)
+}) error {
+	exists, err := c.TableExists(ChecksumTable)
+	if err != nil || !exists {
+		return err
+	}
+	if _, err := q.ExecContext(ctx, fmt.Sprintf("DELETE FROM `%s`", ChecksumTable)); err != nil {
+		return fmt.Errorf("failed to clear checksum cache: %w", err)
+	}
+	return nil
+}
+
+// hashRows counts and hashes the rows with from < rowid <= to, a negative to has no upper bound
+func hashRows(ctx context.Context, q querier, table string, cols []ColumnInfo, from, to int64) (int, checksumSum, error) {
+	var sum checksumSum
+
+	query, err := sqlbuild.Select(table, columnNames(cols))
+	if err != nil {
+		return 0, sum, err
+	}
+	query += " WHERE rowid > ?"
+	args := []any{from}
+	if to >= 0 {
+		query += " AND rowid <= ?"
+		args = append(args, to)
+	}
+
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return 0, sum, fmt.Errorf("failed to query %s: %w", table, err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	count := 0
+	values := make([]any, len(cols))
+	scanPtrs := make([]any, len(cols))
+	for i := range cols {
+		scanPtrs[i] = &values[i]
+	}
+	for rows.Next() {
+		if err := rows.Scan(scanPtrs...); err != nil {
+			return count, sum, fmt.Errorf("failed to scan row: %w", err)
+		}
+		_, rowSum := rowKeyChecksum(cols, values)
+		sum.add(rowSum)
+		count++
+	}
+	return count, sum, rows.Err()
+}
+
+// VerifyStatus is the result of comparing a table of two databases
+type VerifyStatus string
+
+const (
+	VerifyOK       VerifyStatus = "ok"
+	VerifyRowCount VerifyStatus = "row count differs"
+	VerifyContent  VerifyStatus = "content differs"
+	VerifyMissing  VerifyStatus = "missing"
+)
+
+// TableVerification compares a table of the source and target database
+type TableVerification struct {
+	Table  string
+	Source TableSummary
+	Target TableSummary
+	Status VerifyStatus
+}
+
+// VerifyOptions configures Verify
+type VerifyOptions struct {
+	// Cache keeps the checksums of older rows in ChecksumTable of both databases
+	Cache bool
+	// Workers is the number of tables hashed concurrently, 0 means the number of CPUs
+	Workers int
+}
+
+// verifyJob hashes a table of one database
+type verifyJob struct {
+	c       *Client
+	index   int
+	summary *TableSummary
+	exists  *bool
+}
+
+// Verify compares the row counts and checksums of the tables in both databases, e.g.
+// after a transfer. The tables of both databases are hashed concurrently by a bounded
+// number of workers, the result is in the order of tables.
+func Verify(ctx context.Context, src, dst *Client, tables []string, opts VerifyOptions) ([]TableVerification, error) {
+	result := make([]TableVerification, len(tables))
+	exists := make([][2]bool, len(tables))
+
+	var jobs []verifyJob
+	for i, table := range tables {
+		result[i].Table = table
+		jobs = append(jobs,
+			verifyJob{c: src, index: i, summary: &result[i].Source, exists: &exists[i][0]},
+			verifyJob{c: dst, index: i, summary: &result[i].Target, exists: &exists[i][1]})
+	}
+
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	workers = min(workers, len(jobs))
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// Cache updates are written after hashing, a write would wait for the readers of other tables
+	type update struct {
+		c     *Client
+		entry checksumEntry
+	}
+	var (
+		mu       sync.Mutex
+		updates  []update
+		firstErr error
+		wg       sync.WaitGroup
+	)
+	queue := make(chan verifyJob)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range queue {
+				table := tables[job.index]
+				ok, err := job.c.TableExists(table)
+				if err == nil && ok {
+					var entry *checksumEntry
+					if *job.summary, entry, err = job.c.summarizeTable(ctx, table, opts.Cache); err == nil && entry != nil {
+						mu.Lock()
+						updates = append(updates, update{job.c, *entry})
+						mu.Unlock()
+					}
+				}
+				*job.exists = ok
+				if err != nil {
+					mu.Lock()
+					if firstErr == nil {
+						firstErr = err
+						cancel()
+					}
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+
+	for _, job := range jobs {
+		select {
+		case queue <- job:
+		case <-ctx.Done():
+		}
+	}
+	close(queue)
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, u := range updates {
+		if err := u.c.storeChecksumEntry(ctx, u.entry); err != nil {
+			return nil, err
+		}
+	}
+
+	for i := range result {
+		result[i].Status = verifyStatus(result[i], exists[i])
+	}
+	return result, nil
+}
+
+// verifyStatus compares the summaries of a table that exists in the source and target as given
+func verifyStatus(v TableVerification, exists [2]bool) VerifyStatus {
+	switch {
+	case !exists[0] || !exists[1]:
+		return VerifyMissing
+	case v.Source.Rows != v.Target.Rows:
+		return VerifyRowCount
+	case v.Source.Checksum != v.Target.Checksum:
+		return VerifyContent
+	default:
+		return VerifyOK
+	}
+}
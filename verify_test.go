@@ -0,0 +1,104 @@
+package evccdb
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// insertMeters adds n meter readings to the test database
+func insertMeters(t *testing.T, c *Client, n int) {
+	t.Helper()
+	values := make([]string, n)
+	for i := range values {
+		values[i] = fmt.Sprintf("(1, datetime('2024-01-01', '+%d minutes'), %d.5)", i, i)
+	}
+	if _, err := c.db.Exec("INSERT INTO meters (meter, ts, val) VALUES " + strings.Join(values, ",")); err != nil {
+		t.Fatalf("Failed to insert meters: %v", err)
+	}
+}
+
+func TestVerifyChecksumCache(t *testing.T) {
+	ctx := context.Background()
+	src, srcCleanup := createTestDB(t)
+	defer srcCleanup()
+	dst, dstCleanup := createTestDB(t)
+	defer dstCleanup()
+
+	insertMeters(t, src, 2500)
+	if err := Transfer(ctx, src, dst, TransferOptions{Tables: []string{"meters"}}); err != nil {
+		t.Fatalf("Transfer failed: %v", err)
+	}
+
+	full, err := src.SummarizeTable(ctx, "meters", false)
+	if err != nil {
+		t.Fatalf("SummarizeTable failed: %v", err)
+	}
+	for run := 0; run < 2; run++ {
+		cached, err := src.SummarizeTable(ctx, "meters", true)
+		if err != nil {
+			t.Fatalf("SummarizeTable failed: %v", err)
+		}
+		if cached.Checksum != full.Checksum || cached.Rows != 2500 || cached.Cached != 1500 {
+			t.Errorf("Run %d: expected the full checksum with 1500 cached rows, got %+v", run, cached)
+		}
+	}
+
+	result, err := Verify(ctx, src, dst, []string{"meters", "sessions"}, VerifyOptions{Cache: true, Workers: 2})
+	if err != nil {
+		t.Fatalf("Verify failed: %v", err)
+	}
+	for _, v := range result {
+		if v.Status != VerifyOK {
+			t.Errorf("Expected %s to match, got %s", v.Table, v.Status)
+		}
+	}
+
+	// Changes of recent rows are found with a cache
+	_, _ = dst.db.Exec("UPDATE meters SET val = 0 WHERE rowid = (SELECT MAX(rowid) FROM meters)")
+	if result, _ := Verify(ctx, src, dst, []string{"meters"}, VerifyOptions{Cache: true}); result[0].Status != VerifyContent {
+		t.Errorf("Expected content difference, got %s", result[0].Status)
+	}
+
+	// Deleted rows invalidate the cache, which is rebuilt
+	_, _ = dst.db.Exec("DELETE FROM meters WHERE rowid = 1")
+	if result, _ := Verify(ctx, src, dst, []string{"meters"}, VerifyOptions{Cache: true}); result[0].Status != VerifyRowCount || result[0].Target.Rows != 2499 {
+		t.Errorf("Expected row count difference, got %+v", result[0])
+	}
+
+	// Writes of evccdb clear the cache
+	if _, err := src.RenameLoadpoint(ctx, "Garage", "Carport"); err != nil {
+		t.Fatalf("RenameLoadpoint failed: %v", err)
+	}
+	var count int
+	_ = src.db.QueryRow("SELECT COUNT(*) FROM " + ChecksumTable).Scan(&count)
+	if count != 0 {
+		t.Errorf("Expected the checksum cache to be cleared, got %d entries", count)
+	}
+}
+
+func TestVerifyOrder(t *testing.T) {
+	ctx := context.Background()
+	src, srcCleanup := createTestDB(t)
+	defer srcCleanup()
+	dst, dstCleanup := createTestDB(t)
+	defer dstCleanup()
+	insertMeters(t, src, 100)
+	insertMeters(t, dst, 100)
+	_, _ = dst.db.Exec("DELETE FROM sessions WHERE id = 1")
+
+	tables := []string{"sessions", "missing", "meters", "settings", "configs"}
+	for _, workers := range []int{1, 3, 8} {
+		result, err := Verify(ctx, src, dst, tables, VerifyOptions{Workers: workers})
+		if err != nil {
+			t.Fatalf("Verify with %d workers failed: %v", workers, err)
+		}
+		want := []VerifyStatus{VerifyRowCount, VerifyMissing, VerifyOK, VerifyOK, VerifyOK}
+		for i, v := range result {
+			if v.Table != tables[i] || v.Status != want[i] {
+				t.Errorf("Workers %d: expected %s %s at %d, got %s %s", workers, tables[i], want[i], i, v.Table, v.Status)
+			}
+		}
+	}
+}